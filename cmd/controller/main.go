@@ -17,10 +17,16 @@ limitations under the License.
 package main
 
 import (
+	"istio.io/api/networking/v1alpha3"
 	"istio.io/api/networking/v1beta1"
+	"knative.dev/net-istio/pkg/reconciler/domain"
 	"knative.dev/net-istio/pkg/reconciler/ingress"
+	"knative.dev/net-istio/pkg/reconciler/realm"
 	"knative.dev/net-istio/pkg/reconciler/serverlessservice"
 
+	// Falls back to v1alpha3 on Istio control planes not serving v1beta1.
+	_ "knative.dev/net-istio/pkg/istio"
+
 	// This defines the shared main for injected controllers.
 	"knative.dev/pkg/injection/sharedmain"
 )
@@ -30,6 +36,10 @@ func main() {
 	// resilient to clusters containing malformed resources.
 	v1beta1.VirtualServiceUnmarshaler.AllowUnknownFields = true
 	v1beta1.GatewayUnmarshaler.AllowUnknownFields = true
+	// The v1alpha3 API is read when falling back to it.
+	v1alpha3.VirtualServiceUnmarshaler.AllowUnknownFields = true
+	v1alpha3.GatewayUnmarshaler.AllowUnknownFields = true
 
-	sharedmain.Main("istiocontroller", ingress.NewController, serverlessservice.NewController)
+	sharedmain.Main("istiocontroller", ingress.NewController, serverlessservice.NewController,
+		domain.NewController, realm.NewController)
 }
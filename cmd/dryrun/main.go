@@ -0,0 +1,87 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// dryrun renders the Istio resources and Secrets the controller would create
+// for an Ingress, without a cluster. The Ingress, the config-istio and
+// config-network ConfigMaps, and the Services, Secrets and shared Gateways the
+// Ingress refers to are read from manifests on disk.
+//
+//	dryrun -ingress ingress.yaml -config-istio config.yaml -objects cluster/
+//
+// With -diff, the rendered objects are compared to the existing ones read
+// from the given manifests, and the command exits with status 1 when they
+// differ.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"knative.dev/pkg/system"
+)
+
+var (
+	ingressPath       = flag.String("ingress", "", "Path to the Ingress manifest to render.")
+	configIstioPath   = flag.String("config-istio", "", "Path to the config-istio ConfigMap manifest. The defaults are used when empty.")
+	configNetworkPath = flag.String("config-network", "", "Path to the config-network ConfigMap manifest. The defaults are used when empty.")
+	objectsPath       = flag.String("objects", "", "Path to a manifest or a directory of manifests of the Services, Secrets and shared Gateways the Ingress refers to.")
+	diffPath          = flag.String("diff", "", "Path to a manifest or a directory of manifests of the existing objects to diff the rendered objects against.")
+	systemNamespace   = flag.String("system-namespace", "knative-serving", "Namespace of the Knative Serving control plane.")
+)
+
+func main() {
+	flag.Parse()
+	if *ingressPath == "" {
+		log.Fatal("-ingress is required")
+	}
+	if os.Getenv(system.NamespaceEnvKey) == "" {
+		os.Setenv(system.NamespaceEnvKey, *systemNamespace)
+	}
+
+	in, err := readInputs(*ingressPath, *configIstioPath, *configNetworkPath, *objectsPath)
+	if err != nil {
+		log.Fatal("Failed to read the inputs: ", err)
+	}
+	objs, err := render(context.Background(), in)
+	if err != nil {
+		log.Fatal("Failed to render the Ingress: ", err)
+	}
+
+	if *diffPath == "" {
+		out, err := toYAML(objs)
+		if err != nil {
+			log.Fatal("Failed to print the rendered objects: ", err)
+		}
+		fmt.Print(out)
+		return
+	}
+
+	existing, err := readManifests(*diffPath)
+	if err != nil {
+		log.Fatal("Failed to read the existing objects: ", err)
+	}
+	out, err := diff(objs, existing)
+	if err != nil {
+		log.Fatal("Failed to diff the rendered objects: ", err)
+	}
+	if out != "" {
+		fmt.Print(out)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,582 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1beta1"
+	securityv1beta1 "istio.io/client-go/pkg/apis/security/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/yaml"
+
+	istiolisters "knative.dev/net-istio/pkg/client/istio/listers/networking/v1beta1"
+	"knative.dev/net-istio/pkg/reconciler/ingress/config"
+	"knative.dev/net-istio/pkg/reconciler/ingress/resources"
+	network "knative.dev/networking/pkg"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+)
+
+// inputs holds the objects read from disk that the rendering depends on.
+type inputs struct {
+	ingress   *v1alpha1.Ingress
+	config    *config.Config
+	svcLister corev1listers.ServiceLister
+	// secretLister lists the TLS, CA and JWKS Secrets referenced by the Ingress.
+	secretLister corev1listers.SecretLister
+	// gatewayLister lists the shared Gateways the Ingress binds to.
+	gatewayLister istiolisters.GatewayLister
+}
+
+// readInputs reads the Ingress, the ConfigMaps and the Services, Secrets and
+// Gateways standing in for the cluster from the given manifests. Empty paths are skipped.
+func readInputs(ingressPath, configIstioPath, configNetworkPath, objectsPath string) (*inputs, error) {
+	ing := &v1alpha1.Ingress{}
+	if err := readObject(ingressPath, "Ingress", "", ing); err != nil {
+		return nil, err
+	}
+
+	istioCM := &corev1.ConfigMap{}
+	if configIstioPath != "" {
+		if err := readObject(configIstioPath, "ConfigMap", config.IstioConfigName, istioCM); err != nil {
+			return nil, err
+		}
+	}
+	istio, err := config.NewIstioFromConfigMap(istioCM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", config.IstioConfigName, err)
+	}
+	networkCM := &corev1.ConfigMap{}
+	if configNetworkPath != "" {
+		if err := readObject(configNetworkPath, "ConfigMap", network.ConfigName, networkCM); err != nil {
+			return nil, err
+		}
+	}
+	nc, err := network.NewConfigFromConfigMap(networkCM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", network.ConfigName, err)
+	}
+
+	svcIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	secretIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	gatewayIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	if objectsPath != "" {
+		docs, err := readManifests(objectsPath)
+		if err != nil {
+			return nil, err
+		}
+		for _, doc := range docs {
+			var obj runtime.Object
+			switch kindOf(doc) {
+			case "Service":
+				obj = &corev1.Service{}
+				err = yaml.Unmarshal(doc, obj)
+				if err == nil {
+					err = svcIndexer.Add(obj)
+				}
+			case "Secret":
+				obj = &corev1.Secret{}
+				err = yaml.Unmarshal(doc, obj)
+				if err == nil {
+					err = secretIndexer.Add(obj)
+				}
+			case "Gateway":
+				obj = &v1beta1.Gateway{}
+				err = yaml.Unmarshal(doc, obj)
+				if err == nil {
+					err = gatewayIndexer.Add(obj)
+				}
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", objectsPath, err)
+			}
+		}
+	}
+
+	return &inputs{
+		ingress: ing,
+		config: &config.Config{
+			Istio:   istio,
+			Network: nc,
+		},
+		svcLister:     corev1listers.NewServiceLister(svcIndexer),
+		secretLister:  corev1listers.NewSecretLister(secretIndexer),
+		gatewayLister: istiolisters.NewGatewayLister(gatewayIndexer),
+	}, nil
+}
+
+// render makes the Secrets, Gateways, DestinationRules and VirtualServices of
+// the Ingress. It mirrors the reconciliation of the Ingress controller, except
+// for the HTTP servers the controller adds to the shared Gateways under AutoTLS
+// or for the HTTPOption of the Ingress, which change existing Gateways rather
+// than creating objects.
+func render(ctx context.Context, in *inputs) ([]runtime.Object, error) {
+	ctx = config.ToContext(ctx, in.config)
+	ing := in.ingress.DeepCopy()
+	if ing.GetAnnotations()[resources.RealmAnnotationKey] != "" {
+		return nil, errors.New("Ingresses bound to a Realm are not supported")
+	}
+	ing.SetDefaults(ctx)
+	resources.RestoreRetries(ing, in.ingress)
+
+	var objs []runtime.Object
+	gatewayNames := qualifiedGatewayNames(in.config.Istio)
+	ingressGateways := []*v1beta1.Gateway{}
+	var wildcardGateways []*v1beta1.Gateway
+	if isIngressPublic(ing) && (len(ing.Spec.TLS) > 0 || in.config.Network.AutoTLS) {
+		originSecrets, err := resources.GetSecrets(ing, in.secretLister)
+		if err != nil {
+			return nil, err
+		}
+		nonWildcardSecrets, wildcardSecrets, err := resources.CategorizeSecrets(originSecrets)
+		if err != nil {
+			return nil, err
+		}
+		secrets, err := resources.MakeSecrets(ctx, nonWildcardSecrets, ing)
+		if err != nil {
+			return nil, err
+		}
+		wildcardTargetSecrets, err := resources.MakeWildcardSecrets(ctx, wildcardSecrets)
+		if err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, wildcardTargetSecrets...)
+		caSecrets, err := makeClientCASecrets(ctx, ing, in.secretLister, nonWildcardSecrets, wildcardSecrets)
+		if err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, caSecrets...)
+		for _, s := range secrets {
+			objs = append(objs, s)
+		}
+
+		nonWildcardIngressTLS := resources.GetNonWildcardIngressTLS(ing.Spec.TLS, nonWildcardSecrets)
+		ingressGateways, err = resources.MakeIngressTLSGateways(ctx, ing, nonWildcardIngressTLS, nonWildcardSecrets, in.svcLister)
+		if err != nil {
+			return nil, err
+		}
+		wildcardGateways, err = resources.MakeWildcardGateways(ctx, wildcardSecrets, in.svcLister)
+		if err != nil {
+			return nil, err
+		}
+		gatewayNames[v1alpha1.IngressVisibilityExternalIP].Insert(resources.GetQualifiedGatewayNames(wildcardGateways)...)
+	}
+
+	var ingressServers []*istiov1beta1.Server
+	passthroughServer, err := resources.MakeIngressPassthroughServer(ing)
+	if err != nil {
+		return nil, err
+	}
+	if passthroughServer != nil {
+		ingressServers = append(ingressServers, passthroughServer)
+	}
+	tcpServers, err := resources.MakeIngressTCPServers(ing)
+	if err != nil {
+		return nil, err
+	}
+	ingressServers = append(ingressServers, tcpServers...)
+	if len(ingressServers) != 0 {
+		if len(ingressGateways) == 0 {
+			if ingressGateways, err = resources.MakeIngressGateways(ctx, ing, ingressServers, in.svcLister); err != nil {
+				return nil, err
+			}
+		} else {
+			for _, gw := range ingressGateways {
+				gw.Spec.Servers = append(gw.Spec.Servers, ingressServers...)
+			}
+		}
+	}
+	gatewayNames[v1alpha1.IngressVisibilityExternalIP].Insert(resources.GetQualifiedGatewayNames(ingressGateways)...)
+	for _, gw := range append(ingressGateways, wildcardGateways...) {
+		objs = append(objs, gw)
+	}
+
+	policyGateways, err := makePolicyGateways(in)
+	if err != nil {
+		return nil, err
+	}
+	policyGateways.AddGeneratedGateways(ctx, append(ingressGateways, wildcardGateways...))
+
+	aps, _, err := resources.MakeClusterLocalAuthorizationPolicies(ctx, ing, policyGateways, in.svcLister)
+	if err != nil {
+		return nil, err
+	}
+	var jwks string
+	if name := ing.GetAnnotations()[resources.JWTJwksSecretAnnotationKey]; name != "" {
+		secret, err := in.secretLister.Secrets(ing.Namespace).Get(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get JWKS Secret %s/%s: %w", ing.Namespace, name, err)
+		}
+		jwks = string(secret.Data[resources.JWKSSecretKey])
+	}
+	ras, jwtAPs, err := resources.MakeJWTPolicies(ing, jwks, policyGateways, in.svcLister)
+	if err != nil {
+		return nil, err
+	}
+	extAuthzAPs, err := resources.MakeExtAuthzPolicies(ctx, ing, policyGateways, in.svcLister)
+	if err != nil {
+		return nil, err
+	}
+	for _, ra := range ras {
+		objs = append(objs, ra)
+	}
+	for _, ap := range append(append(aps, jwtAPs...), extAuthzAPs...) {
+		objs = append(objs, ap)
+	}
+
+	efs, err := resources.MakeRateLimitEnvoyFilters(ing, policyGateways, in.svcLister)
+	if err != nil {
+		return nil, err
+	}
+	for _, ef := range efs {
+		objs = append(objs, ef)
+	}
+
+	drs, _, err := resources.MakeDestinationRules(ctx, ing)
+	if err != nil {
+		return nil, err
+	}
+	for _, dr := range drs {
+		objs = append(objs, dr)
+	}
+
+	vses, err := resources.MakeVirtualServices(ctx, ing, gatewayNames)
+	if err != nil {
+		return nil, err
+	}
+	for _, vs := range vses {
+		objs = append(objs, vs)
+	}
+
+	for _, obj := range objs {
+		setGroupVersionKind(obj)
+	}
+	return objs, nil
+}
+
+// makeClientCASecrets makes the copies of the CA Secrets verifying the client
+// certificates, like the Ingress controller does.
+func makeClientCASecrets(ctx context.Context, ing *v1alpha1.Ingress, secretLister corev1listers.SecretLister,
+	nonWildcardSecrets, wildcardSecrets map[string]*corev1.Secret) ([]*corev1.Secret, error) {
+	var secrets []*corev1.Secret
+	if key := resources.ClientCASecretKey(ctx, ing); key != "" {
+		caSecret, err := getSecret(secretLister, key)
+		if err != nil {
+			return nil, err
+		}
+		caSecrets, err := resources.MakeClientCASecrets(ctx, caSecret, nonWildcardSecrets, ing)
+		if err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, caSecrets...)
+	}
+	if key := config.FromContext(ctx).Istio.ClientCASecret; key != "" {
+		caSecret, err := getSecret(secretLister, key)
+		if err != nil {
+			return nil, err
+		}
+		caSecrets, err := resources.MakeWildcardClientCASecrets(ctx, caSecret, wildcardSecrets)
+		if err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, caSecrets...)
+	}
+	return secrets, nil
+}
+
+func getSecret(secretLister corev1listers.SecretLister, key string) (*corev1.Secret, error) {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := secretLister.Secrets(namespace).Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Secret %s: %w", key, err)
+	}
+	return secret, nil
+}
+
+func qualifiedGatewayNames(istio *config.Istio) map[v1alpha1.IngressVisibility]sets.String {
+	publicGateways := sets.NewString()
+	for _, gw := range istio.IngressGateways {
+		publicGateways.Insert(gw.QualifiedName())
+	}
+	privateGateways := sets.NewString()
+	for _, gw := range istio.LocalGateways {
+		privateGateways.Insert(gw.QualifiedName())
+	}
+	return map[v1alpha1.IngressVisibility]sets.String{
+		v1alpha1.IngressVisibilityExternalIP:   publicGateways,
+		v1alpha1.IngressVisibilityClusterLocal: privateGateways,
+	}
+}
+
+// makePolicyGateways returns the gateways of config-istio along with the shared
+// Gateways read from disk.
+func makePolicyGateways(in *inputs) (*resources.PolicyGateways, error) {
+	pg := &resources.PolicyGateways{
+		Services: map[v1alpha1.IngressVisibility][]config.Gateway{
+			v1alpha1.IngressVisibilityExternalIP:   in.config.Istio.IngressGateways,
+			v1alpha1.IngressVisibilityClusterLocal: in.config.Istio.LocalGateways,
+		},
+		Gateways: map[v1alpha1.IngressVisibility][]*v1beta1.Gateway{},
+	}
+	for visibility, gateways := range pg.Services {
+		for _, gw := range gateways {
+			gateway, err := in.gatewayLister.Gateways(gw.Namespace).Get(gw.Name)
+			if apierrs.IsNotFound(err) {
+				continue
+			} else if err != nil {
+				return nil, fmt.Errorf("failed to get Gateway %s: %w", gw.QualifiedName(), err)
+			}
+			pg.Gateways[visibility] = append(pg.Gateways[visibility], gateway)
+		}
+	}
+	return pg, nil
+}
+
+func isIngressPublic(ing *v1alpha1.Ingress) bool {
+	for _, rule := range ing.Spec.Rules {
+		if rule.Visibility == v1alpha1.IngressVisibilityExternalIP {
+			return true
+		}
+	}
+	return false
+}
+
+// setGroupVersionKind fills in the type of the given object, which the
+// resources package leaves empty as the clients don't need it.
+func setGroupVersionKind(obj runtime.Object) {
+	var gvk schema.GroupVersionKind
+	switch obj.(type) {
+	case *corev1.Secret:
+		gvk = corev1.SchemeGroupVersion.WithKind("Secret")
+	case *v1beta1.Gateway:
+		gvk = v1beta1.SchemeGroupVersion.WithKind("Gateway")
+	case *v1beta1.DestinationRule:
+		gvk = v1beta1.SchemeGroupVersion.WithKind("DestinationRule")
+	case *v1beta1.VirtualService:
+		gvk = v1beta1.SchemeGroupVersion.WithKind("VirtualService")
+	case *securityv1beta1.AuthorizationPolicy:
+		gvk = securityv1beta1.SchemeGroupVersion.WithKind("AuthorizationPolicy")
+	case *securityv1beta1.RequestAuthentication:
+		gvk = securityv1beta1.SchemeGroupVersion.WithKind("RequestAuthentication")
+	case *v1alpha3.EnvoyFilter:
+		gvk = v1alpha3.SchemeGroupVersion.WithKind("EnvoyFilter")
+	}
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+}
+
+// toYAML prints the given objects as a multi-document YAML.
+func toYAML(objs []runtime.Object) (string, error) {
+	var sb strings.Builder
+	for _, obj := range objs {
+		b, err := yaml.Marshal(obj)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString("---\n")
+		sb.Write(b)
+	}
+	return sb.String(), nil
+}
+
+// diff compares the rendered objects to the existing ones and returns the
+// differences, or an empty string when there is none. Objects are matched by
+// group, kind, namespace and name, and only the fields the controller manages
+// are compared, so that API versions and server populated fields don't show up.
+func diff(rendered []runtime.Object, existing [][]byte) (string, error) {
+	current := make(map[string]string, len(existing))
+	for _, doc := range existing {
+		key, normalized, err := normalize(doc)
+		if err != nil {
+			return "", err
+		}
+		current[key] = normalized
+	}
+
+	var sb strings.Builder
+	for _, obj := range rendered {
+		b, err := yaml.Marshal(obj)
+		if err != nil {
+			return "", err
+		}
+		key, want, err := normalize(b)
+		if err != nil {
+			return "", err
+		}
+		got, ok := current[key]
+		if !ok {
+			fmt.Fprintf(&sb, "%s would be created (-existing, +rendered):\n%s\n", key, cmp.Diff("", want))
+			continue
+		}
+		if d := cmp.Diff(got, want); d != "" {
+			fmt.Fprintf(&sb, "%s would be updated (-existing, +rendered):\n%s\n", key, d)
+		}
+	}
+	return sb.String(), nil
+}
+
+// managedFields lists the top-level fields of the objects the controller manages.
+var managedFields = []string{"spec", "data", "type"}
+
+// normalize returns the key identifying the object of the given manifest, and
+// the YAML of the fields of the object the controller manages.
+func normalize(doc []byte) (string, string, error) {
+	var obj struct {
+		metav1.TypeMeta `json:",inline"`
+		Metadata        metav1.ObjectMeta `json:"metadata"`
+	}
+	if err := yaml.Unmarshal(doc, &obj); err != nil {
+		return "", "", err
+	}
+	var fields map[string]interface{}
+	if err := yaml.Unmarshal(doc, &fields); err != nil {
+		return "", "", err
+	}
+
+	normalized := map[string]interface{}{
+		"metadata": metav1.ObjectMeta{
+			Labels:      obj.Metadata.Labels,
+			Annotations: obj.Metadata.Annotations,
+		},
+	}
+	for _, f := range managedFields {
+		if v, ok := fields[f]; ok {
+			normalized[f] = v
+		}
+	}
+	b, err := yaml.Marshal(normalized)
+	if err != nil {
+		return "", "", err
+	}
+
+	gk := obj.GroupVersionKind().GroupKind()
+	key := fmt.Sprintf("%s %s/%s", gk.String(), obj.Metadata.Namespace, obj.Metadata.Name)
+	return key, string(b), nil
+}
+
+// readObject reads the first object of the given kind, and of the given name
+// unless empty, from the manifests at path into obj.
+func readObject(path, kind, name string, obj runtime.Object) error {
+	docs, err := readManifests(path)
+	if err != nil {
+		return err
+	}
+	for _, doc := range docs {
+		if kindOf(doc) != kind {
+			continue
+		}
+		var meta struct {
+			Metadata metav1.ObjectMeta `json:"metadata"`
+		}
+		if err := yaml.Unmarshal(doc, &meta); err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if name != "" && meta.Metadata.Name != name {
+			continue
+		}
+		if err := yaml.Unmarshal(doc, obj); err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		return nil
+	}
+	if name != "" {
+		return fmt.Errorf("no %s %s found in %s", kind, name, path)
+	}
+	return fmt.Errorf("no %s found in %s", kind, path)
+}
+
+// readManifests reads the YAML documents of the manifest at path, or of the
+// manifests in the directory at path.
+func readManifests(path string) ([][]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return readManifest(path)
+	}
+
+	var docs [][]byte
+	err = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		switch filepath.Ext(p) {
+		case ".yaml", ".yml", ".json":
+			d, err := readManifest(p)
+			if err != nil {
+				return err
+			}
+			docs = append(docs, d...)
+		}
+		return nil
+	})
+	return docs, err
+}
+
+func readManifest(path string) ([][]byte, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var docs [][]byte
+	r := k8syaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(b)))
+	for {
+		doc, err := r.Read()
+		if err == io.EOF {
+			return docs, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if len(bytes.TrimSpace(doc)) == 0 || kindOf(doc) == "" {
+			continue
+		}
+		docs = append(docs, doc)
+	}
+}
+
+// kindOf returns the kind of the object of the given manifest, or an empty
+// string when it is not a Kubernetes object.
+func kindOf(doc []byte) string {
+	var tm metav1.TypeMeta
+	if err := yaml.Unmarshal(doc, &tm); err != nil {
+		return ""
+	}
+	return tm.Kind
+}
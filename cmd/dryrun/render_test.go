@@ -0,0 +1,166 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"istio.io/client-go/pkg/apis/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"knative.dev/net-istio/pkg/reconciler/ingress/resources"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/pkg/system"
+
+	_ "knative.dev/pkg/system/testing"
+)
+
+func renderTestdata(t *testing.T) []runtime.Object {
+	t.Helper()
+	in, err := readInputs("testdata/ingress.yaml", "../../config/config.yaml", "", "testdata/objects.yaml")
+	if err != nil {
+		t.Fatal("readInputs() =", err)
+	}
+	objs, err := render(context.Background(), in)
+	if err != nil {
+		t.Fatal("render() =", err)
+	}
+	return objs
+}
+
+func TestRender(t *testing.T) {
+	objs := renderTestdata(t)
+
+	got := make([]string, 0, len(objs))
+	for _, obj := range objs {
+		got = append(got, obj.GetObjectKind().GroupVersionKind().Kind+" "+obj.(kmeta.Accessor).GetName())
+	}
+	want := []string{
+		"DestinationRule hello-00001-traffic-policy",
+		"VirtualService hello-mesh",
+		"VirtualService hello-ingress",
+	}
+	if !cmp.Equal(got, want) {
+		t.Error("Unexpected rendered objects (-want, +got):", cmp.Diff(want, got))
+	}
+
+	// The HTTP server of the HTTPOption goes to the shared Gateways, which are not rendered.
+	vs := objs[2].(*v1beta1.VirtualService)
+	wantGateways := []string{
+		system.Namespace() + "/knative-ingress-gateway",
+		system.Namespace() + "/knative-local-gateway",
+	}
+	if !cmp.Equal(vs.Spec.Gateways, wantGateways) {
+		t.Error("Unexpected VirtualService Gateways (-want, +got):", cmp.Diff(wantGateways, vs.Spec.Gateways))
+	}
+}
+
+func TestRender_Retries(t *testing.T) {
+	in, err := readInputs("testdata/ingress.yaml", "../../config/config.yaml", "", "testdata/objects.yaml")
+	if err != nil {
+		t.Fatal("readInputs() =", err)
+	}
+	// The retries are cleared when the Ingress is defaulted, like the controller does.
+	in.ingress.Annotations[resources.RoutePolicyAnnotationKey] = "true"
+	for _, rule := range in.ingress.Spec.Rules {
+		for i := range rule.HTTP.Paths {
+			rule.HTTP.Paths[i].DeprecatedRetries = &v1alpha1.HTTPRetry{Attempts: 3}
+		}
+	}
+	objs, err := render(context.Background(), in)
+	if err != nil {
+		t.Fatal("render() =", err)
+	}
+
+	for _, obj := range objs {
+		vs, ok := obj.(*v1beta1.VirtualService)
+		if !ok {
+			continue
+		}
+		for _, route := range vs.Spec.Http {
+			if route.Retries == nil || route.Retries.Attempts != 3 {
+				t.Errorf("VirtualService %s route retries = %v, wanted 3 attempts", vs.Name, route.Retries)
+			}
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	objs := renderTestdata(t)
+	out, err := toYAML(objs)
+	if err != nil {
+		t.Fatal("toYAML() =", err)
+	}
+
+	existing := filepath.Join(t.TempDir(), "existing.yaml")
+	write := func(content string) [][]byte {
+		if err := ioutil.WriteFile(existing, []byte(content), 0644); err != nil {
+			t.Fatal("WriteFile() =", err)
+		}
+		docs, err := readManifests(existing)
+		if err != nil {
+			t.Fatal("readManifests() =", err)
+		}
+		return docs
+	}
+
+	tests := []struct {
+		name     string
+		existing string
+		want     []string
+	}{{
+		name:     "up to date",
+		existing: out,
+	}, {
+		name: "up to date in another API version",
+		existing: strings.ReplaceAll(out,
+			"networking.istio.io/v1beta1", "networking.istio.io/v1alpha3"),
+	}, {
+		name:     "spec differs",
+		existing: strings.Replace(out, "simple: LEAST_CONN", "simple: RANDOM", 1),
+		want:     []string{"DestinationRule.networking.istio.io default/hello-00001-traffic-policy would be updated"},
+	}, {
+		name:     "missing",
+		existing: out[:strings.Index(out, "---\napiVersion: networking.istio.io/v1beta1\nkind: VirtualService")],
+		want: []string{
+			"VirtualService.networking.istio.io default/hello-mesh would be created",
+			"VirtualService.networking.istio.io default/hello-ingress would be created",
+		},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := diff(objs, write(test.existing))
+			if err != nil {
+				t.Fatal("diff() =", err)
+			}
+			if len(test.want) == 0 && got != "" {
+				t.Errorf("diff() = %s, wanted no difference", got)
+			}
+			for _, w := range test.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("diff() = %s, wanted it to contain %q", got, w)
+				}
+			}
+		})
+	}
+}
@@ -27,6 +27,10 @@ import (
 	clientset "knative.dev/net-istio/pkg/client/istio/clientset/versioned"
 	networkingv1alpha3 "knative.dev/net-istio/pkg/client/istio/clientset/versioned/typed/networking/v1alpha3"
 	fakenetworkingv1alpha3 "knative.dev/net-istio/pkg/client/istio/clientset/versioned/typed/networking/v1alpha3/fake"
+	networkingv1beta1 "knative.dev/net-istio/pkg/client/istio/clientset/versioned/typed/networking/v1beta1"
+	fakenetworkingv1beta1 "knative.dev/net-istio/pkg/client/istio/clientset/versioned/typed/networking/v1beta1/fake"
+	securityv1beta1 "knative.dev/net-istio/pkg/client/istio/clientset/versioned/typed/security/v1beta1"
+	fakesecurityv1beta1 "knative.dev/net-istio/pkg/client/istio/clientset/versioned/typed/security/v1beta1/fake"
 )
 
 // NewSimpleClientset returns a clientset that will respond with the provided objects.
@@ -80,3 +84,13 @@ var _ clientset.Interface = &Clientset{}
 func (c *Clientset) NetworkingV1alpha3() networkingv1alpha3.NetworkingV1alpha3Interface {
 	return &fakenetworkingv1alpha3.FakeNetworkingV1alpha3{Fake: &c.Fake}
 }
+
+// NetworkingV1beta1 retrieves the NetworkingV1beta1Client
+func (c *Clientset) NetworkingV1beta1() networkingv1beta1.NetworkingV1beta1Interface {
+	return &fakenetworkingv1beta1.FakeNetworkingV1beta1{Fake: &c.Fake}
+}
+
+// SecurityV1beta1 retrieves the SecurityV1beta1Client
+func (c *Clientset) SecurityV1beta1() securityv1beta1.SecurityV1beta1Interface {
+	return &fakesecurityv1beta1.FakeSecurityV1beta1{Fake: &c.Fake}
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package istio
+
+import (
+	"context"
+	"fmt"
+
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1beta1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	"knative.dev/net-istio/pkg/client/istio/clientset/versioned"
+	networkingv1beta1 "knative.dev/net-istio/pkg/client/istio/clientset/versioned/typed/networking/v1beta1"
+	istioclient "knative.dev/net-istio/pkg/client/istio/injection/client"
+	"knative.dev/pkg/injection"
+	"knative.dev/pkg/logging"
+)
+
+func init() {
+	// This runs after the registration of the generated Istio client, so the
+	// client injected here replaces it when falling back to v1alpha3.
+	injection.Default.RegisterClient(withClient)
+}
+
+func withClient(ctx context.Context, cfg *rest.Config) context.Context {
+	logger := logging.FromContext(ctx)
+	served, err := ServesV1beta1(discovery.NewDiscoveryClientForConfigOrDie(cfg))
+	if err != nil {
+		logger.Warnw("Failed to discover the Istio networking API versions, assuming v1beta1", "error", err)
+		return ctx
+	}
+	if served {
+		return ctx
+	}
+	logger.Infof("%s is not served, falling back to %s", v1beta1.SchemeGroupVersion, v1alpha3.SchemeGroupVersion)
+	client, err := NewV1alpha3Clientset(cfg)
+	if err != nil {
+		logger.Fatalw("Failed to create the v1alpha3 Istio client", "error", err)
+	}
+	return context.WithValue(ctx, istioclient.Key{}, client)
+}
+
+// ServesV1beta1 returns whether the API server serves the v1beta1 version of the
+// Istio networking API. Control planes older than Istio 1.5 only serve v1alpha3.
+func ServesV1beta1(client discovery.DiscoveryInterface) (bool, error) {
+	if _, err := client.ServerResourcesForGroupVersion(v1beta1.SchemeGroupVersion.String()); err != nil {
+		if apierrs.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get the resources of %s: %w", v1beta1.SchemeGroupVersion, err)
+	}
+	return true, nil
+}
+
+// v1alpha3Clientset is an Istio clientset whose v1beta1 networking client talks
+// v1alpha3 to the API server.
+type v1alpha3Clientset struct {
+	versioned.Interface
+	networkingV1beta1 networkingv1beta1.NetworkingV1beta1Interface
+}
+
+// NetworkingV1beta1 implements versioned.Interface.
+func (c *v1alpha3Clientset) NetworkingV1beta1() networkingv1beta1.NetworkingV1beta1Interface {
+	return c.networkingV1beta1
+}
+
+// NewV1alpha3Clientset creates an Istio clientset for the given config whose v1beta1
+// networking client reads and writes the v1alpha3 version of the resources, converting
+// them on the fly. Both versions share the same schema.
+func NewV1alpha3Clientset(cfg *rest.Config) (versioned.Interface, error) {
+	cs, err := versioned.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	config := rest.CopyConfig(cfg)
+	gv := v1alpha3.SchemeGroupVersion
+	config.GroupVersion = &gv
+	config.APIPath = "/apis"
+	config.NegotiatedSerializer = negotiatedSerializer{}
+	if config.UserAgent == "" {
+		config.UserAgent = rest.DefaultKubernetesUserAgent()
+	}
+	restClient, err := rest.RESTClientFor(config)
+	if err != nil {
+		return nil, err
+	}
+	return &v1alpha3Clientset{
+		Interface:         cs,
+		networkingV1beta1: networkingv1beta1.New(restClient),
+	}, nil
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package istio
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	"istio.io/client-go/pkg/apis/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+)
+
+const v1alpha3Path = "/apis/networking.istio.io/v1alpha3/namespaces/test-ns/virtualservices"
+
+func TestServesV1beta1(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		want    bool
+		wantErr bool
+	}{{
+		name:   "served",
+		status: http.StatusOK,
+		want:   true,
+	}, {
+		name:   "not served",
+		status: http.StatusNotFound,
+	}, {
+		name:    "discovery failure",
+		status:  http.StatusInternalServerError,
+		wantErr: true,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/apis/networking.istio.io/v1beta1" {
+					t.Errorf("Path = %s, want /apis/networking.istio.io/v1beta1", r.URL.Path)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(test.status)
+				json.NewEncoder(w).Encode(metav1.APIResourceList{GroupVersion: "networking.istio.io/v1beta1"})
+			}))
+			defer srv.Close()
+
+			got, err := ServesV1beta1(discovery.NewDiscoveryClientForConfigOrDie(&rest.Config{Host: srv.URL}))
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ServesV1beta1() error = %v, WantErr %v", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("ServesV1beta1() = %t, want %t", got, test.want)
+			}
+		})
+	}
+}
+
+func TestV1alpha3Clientset(t *testing.T) {
+	const v1alpha3VS = `{"apiVersion":"networking.istio.io/v1alpha3","kind":"VirtualService",` +
+		`"metadata":{"name":"vs","namespace":"test-ns"},"spec":{"hosts":["example.com"]}}`
+	want := &v1beta1.VirtualService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "vs",
+			Namespace: "test-ns",
+		},
+		Spec: istiov1beta1.VirtualService{
+			Hosts: []string{"example.com"},
+		},
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == v1alpha3Path:
+			body, _ := ioutil.ReadAll(r.Body)
+			var got map[string]interface{}
+			json.Unmarshal(body, &got)
+			if got["apiVersion"] != "networking.istio.io/v1alpha3" {
+				t.Errorf("Sent apiVersion = %v, want networking.istio.io/v1alpha3", got["apiVersion"])
+			}
+			w.Write([]byte(v1alpha3VS))
+		case r.Method == http.MethodGet && r.URL.Path == v1alpha3Path && r.URL.Query().Get("watch") == "true":
+			w.Write([]byte(`{"type":"ADDED","object":` + v1alpha3VS + "}\n"))
+		case r.Method == http.MethodGet && r.URL.Path == v1alpha3Path:
+			w.Write([]byte(`{"apiVersion":"networking.istio.io/v1alpha3","kind":"VirtualServiceList",` +
+				`"metadata":{},"items":[` + v1alpha3VS + "]}"))
+		case r.Method == http.MethodDelete && r.URL.Path == v1alpha3Path+"/vs":
+			w.Write([]byte(`{"apiVersion":"v1","kind":"Status","status":"Success"}`))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	cs, err := NewV1alpha3Clientset(&rest.Config{Host: srv.URL})
+	if err != nil {
+		t.Fatal("NewV1alpha3Clientset() =", err)
+	}
+	client := cs.NetworkingV1beta1().VirtualServices("test-ns")
+	ctx := context.Background()
+
+	created, err := client.Create(ctx, want, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal("Create() =", err)
+	}
+	if diff := cmp.Diff(want, created); diff != "" {
+		t.Error("Unexpected created VirtualService (-want, +got):", diff)
+	}
+
+	list, err := client.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatal("List() =", err)
+	}
+	if diff := cmp.Diff([]v1beta1.VirtualService{*want}, list.Items); diff != "" {
+		t.Error("Unexpected listed VirtualServices (-want, +got):", diff)
+	}
+
+	w, err := client.Watch(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatal("Watch() =", err)
+	}
+	event := <-w.ResultChan()
+	w.Stop()
+	if event.Type != watch.Added {
+		t.Errorf("Event type = %s, want %s", event.Type, watch.Added)
+	}
+	if diff := cmp.Diff(want, event.Object); diff != "" {
+		t.Error("Unexpected watched VirtualService (-want, +got):", diff)
+	}
+
+	if err := client.Delete(ctx, "vs", metav1.DeleteOptions{}); err != nil {
+		t.Fatal("Delete() =", err)
+	}
+}
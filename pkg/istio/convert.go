@@ -0,0 +1,100 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package istio
+
+import (
+	"encoding/json"
+
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/conversion"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+)
+
+var (
+	scheme = runtime.NewScheme()
+	codecs = serializer.NewCodecFactory(scheme)
+)
+
+func init() {
+	utilruntime.Must(v1alpha3.AddToScheme(scheme))
+	utilruntime.Must(v1beta1.AddToScheme(scheme))
+	for _, pair := range [][2]runtime.Object{
+		{&v1alpha3.DestinationRule{}, &v1beta1.DestinationRule{}},
+		{&v1alpha3.DestinationRuleList{}, &v1beta1.DestinationRuleList{}},
+		{&v1alpha3.Gateway{}, &v1beta1.Gateway{}},
+		{&v1alpha3.GatewayList{}, &v1beta1.GatewayList{}},
+		{&v1alpha3.ServiceEntry{}, &v1beta1.ServiceEntry{}},
+		{&v1alpha3.ServiceEntryList{}, &v1beta1.ServiceEntryList{}},
+		{&v1alpha3.Sidecar{}, &v1beta1.Sidecar{}},
+		{&v1alpha3.SidecarList{}, &v1beta1.SidecarList{}},
+		{&v1alpha3.VirtualService{}, &v1beta1.VirtualService{}},
+		{&v1alpha3.VirtualServiceList{}, &v1beta1.VirtualServiceList{}},
+		{&v1alpha3.WorkloadEntry{}, &v1beta1.WorkloadEntry{}},
+		{&v1alpha3.WorkloadEntryList{}, &v1beta1.WorkloadEntryList{}},
+	} {
+		utilruntime.Must(scheme.AddConversionFunc(pair[0], pair[1], convert))
+		utilruntime.Must(scheme.AddConversionFunc(pair[1], pair[0], convert))
+	}
+}
+
+// convert converts between the v1alpha3 and v1beta1 versions of an Istio
+// networking resource. They only differ by their version, so going through
+// their JSON representation is lossless.
+func convert(in, out interface{}, _ conversion.Scope) error {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, out); err != nil {
+		return err
+	}
+	// Like the typed clients, leave the version out of the items of the lists.
+	if obj := out.(runtime.Object); meta.IsListType(obj) {
+		return meta.EachListItem(obj, func(item runtime.Object) error {
+			item.GetObjectKind().SetGroupVersionKind(schema.GroupVersionKind{})
+			return nil
+		})
+	}
+	return nil
+}
+
+// negotiatedSerializer sends v1alpha3 resources to the API server and decodes
+// its responses into v1beta1 resources.
+type negotiatedSerializer struct{}
+
+var _ runtime.NegotiatedSerializer = negotiatedSerializer{}
+
+// SupportedMediaTypes implements runtime.NegotiatedSerializer.
+func (negotiatedSerializer) SupportedMediaTypes() []runtime.SerializerInfo {
+	return codecs.SupportedMediaTypes()
+}
+
+// EncoderForVersion implements runtime.NegotiatedSerializer.
+func (negotiatedSerializer) EncoderForVersion(encoder runtime.Encoder, _ runtime.GroupVersioner) runtime.Encoder {
+	return codecs.EncoderForVersion(encoder, v1alpha3.SchemeGroupVersion)
+}
+
+// DecoderToVersion implements runtime.NegotiatedSerializer.
+func (negotiatedSerializer) DecoderToVersion(decoder runtime.Decoder, _ runtime.GroupVersioner) runtime.Decoder {
+	// Like the typed clients, leave the version out of the decoded objects.
+	return runtime.WithoutVersionDecoder{Decoder: codecs.DecoderToVersion(decoder, v1beta1.SchemeGroupVersion)}
+}
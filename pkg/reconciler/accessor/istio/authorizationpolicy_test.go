@@ -0,0 +1,169 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package istio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	istiosecurity "istio.io/api/security/v1beta1"
+	"istio.io/client-go/pkg/apis/security/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	istioclientset "knative.dev/net-istio/pkg/client/istio/clientset/versioned"
+	fakeistioclient "knative.dev/net-istio/pkg/client/istio/injection/client/fake"
+	fakeapinformer "knative.dev/net-istio/pkg/client/istio/injection/informers/security/v1beta1/authorizationpolicy/fake"
+	istiolisters "knative.dev/net-istio/pkg/client/istio/listers/security/v1beta1"
+	kaccessor "knative.dev/net-istio/pkg/reconciler/accessor"
+
+	. "knative.dev/pkg/reconciler/testing"
+)
+
+var (
+	originAP = &v1beta1.AuthorizationPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ap",
+			Namespace: "istio-system",
+			Labels:    map[string]string{"owner": "ownerObj"},
+		},
+		Spec: istiosecurity.AuthorizationPolicy{
+			Action: istiosecurity.AuthorizationPolicy_ALLOW,
+		},
+	}
+
+	desiredAP = &v1beta1.AuthorizationPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ap",
+			Namespace: "istio-system",
+			Labels:    map[string]string{"owner": "ownerObj"},
+		},
+		Spec: istiosecurity.AuthorizationPolicy{
+			Action: istiosecurity.AuthorizationPolicy_DENY,
+		},
+	}
+)
+
+type FakeAuthorizationPolicyAccessor struct {
+	client   istioclientset.Interface
+	apLister istiolisters.AuthorizationPolicyLister
+}
+
+func (f *FakeAuthorizationPolicyAccessor) GetIstioClient() istioclientset.Interface {
+	return f.client
+}
+
+func (f *FakeAuthorizationPolicyAccessor) GetAuthorizationPolicyLister() istiolisters.AuthorizationPolicyLister {
+	return f.apLister
+}
+
+func TestReconcileAuthorizationPolicy_Create(t *testing.T) {
+	ctx, cancel, informers := SetupFakeContextWithCancel(t)
+
+	istio := fakeistioclient.Get(ctx)
+	apInformer := fakeapinformer.Get(ctx)
+
+	waitInformers, err := RunAndSyncInformers(ctx, informers...)
+	if err != nil {
+		t.Fatal("Failed to start informers")
+	}
+	defer func() {
+		cancel()
+		waitInformers()
+	}()
+
+	accessor := &FakeAuthorizationPolicyAccessor{
+		client:   istio,
+		apLister: apInformer.Lister(),
+	}
+
+	h := NewHooks()
+	h.OnCreate(&istio.Fake, "authorizationpolicies", func(obj runtime.Object) HookResult {
+		got := obj.(*v1beta1.AuthorizationPolicy)
+		if diff := cmp.Diff(got, desiredAP); diff != "" {
+			t.Log("Unexpected AuthorizationPolicy (-want, +got):", diff)
+			return HookIncomplete
+		}
+		return HookComplete
+	})
+
+	ReconcileAuthorizationPolicy(ctx, ownerObj, desiredAP, accessor)
+
+	if err := h.WaitForHooks(3 * time.Second); err != nil {
+		t.Error("Failed to Reconcile AuthorizationPolicy:", err)
+	}
+}
+
+func TestReconcileAuthorizationPolicy_Update(t *testing.T) {
+	ctx, cancel, informers := SetupFakeContextWithCancel(t)
+
+	istio := fakeistioclient.Get(ctx)
+	apInformer := fakeapinformer.Get(ctx)
+
+	waitInformers, err := RunAndSyncInformers(ctx, informers...)
+	if err != nil {
+		t.Fatal("Failed to start informers")
+	}
+	defer func() {
+		cancel()
+		waitInformers()
+	}()
+
+	accessor := &FakeAuthorizationPolicyAccessor{
+		client:   istio,
+		apLister: apInformer.Lister(),
+	}
+
+	istio.SecurityV1beta1().AuthorizationPolicies(originAP.Namespace).Create(ctx, originAP, metav1.CreateOptions{})
+	apInformer.Informer().GetIndexer().Add(originAP)
+
+	h := NewHooks()
+	h.OnUpdate(&istio.Fake, "authorizationpolicies", func(obj runtime.Object) HookResult {
+		got := obj.(*v1beta1.AuthorizationPolicy)
+		if diff := cmp.Diff(got, desiredAP); diff != "" {
+			t.Log("Unexpected AuthorizationPolicy (-want, +got):", diff)
+			return HookIncomplete
+		}
+		return HookComplete
+	})
+
+	ReconcileAuthorizationPolicy(ctx, ownerObj, desiredAP, accessor)
+	if err := h.WaitForHooks(3 * time.Second); err != nil {
+		t.Error("Failed to Reconcile AuthorizationPolicy:", err)
+	}
+}
+
+func TestReconcileAuthorizationPolicy_NotOwned(t *testing.T) {
+	ctx, cancel, _ := SetupFakeContextWithCancel(t)
+	defer cancel()
+
+	istio := fakeistioclient.Get(ctx)
+	apInformer := fakeapinformer.Get(ctx)
+
+	accessor := &FakeAuthorizationPolicyAccessor{
+		client:   istio,
+		apLister: apInformer.Lister(),
+	}
+
+	notOwned := originAP.DeepCopy()
+	notOwned.Labels = map[string]string{"owner": "someone-else"}
+	apInformer.Informer().GetIndexer().Add(notOwned)
+
+	if _, err := ReconcileAuthorizationPolicy(ctx, ownerObj, desiredAP, accessor); !kaccessor.IsNotOwned(err) {
+		t.Errorf("ReconcileAuthorizationPolicy() = %v, wanted a not owned error", err)
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package istio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	istionetworking "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	istioclientset "knative.dev/net-istio/pkg/client/istio/clientset/versioned"
+	fakeistioclient "knative.dev/net-istio/pkg/client/istio/injection/client/fake"
+	fakeefinformer "knative.dev/net-istio/pkg/client/istio/injection/informers/networking/v1alpha3/envoyfilter/fake"
+	istiolisters "knative.dev/net-istio/pkg/client/istio/listers/networking/v1alpha3"
+	kaccessor "knative.dev/net-istio/pkg/reconciler/accessor"
+
+	. "knative.dev/pkg/reconciler/testing"
+)
+
+var (
+	originEF = &v1alpha3.EnvoyFilter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ef",
+			Namespace: "istio-system",
+			Labels:    map[string]string{"owner": "ownerObj"},
+		},
+		Spec: istionetworking.EnvoyFilter{
+			WorkloadSelector: &istionetworking.WorkloadSelector{
+				Labels: map[string]string{"istio": "ingressgateway"},
+			},
+		},
+	}
+
+	desiredEF = &v1alpha3.EnvoyFilter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ef",
+			Namespace: "istio-system",
+			Labels:    map[string]string{"owner": "ownerObj"},
+		},
+		Spec: istionetworking.EnvoyFilter{
+			WorkloadSelector: &istionetworking.WorkloadSelector{
+				Labels: map[string]string{"istio": "custom-gateway"},
+			},
+		},
+	}
+)
+
+type FakeEnvoyFilterAccessor struct {
+	client   istioclientset.Interface
+	efLister istiolisters.EnvoyFilterLister
+}
+
+func (f *FakeEnvoyFilterAccessor) GetIstioClient() istioclientset.Interface {
+	return f.client
+}
+
+func (f *FakeEnvoyFilterAccessor) GetEnvoyFilterLister() istiolisters.EnvoyFilterLister {
+	return f.efLister
+}
+
+func TestReconcileEnvoyFilter_Create(t *testing.T) {
+	ctx, cancel, informers := SetupFakeContextWithCancel(t)
+
+	istio := fakeistioclient.Get(ctx)
+	efInformer := fakeefinformer.Get(ctx)
+
+	waitInformers, err := RunAndSyncInformers(ctx, informers...)
+	if err != nil {
+		t.Fatal("Failed to start informers")
+	}
+	defer func() {
+		cancel()
+		waitInformers()
+	}()
+
+	accessor := &FakeEnvoyFilterAccessor{
+		client:   istio,
+		efLister: efInformer.Lister(),
+	}
+
+	h := NewHooks()
+	h.OnCreate(&istio.Fake, "envoyfilters", func(obj runtime.Object) HookResult {
+		got := obj.(*v1alpha3.EnvoyFilter)
+		if diff := cmp.Diff(got, desiredEF); diff != "" {
+			t.Log("Unexpected EnvoyFilter (-want, +got):", diff)
+			return HookIncomplete
+		}
+		return HookComplete
+	})
+
+	ReconcileEnvoyFilter(ctx, ownerObj, desiredEF, accessor)
+
+	if err := h.WaitForHooks(3 * time.Second); err != nil {
+		t.Error("Failed to Reconcile EnvoyFilter:", err)
+	}
+}
+
+func TestReconcileEnvoyFilter_Update(t *testing.T) {
+	ctx, cancel, informers := SetupFakeContextWithCancel(t)
+
+	istio := fakeistioclient.Get(ctx)
+	efInformer := fakeefinformer.Get(ctx)
+
+	waitInformers, err := RunAndSyncInformers(ctx, informers...)
+	if err != nil {
+		t.Fatal("Failed to start informers")
+	}
+	defer func() {
+		cancel()
+		waitInformers()
+	}()
+
+	accessor := &FakeEnvoyFilterAccessor{
+		client:   istio,
+		efLister: efInformer.Lister(),
+	}
+
+	istio.NetworkingV1alpha3().EnvoyFilters(originEF.Namespace).Create(ctx, originEF, metav1.CreateOptions{})
+	efInformer.Informer().GetIndexer().Add(originEF)
+
+	h := NewHooks()
+	h.OnUpdate(&istio.Fake, "envoyfilters", func(obj runtime.Object) HookResult {
+		got := obj.(*v1alpha3.EnvoyFilter)
+		if diff := cmp.Diff(got, desiredEF); diff != "" {
+			t.Log("Unexpected EnvoyFilter (-want, +got):", diff)
+			return HookIncomplete
+		}
+		return HookComplete
+	})
+
+	ReconcileEnvoyFilter(ctx, ownerObj, desiredEF, accessor)
+	if err := h.WaitForHooks(3 * time.Second); err != nil {
+		t.Error("Failed to Reconcile EnvoyFilter:", err)
+	}
+}
+
+func TestReconcileEnvoyFilter_NotOwned(t *testing.T) {
+	ctx, cancel, _ := SetupFakeContextWithCancel(t)
+	defer cancel()
+
+	istio := fakeistioclient.Get(ctx)
+	efInformer := fakeefinformer.Get(ctx)
+
+	accessor := &FakeEnvoyFilterAccessor{
+		client:   istio,
+		efLister: efInformer.Lister(),
+	}
+
+	notOwned := originEF.DeepCopy()
+	notOwned.Labels = map[string]string{"owner": "someone-else"}
+	efInformer.Informer().GetIndexer().Add(notOwned)
+
+	if _, err := ReconcileEnvoyFilter(ctx, ownerObj, desiredEF, accessor); !kaccessor.IsNotOwned(err) {
+		t.Errorf("ReconcileEnvoyFilter() = %v, wanted a not owned error", err)
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package istio
+
+import (
+	"context"
+	"fmt"
+
+	"istio.io/client-go/pkg/apis/networking/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	istioclientset "knative.dev/net-istio/pkg/client/istio/clientset/versioned"
+	istiolisters "knative.dev/net-istio/pkg/client/istio/listers/networking/v1beta1"
+	kaccessor "knative.dev/net-istio/pkg/reconciler/accessor"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/kmeta"
+)
+
+// GatewayAccessor is an interface for accessing Gateway.
+type GatewayAccessor interface {
+	GetIstioClient() istioclientset.Interface
+	GetGatewayLister() istiolisters.GatewayLister
+}
+
+func gatewayIsDifferent(current, desired *v1beta1.Gateway) bool {
+	return !equality.Semantic.DeepEqual(current.Spec, desired.Spec) ||
+		!equality.Semantic.DeepEqual(current.Labels, desired.Labels) ||
+		!equality.Semantic.DeepEqual(current.Annotations, desired.Annotations)
+}
+
+// ReconcileGateway reconciles Gateway to the desired status.
+func ReconcileGateway(ctx context.Context, owner kmeta.Accessor, desired *v1beta1.Gateway,
+	gwAccessor GatewayAccessor) (*v1beta1.Gateway, error) {
+
+	recorder := controller.GetEventRecorder(ctx)
+	if recorder == nil {
+		return nil, fmt.Errorf("recorder for reconciling Gateway %s/%s is not created", desired.Namespace, desired.Name)
+	}
+	ns := desired.Namespace
+	name := desired.Name
+	gw, err := gwAccessor.GetGatewayLister().Gateways(ns).Get(name)
+	if apierrs.IsNotFound(err) {
+		gw, err = gwAccessor.GetIstioClient().NetworkingV1beta1().Gateways(ns).Create(ctx, desired, metav1.CreateOptions{})
+		if err != nil {
+			recorder.Eventf(owner, corev1.EventTypeWarning, "CreationFailed",
+				"Failed to create Gateway %s/%s: %v", ns, name, err)
+			return nil, fmt.Errorf("failed to create Gateway: %w", err)
+		}
+		recorder.Eventf(owner, corev1.EventTypeNormal, "Created", "Created Gateway %q", desired.Name)
+	} else if err != nil {
+		return nil, err
+	} else if !metav1.IsControlledBy(gw, owner) {
+		// Return an error with NotControlledBy information.
+		return nil, kaccessor.NewAccessorError(
+			fmt.Errorf("owner: %s with Type %T does not own Gateway: %q", owner.GetName(), owner, name),
+			kaccessor.NotOwnResource)
+	} else if gatewayIsDifferent(gw, desired) {
+		// Don't modify the informers copy
+		existing := gw.DeepCopy()
+		existing.Spec = desired.Spec
+		existing.Labels = desired.Labels
+		existing.Annotations = desired.Annotations
+		gw, err = gwAccessor.GetIstioClient().NetworkingV1beta1().Gateways(ns).Update(ctx, existing, metav1.UpdateOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to update Gateway: %w", err)
+		}
+		recorder.Eventf(owner, corev1.EventTypeNormal, "Updated", "Updated Gateway %s/%s", ns, name)
+	}
+	return gw, nil
+}
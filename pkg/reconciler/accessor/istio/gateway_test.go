@@ -0,0 +1,151 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package istio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	"istio.io/client-go/pkg/apis/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	istioclientset "knative.dev/net-istio/pkg/client/istio/clientset/versioned"
+	fakeistioclient "knative.dev/net-istio/pkg/client/istio/injection/client/fake"
+	fakegwinformer "knative.dev/net-istio/pkg/client/istio/injection/informers/networking/v1beta1/gateway/fake"
+	istiolisters "knative.dev/net-istio/pkg/client/istio/listers/networking/v1beta1"
+
+	. "knative.dev/pkg/reconciler/testing"
+)
+
+var (
+	originGateway = &v1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "gateway",
+			Namespace:       "default",
+			OwnerReferences: []metav1.OwnerReference{ownerRef},
+		},
+		Spec: istiov1beta1.Gateway{
+			Servers: []*istiov1beta1.Server{{
+				Hosts: []string{"origin.example.com"},
+			}},
+		},
+	}
+
+	desiredGateway = &v1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "gateway",
+			Namespace:       "default",
+			OwnerReferences: []metav1.OwnerReference{ownerRef},
+		},
+		Spec: istiov1beta1.Gateway{
+			Servers: []*istiov1beta1.Server{{
+				Hosts: []string{"desired.example.com"},
+			}},
+		},
+	}
+)
+
+type FakeGatewayAccessor struct {
+	client   istioclientset.Interface
+	gwLister istiolisters.GatewayLister
+}
+
+func (f *FakeGatewayAccessor) GetIstioClient() istioclientset.Interface {
+	return f.client
+}
+
+func (f *FakeGatewayAccessor) GetGatewayLister() istiolisters.GatewayLister {
+	return f.gwLister
+}
+
+func TestReconcileGateway_Create(t *testing.T) {
+	ctx, cancel, informers := SetupFakeContextWithCancel(t)
+
+	istio := fakeistioclient.Get(ctx)
+	gwInformer := fakegwinformer.Get(ctx)
+
+	waitInformers, err := RunAndSyncInformers(ctx, informers...)
+	if err != nil {
+		t.Fatal("Failed to start informers")
+	}
+	defer func() {
+		cancel()
+		waitInformers()
+	}()
+
+	accessor := &FakeGatewayAccessor{
+		client:   istio,
+		gwLister: gwInformer.Lister(),
+	}
+
+	h := NewHooks()
+	h.OnCreate(&istio.Fake, "gateways", func(obj runtime.Object) HookResult {
+		got := obj.(*v1beta1.Gateway)
+		if diff := cmp.Diff(got, desiredGateway); diff != "" {
+			t.Log("Unexpected Gateway (-want, +got):", diff)
+			return HookIncomplete
+		}
+		return HookComplete
+	})
+
+	ReconcileGateway(ctx, ownerObj, desiredGateway, accessor)
+
+	if err := h.WaitForHooks(3 * time.Second); err != nil {
+		t.Error("Failed to Reconcile Gateway:", err)
+	}
+}
+
+func TestReconcileGateway_Update(t *testing.T) {
+	ctx, cancel, informers := SetupFakeContextWithCancel(t)
+
+	istio := fakeistioclient.Get(ctx)
+	gwInformer := fakegwinformer.Get(ctx)
+
+	waitInformers, err := RunAndSyncInformers(ctx, informers...)
+	if err != nil {
+		t.Fatal("Failed to start informers")
+	}
+	defer func() {
+		cancel()
+		waitInformers()
+	}()
+
+	accessor := &FakeGatewayAccessor{
+		client:   istio,
+		gwLister: gwInformer.Lister(),
+	}
+
+	istio.NetworkingV1beta1().Gateways(origin.Namespace).Create(ctx, originGateway, metav1.CreateOptions{})
+	gwInformer.Informer().GetIndexer().Add(originGateway)
+
+	h := NewHooks()
+	h.OnUpdate(&istio.Fake, "gateways", func(obj runtime.Object) HookResult {
+		got := obj.(*v1beta1.Gateway)
+		if diff := cmp.Diff(got, desiredGateway); diff != "" {
+			t.Log("Unexpected Gateway (-want, +got):", diff)
+			return HookIncomplete
+		}
+		return HookComplete
+	})
+
+	ReconcileGateway(ctx, ownerObj, desiredGateway, accessor)
+	if err := h.WaitForHooks(3 * time.Second); err != nil {
+		t.Error("Failed to Reconcile Gateway:", err)
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package istio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	istiosecurity "istio.io/api/security/v1beta1"
+	"istio.io/client-go/pkg/apis/security/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	istioclientset "knative.dev/net-istio/pkg/client/istio/clientset/versioned"
+	fakeistioclient "knative.dev/net-istio/pkg/client/istio/injection/client/fake"
+	fakerainformer "knative.dev/net-istio/pkg/client/istio/injection/informers/security/v1beta1/requestauthentication/fake"
+	istiolisters "knative.dev/net-istio/pkg/client/istio/listers/security/v1beta1"
+	kaccessor "knative.dev/net-istio/pkg/reconciler/accessor"
+
+	. "knative.dev/pkg/reconciler/testing"
+)
+
+var (
+	originRA = &v1beta1.RequestAuthentication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ra",
+			Namespace: "istio-system",
+			Labels:    map[string]string{"owner": "ownerObj"},
+		},
+		Spec: istiosecurity.RequestAuthentication{
+			JwtRules: []*istiosecurity.JWTRule{{
+				Issuer: "origin.example.com",
+			}},
+		},
+	}
+
+	desiredRA = &v1beta1.RequestAuthentication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ra",
+			Namespace: "istio-system",
+			Labels:    map[string]string{"owner": "ownerObj"},
+		},
+		Spec: istiosecurity.RequestAuthentication{
+			JwtRules: []*istiosecurity.JWTRule{{
+				Issuer: "desired.example.com",
+			}},
+		},
+	}
+)
+
+type FakeRequestAuthenticationAccessor struct {
+	client   istioclientset.Interface
+	raLister istiolisters.RequestAuthenticationLister
+}
+
+func (f *FakeRequestAuthenticationAccessor) GetIstioClient() istioclientset.Interface {
+	return f.client
+}
+
+func (f *FakeRequestAuthenticationAccessor) GetRequestAuthenticationLister() istiolisters.RequestAuthenticationLister {
+	return f.raLister
+}
+
+func TestReconcileRequestAuthentication_Create(t *testing.T) {
+	ctx, cancel, informers := SetupFakeContextWithCancel(t)
+
+	istio := fakeistioclient.Get(ctx)
+	raInformer := fakerainformer.Get(ctx)
+
+	waitInformers, err := RunAndSyncInformers(ctx, informers...)
+	if err != nil {
+		t.Fatal("Failed to start informers")
+	}
+	defer func() {
+		cancel()
+		waitInformers()
+	}()
+
+	accessor := &FakeRequestAuthenticationAccessor{
+		client:   istio,
+		raLister: raInformer.Lister(),
+	}
+
+	h := NewHooks()
+	h.OnCreate(&istio.Fake, "requestauthentications", func(obj runtime.Object) HookResult {
+		got := obj.(*v1beta1.RequestAuthentication)
+		if diff := cmp.Diff(got, desiredRA); diff != "" {
+			t.Log("Unexpected RequestAuthentication (-want, +got):", diff)
+			return HookIncomplete
+		}
+		return HookComplete
+	})
+
+	ReconcileRequestAuthentication(ctx, ownerObj, desiredRA, accessor)
+
+	if err := h.WaitForHooks(3 * time.Second); err != nil {
+		t.Error("Failed to Reconcile RequestAuthentication:", err)
+	}
+}
+
+func TestReconcileRequestAuthentication_Update(t *testing.T) {
+	ctx, cancel, informers := SetupFakeContextWithCancel(t)
+
+	istio := fakeistioclient.Get(ctx)
+	raInformer := fakerainformer.Get(ctx)
+
+	waitInformers, err := RunAndSyncInformers(ctx, informers...)
+	if err != nil {
+		t.Fatal("Failed to start informers")
+	}
+	defer func() {
+		cancel()
+		waitInformers()
+	}()
+
+	accessor := &FakeRequestAuthenticationAccessor{
+		client:   istio,
+		raLister: raInformer.Lister(),
+	}
+
+	istio.SecurityV1beta1().RequestAuthentications(originRA.Namespace).Create(ctx, originRA, metav1.CreateOptions{})
+	raInformer.Informer().GetIndexer().Add(originRA)
+
+	h := NewHooks()
+	h.OnUpdate(&istio.Fake, "requestauthentications", func(obj runtime.Object) HookResult {
+		got := obj.(*v1beta1.RequestAuthentication)
+		if diff := cmp.Diff(got, desiredRA); diff != "" {
+			t.Log("Unexpected RequestAuthentication (-want, +got):", diff)
+			return HookIncomplete
+		}
+		return HookComplete
+	})
+
+	ReconcileRequestAuthentication(ctx, ownerObj, desiredRA, accessor)
+	if err := h.WaitForHooks(3 * time.Second); err != nil {
+		t.Error("Failed to Reconcile RequestAuthentication:", err)
+	}
+}
+
+func TestReconcileRequestAuthentication_NotOwned(t *testing.T) {
+	ctx, cancel, _ := SetupFakeContextWithCancel(t)
+	defer cancel()
+
+	istio := fakeistioclient.Get(ctx)
+	raInformer := fakerainformer.Get(ctx)
+
+	accessor := &FakeRequestAuthenticationAccessor{
+		client:   istio,
+		raLister: raInformer.Lister(),
+	}
+
+	notOwned := originRA.DeepCopy()
+	notOwned.Labels = map[string]string{"owner": "someone-else"}
+	raInformer.Informer().GetIndexer().Add(notOwned)
+
+	if _, err := ReconcileRequestAuthentication(ctx, ownerObj, desiredRA, accessor); !kaccessor.IsNotOwned(err) {
+		t.Errorf("ReconcileRequestAuthentication() = %v, wanted a not owned error", err)
+	}
+}
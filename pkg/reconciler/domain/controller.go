@@ -0,0 +1,107 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package domain
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	istioclient "knative.dev/net-istio/pkg/client/istio/injection/client"
+	gatewayinformer "knative.dev/net-istio/pkg/client/istio/injection/informers/networking/v1beta1/gateway"
+	"knative.dev/net-istio/pkg/reconciler/ingress/config"
+	network "knative.dev/networking/pkg"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	domaininformer "knative.dev/networking/pkg/client/injection/informers/networking/v1alpha1/domain"
+	domainreconciler "knative.dev/networking/pkg/client/injection/reconciler/networking/v1alpha1/domain"
+	serviceinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/service"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/tracker"
+)
+
+// NewController initializes the controller and is called by the generated code.
+// Registers eventhandlers to enqueue events.
+func NewController(
+	ctx context.Context,
+	cmw configmap.Watcher,
+) *controller.Impl {
+
+	logger := logging.FromContext(ctx)
+	domainInformer := domaininformer.Get(ctx)
+	gatewayInformer := gatewayinformer.Get(ctx)
+	serviceInformer := serviceinformer.Get(ctx)
+
+	c := &reconciler{
+		istioClientSet: istioclient.Get(ctx),
+		gatewayLister:  gatewayInformer.Lister(),
+		svcLister:      serviceInformer.Lister(),
+	}
+	impl := domainreconciler.NewImpl(ctx, c, func(impl *controller.Impl) controller.Options {
+		logger.Info("Setting up ConfigMap receivers")
+		resync := configmap.TypeFilter(&config.Istio{})(func(string, interface{}) {
+			impl.FilteredGlobalResync(isIstioDomain, domainInformer.Informer())
+		})
+		configStore := config.NewStore(logger.Named("config-store"), resync)
+		configStore.WatchConfigs(cmw)
+		return controller.Options{ConfigStore: configStore}
+	})
+
+	logger.Info("Setting up event handlers")
+	domainInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: isIstioDomain,
+		Handler:    controller.HandleAll(impl.Enqueue),
+	})
+
+	// The Domains are only enqueued while they are of the Istio ingress class,
+	// so that the status of the Domains of other classes is never touched.
+	enqueueIstioDomain := func(key types.NamespacedName) {
+		if d, err := domainInformer.Lister().Get(key.Name); err == nil && isIstioDomain(d) {
+			impl.EnqueueKey(key)
+		}
+	}
+	gatewayInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: controller.FilterController(&v1alpha1.Domain{}),
+		Handler: controller.HandleAll(func(obj interface{}) {
+			if object, err := kmeta.DeletionHandlingAccessor(obj); err == nil {
+				enqueueIstioDomain(types.NamespacedName{Name: metav1.GetControllerOf(object).Name})
+			}
+		}),
+	})
+
+	// Domains select the pods of their gateway Services.
+	c.tracker = tracker.New(enqueueIstioDomain, controller.GetTrackerLease(ctx))
+	serviceInformer.Informer().AddEventHandler(controller.HandleAll(
+		controller.EnsureTypeMeta(
+			c.tracker.OnChanged,
+			corev1.SchemeGroupVersion.WithKind("Service"),
+		),
+	))
+
+	return impl
+}
+
+// isIstioDomain returns whether the given object is a Domain of the Istio
+// ingress class.
+func isIstioDomain(obj interface{}) bool {
+	d, ok := obj.(*v1alpha1.Domain)
+	return ok && d.Spec.IngressClass == network.IstioIngressClassName
+}
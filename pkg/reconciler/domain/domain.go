@@ -0,0 +1,104 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package domain
+
+import (
+	"context"
+	"fmt"
+
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	istioclientset "knative.dev/net-istio/pkg/client/istio/clientset/versioned"
+	istiolisters "knative.dev/net-istio/pkg/client/istio/listers/networking/v1beta1"
+	istioaccessor "knative.dev/net-istio/pkg/reconciler/accessor/istio"
+	"knative.dev/net-istio/pkg/reconciler/domain/resources"
+	ingressresources "knative.dev/net-istio/pkg/reconciler/ingress/resources"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	domainreconciler "knative.dev/networking/pkg/client/injection/reconciler/networking/v1alpha1/domain"
+	"knative.dev/pkg/apis"
+	pkgreconciler "knative.dev/pkg/reconciler"
+	"knative.dev/pkg/tracker"
+)
+
+const (
+	gatewayServiceMissing = "GatewayServiceMissing"
+	gatewayNotReconciled  = "ReconcileGatewayFailed"
+)
+
+// reconciler implements controller.Reconciler for Domain resources.
+type reconciler struct {
+	istioClientSet istioclientset.Interface
+	gatewayLister  istiolisters.GatewayLister
+	svcLister      corev1listers.ServiceLister
+
+	tracker tracker.Interface
+}
+
+// Check that our reconciler implements various interfaces.
+var (
+	_ domainreconciler.Interface    = (*reconciler)(nil)
+	_ istioaccessor.GatewayAccessor = (*reconciler)(nil)
+)
+
+// ReconcileKind creates the Gateway accepting the traffic for the hosts of the
+// Domain. The load balancers of its gateway Service are not published: the
+// status of a Domain has no field for them, and they are reported by the
+// Ingresses bound to the Domain instead.
+func (r *reconciler) ReconcileKind(ctx context.Context, d *v1alpha1.Domain) pkgreconciler.Event {
+	conditions := d.GetConditionSet().Manage(d.GetStatus())
+
+	svc, err := resources.GatewayService(ctx, d, r.svcLister)
+	if err != nil {
+		conditions.MarkFalse(apis.ConditionReady, gatewayServiceMissing, err.Error())
+		if apierrs.IsNotFound(err) {
+			// We will be re-enqueued by the tracker when the Service is created.
+			return nil
+		}
+		return err
+	}
+	if err := r.tracker.TrackReference(tracker.Reference{
+		APIVersion: "v1",
+		Kind:       "Service",
+		Namespace:  svc.Namespace,
+		Name:       svc.Name,
+	}, d); err != nil {
+		return fmt.Errorf("failed to track Service: %w", err)
+	}
+
+	desired := resources.MakeGateway(d, svc)
+	// The Ingresses bound to the Domain add their specific servers to its Gateway.
+	if gw, err := r.gatewayLister.Gateways(desired.Namespace).Get(desired.Name); err == nil {
+		desired.Spec.Servers = ingressresources.SortServers(append(desired.Spec.Servers, ingressresources.GetIngressServers(gw)...))
+	}
+	if _, err := istioaccessor.ReconcileGateway(ctx, d, desired, r); err != nil {
+		conditions.MarkFalse(apis.ConditionReady, gatewayNotReconciled, err.Error())
+		return fmt.Errorf("failed to reconcile Gateway: %w", err)
+	}
+
+	conditions.MarkTrue(apis.ConditionReady)
+	return nil
+}
+
+// GetIstioClient returns the client to access Istio resources.
+func (r *reconciler) GetIstioClient() istioclientset.Interface {
+	return r.istioClientSet
+}
+
+// GetGatewayLister returns the lister for Gateway.
+func (r *reconciler) GetGatewayLister() istiolisters.GatewayLister {
+	return r.gatewayLister
+}
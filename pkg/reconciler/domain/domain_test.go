@@ -0,0 +1,231 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package domain
+
+import (
+	"context"
+	"testing"
+
+	// Inject our fakes
+	istioclient "knative.dev/net-istio/pkg/client/istio/injection/client"
+	fakenetworkingclient "knative.dev/networking/pkg/client/injection/client/fake"
+
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	"istio.io/client-go/pkg/apis/networking/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgotesting "k8s.io/client-go/testing"
+	"knative.dev/net-istio/pkg/reconciler/domain/resources"
+	"knative.dev/net-istio/pkg/reconciler/ingress/config"
+	ingressresources "knative.dev/net-istio/pkg/reconciler/ingress/resources"
+	network "knative.dev/networking/pkg"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	domainreconciler "knative.dev/networking/pkg/client/injection/reconciler/networking/v1alpha1/domain"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+
+	. "knative.dev/net-istio/pkg/reconciler/testing"
+	. "knative.dev/pkg/reconciler/testing"
+)
+
+var gatewayService = &corev1.Service{
+	ObjectMeta: metav1.ObjectMeta{
+		Name:      "istio-ingressgateway",
+		Namespace: "istio-system",
+	},
+	Spec: corev1.ServiceSpec{
+		Selector: map[string]string{"istio": "ingressgateway"},
+	},
+}
+
+func domain(name string, opts ...func(*v1alpha1.Domain)) *v1alpha1.Domain {
+	d := &v1alpha1.Domain{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: v1alpha1.DomainSpec{
+			IngressClass: network.IstioIngressClassName,
+			Suffix:       "example.com",
+			LoadBalancers: []v1alpha1.LoadBalancerIngressSpec{{
+				DomainInternal: "istio-ingressgateway.istio-system.svc.cluster.local",
+			}},
+		},
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+func withCondition(cond apis.Condition) func(*v1alpha1.Domain) {
+	return func(d *v1alpha1.Domain) {
+		d.Status.Conditions = duckv1.Conditions{cond}
+	}
+}
+
+var ready = apis.Condition{
+	Type:   apis.ConditionReady,
+	Status: corev1.ConditionTrue,
+}
+
+func gatewayWithIngressServer(gw *v1beta1.Gateway) *v1beta1.Gateway {
+	gw.Spec.Servers = ingressresources.SortServers(append(gw.Spec.Servers, &istiov1beta1.Server{
+		Hosts: []string{"hello.default.example.com"},
+		Port: &istiov1beta1.Port{
+			Name:     "default/hello:http",
+			Number:   80,
+			Protocol: "HTTP",
+		},
+	}))
+	return gw
+}
+
+func TestReconcile(t *testing.T) {
+	table := TableTest{{
+		Name: "bad workqueue key",
+		Key:  "too/many/parts",
+	}, {
+		Name: "key not found",
+		Key:  "not-found",
+	}, {
+		Name:                    "create Gateway",
+		SkipNamespaceValidation: true,
+		Key:                     "test",
+		Objects: []runtime.Object{
+			domain("test"),
+			gatewayService,
+		},
+		WantCreates: []runtime.Object{
+			resources.MakeGateway(domain("test"), gatewayService),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: domain("test", withCondition(ready)),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created Gateway %q", "test-domain"),
+		},
+	}, {
+		Name: "stable state",
+		Key:  "test",
+		Objects: []runtime.Object{
+			domain("test", withCondition(ready)),
+			resources.MakeGateway(domain("test"), gatewayService),
+			gatewayService,
+		},
+	}, {
+		Name: "keep the servers of the Ingresses bound to the Domain",
+		Key:  "test",
+		Objects: []runtime.Object{
+			domain("test", withCondition(ready)),
+			gatewayWithIngressServer(resources.MakeGateway(domain("test"), gatewayService)),
+			gatewayService,
+		},
+	}, {
+		Name: "gateway Service missing",
+		Key:  "test",
+		Objects: []runtime.Object{
+			domain("test"),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: domain("test", withCondition(apis.Condition{
+				Type:    apis.ConditionReady,
+				Status:  corev1.ConditionFalse,
+				Reason:  gatewayServiceMissing,
+				Message: `service "istio-ingressgateway" not found`,
+			})),
+		}},
+	}, {
+		Name: "gateway Service deleted",
+		Key:  "test",
+		Objects: []runtime.Object{
+			domain("test", withCondition(ready)),
+			resources.MakeGateway(domain("test"), gatewayService),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: domain("test", withCondition(apis.Condition{
+				Type:    apis.ConditionReady,
+				Status:  corev1.ConditionFalse,
+				Reason:  gatewayServiceMissing,
+				Message: `service "istio-ingressgateway" not found`,
+			})),
+		}},
+	}, {
+		Name:                    "failure creating Gateway",
+		SkipNamespaceValidation: true,
+		Key:                     "test",
+		WantErr:                 true,
+		WithReactors: []clientgotesting.ReactionFunc{
+			InduceFailure("create", "gateways"),
+		},
+		Objects: []runtime.Object{
+			domain("test"),
+			gatewayService,
+		},
+		WantCreates: []runtime.Object{
+			resources.MakeGateway(domain("test"), gatewayService),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: domain("test", withCondition(apis.Condition{
+				Type:    apis.ConditionReady,
+				Status:  corev1.ConditionFalse,
+				Reason:  gatewayNotReconciled,
+				Message: "failed to create Gateway: inducing failure for create gateways",
+			})),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeWarning, "CreationFailed", "Failed to create Gateway %s: inducing failure for create gateways", "istio-system/test-domain"),
+			Eventf(corev1.EventTypeWarning, "InternalError", "failed to reconcile Gateway: failed to create Gateway: inducing failure for create gateways"),
+		},
+	}}
+
+	table.Test(t, MakeFactory(func(ctx context.Context, listers *Listers, cmw configmap.Watcher) controller.Reconciler {
+		r := &reconciler{
+			istioClientSet: istioclient.Get(ctx),
+			gatewayLister:  listers.GetGatewayLister(),
+			svcLister:      listers.GetK8sServiceLister(),
+			tracker:        &NullTracker{},
+		}
+
+		return domainreconciler.NewReconciler(ctx, logging.FromContext(ctx), fakenetworkingclient.Get(ctx),
+			listers.GetDomainLister(), controller.GetEventRecorder(ctx), r, controller.Options{
+				ConfigStore: &testConfigStore{
+					config: &config.Config{
+						Istio: &config.Istio{
+							IngressGateways: []config.Gateway{{
+								Namespace:  "knative-serving",
+								Name:       config.KnativeIngressGateway,
+								ServiceURL: "istio-ingressgateway.istio-system.svc.cluster.local",
+							}},
+						},
+						Network: &network.Config{},
+					},
+				},
+			})
+	}))
+}
+
+type testConfigStore struct {
+	config *config.Config
+}
+
+func (t *testConfigStore) ToContext(ctx context.Context) context.Context {
+	return config.ToContext(ctx, t.config)
+}
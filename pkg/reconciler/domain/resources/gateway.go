@@ -0,0 +1,127 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	"istio.io/client-go/pkg/apis/networking/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"knative.dev/net-istio/pkg/reconciler/ingress/config"
+	ingressresources "knative.dev/net-istio/pkg/reconciler/ingress/resources"
+	networking "knative.dev/networking/pkg"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/pkg/network"
+)
+
+const (
+	// DomainLabelKey is the label key attached to the Gateways created for a
+	// Domain. Its value is the name of the Domain.
+	DomainLabelKey = "istio.networking.knative.dev/domain"
+
+	// ServiceConfigType is the type of the Domain IngressConfig that references
+	// the Istio ingress gateway Service serving the Domain.
+	ServiceConfigType = "Service"
+)
+
+// GatewayName returns the name of the Gateway created for the given Domain.
+func GatewayName(d *v1alpha1.Domain) string {
+	return kmeta.ChildName(d.Name, "-domain")
+}
+
+// QualifiedGatewayName returns the `<namespace>/<name>` of the Gateway created
+// for the given Domain and served by the given gateway Service.
+func QualifiedGatewayName(d *v1alpha1.Domain, gatewayService *corev1.Service) string {
+	return gatewayService.Namespace + "/" + GatewayName(d)
+}
+
+// Suffix returns the domain suffix served by the given Domain. Domains without
+// a suffix serve the cluster local domain.
+func Suffix(d *v1alpha1.Domain) string {
+	if d.Spec.Suffix != "" {
+		return d.Spec.Suffix
+	}
+	return "svc." + network.GetClusterDomainName()
+}
+
+// IsClusterLocal returns whether the given Domain serves cluster local hosts.
+func IsClusterLocal(d *v1alpha1.Domain) bool {
+	return strings.HasSuffix(Suffix(d), network.GetClusterDomainName())
+}
+
+// Hosts returns the hosts accepted by the Gateway of the given Domain. Cluster
+// local hosts are also reached through their short `name.namespace.svc` and
+// `name.namespace` forms, the latter sharing no suffix, so cluster local
+// Domains accept every host like the cluster local gateway of config-istio.
+func Hosts(d *v1alpha1.Domain) []string {
+	if IsClusterLocal(d) {
+		return []string{"*"}
+	}
+	return []string{"*." + Suffix(d)}
+}
+
+// GatewayService returns the Istio ingress gateway Service serving the given
+// Domain. It is the Service referenced by the Domain configs when there is one,
+// and the first gateway of config-istio matching the Domain visibility otherwise.
+func GatewayService(ctx context.Context, d *v1alpha1.Domain, svcLister corev1listers.ServiceLister) (*corev1.Service, error) {
+	for _, cfg := range d.Spec.Configs {
+		if cfg.Type == ServiceConfigType {
+			return svcLister.Services(cfg.Namespace).Get(cfg.Name)
+		}
+	}
+
+	istio := config.FromContext(ctx).Istio
+	gateways := istio.IngressGateways
+	if IsClusterLocal(d) {
+		gateways = istio.LocalGateways
+	}
+	if len(gateways) == 0 {
+		return nil, fmt.Errorf("no gateway configured for Domain %s", d.Name)
+	}
+	parts := strings.SplitN(gateways[0].ServiceURL, ".", 3)
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("unexpected service URL form: %s", gateways[0].ServiceURL)
+	}
+	return svcLister.Services(parts[1]).Get(parts[0])
+}
+
+// MakeGateway creates the Gateway accepting the HTTP traffic for the hosts of
+// the given Domain on the given Istio ingress gateway Service.
+func MakeGateway(d *v1alpha1.Domain, gatewayService *corev1.Service) *v1beta1.Gateway {
+	return &v1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            GatewayName(d),
+			Namespace:       gatewayService.Namespace,
+			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(d)},
+			Labels: map[string]string{
+				DomainLabelKey: d.Name,
+			},
+		},
+		Spec: istiov1beta1.Gateway{
+			Selector: gatewayService.Spec.Selector,
+			Servers: []*istiov1beta1.Server{
+				ingressresources.MakeHTTPServer(networking.HTTPEnabled, Hosts(d)),
+			},
+		},
+	}
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+)
+
+func TestHosts(t *testing.T) {
+	tests := []struct {
+		name   string
+		suffix string
+		want   []string
+	}{{
+		name:   "public domain",
+		suffix: "example.com",
+		want:   []string{"*.example.com"},
+	}, {
+		name: "cluster local domain",
+		want: []string{"*"},
+	}, {
+		name:   "cluster local suffix",
+		suffix: "svc.cluster.local",
+		want:   []string{"*"},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := &v1alpha1.Domain{
+				Spec: v1alpha1.DomainSpec{
+					Suffix: test.suffix,
+				},
+			}
+			if got := Hosts(d); !cmp.Equal(got, test.want) {
+				t.Errorf("Hosts() = %v, want: %v", got, test.want)
+			}
+		})
+	}
+}
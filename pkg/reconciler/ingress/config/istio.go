@@ -22,7 +22,9 @@ import (
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/cache"
 	cm "knative.dev/pkg/configmap"
 	"knative.dev/pkg/network"
 	"knative.dev/pkg/system"
@@ -53,6 +55,34 @@ const (
 
 	// EnableVSStatus is the config for enabling using Istio's Virtual Service status to determine its readiness
 	EnableVSStatus = "enable-virtualservice-status"
+
+	// EnableRoutePolicy is the config for enabling the translation of the timeout and retries
+	// of the Ingress paths into the VirtualService routes.
+	EnableRoutePolicy = "enable-route-policy"
+
+	// ClientCASecret is the config for the Secret holding the CA bundle used to
+	// verify the client certificates presented to the TLS servers of the Ingresses.
+	ClientCASecret = "client-ca-secret"
+
+	// EnableClusterLocalAuthorization is the config for enabling the AuthorizationPolicies
+	// that restrict the access to the cluster-local hosts of the Ingresses.
+	EnableClusterLocalAuthorization = "enable-cluster-local-authorization"
+
+	// ClusterLocalAllowedNamespaces is the config for the namespaces allowed to access
+	// the cluster-local hosts of the Ingresses.
+	ClusterLocalAllowedNamespaces = "cluster-local-allowed-namespaces"
+
+	// ExtAuthzProviders is the config for the external authorization providers the
+	// Ingresses may delegate the authorization of their requests to.
+	ExtAuthzProviders = "ext-authz-providers"
+
+	// trafficPolicyKeyPrefix is the prefix of all keys to configure the default traffic
+	// policy of the backends of the Ingresses.
+	trafficPolicyKeyPrefix = "traffic-policy."
+
+	// headerPolicyKeyPrefix is the prefix of all keys to configure the default header
+	// operations of the routes of the Ingresses.
+	headerPolicyKeyPrefix = "header-policy."
 )
 
 func defaultIngressGateways() []Gateway {
@@ -95,6 +125,40 @@ type Istio struct {
 	// EnableVirtualServiceStatus specifies whether we should look for a status field
 	// to determine istio VirtualService readiness.
 	EnableVirtualServiceStatus bool
+
+	// EnableRoutePolicy specifies whether the timeout and retries of the Ingress
+	// paths should be translated into the VirtualService routes.
+	EnableRoutePolicy bool
+
+	// ClientCASecret specifies the `<namespace>/<name>` of the Secret holding the
+	// CA bundle used to verify client certificates. When set, the TLS servers
+	// require client certificates.
+	ClientCASecret string
+
+	// TrafficPolicy specifies the default traffic policy settings of the backends of
+	// the Ingresses, keyed by setting name. Ingresses override them with annotations.
+	TrafficPolicy map[string]string
+
+	// HeaderPolicy specifies the default header operations of the routes of the
+	// Ingresses, keyed by setting name. Ingresses override them with annotations.
+	HeaderPolicy map[string]string
+
+	// EnableClusterLocalAuthorization specifies whether the access to the cluster-local
+	// hosts of the Ingresses is restricted with AuthorizationPolicies on the gateways.
+	// The clients are only identified on the ports of the servers in ISTIO_MUTUAL TLS
+	// mode, so it requires local gateways with such servers. The other ports are left
+	// open, which is reported by a warning event on the Ingresses.
+	EnableClusterLocalAuthorization bool
+
+	// ClusterLocalAllowedNamespaces specifies the namespaces allowed to access the
+	// cluster-local hosts of the Ingresses on the ISTIO_MUTUAL ports of the local
+	// gateways. Any workload of the mesh is allowed when empty.
+	ClusterLocalAllowedNamespaces []string
+
+	// ExtAuthzProviders specifies the names of the external authorization providers,
+	// defined as extension providers in the Istio mesh config, that the Ingresses may
+	// delegate the authorization of their requests to. It requires Istio 1.9 or later.
+	ExtAuthzProviders []string
 }
 
 func parseGateways(configMap *corev1.ConfigMap, prefix string) ([]Gateway, error) {
@@ -150,20 +214,102 @@ func NewIstioFromConfigMap(configMap *corev1.ConfigMap) (*Istio, error) {
 	}
 	localGateways = removeMeshGateway(localGateways)
 
-	var statusEnabled bool
+	var statusEnabled, routePolicyEnabled, clusterLocalAuthorizationEnabled bool
 	if err := cm.Parse(configMap.Data,
 		cm.AsBool(EnableVSStatus, &statusEnabled),
+		cm.AsBool(EnableRoutePolicy, &routePolicyEnabled),
+		cm.AsBool(EnableClusterLocalAuthorization, &clusterLocalAuthorizationEnabled),
 	); err != nil {
 		return nil, err
 	}
+	allowedNamespaces, err := parseAllowedNamespaces(configMap.Data[ClusterLocalAllowedNamespaces])
+	if err != nil {
+		return nil, err
+	}
+
+	clientCASecret, err := parseClientCASecret(configMap.Data[ClientCASecret])
+	if err != nil {
+		return nil, err
+	}
 
 	return &Istio{
 		IngressGateways:            gateways,
 		LocalGateways:              localGateways,
 		EnableVirtualServiceStatus: statusEnabled,
+		EnableRoutePolicy:          routePolicyEnabled,
+		ClientCASecret:             clientCASecret,
+		TrafficPolicy:              parsePrefixedSettings(configMap, trafficPolicyKeyPrefix),
+		HeaderPolicy:               parsePrefixedSettings(configMap, headerPolicyKeyPrefix),
+
+		EnableClusterLocalAuthorization: clusterLocalAuthorizationEnabled,
+		ClusterLocalAllowedNamespaces:   allowedNamespaces,
+
+		ExtAuthzProviders: parseList(configMap.Data[ExtAuthzProviders]),
 	}, nil
 }
 
+// parseAllowedNamespaces parses the comma separated list of namespaces allowed
+// to access the cluster-local hosts.
+func parseAllowedNamespaces(v string) ([]string, error) {
+	namespaces := parseList(v)
+	for _, ns := range namespaces {
+		if errs := validation.IsDNS1123Label(ns); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid %s %q: %v", ClusterLocalAllowedNamespaces, ns, errs)
+		}
+	}
+	return namespaces, nil
+}
+
+// parseList parses the given comma separated list into a sorted list without
+// duplicates, or nil when empty.
+func parseList(v string) []string {
+	items := sets.NewString()
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items.Insert(item)
+		}
+	}
+	if items.Len() == 0 {
+		return nil
+	}
+	return items.List()
+}
+
+// parsePrefixedSettings returns the settings whose keys start with the given
+// prefix, keyed by the rest of their key, or nil when there is none.
+func parsePrefixedSettings(configMap *corev1.ConfigMap, prefix string) map[string]string {
+	var settings map[string]string
+	for k, v := range configMap.Data {
+		if !strings.HasPrefix(k, prefix) || k == prefix {
+			continue
+		}
+		if settings == nil {
+			settings = map[string]string{}
+		}
+		settings[k[len(prefix):]] = v
+	}
+	return settings
+}
+
+// parseClientCASecret qualifies the given `[<namespace>/]<name>` Secret reference.
+// Secrets without a namespace are looked up in the system namespace.
+func parseClientCASecret(v string) (string, error) {
+	if v == "" {
+		return "", nil
+	}
+	namespace, name, err := cache.SplitMetaNamespaceKey(v)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s %q: %w", ClientCASecret, v, err)
+	}
+	if namespace == "" {
+		namespace = system.Namespace()
+	}
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return "", fmt.Errorf("invalid %s %q: %v", ClientCASecret, v, errs)
+	}
+	return namespace + "/" + name, nil
+}
+
 func removeMeshGateway(gateways []Gateway) []Gateway {
 	gws := []Gateway{}
 	for _, g := range gateways {
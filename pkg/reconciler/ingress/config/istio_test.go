@@ -308,3 +308,221 @@ func TestVSStatusEnabled(t *testing.T) {
 		})
 	}
 }
+
+func TestRoutePolicyEnabled(t *testing.T) {
+	routePolicyTests := []struct {
+		name        string
+		wantErr     bool
+		wantEnabled bool
+		data        map[string]string
+	}{{
+		name:        "enabled",
+		wantEnabled: true,
+		data: map[string]string{
+			EnableRoutePolicy: "true",
+		},
+	}, {
+		name:        "disabled default",
+		wantEnabled: false,
+	}, {
+		name:    "invalid",
+		wantErr: true,
+		data: map[string]string{
+			EnableRoutePolicy: "not_a_bool",
+		},
+	}}
+	for _, tt := range routePolicyTests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := NewIstioFromConfigMap(&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: system.Namespace(),
+					Name:      IstioConfigName,
+				},
+				Data: tt.data,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewIstioFromConfigMap() error = %v, WantErr %v", err, tt.wantErr)
+			}
+
+			if err == nil && config.EnableRoutePolicy != tt.wantEnabled {
+				t.Errorf("Want %v, but got %v", tt.wantEnabled, config.EnableRoutePolicy)
+			}
+		})
+	}
+}
+
+func TestClientCASecret(t *testing.T) {
+	clientCASecretTests := []struct {
+		name    string
+		wantErr bool
+		want    string
+		data    map[string]string
+	}{{
+		name: "disabled default",
+	}, {
+		name: "qualified",
+		want: "istio-system/client-ca",
+		data: map[string]string{
+			ClientCASecret: "istio-system/client-ca",
+		},
+	}, {
+		name: "system namespace",
+		want: system.Namespace() + "/client-ca",
+		data: map[string]string{
+			ClientCASecret: "client-ca",
+		},
+	}, {
+		name:    "invalid",
+		wantErr: true,
+		data: map[string]string{
+			ClientCASecret: "a/b/c",
+		},
+	}, {
+		name:    "invalid name",
+		wantErr: true,
+		data: map[string]string{
+			ClientCASecret: "istio-system/Client_CA",
+		},
+	}}
+	for _, tt := range clientCASecretTests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := NewIstioFromConfigMap(&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: system.Namespace(),
+					Name:      IstioConfigName,
+				},
+				Data: tt.data,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewIstioFromConfigMap() error = %v, WantErr %v", err, tt.wantErr)
+			}
+
+			if err == nil && config.ClientCASecret != tt.want {
+				t.Errorf("Want %q, but got %q", tt.want, config.ClientCASecret)
+			}
+		})
+	}
+}
+
+func TestTrafficPolicy(t *testing.T) {
+	config, err := NewIstioFromConfigMap(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: system.Namespace(),
+			Name:      IstioConfigName,
+		},
+		Data: map[string]string{
+			"traffic-policy.load-balancer":   "LEAST_CONN",
+			"traffic-policy.max-connections": "100",
+			"traffic-policy.":                "ignored",
+		},
+	})
+	if err != nil {
+		t.Fatal("NewIstioFromConfigMap() =", err)
+	}
+	want := map[string]string{
+		"load-balancer":   "LEAST_CONN",
+		"max-connections": "100",
+	}
+	if diff := cmp.Diff(want, config.TrafficPolicy); diff != "" {
+		t.Error("Unexpected traffic policy (-want, +got):", diff)
+	}
+}
+
+func TestHeaderPolicy(t *testing.T) {
+	config, err := NewIstioFromConfigMap(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: system.Namespace(),
+			Name:      IstioConfigName,
+		},
+		Data: map[string]string{
+			"header-policy.response-headers-set":    "X-Frame-Options=DENY",
+			"header-policy.response-headers-remove": "server",
+			"header-policy.":                        "ignored",
+		},
+	})
+	if err != nil {
+		t.Fatal("NewIstioFromConfigMap() =", err)
+	}
+	want := map[string]string{
+		"response-headers-set":    "X-Frame-Options=DENY",
+		"response-headers-remove": "server",
+	}
+	if diff := cmp.Diff(want, config.HeaderPolicy); diff != "" {
+		t.Error("Unexpected header policy (-want, +got):", diff)
+	}
+}
+
+func TestClusterLocalAuthorization(t *testing.T) {
+	clusterLocalAuthorizationTests := []struct {
+		name        string
+		wantErr     bool
+		wantEnabled bool
+		want        []string
+		data        map[string]string
+	}{{
+		name: "disabled default",
+	}, {
+		name:        "enabled",
+		wantEnabled: true,
+		data: map[string]string{
+			EnableClusterLocalAuthorization: "true",
+		},
+	}, {
+		name:        "allowed namespaces",
+		wantEnabled: true,
+		want:        []string{"monitoring", "tenant-a"},
+		data: map[string]string{
+			EnableClusterLocalAuthorization: "true",
+			ClusterLocalAllowedNamespaces:   "tenant-a, monitoring,",
+		},
+	}, {
+		name:    "invalid namespace",
+		wantErr: true,
+		data: map[string]string{
+			ClusterLocalAllowedNamespaces: "Tenant_A",
+		},
+	}}
+	for _, tt := range clusterLocalAuthorizationTests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := NewIstioFromConfigMap(&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: system.Namespace(),
+					Name:      IstioConfigName,
+				},
+				Data: tt.data,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewIstioFromConfigMap() error = %v, WantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if config.EnableClusterLocalAuthorization != tt.wantEnabled {
+				t.Errorf("Want %v, but got %v", tt.wantEnabled, config.EnableClusterLocalAuthorization)
+			}
+			if diff := cmp.Diff(tt.want, config.ClusterLocalAllowedNamespaces); diff != "" {
+				t.Error("Unexpected allowed namespaces (-want, +got):", diff)
+			}
+		})
+	}
+}
+
+func TestExtAuthzProviders(t *testing.T) {
+	config, err := NewIstioFromConfigMap(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: system.Namespace(),
+			Name:      IstioConfigName,
+		},
+		Data: map[string]string{
+			ExtAuthzProviders: "opa, oauth2-proxy,,opa",
+		},
+	})
+	if err != nil {
+		t.Fatal("NewIstioFromConfigMap() =", err)
+	}
+	want := []string{"oauth2-proxy", "opa"}
+	if diff := cmp.Diff(want, config.ExtAuthzProviders); diff != "" {
+		t.Error("Unexpected ext_authz providers (-want, +got):", diff)
+	}
+}
@@ -19,6 +19,7 @@ package config
 import (
 	"context"
 
+	corev1 "k8s.io/api/core/v1"
 	network "knative.dev/networking/pkg"
 	"knative.dev/pkg/configmap"
 )
@@ -37,6 +38,20 @@ func FromContext(ctx context.Context) *Config {
 	return ctx.Value(cfgKey{}).(*Config)
 }
 
+// FromContextOrDefaults is like FromContext, but when no Config is attached it
+// returns a Config populated with the defaults for each of the Config fields.
+func FromContextOrDefaults(ctx context.Context) *Config {
+	if cfg, ok := ctx.Value(cfgKey{}).(*Config); ok {
+		return cfg
+	}
+	istio, _ := NewIstioFromConfigMap(&corev1.ConfigMap{})
+	nc, _ := network.NewConfigFromMap(nil)
+	return &Config{
+		Istio:   istio,
+		Network: nc,
+	}
+}
+
 // ToContext adds config to given context.
 func ToContext(ctx context.Context, c *Config) context.Context {
 	return context.WithValue(ctx, cfgKey{}, c)
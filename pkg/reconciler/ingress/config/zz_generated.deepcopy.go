@@ -49,6 +49,30 @@ func (in *Istio) DeepCopyInto(out *Istio) {
 		*out = make([]Gateway, len(*in))
 		copy(*out, *in)
 	}
+	if in.TrafficPolicy != nil {
+		in, out := &in.TrafficPolicy, &out.TrafficPolicy
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.HeaderPolicy != nil {
+		in, out := &in.HeaderPolicy, &out.HeaderPolicy
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ClusterLocalAllowedNamespaces != nil {
+		in, out := &in.ClusterLocalAllowedNamespaces, &out.ClusterLocalAllowedNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExtAuthzProviders != nil {
+		in, out := &in.ExtAuthzProviders, &out.ExtAuthzProviders
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
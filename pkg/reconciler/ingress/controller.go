@@ -21,13 +21,20 @@ import (
 
 	"go.uber.org/zap"
 	istioclient "knative.dev/net-istio/pkg/client/istio/injection/client"
-	gatewayinformer "knative.dev/net-istio/pkg/client/istio/injection/informers/networking/v1alpha3/gateway"
-	virtualserviceinformer "knative.dev/net-istio/pkg/client/istio/injection/informers/networking/v1alpha3/virtualservice"
+	envoyfilterinformer "knative.dev/net-istio/pkg/client/istio/injection/informers/networking/v1alpha3/envoyfilter"
+	destinationruleinformer "knative.dev/net-istio/pkg/client/istio/injection/informers/networking/v1beta1/destinationrule"
+	gatewayinformer "knative.dev/net-istio/pkg/client/istio/injection/informers/networking/v1beta1/gateway"
+	virtualserviceinformer "knative.dev/net-istio/pkg/client/istio/injection/informers/networking/v1beta1/virtualservice"
+	authorizationpolicyinformer "knative.dev/net-istio/pkg/client/istio/injection/informers/security/v1beta1/authorizationpolicy"
+	requestauthenticationinformer "knative.dev/net-istio/pkg/client/istio/injection/informers/security/v1beta1/requestauthentication"
 	"knative.dev/net-istio/pkg/reconciler/ingress/config"
+	"knative.dev/net-istio/pkg/reconciler/ingress/resources"
 	network "knative.dev/networking/pkg"
 	"knative.dev/networking/pkg/apis/networking"
 	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	domaininformer "knative.dev/networking/pkg/client/injection/informers/networking/v1alpha1/domain"
 	ingressinformer "knative.dev/networking/pkg/client/injection/informers/networking/v1alpha1/ingress"
+	realminformer "knative.dev/networking/pkg/client/injection/informers/networking/v1alpha1/realm"
 	ingressreconciler "knative.dev/networking/pkg/client/injection/reconciler/networking/v1alpha1/ingress"
 	"knative.dev/networking/pkg/status"
 	kubeclient "knative.dev/pkg/client/injection/kube/client"
@@ -42,7 +49,7 @@ import (
 	"knative.dev/pkg/reconciler"
 	"knative.dev/pkg/tracker"
 
-	v1alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	v1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/cache"
@@ -81,18 +88,31 @@ func newControllerWithOptions(
 	ctx = AnnotateLoggerWithName(ctx, controllerAgentName)
 	logger := logging.FromContext(ctx)
 	virtualServiceInformer := virtualserviceinformer.Get(ctx)
+	destinationRuleInformer := destinationruleinformer.Get(ctx)
 	gatewayInformer := gatewayinformer.Get(ctx)
+	authorizationPolicyInformer := authorizationpolicyinformer.Get(ctx)
+	requestAuthenticationInformer := requestauthenticationinformer.Get(ctx)
+	envoyFilterInformer := envoyfilterinformer.Get(ctx)
 	secretInformer := secretinformer.Get(ctx)
 	serviceInformer := serviceinformer.Get(ctx)
 	ingressInformer := ingressinformer.Get(ctx)
+	realmInformer := realminformer.Get(ctx)
+	domainInformer := domaininformer.Get(ctx)
 
 	c := &Reconciler{
-		kubeclient:           kubeclient.Get(ctx),
-		istioClientSet:       istioclient.Get(ctx),
-		virtualServiceLister: virtualServiceInformer.Lister(),
-		gatewayLister:        gatewayInformer.Lister(),
-		secretLister:         secretInformer.Lister(),
-		svcLister:            serviceInformer.Lister(),
+		kubeclient:                  kubeclient.Get(ctx),
+		istioClientSet:              istioclient.Get(ctx),
+		ingressLister:               ingressInformer.Lister(),
+		virtualServiceLister:        virtualServiceInformer.Lister(),
+		destinationRuleLister:       destinationRuleInformer.Lister(),
+		gatewayLister:               gatewayInformer.Lister(),
+		authorizationPolicyLister:   authorizationPolicyInformer.Lister(),
+		requestAuthenticationLister: requestAuthenticationInformer.Lister(),
+		envoyFilterLister:           envoyFilterInformer.Lister(),
+		secretLister:                secretInformer.Lister(),
+		svcLister:                   serviceInformer.Lister(),
+		realmLister:                 realmInformer.Lister(),
+		domainLister:                domainInformer.Lister(),
 	}
 	myFilterFunc := reconciler.AnnotationFilterFunc(networking.IngressClassAnnotationKey, network.IstioIngressClassName, true)
 
@@ -122,26 +142,51 @@ func newControllerWithOptions(
 		Handler:    controller.HandleAll(impl.EnqueueControllerOf),
 	})
 
+	destinationRuleInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: controller.FilterController(&v1alpha1.Ingress{}),
+		Handler:    controller.HandleAll(impl.EnqueueControllerOf),
+	})
+
+	authorizationPolicyInformer.Informer().AddEventHandler(controller.HandleAll(
+		impl.EnqueueLabelOfNamespaceScopedResource(resources.IngressNamespaceLabelKey, networking.IngressLabelKey)))
+
+	requestAuthenticationInformer.Informer().AddEventHandler(controller.HandleAll(
+		impl.EnqueueLabelOfNamespaceScopedResource(resources.IngressNamespaceLabelKey, networking.IngressLabelKey)))
+
+	envoyFilterInformer.Informer().AddEventHandler(controller.HandleAll(
+		impl.EnqueueLabelOfNamespaceScopedResource(resources.IngressNamespaceLabelKey, networking.IngressLabelKey)))
+
 	logger.Info("Setting up statusManager")
 	endpointsInformer := endpointsinformer.Get(ctx)
 	podInformer := podinformer.Get(ctx)
 	resyncOnIngressReady := func(ing *v1alpha1.Ingress) {
 		impl.EnqueueKey(types.NamespacedName{Namespace: ing.GetNamespace(), Name: ing.GetName()})
 	}
+	probeTargetLister := NewProbeTargetLister(
+		logger.Named("probe-lister"),
+		gatewayInformer.Lister(),
+		endpointsInformer.Lister(),
+		serviceInformer.Lister(),
+		realmInformer.Lister(),
+		domainInformer.Lister())
 	statusProber := status.NewProber(
 		logger.Named("status-manager"),
-		NewProbeTargetLister(
-			logger.Named("probe-lister"),
-			gatewayInformer.Lister(),
-			endpointsInformer.Lister(),
-			serviceInformer.Lister()),
+		probeTargetLister,
+		resyncOnIngressReady)
+	// The TCP servers of the gateways cannot be probed over HTTP.
+	tcpProber := newTCPProber(
+		logger.Named("tcp-status-manager"),
+		probeTargetLister,
 		resyncOnIngressReady)
-	c.statusManager = statusProber
+	c.statusManager = statusManagers{statusProber, tcpProber}
 	statusProber.Start(ctx.Done())
 
 	podInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		// Cancel probing when a Pod is deleted
-		DeleteFunc: statusProber.CancelPodProbing,
+		DeleteFunc: combineFunc(
+			statusProber.CancelPodProbing,
+			tcpProber.CancelPodProbing,
+		),
 	})
 
 	logger.Info("Setting up secret informer event handler")
@@ -158,7 +203,21 @@ func newControllerWithOptions(
 	gatewayInformer.Informer().AddEventHandler(controller.HandleAll(
 		controller.EnsureTypeMeta(
 			tracker.OnChanged,
-			v1alpha3.SchemeGroupVersion.WithKind("Gateway"),
+			v1beta1.SchemeGroupVersion.WithKind("Gateway"),
+		),
+	))
+
+	realmInformer.Informer().AddEventHandler(controller.HandleAll(
+		controller.EnsureTypeMeta(
+			tracker.OnChanged,
+			v1alpha1.SchemeGroupVersion.WithKind("Realm"),
+		),
+	))
+
+	domainInformer.Informer().AddEventHandler(controller.HandleAll(
+		controller.EnsureTypeMeta(
+			tracker.OnChanged,
+			v1alpha1.SchemeGroupVersion.WithKind("Domain"),
 		),
 	))
 
@@ -166,6 +225,7 @@ func newControllerWithOptions(
 		// Cancel probing when a Ingress is deleted
 		DeleteFunc: combineFunc(
 			statusProber.CancelIngressProbing,
+			tcpProber.CancelIngressProbing,
 			tracker.OnDeletedObserver,
 		),
 	})
@@ -24,10 +24,15 @@ import (
 
 	"go.uber.org/zap"
 
-	istiov1alpha3 "istio.io/api/networking/v1alpha3"
+	istiov1beta1 "istio.io/api/networking/v1beta1"
 	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1beta1"
 
-	istiolisters "knative.dev/net-istio/pkg/client/istio/listers/networking/v1alpha3"
+	securityv1beta1 "istio.io/client-go/pkg/apis/security/v1beta1"
+
+	istiov1alpha3listers "knative.dev/net-istio/pkg/client/istio/listers/networking/v1alpha3"
+	istiolisters "knative.dev/net-istio/pkg/client/istio/listers/networking/v1beta1"
+	securitylisters "knative.dev/net-istio/pkg/client/istio/listers/security/v1beta1"
 	pkgreconciler "knative.dev/pkg/reconciler"
 	"knative.dev/pkg/tracker"
 
@@ -41,6 +46,7 @@ import (
 	"knative.dev/networking/pkg/apis/networking"
 	"knative.dev/networking/pkg/apis/networking/v1alpha1"
 	ingressreconciler "knative.dev/networking/pkg/client/injection/reconciler/networking/v1alpha1/ingress"
+	networkinglisters "knative.dev/networking/pkg/client/listers/networking/v1alpha1"
 	"knative.dev/networking/pkg/status"
 	"knative.dev/pkg/controller"
 	"knative.dev/pkg/logging"
@@ -54,6 +60,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes"
 	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
 )
 
 const (
@@ -61,17 +68,27 @@ const (
 	virtualServiceNotReconciled       = "ReconcileVirtualServiceFailed"
 	notReconciledReason               = "ReconcileIngressFailed"
 	notReconciledMessage              = "Ingress reconciliation failed"
+	extAuthzProviderNotFound          = "ExtAuthzProviderNotFound"
+	clusterLocalAccessNotRestricted   = "ClusterLocalAccessNotRestricted"
+	trafficPolicyNotApplied           = "TrafficPolicyNotApplied"
 )
 
 // Reconciler implements the control loop for the Ingress resources.
 type Reconciler struct {
 	kubeclient kubernetes.Interface
 
-	istioClientSet       istioclientset.Interface
-	virtualServiceLister istiolisters.VirtualServiceLister
-	gatewayLister        istiolisters.GatewayLister
-	secretLister         corev1listers.SecretLister
-	svcLister            corev1listers.ServiceLister
+	istioClientSet              istioclientset.Interface
+	ingressLister               networkinglisters.IngressLister
+	virtualServiceLister        istiolisters.VirtualServiceLister
+	destinationRuleLister       istiolisters.DestinationRuleLister
+	gatewayLister               istiolisters.GatewayLister
+	authorizationPolicyLister   securitylisters.AuthorizationPolicyLister
+	requestAuthenticationLister securitylisters.RequestAuthenticationLister
+	envoyFilterLister           istiov1alpha3listers.EnvoyFilterLister
+	secretLister                corev1listers.SecretLister
+	svcLister                   corev1listers.ServiceLister
+	realmLister                 networkinglisters.RealmLister
+	domainLister                networkinglisters.DomainLister
 
 	tracker tracker.Interface
 
@@ -79,10 +96,14 @@ type Reconciler struct {
 }
 
 var (
-	_ ingressreconciler.Interface          = (*Reconciler)(nil)
-	_ ingressreconciler.Finalizer          = (*Reconciler)(nil)
-	_ coreaccessor.SecretAccessor          = (*Reconciler)(nil)
-	_ istioaccessor.VirtualServiceAccessor = (*Reconciler)(nil)
+	_ ingressreconciler.Interface                 = (*Reconciler)(nil)
+	_ ingressreconciler.Finalizer                 = (*Reconciler)(nil)
+	_ coreaccessor.SecretAccessor                 = (*Reconciler)(nil)
+	_ istioaccessor.VirtualServiceAccessor        = (*Reconciler)(nil)
+	_ istioaccessor.DestinationRuleAccessor       = (*Reconciler)(nil)
+	_ istioaccessor.AuthorizationPolicyAccessor   = (*Reconciler)(nil)
+	_ istioaccessor.RequestAuthenticationAccessor = (*Reconciler)(nil)
+	_ istioaccessor.EnvoyFilterAccessor           = (*Reconciler)(nil)
 )
 
 // Reconcile compares the actual state with the desired, and attempts to
@@ -92,6 +113,11 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, ingress *v1alpha1.Ingres
 	logger := logging.FromContext(ctx)
 
 	reconcileErr := r.reconcileIngress(ctx, ingress)
+	var event *pkgreconciler.ReconcilerEvent
+	if pkgreconciler.EventAs(reconcileErr, &event) {
+		// The events come with the status of the Ingress.
+		return reconcileErr
+	}
 	if reconcileErr != nil {
 		logger.Errorw("Failed to reconcile Ingress: ", zap.Error(reconcileErr))
 		ingress.Status.MarkIngressNotReady(notReconciledReason, notReconciledMessage)
@@ -100,6 +126,22 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, ingress *v1alpha1.Ingres
 	return nil
 }
 
+// restoreRetries restores the retries of the paths of the given Ingress from
+// its informer copy, since the Ingress is defaulted before being reconciled.
+// The Ingress is left alone when its informer copy sets no retries.
+func (r *Reconciler) restoreRetries(ing *v1alpha1.Ingress) error {
+	original, err := r.ingressLister.Ingresses(ing.Namespace).Get(ing.Name)
+	if apierrs.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get Ingress: %w", err)
+	}
+	if resources.HasRetries(original) {
+		resources.RestoreRetries(ing, original)
+	}
+	return nil
+}
+
 func (r *Reconciler) reconcileIngress(ctx context.Context, ing *v1alpha1.Ingress) error {
 	logger := logging.FromContext(ctx)
 
@@ -108,11 +150,39 @@ func (r *Reconciler) reconcileIngress(ctx context.Context, ing *v1alpha1.Ingress
 	// in this getting written back to the API Server, but lets downstream logic make
 	// assumptions about defaulting.
 	ing.SetDefaults(ctx)
+	if err := r.restoreRetries(ing); err != nil {
+		return err
+	}
 
 	ing.Status.InitializeConditions()
 	logger.Infof("Reconciling ingress: %#v", ing)
 
-	gatewayNames := qualifiedGatewayNamesFromContext(ctx)
+	if err := resources.CheckExtAuthzProvider(ctx, ing); resources.IsUnknownExtAuthzProvider(err) {
+		// The hosts of the Ingress are not exposed until the provider is
+		// configured, which resyncs the Ingresses.
+		logger.Info("Deleting VirtualServices")
+		if err := r.reconcileVirtualServices(ctx, ing, nil); err != nil {
+			return err
+		}
+		ing.GetConditionSet().Manage(&ing.Status).MarkFalse(v1alpha1.IngressConditionNetworkConfigured,
+			extAuthzProviderNotFound, err.Error())
+		ing.Status.MarkLoadBalancerNotReady()
+		return pkgreconciler.NewEvent(corev1.EventTypeWarning, extAuthzProviderNotFound, "%v", err)
+	}
+
+	if name := realmName(ing); name != "" {
+		if err := r.trackRealm(ing, name); err != nil {
+			return err
+		}
+	}
+	gws, err := resolveGateways(ctx, ing, r.realmLister, r.domainLister, r.svcLister)
+	if err != nil {
+		return err
+	}
+	gatewayNames := gws.names
+	publicGateways := sets.NewString(gatewayNames[v1alpha1.IngressVisibilityExternalIP].UnsortedList()...)
+	ingressGateways := []*v1beta1.Gateway{}
+	var wildcardGateways []*v1beta1.Gateway
 	if r.shouldReconcileTLS(ctx, ing) {
 		originSecrets, err := resources.GetSecrets(ing, r.secretLister)
 		if err != nil {
@@ -130,41 +200,88 @@ func (r *Reconciler) reconcileIngress(ctx context.Context, ing *v1alpha1.Ingress
 		if err != nil {
 			return err
 		}
-		targetSecrets := make([]*corev1.Secret, 0, len(targetNonwildcardSecrets)+len(targetWildcardSecrets))
+		targetClientCASecrets, err := r.makeClientCASecrets(ctx, ing, nonWildcardSecrets, wildcardSecrets)
+		if err != nil {
+			return err
+		}
+		targetSecrets := make([]*corev1.Secret, 0, len(targetNonwildcardSecrets)+len(targetWildcardSecrets)+len(targetClientCASecrets))
 		targetSecrets = append(targetSecrets, targetNonwildcardSecrets...)
 		targetSecrets = append(targetSecrets, targetWildcardSecrets...)
+		targetSecrets = append(targetSecrets, targetClientCASecrets...)
 		if err := r.reconcileCertSecrets(ctx, ing, targetSecrets); err != nil {
 			return err
 		}
 
 		nonWildcardIngressTLS := resources.GetNonWildcardIngressTLS(ing.Spec.TLS, nonWildcardSecrets)
-		ingressGateways, err := resources.MakeIngressTLSGateways(ctx, ing, nonWildcardIngressTLS, nonWildcardSecrets, r.svcLister)
+		ingressGateways, err = resources.MakeIngressTLSGateways(ctx, ing, nonWildcardIngressTLS, nonWildcardSecrets, r.svcLister)
 		if err != nil {
 			return err
 		}
-		if err := r.reconcileIngressGateways(ctx, ingressGateways); err != nil {
-			return err
-		}
 
 		// For Ingress TLS referencing wildcard certificates, we reconcile a separate Gateway
 		// that will be shared by other Ingresses that reference the
 		// same wildcard host. We need to handle wildcard certificate specially because Istio does
 		// not fully support multiple TLS Servers (or Gateways) share the same certificate.
 		// https://istio.io/docs/ops/common-problems/network-issues/
-		desiredWildcardGateways, err := resources.MakeWildcardGateways(ctx, wildcardSecrets, r.svcLister)
+		wildcardGateways, err = resources.MakeWildcardGateways(ctx, wildcardSecrets, r.svcLister)
 		if err != nil {
 			return err
 		}
-		if err := r.reconcileWildcardGateways(ctx, desiredWildcardGateways, ing); err != nil {
+		if err := r.reconcileWildcardGateways(ctx, wildcardGateways, ing); err != nil {
 			return err
 		}
 
-		// VirtualService will be attached to both global Gateways and the Knative generated Gateways.
-		// We still want to attach to the global Gateways to respect any global Gateway configuration.
-		gatewayNames[v1alpha1.IngressVisibilityExternalIP].Insert(resources.GetQualifiedGatewayNames(ingressGateways)...)
-		gatewayNames[v1alpha1.IngressVisibilityExternalIP].Insert(resources.GetQualifiedGatewayNames(desiredWildcardGateways)...)
+		gatewayNames[v1alpha1.IngressVisibilityExternalIP].Insert(resources.GetQualifiedGatewayNames(wildcardGateways)...)
 	}
 
+	// The HTTPOption of the Ingress takes precedence over the global HTTPProtocol for
+	// its hosts, so we program an Ingress specific HTTP server next to the default HTTP
+	// server of the public Gateways the Ingress binds to. Istio programs a host on a port
+	// with a single server: the first one within a Gateway, but the one of the oldest
+	// Gateway across Gateways.
+	httpServers := []*istiov1beta1.Server{}
+	if isIngressPublic(ing) {
+		httpServers = append(httpServers, resources.MakeIngressHTTPServers(ctx, ing)...)
+	}
+	for _, gw := range publicGateways.List() {
+		if err := r.reconcileSharedGatewayServers(ctx, ing, gw, httpServers); err != nil {
+			return err
+		}
+	}
+
+	// The servers of the hosts in TLS passthrough mode and of the TCP ports are
+	// programmed on the Knative generated Gateways.
+	var ingressServers []*istiov1beta1.Server
+	passthroughServer, err := resources.MakeIngressPassthroughServer(ing)
+	if err != nil {
+		return err
+	}
+	if passthroughServer != nil {
+		ingressServers = append(ingressServers, passthroughServer)
+	}
+	tcpServers, err := resources.MakeIngressTCPServers(ing)
+	if err != nil {
+		return err
+	}
+	ingressServers = append(ingressServers, tcpServers...)
+	if len(ingressServers) != 0 {
+		if len(ingressGateways) == 0 {
+			if ingressGateways, err = resources.MakeIngressGateways(ctx, ing, ingressServers, r.svcLister); err != nil {
+				return err
+			}
+		} else {
+			for _, gw := range ingressGateways {
+				gw.Spec.Servers = append(gw.Spec.Servers, ingressServers...)
+			}
+		}
+	}
+	if err := r.reconcileIngressGateways(ctx, ing, ingressGateways); err != nil {
+		return err
+	}
+	// VirtualService will be attached to both global Gateways and the Knative generated Gateways.
+	// We still want to attach to the global Gateways to respect any global Gateway configuration.
+	gatewayNames[v1alpha1.IngressVisibilityExternalIP].Insert(resources.GetQualifiedGatewayNames(ingressGateways)...)
+
 	// HTTPProtocol should be effective only when Auto TLS is enabled per its definition.
 	// TODO(zhiminx): figure out a better way to handle HTTP behavior.
 	// https://github.com/knative/serving/issues/6373
@@ -177,6 +294,88 @@ func (r *Reconciler) reconcileIngress(ctx context.Context, ing *v1alpha1.Ingress
 		}
 	}
 
+	// The policies of the Ingress select the gateways of its Realm or of
+	// config-istio, as well as the gateways of its Knative generated Gateways.
+	policyGateways, err := r.policyGateways(gws)
+	if err != nil {
+		return err
+	}
+	policyGateways.AddGeneratedGateways(ctx, append(ingressGateways, wildcardGateways...))
+
+	aps, open, err := resources.MakeClusterLocalAuthorizationPolicies(ctx, ing, policyGateways, r.svcLister)
+	if err != nil {
+		return err
+	}
+	if len(open) != 0 {
+		// Only the servers in ISTIO_MUTUAL TLS mode identify the clients.
+		controller.GetEventRecorder(ctx).Eventf(ing, corev1.EventTypeWarning, clusterLocalAccessNotRestricted,
+			"The access to the cluster-local hosts is not restricted on the ports of the gateways %s not in ISTIO_MUTUAL TLS mode",
+			strings.Join(open, ", "))
+	}
+	jwks, err := r.getJWKS(ing)
+	if err != nil {
+		return err
+	}
+	ras, jwtAPs, err := resources.MakeJWTPolicies(ing, jwks, policyGateways, r.svcLister)
+	if err != nil {
+		return err
+	}
+	aps = append(aps, jwtAPs...)
+	extAuthzAPs, err := resources.MakeExtAuthzPolicies(ctx, ing, policyGateways, r.svcLister)
+	if err != nil {
+		return err
+	}
+	aps = append(aps, extAuthzAPs...)
+
+	// The authentication and authorization policies go first so that the hosts
+	// are never exposed without them.
+	logger.Info("Creating/Updating RequestAuthentications")
+	if err := r.reconcileRequestAuthentications(ctx, ing, ras); err != nil {
+		return err
+	}
+	logger.Info("Creating/Updating AuthorizationPolicies")
+	if err := r.reconcileAuthorizationPolicies(ctx, ing, aps); err != nil {
+		if len(extAuthzAPs) != 0 {
+			// The hosts of the Ingress are not exposed without their external
+			// authorization, e.g. when Istio rejects the CUSTOM action.
+			logger.Info("Deleting VirtualServices")
+			if err := r.reconcileVirtualServices(ctx, ing, nil); err != nil {
+				return err
+			}
+			ing.Status.MarkLoadBalancerNotReady()
+		}
+		return err
+	}
+
+	efs, err := resources.MakeRateLimitEnvoyFilters(ing, policyGateways, r.svcLister)
+	if err != nil {
+		return err
+	}
+
+	// The EnvoyFilters go first so that the hosts are never exposed without
+	// their rate limit.
+	logger.Info("Creating/Updating EnvoyFilters")
+	if err := r.reconcileEnvoyFilters(ctx, ing, efs); err != nil {
+		return err
+	}
+
+	drs, skipped, err := resources.MakeDestinationRules(ctx, ing)
+	if err != nil {
+		return err
+	}
+	if len(skipped) != 0 {
+		controller.GetEventRecorder(ctx).Eventf(ing, corev1.EventTypeWarning, trafficPolicyNotApplied,
+			"The traffic policy is not applied to the backends %s outside of the namespace of the Ingress",
+			strings.Join(skipped, ", "))
+	}
+
+	// The DestinationRules go first so that the traffic policy of the backends
+	// is in place before the VirtualServices route to them.
+	logger.Info("Creating/Updating DestinationRules")
+	if err := r.reconcileDestinationRules(ctx, ing, drs); err != nil {
+		return err
+	}
+
 	vses, err := resources.MakeVirtualServices(ctx, ing, gatewayNames)
 	if err != nil {
 		return err
@@ -194,7 +393,7 @@ func (r *Reconciler) reconcileIngress(ctx context.Context, ing *v1alpha1.Ingress
 		// We should handle the deletion after VirtualService is created with the newly generated non-wildcard Gateway
 		// in order to make sure the ongoing traffic won't be affected.
 		for _, gw := range config.FromContext(ctx).Istio.IngressGateways {
-			if err := r.reconcileIngressServers(ctx, ing, gw, []*istiov1alpha3.Server{}); err != nil {
+			if err := r.reconcileIngressServers(ctx, ing, gw, []*istiov1beta1.Server{}); err != nil {
 				return err
 			}
 		}
@@ -220,7 +419,7 @@ func (r *Reconciler) reconcileIngress(ctx context.Context, ing *v1alpha1.Ingress
 		// Check if our VirtualServices have a status property.
 		// If they do and we're ready, we can use that to determine readiness.
 
-		if p, ok := r.statusManager.(*status.Prober); ok {
+		if p, ok := r.statusManager.(ingressProbingCanceler); ok {
 			// if possible, cancel probing in case we've started it
 			p.CancelIngressProbing(ing)
 		}
@@ -235,8 +434,8 @@ func (r *Reconciler) reconcileIngress(ctx context.Context, ing *v1alpha1.Ingress
 	}
 
 	if ready {
-		publicLbs := getLBStatus(publicGatewayServiceURLFromContext(ctx))
-		privateLbs := getLBStatus(privateGatewayServiceURLFromContext(ctx))
+		publicLbs := getLBStatus(gws.serviceURLs[v1alpha1.IngressVisibilityExternalIP])
+		privateLbs := getLBStatus(gws.serviceURLs[v1alpha1.IngressVisibilityClusterLocal])
 		ing.Status.MarkLoadBalancerReady(publicLbs, privateLbs)
 	} else {
 		ing.Status.MarkLoadBalancerNotReady()
@@ -262,7 +461,59 @@ func (r *Reconciler) reconcileCertSecrets(ctx context.Context, ing *v1alpha1.Ing
 	return nil
 }
 
-func (r *Reconciler) reconcileWildcardGateways(ctx context.Context, gateways []*v1alpha3.Gateway, ing *v1alpha1.Ingress) error {
+// makeClientCASecrets makes the copies of the CA Secrets verifying the client certificates
+// presented to the TLS servers of the given Ingress and of the wildcard Gateways it uses.
+func (r *Reconciler) makeClientCASecrets(ctx context.Context, ing *v1alpha1.Ingress,
+	nonWildcardSecrets, wildcardSecrets map[string]*corev1.Secret) ([]*corev1.Secret, error) {
+	secrets := []*corev1.Secret{}
+	caSecret, err := r.getClientCASecret(ing, resources.ClientCASecretKey(ctx, ing))
+	if err != nil {
+		return nil, err
+	}
+	if caSecret != nil {
+		caSecrets, err := resources.MakeClientCASecrets(ctx, caSecret, nonWildcardSecrets, ing)
+		if err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, caSecrets...)
+	}
+
+	wildcardCASecret, err := r.getClientCASecret(ing, config.FromContext(ctx).Istio.ClientCASecret)
+	if err != nil {
+		return nil, err
+	}
+	if wildcardCASecret != nil {
+		caSecrets, err := resources.MakeWildcardClientCASecrets(ctx, wildcardCASecret, wildcardSecrets)
+		if err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, caSecrets...)
+	}
+	return secrets, nil
+}
+
+// getClientCASecret returns the CA Secret with the given `<namespace>/<name>` key, or nil
+// when the key is empty.
+func (r *Reconciler) getClientCASecret(ing *v1alpha1.Ingress, key string) (*corev1.Secret, error) {
+	if key == "" {
+		return nil, nil
+	}
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return nil, err
+	}
+	// We track the CA secret so that its copies are synced when it is refreshed.
+	if err := r.tracker.TrackReference(resources.SecretRef(namespace, name), ing); err != nil {
+		return nil, fmt.Errorf("failed to track client CA Secret: %w", err)
+	}
+	secret, err := r.secretLister.Secrets(namespace).Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client CA Secret %s: %w", key, err)
+	}
+	return secret, nil
+}
+
+func (r *Reconciler) reconcileWildcardGateways(ctx context.Context, gateways []*v1beta1.Gateway, ing *v1alpha1.Ingress) error {
 	for _, gateway := range gateways {
 		r.tracker.TrackReference(resources.GatewayRef(gateway), ing)
 		if err := r.reconcileSystemGeneratedGateway(ctx, gateway); err != nil {
@@ -272,19 +523,40 @@ func (r *Reconciler) reconcileWildcardGateways(ctx context.Context, gateways []*
 	return nil
 }
 
-func (r *Reconciler) reconcileIngressGateways(ctx context.Context, gateways []*v1alpha3.Gateway) error {
+func (r *Reconciler) reconcileIngressGateways(ctx context.Context, ing *v1alpha1.Ingress, gateways []*v1beta1.Gateway) error {
+	kept := sets.NewString()
 	for _, gateway := range gateways {
 		if err := r.reconcileSystemGeneratedGateway(ctx, gateway); err != nil {
 			return err
 		}
+		kept.Insert(gateway.Name)
+	}
+
+	// Remove the Ingress specific Gateways that are no longer needed, e.g. when
+	// the TLS and the HTTPOption of the Ingress were removed.
+	existing, err := r.gatewayLister.Gateways(ing.GetNamespace()).List(
+		labels.SelectorFromSet(labels.Set{networking.IngressLabelKey: ing.GetName()}))
+	if err != nil {
+		return fmt.Errorf("failed to list Gateways: %w", err)
+	}
+	sort.Slice(existing, func(i, j int) bool {
+		return existing[i].Name < existing[j].Name
+	})
+	for _, gw := range existing {
+		if kept.Has(gw.Name) || !metav1.IsControlledBy(gw, ing) {
+			continue
+		}
+		if err := r.istioClientSet.NetworkingV1beta1().Gateways(gw.Namespace).Delete(ctx, gw.Name, metav1.DeleteOptions{}); err != nil {
+			return fmt.Errorf("failed to delete Gateway: %w", err)
+		}
 	}
 	return nil
 }
 
-func (r *Reconciler) reconcileSystemGeneratedGateway(ctx context.Context, desired *v1alpha3.Gateway) error {
+func (r *Reconciler) reconcileSystemGeneratedGateway(ctx context.Context, desired *v1beta1.Gateway) error {
 	existing, err := r.gatewayLister.Gateways(desired.Namespace).Get(desired.Name)
 	if apierrs.IsNotFound(err) {
-		if _, err := r.istioClientSet.NetworkingV1alpha3().Gateways(desired.Namespace).Create(ctx, desired, metav1.CreateOptions{}); err != nil {
+		if _, err := r.istioClientSet.NetworkingV1beta1().Gateways(desired.Namespace).Create(ctx, desired, metav1.CreateOptions{}); err != nil {
 			return err
 		}
 	} else if err != nil {
@@ -292,7 +564,7 @@ func (r *Reconciler) reconcileSystemGeneratedGateway(ctx context.Context, desire
 	} else if !equality.Semantic.DeepEqual(existing.Spec, desired.Spec) {
 		copy := existing.DeepCopy()
 		copy.Spec = desired.Spec
-		if _, err := r.istioClientSet.NetworkingV1alpha3().Gateways(desired.Namespace).Update(ctx, copy, metav1.UpdateOptions{}); err != nil {
+		if _, err := r.istioClientSet.NetworkingV1beta1().Gateways(desired.Namespace).Update(ctx, copy, metav1.UpdateOptions{}); err != nil {
 			return err
 		}
 	}
@@ -300,7 +572,7 @@ func (r *Reconciler) reconcileSystemGeneratedGateway(ctx context.Context, desire
 }
 
 func (r *Reconciler) reconcileVirtualServices(ctx context.Context, ing *v1alpha1.Ingress,
-	desired []*v1alpha3.VirtualService) error {
+	desired []*v1beta1.VirtualService) error {
 	// First, create all needed VirtualServices.
 	kept := sets.NewString()
 	for _, d := range desired {
@@ -319,6 +591,7 @@ func (r *Reconciler) reconcileVirtualServices(ctx context.Context, ing *v1alpha1
 	}
 
 	// Now, remove the extra ones.
+	deleted := sets.NewString()
 	selectors := map[string]string{
 		networking.IngressLabelKey: ing.GetName(),                            // VS created from 0.12 on
 		resources.RouteLabelKey:    ing.GetLabels()[resources.RouteLabelKey], // VS created before 0.12
@@ -337,32 +610,208 @@ func (r *Reconciler) reconcileVirtualServices(ctx context.Context, ing *v1alpha1
 
 		for _, vs := range vses {
 			n, ns := vs.Name, vs.Namespace
-			if kept.Has(n) {
+			if kept.Has(n) || deleted.Has(n) {
 				continue
 			}
 			if !metav1.IsControlledBy(vs, ing) {
 				// We shouldn't remove resources not controlled by us.
 				continue
 			}
-			if err = r.istioClientSet.NetworkingV1alpha3().VirtualServices(ns).Delete(ctx, n, metav1.DeleteOptions{}); err != nil {
+			if err = r.istioClientSet.NetworkingV1beta1().VirtualServices(ns).Delete(ctx, n, metav1.DeleteOptions{}); err != nil {
 				return fmt.Errorf("failed to delete VirtualService: %w", err)
 			}
+			// Do not delete again the VirtualServices matching both selectors.
+			deleted.Insert(n)
+		}
+	}
+	return nil
+}
+
+func (r *Reconciler) reconcileDestinationRules(ctx context.Context, ing *v1alpha1.Ingress,
+	desired []*v1beta1.DestinationRule) error {
+	kept := sets.NewString()
+	for _, d := range desired {
+		if _, err := istioaccessor.ReconcileDestinationRule(ctx, ing, d, r); err != nil {
+			if kaccessor.IsNotOwned(err) {
+				ing.Status.MarkResourceNotOwned("DestinationRule", d.Name)
+			}
+			return err
+		}
+		kept.Insert(d.Name)
+	}
+
+	// Remove the DestinationRules of the backends that are gone or of the
+	// settings that are unset.
+	drs, err := r.destinationRuleLister.DestinationRules(ing.GetNamespace()).List(
+		labels.SelectorFromSet(labels.Set{networking.IngressLabelKey: ing.GetName()}))
+	if err != nil {
+		return fmt.Errorf("failed to list DestinationRules: %w", err)
+	}
+	sort.Slice(drs, func(i, j int) bool {
+		return drs[i].Name < drs[j].Name
+	})
+	for _, dr := range drs {
+		if kept.Has(dr.Name) || !metav1.IsControlledBy(dr, ing) {
+			continue
+		}
+		if err := r.istioClientSet.NetworkingV1beta1().DestinationRules(dr.Namespace).Delete(ctx, dr.Name, metav1.DeleteOptions{}); err != nil {
+			return fmt.Errorf("failed to delete DestinationRule: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *Reconciler) reconcileAuthorizationPolicies(ctx context.Context, ing *v1alpha1.Ingress,
+	desired []*securityv1beta1.AuthorizationPolicy) error {
+	kept := sets.NewString()
+	for _, d := range desired {
+		if _, err := istioaccessor.ReconcileAuthorizationPolicy(ctx, ing, d, r); err != nil {
+			if kaccessor.IsNotOwned(err) {
+				ing.Status.MarkResourceNotOwned("AuthorizationPolicy", d.Name)
+			}
+			return err
+		}
+		kept.Insert(d.Namespace + "/" + d.Name)
+	}
+
+	// The AuthorizationPolicies live in the namespaces of the gateways, so
+	// they are found by labels rather than owner references.
+	aps, err := r.authorizationPolicyLister.List(labels.SelectorFromSet(resources.MakeGatewayPolicyLabels(ing)))
+	if err != nil {
+		return fmt.Errorf("failed to list AuthorizationPolicies: %w", err)
+	}
+	sort.Slice(aps, func(i, j int) bool {
+		return aps[i].Namespace+"/"+aps[i].Name < aps[j].Namespace+"/"+aps[j].Name
+	})
+	for _, ap := range aps {
+		if kept.Has(ap.Namespace + "/" + ap.Name) {
+			continue
+		}
+		if err := r.istioClientSet.SecurityV1beta1().AuthorizationPolicies(ap.Namespace).Delete(ctx, ap.Name, metav1.DeleteOptions{}); err != nil {
+			return fmt.Errorf("failed to delete AuthorizationPolicy: %w", err)
 		}
 	}
 	return nil
 }
 
+func (r *Reconciler) reconcileRequestAuthentications(ctx context.Context, ing *v1alpha1.Ingress,
+	desired []*securityv1beta1.RequestAuthentication) error {
+	kept := sets.NewString()
+	for _, d := range desired {
+		if _, err := istioaccessor.ReconcileRequestAuthentication(ctx, ing, d, r); err != nil {
+			if kaccessor.IsNotOwned(err) {
+				ing.Status.MarkResourceNotOwned("RequestAuthentication", d.Name)
+			}
+			return err
+		}
+		kept.Insert(d.Namespace + "/" + d.Name)
+	}
+
+	// Like the AuthorizationPolicies, the RequestAuthentications live in the
+	// namespaces of the gateways.
+	ras, err := r.requestAuthenticationLister.List(labels.SelectorFromSet(resources.MakeGatewayPolicyLabels(ing)))
+	if err != nil {
+		return fmt.Errorf("failed to list RequestAuthentications: %w", err)
+	}
+	sort.Slice(ras, func(i, j int) bool {
+		return ras[i].Namespace+"/"+ras[i].Name < ras[j].Namespace+"/"+ras[j].Name
+	})
+	for _, ra := range ras {
+		if kept.Has(ra.Namespace + "/" + ra.Name) {
+			continue
+		}
+		if err := r.istioClientSet.SecurityV1beta1().RequestAuthentications(ra.Namespace).Delete(ctx, ra.Name, metav1.DeleteOptions{}); err != nil {
+			return fmt.Errorf("failed to delete RequestAuthentication: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *Reconciler) reconcileEnvoyFilters(ctx context.Context, ing *v1alpha1.Ingress,
+	desired []*v1alpha3.EnvoyFilter) error {
+	kept := sets.NewString()
+	for _, d := range desired {
+		if _, err := istioaccessor.ReconcileEnvoyFilter(ctx, ing, d, r); err != nil {
+			if kaccessor.IsNotOwned(err) {
+				ing.Status.MarkResourceNotOwned("EnvoyFilter", d.Name)
+			}
+			return err
+		}
+		kept.Insert(d.Namespace + "/" + d.Name)
+	}
+
+	// Like the AuthorizationPolicies, the EnvoyFilters live in the namespaces
+	// of the gateways.
+	efs, err := r.envoyFilterLister.List(labels.SelectorFromSet(resources.MakeGatewayPolicyLabels(ing)))
+	if err != nil {
+		return fmt.Errorf("failed to list EnvoyFilters: %w", err)
+	}
+	sort.Slice(efs, func(i, j int) bool {
+		return efs[i].Namespace+"/"+efs[i].Name < efs[j].Namespace+"/"+efs[j].Name
+	})
+	for _, ef := range efs {
+		if kept.Has(ef.Namespace + "/" + ef.Name) {
+			continue
+		}
+		if err := r.istioClientSet.NetworkingV1alpha3().EnvoyFilters(ef.Namespace).Delete(ctx, ef.Name, metav1.DeleteOptions{}); err != nil {
+			return fmt.Errorf("failed to delete EnvoyFilter: %w", err)
+		}
+	}
+	return nil
+}
+
+// getJWKS returns the JSON Web Key Set of the Secret referred to by the JWT
+// annotations of the given Ingress, or an empty string when there is none.
+func (r *Reconciler) getJWKS(ing *v1alpha1.Ingress) (string, error) {
+	name := ing.GetAnnotations()[resources.JWTJwksSecretAnnotationKey]
+	if name == "" {
+		return "", nil
+	}
+	// We track the Secret so that the key rotations are picked up.
+	if err := r.tracker.TrackReference(resources.SecretRef(ing.Namespace, name), ing); err != nil {
+		return "", fmt.Errorf("failed to track JWKS Secret: %w", err)
+	}
+	secret, err := r.secretLister.Secrets(ing.Namespace).Get(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to get JWKS Secret %s/%s: %w", ing.Namespace, name, err)
+	}
+	return string(secret.Data[resources.JWKSSecretKey]), nil
+}
+
 func (r *Reconciler) FinalizeKind(ctx context.Context, ing *v1alpha1.Ingress) pkgreconciler.Event {
 	logger := logging.FromContext(ctx)
 	istiocfg := config.FromContext(ctx).Istio
 	logger.Info("Cleaning up Gateway Servers")
 	for _, gws := range [][]config.Gateway{istiocfg.IngressGateways, istiocfg.LocalGateways} {
 		for _, gw := range gws {
-			if err := r.reconcileIngressServers(ctx, ing, gw, []*istiov1alpha3.Server{}); err != nil {
+			if err := r.reconcileIngressServers(ctx, ing, gw, []*istiov1beta1.Server{}); err != nil {
 				return err
 			}
 		}
 	}
+	if realmName(ing) != "" {
+		// The Domains of the Realm may be gone already, along with their Gateways.
+		if gws, err := resolveGateways(ctx, ing, r.realmLister, r.domainLister, r.svcLister); err == nil {
+			for _, gw := range gws.names[v1alpha1.IngressVisibilityExternalIP].List() {
+				if err := r.reconcileSharedGatewayServers(ctx, ing, gw, []*istiov1beta1.Server{}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	logger.Info("Cleaning up AuthorizationPolicies")
+	if err := r.reconcileAuthorizationPolicies(ctx, ing, nil); err != nil {
+		return err
+	}
+	logger.Info("Cleaning up RequestAuthentications")
+	if err := r.reconcileRequestAuthentications(ctx, ing, nil); err != nil {
+		return err
+	}
+	logger.Info("Cleaning up EnvoyFilters")
+	if err := r.reconcileEnvoyFilters(ctx, ing, nil); err != nil {
+		return err
+	}
 
 	return r.reconcileDeletion(ctx, ing)
 }
@@ -396,7 +845,7 @@ func (r *Reconciler) reconcileDeletion(ctx context.Context, ing *v1alpha1.Ingres
 	return errors.NewAggregate(errs)
 }
 
-func (r *Reconciler) reconcileIngressServers(ctx context.Context, ing *v1alpha1.Ingress, gw config.Gateway, desired []*istiov1alpha3.Server) error {
+func (r *Reconciler) reconcileIngressServers(ctx context.Context, ing *v1alpha1.Ingress, gw config.Gateway, desired []*istiov1beta1.Server) error {
 	gateway, err := r.gatewayLister.Gateways(gw.Namespace).Get(gw.Name)
 	if err != nil {
 		// Unlike VirtualService, a default gateway needs to be existent.
@@ -407,32 +856,70 @@ func (r *Reconciler) reconcileIngressServers(ctx context.Context, ing *v1alpha1.
 	return r.reconcileGateway(ctx, ing, gateway, existing, desired)
 }
 
-func (r *Reconciler) reconcileHTTPServer(ctx context.Context, ing *v1alpha1.Ingress, gw config.Gateway, desiredHTTP *istiov1alpha3.Server) error {
+// reconcileSharedGatewayServers reconciles the servers of the given Ingress on the
+// shared Gateway with the given `<namespace>/<name>`.
+func (r *Reconciler) reconcileSharedGatewayServers(ctx context.Context, ing *v1alpha1.Ingress, qualifiedName string, desired []*istiov1beta1.Server) error {
+	ns, name, err := cache.SplitMetaNamespaceKey(qualifiedName)
+	if err != nil {
+		return fmt.Errorf("invalid Gateway name %q: %w", qualifiedName, err)
+	}
+	gateway, err := r.gatewayLister.Gateways(ns).Get(name)
+	if apierrs.IsNotFound(err) && len(desired) == 0 {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get Gateway: %w", err)
+	}
+	existing := resources.GetServers(gateway, ing)
+	return r.reconcileGateway(ctx, ing, gateway, existing, desired)
+}
+
+// policyGateways returns the given gateways of an Ingress along with their
+// existing Gateways.
+func (r *Reconciler) policyGateways(gws *ingressGateways) (*resources.PolicyGateways, error) {
+	pg := &resources.PolicyGateways{
+		Services: gws.gateways,
+		Gateways: map[v1alpha1.IngressVisibility][]*v1beta1.Gateway{},
+	}
+	for visibility, gateways := range gws.gateways {
+		for _, gw := range gateways {
+			gateway, err := r.gatewayLister.Gateways(gw.Namespace).Get(gw.Name)
+			if apierrs.IsNotFound(err) {
+				continue
+			} else if err != nil {
+				return nil, fmt.Errorf("failed to get Gateway: %w", err)
+			}
+			pg.Gateways[visibility] = append(pg.Gateways[visibility], gateway)
+		}
+	}
+	return pg, nil
+}
+
+func (r *Reconciler) reconcileHTTPServer(ctx context.Context, ing *v1alpha1.Ingress, gw config.Gateway, desiredHTTP *istiov1beta1.Server) error {
 	gateway, err := r.gatewayLister.Gateways(gw.Namespace).Get(gw.Name)
 	if err != nil {
 		// Unlike VirtualService, a default gateway needs to be existent.
 		// It should be installed when installing Knative.
 		return fmt.Errorf("failed to get Gateway: %w", err)
 	}
-	existing := []*istiov1alpha3.Server{}
+	existing := []*istiov1beta1.Server{}
 	if e := resources.GetHTTPServer(gateway); e != nil {
 		existing = append(existing, e)
 	}
-	desired := []*istiov1alpha3.Server{}
+	desired := []*istiov1beta1.Server{}
 	if desiredHTTP != nil {
 		desired = append(desired, desiredHTTP)
 	}
 	return r.reconcileGateway(ctx, ing, gateway, existing, desired)
 }
 
-func (r *Reconciler) reconcileGateway(ctx context.Context, ing *v1alpha1.Ingress, gateway *v1alpha3.Gateway, existing []*istiov1alpha3.Server, desired []*istiov1alpha3.Server) error {
+func (r *Reconciler) reconcileGateway(ctx context.Context, ing *v1alpha1.Ingress, gateway *v1beta1.Gateway, existing []*istiov1beta1.Server, desired []*istiov1beta1.Server) error {
 	if equality.Semantic.DeepEqual(existing, desired) {
 		return nil
 	}
 
 	copy := gateway.DeepCopy()
 	copy = resources.UpdateGateway(copy, desired, existing)
-	if _, err := r.istioClientSet.NetworkingV1alpha3().Gateways(copy.Namespace).Update(ctx, copy, metav1.UpdateOptions{}); err != nil {
+	if _, err := r.istioClientSet.NetworkingV1beta1().Gateways(copy.Namespace).Update(ctx, copy, metav1.UpdateOptions{}); err != nil {
 		return fmt.Errorf("failed to update Gateway: %w", err)
 	}
 	controller.GetEventRecorder(ctx).Eventf(ing, corev1.EventTypeNormal,
@@ -460,6 +947,26 @@ func (r *Reconciler) GetVirtualServiceLister() istiolisters.VirtualServiceLister
 	return r.virtualServiceLister
 }
 
+// GetDestinationRuleLister returns the lister for DestinationRule.
+func (r *Reconciler) GetDestinationRuleLister() istiolisters.DestinationRuleLister {
+	return r.destinationRuleLister
+}
+
+// GetAuthorizationPolicyLister returns the lister for AuthorizationPolicy.
+func (r *Reconciler) GetAuthorizationPolicyLister() securitylisters.AuthorizationPolicyLister {
+	return r.authorizationPolicyLister
+}
+
+// GetRequestAuthenticationLister returns the lister for RequestAuthentication.
+func (r *Reconciler) GetRequestAuthenticationLister() securitylisters.RequestAuthenticationLister {
+	return r.requestAuthenticationLister
+}
+
+// GetEnvoyFilterLister returns the lister for EnvoyFilter.
+func (r *Reconciler) GetEnvoyFilterLister() istiov1alpha3listers.EnvoyFilterLister {
+	return r.envoyFilterLister
+}
+
 // qualifiedGatewayNamesFromContext get gateway names from context
 func qualifiedGatewayNamesFromContext(ctx context.Context) map[v1alpha1.IngressVisibility]sets.String {
 	ci := config.FromContext(ctx).Istio
@@ -530,7 +1037,7 @@ func isIngressPublic(ing *v1alpha1.Ingress) bool {
 // The return values are (hasStatus, ready), where:
 //	hasStatus indicates whether all the virtualServices have a status field
 //	ready indicates whether they all have been reconciled and are able to receive requests
-func (r *Reconciler) areVirtualServicesReady(ctx context.Context, vses []*v1alpha3.VirtualService) (hasStatus, ready bool) {
+func (r *Reconciler) areVirtualServicesReady(ctx context.Context, vses []*v1beta1.VirtualService) (hasStatus, ready bool) {
 	logger := logging.FromContext(ctx)
 
 	for _, vs := range vses {
@@ -557,7 +1064,7 @@ func (r *Reconciler) areVirtualServicesReady(ctx context.Context, vses []*v1alph
 //	hasStatus indicates whether the virtualService has a status field
 //	ready indicates whether it's been reconciled and able to receive requests
 //	err indicates an error occurred while looking up the status.
-func (r *Reconciler) isVirtualServiceReady(ctx context.Context, vs *v1alpha3.VirtualService) (hasStatus, ready bool, err error) {
+func (r *Reconciler) isVirtualServiceReady(ctx context.Context, vs *v1beta1.VirtualService) (hasStatus, ready bool, err error) {
 	logger := logging.FromContext(ctx)
 
 	if !config.FromContext(ctx).Istio.EnableVirtualServiceStatus {
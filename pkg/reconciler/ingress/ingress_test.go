@@ -19,6 +19,7 @@ package ingress
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"sort"
 	"strings"
 	"testing"
@@ -27,10 +28,16 @@ import (
 	// Inject our fakes
 	istioclient "knative.dev/net-istio/pkg/client/istio/injection/client"
 	fakeistioclient "knative.dev/net-istio/pkg/client/istio/injection/client/fake"
-	_ "knative.dev/net-istio/pkg/client/istio/injection/informers/networking/v1alpha3/gateway/fake"
-	_ "knative.dev/net-istio/pkg/client/istio/injection/informers/networking/v1alpha3/virtualservice/fake"
+	_ "knative.dev/net-istio/pkg/client/istio/injection/informers/networking/v1alpha3/envoyfilter/fake"
+	_ "knative.dev/net-istio/pkg/client/istio/injection/informers/networking/v1beta1/destinationrule/fake"
+	_ "knative.dev/net-istio/pkg/client/istio/injection/informers/networking/v1beta1/gateway/fake"
+	_ "knative.dev/net-istio/pkg/client/istio/injection/informers/networking/v1beta1/virtualservice/fake"
+	_ "knative.dev/net-istio/pkg/client/istio/injection/informers/security/v1beta1/authorizationpolicy/fake"
+	_ "knative.dev/net-istio/pkg/client/istio/injection/informers/security/v1beta1/requestauthentication/fake"
 	fakenetworkingclient "knative.dev/networking/pkg/client/injection/client/fake"
+	_ "knative.dev/networking/pkg/client/injection/informers/networking/v1alpha1/domain/fake"
 	fakeingressclient "knative.dev/networking/pkg/client/injection/informers/networking/v1alpha1/ingress/fake"
+	_ "knative.dev/networking/pkg/client/injection/informers/networking/v1alpha1/realm/fake"
 	"knative.dev/networking/pkg/ingress"
 	"knative.dev/networking/pkg/status"
 	fakestatusmanager "knative.dev/networking/pkg/testing/status"
@@ -46,8 +53,12 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	istiov1alpha1 "istio.io/api/meta/v1alpha1"
-	istiov1alpha3 "istio.io/api/networking/v1alpha3"
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	istiosecurity "istio.io/api/security/v1beta1"
+	istiotype "istio.io/api/type/v1beta1"
 	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1beta1"
+	securityv1beta1 "istio.io/client-go/pkg/apis/security/v1beta1"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -59,6 +70,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	clientgotesting "k8s.io/client-go/testing"
 
+	domainresources "knative.dev/net-istio/pkg/reconciler/domain/resources"
 	"knative.dev/net-istio/pkg/reconciler/ingress/config"
 	"knative.dev/net-istio/pkg/reconciler/ingress/resources"
 	network "knative.dev/networking/pkg"
@@ -114,15 +126,33 @@ var (
 			Selector: selector,
 		},
 	}
-	wildcardTLSServer = &istiov1alpha3.Server{
+	testIngressService = &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingressgateway",
+			Namespace: "istio-system",
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: selector,
+		},
+	}
+	localGatewayService = &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "knative-local-gateway",
+			Namespace: "istio-system",
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: selector,
+		},
+	}
+	wildcardTLSServer = &istiov1beta1.Server{
 		Hosts: []string{"*.example.com"},
-		Port: &istiov1alpha3.Port{
+		Port: &istiov1beta1.Port{
 			Name:     "https",
 			Number:   443,
 			Protocol: "HTTPS",
 		},
-		Tls: &istiov1alpha3.ServerTLSSettings{
-			Mode:              istiov1alpha3.ServerTLSSettings_SIMPLE,
+		Tls: &istiov1beta1.ServerTLSSettings{
+			Mode:              istiov1beta1.ServerTLSSettings_SIMPLE,
 			ServerCertificate: corev1.TLSCertKey,
 			PrivateKey:        corev1.TLSPrivateKeyKey,
 			CredentialName:    "secret0",
@@ -141,6 +171,10 @@ var (
 	gateways = map[v1alpha1.IngressVisibility]sets.String{
 		v1alpha1.IngressVisibilityExternalIP: sets.NewString("knative-test-gateway", config.KnativeIngressGateway),
 	}
+	realmGateways = map[v1alpha1.IngressVisibility]sets.String{
+		v1alpha1.IngressVisibilityExternalIP:   sets.NewString("istio-system/external-domain"),
+		v1alpha1.IngressVisibilityClusterLocal: sets.NewString("istio-system/internal-domain"),
+	}
 	perIngressGatewayName = resources.GatewayName(ingressWithTLS("reconciling-ingress", ingressTLS), ingressService)
 )
 
@@ -172,51 +206,87 @@ var (
 	}}
 
 	// The gateway server according to ingressTLS.
-	ingressTLSServer = &istiov1alpha3.Server{
+	ingressTLSServer = &istiov1beta1.Server{
 		Hosts: []string{"host-tls.example.com"},
-		Port: &istiov1alpha3.Port{
+		Port: &istiov1beta1.Port{
 			Name:     "test-ns/reconciling-ingress:0",
 			Number:   443,
 			Protocol: "HTTPS",
 		},
-		Tls: &istiov1alpha3.ServerTLSSettings{
-			Mode:              istiov1alpha3.ServerTLSSettings_SIMPLE,
+		Tls: &istiov1beta1.ServerTLSSettings{
+			Mode:              istiov1beta1.ServerTLSSettings_SIMPLE,
 			ServerCertificate: "tls.crt",
 			PrivateKey:        "tls.key",
 			CredentialName:    "secret0",
 		},
 	}
 
-	ingressHTTPRedirectServer = &istiov1alpha3.Server{
+	ingressHTTPRedirectServer = &istiov1beta1.Server{
 		Hosts: []string{"*"},
-		Port: &istiov1alpha3.Port{
+		Port: &istiov1beta1.Port{
 			Name:     "http-server",
 			Number:   80,
 			Protocol: "HTTP",
 		},
-		Tls: &istiov1alpha3.ServerTLSSettings{
+		Tls: &istiov1beta1.ServerTLSSettings{
+			HttpsRedirect: true,
+		},
+	}
+
+	// The Ingress specific HTTP server according to the HTTPOption.
+	ingressHTTPOptionRedirectServer = &istiov1beta1.Server{
+		Hosts: []string{"host-tls.example.com"},
+		Port: &istiov1beta1.Port{
+			Name:     "test-ns/reconciling-ingress:http",
+			Number:   80,
+			Protocol: "HTTP",
+		},
+		Tls: &istiov1beta1.ServerTLSSettings{
 			HttpsRedirect: true,
 		},
 	}
 
+	// The Ingress specific server of the hosts in TLS passthrough mode.
+	ingressPassthroughServer = &istiov1beta1.Server{
+		Hosts: []string{"host-tls.example.com"},
+		Port: &istiov1beta1.Port{
+			Name:     "test-ns/reconciling-ingress:passthrough",
+			Number:   443,
+			Protocol: "TLS",
+		},
+		Tls: &istiov1beta1.ServerTLSSettings{
+			Mode: istiov1beta1.ServerTLSSettings_PASSTHROUGH,
+		},
+	}
+
+	// The Ingress specific server of a TCP port.
+	ingressTCPServer = &istiov1beta1.Server{
+		Hosts: []string{"host-tls.example.com"},
+		Port: &istiov1beta1.Port{
+			Name:     "test-ns/reconciling-ingress:tcp-9000",
+			Number:   9000,
+			Protocol: "TCP",
+		},
+	}
+
 	// The gateway server irrelevant to ingressTLS.
-	irrelevantServer = &istiov1alpha3.Server{
+	irrelevantServer = &istiov1beta1.Server{
 		Hosts: []string{"host-tls.example.com", "host-tls.test-ns.svc.cluster.local"},
-		Port: &istiov1alpha3.Port{
+		Port: &istiov1beta1.Port{
 			Name:     "test:0",
 			Number:   443,
 			Protocol: "HTTPS",
 		},
-		Tls: &istiov1alpha3.ServerTLSSettings{
-			Mode:              istiov1alpha3.ServerTLSSettings_SIMPLE,
+		Tls: &istiov1beta1.ServerTLSSettings{
+			Mode:              istiov1beta1.ServerTLSSettings_SIMPLE,
 			ServerCertificate: "tls.crt",
 			PrivateKey:        "tls.key",
 			CredentialName:    "other-secret",
 		},
 	}
-	irrelevantServer1 = &istiov1alpha3.Server{
+	irrelevantServer1 = &istiov1beta1.Server{
 		Hosts: []string{"*"},
-		Port: &istiov1alpha3.Port{
+		Port: &istiov1beta1.Port{
 			Name:     "http-server",
 			Number:   80,
 			Protocol: "HTTP",
@@ -262,7 +332,7 @@ func TestReconcile(t *testing.T) {
 					},
 				},
 			),
-			&v1alpha3.VirtualService{
+			&v1beta1.VirtualService{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "reconcile-failed-ingress",
 					Namespace: testNS,
@@ -271,14 +341,14 @@ func TestReconcile(t *testing.T) {
 					},
 					OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(ing("reconcile-failed"))},
 				},
-				Spec: istiov1alpha3.VirtualService{},
+				Spec: istiov1beta1.VirtualService{},
 			},
 		},
 		WantCreates: []runtime.Object{
-			resources.MakeMeshVirtualService(context.Background(), insertProbe(ing("reconcile-failed")), gateways),
+			meshVirtualService(context.Background(), insertProbe(ing("reconcile-failed")), gateways),
 		},
 		WantUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: resources.MakeIngressVirtualService(context.Background(), insertProbe(ing("reconcile-failed")),
+			Object: ingressVirtualService(context.Background(), insertProbe(ing("reconcile-failed")),
 				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil)),
 		}},
 		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
@@ -318,9 +388,9 @@ func TestReconcile(t *testing.T) {
 		Name: "reconcile VirtualService to match desired one",
 		Objects: []runtime.Object{
 			ing("reconcile-virtualservice"),
-			gateway("knative-ingress-gateway", system.Namespace(), []*istiov1alpha3.Server{irrelevantServer1}),
-			gateway("knative-test-gateway", system.Namespace(), []*istiov1alpha3.Server{irrelevantServer1}),
-			&v1alpha3.VirtualService{
+			gateway("knative-ingress-gateway", system.Namespace(), []*istiov1beta1.Server{irrelevantServer1}),
+			gateway("knative-test-gateway", system.Namespace(), []*istiov1beta1.Server{irrelevantServer1}),
+			&v1beta1.VirtualService{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "reconcile-virtualservice-ingress",
 					Namespace: testNS,
@@ -329,9 +399,9 @@ func TestReconcile(t *testing.T) {
 					},
 					OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(ing("reconcile-virtualservice"))},
 				},
-				Spec: istiov1alpha3.VirtualService{},
+				Spec: istiov1beta1.VirtualService{},
 			},
-			&v1alpha3.VirtualService{
+			&v1beta1.VirtualService{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "reconcile-virtualservice-extra",
 					Namespace: testNS,
@@ -340,21 +410,21 @@ func TestReconcile(t *testing.T) {
 					},
 					OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(ing("reconcile-virtualservice"))},
 				},
-				Spec: istiov1alpha3.VirtualService{},
+				Spec: istiov1beta1.VirtualService{},
 			},
 		},
 		WantUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: resources.MakeIngressVirtualService(context.Background(), insertProbe(ing("reconcile-virtualservice")),
+			Object: ingressVirtualService(context.Background(), insertProbe(ing("reconcile-virtualservice")),
 				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil)),
 		}},
 		WantCreates: []runtime.Object{
-			resources.MakeMeshVirtualService(context.Background(), insertProbe(ing("reconcile-virtualservice")), gateways),
+			meshVirtualService(context.Background(), insertProbe(ing("reconcile-virtualservice")), gateways),
 		},
 		WantDeletes: []clientgotesting.DeleteActionImpl{{
 			ActionImpl: clientgotesting.ActionImpl{
 				Namespace: testNS,
 				Verb:      "delete",
-				Resource:  v1alpha3.SchemeGroupVersion.WithResource("virtualservices"),
+				Resource:  v1beta1.SchemeGroupVersion.WithResource("virtualservices"),
 			},
 			Name: "reconcile-virtualservice-extra",
 		}},
@@ -405,280 +475,1038 @@ func TestReconcile(t *testing.T) {
 		Key:  "test-ns/ingress-ready",
 		Objects: []runtime.Object{
 			basicReconciledIngress("ingress-ready"),
-			resources.MakeMeshVirtualService(context.Background(), insertProbe(ing("ingress-ready")),
+			meshVirtualService(context.Background(), insertProbe(ing("ingress-ready")),
 				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil)),
-			resources.MakeIngressVirtualService(context.Background(), insertProbe(ing("ingress-ready")),
+			ingressVirtualService(context.Background(), insertProbe(ing("ingress-ready")),
 				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil)),
 		},
 		PostConditions: []func(*testing.T, *TableRow){proberCalledTimes(0)},
 	}, {
-		Name: "virtualService status ready should make ingress ready without probing",
-		Key:  "test-ns/ingress-virtualservice-ready",
+		// The Ingress goes through its defaulting, which clears the retries.
+		Name: "translate the retries of the Ingress paths into the VirtualService routes",
+		Key:  "test-ns/retries",
 		Objects: []runtime.Object{
-			ingressWithStatusAndFinalizers("ingress-virtualservice-ready", v1alpha1.IngressStatus{
-				Status: duckv1.Status{
-					Conditions: duckv1.Conditions{{
-						Type:   v1alpha1.IngressConditionLoadBalancerReady,
-						Status: corev1.ConditionFalse,
-					}, {
-						Type:   v1alpha1.IngressConditionNetworkConfigured,
-						Status: corev1.ConditionTrue,
-					}, {
-						Type:   v1alpha1.IngressConditionReady,
-						Status: corev1.ConditionFalse,
-					}},
+			ingressWithRetries(ingressWithRoutePolicy(basicReconciledIngress("retries"))),
+			meshVirtualService(context.Background(), insertProbe(ingressWithRoutePolicy(ing("retries"))),
+				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil)),
+			ingressVirtualService(context.Background(), insertProbe(ingressWithRoutePolicy(ing("retries"))),
+				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil)),
+		},
+		WantUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: withRouteRetries(meshVirtualService(context.Background(), insertProbe(ingressWithRetries(ingressWithRoutePolicy(ing("retries")))),
+				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil))),
+		}, {
+			Object: withRouteRetries(ingressVirtualService(context.Background(), insertProbe(ingressWithRetries(ingressWithRoutePolicy(ing("retries")))),
+				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil))),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Updated", "Updated VirtualService %s/%s", "test-ns", "retries-mesh"),
+			Eventf(corev1.EventTypeNormal, "Updated", "Updated VirtualService %s/%s", "test-ns", "retries-ingress"),
+		},
+		PostConditions: []func(*testing.T, *TableRow){proberCalledTimes(0)},
+	}, {
+		Name: "drop the route retries once the Ingress paths no longer set them",
+		Key:  "test-ns/retries",
+		Objects: []runtime.Object{
+			ingressWithRoutePolicy(basicReconciledIngress("retries")),
+			withRouteRetries(meshVirtualService(context.Background(), insertProbe(ingressWithRoutePolicy(ing("retries"))),
+				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil))),
+			withRouteRetries(ingressVirtualService(context.Background(), insertProbe(ingressWithRoutePolicy(ing("retries"))),
+				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil))),
+		},
+		WantUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: meshVirtualService(context.Background(), insertProbe(ingressWithRoutePolicy(ing("retries"))),
+				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil)),
+		}, {
+			Object: ingressVirtualService(context.Background(), insertProbe(ingressWithRoutePolicy(ing("retries"))),
+				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil)),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Updated", "Updated VirtualService %s/%s", "test-ns", "retries-mesh"),
+			Eventf(corev1.EventTypeNormal, "Updated", "Updated VirtualService %s/%s", "test-ns", "retries-ingress"),
+		},
+		PostConditions: []func(*testing.T, *TableRow){proberCalledTimes(0)},
+	}, {
+		Name: "reconcile DestinationRules from the traffic policy annotations",
+		Key:  "test-ns/traffic-policy",
+		Objects: []runtime.Object{
+			ingressWithLoadBalancer(basicReconciledIngress("traffic-policy"), "LEAST_CONN"),
+			meshVirtualService(context.Background(), insertProbe(ingressWithLoadBalancer(ing("traffic-policy"), "LEAST_CONN")),
+				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil)),
+			ingressVirtualService(context.Background(), insertProbe(ingressWithLoadBalancer(ing("traffic-policy"), "LEAST_CONN")),
+				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil)),
+			&v1beta1.DestinationRule{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "traffic-policy-removed-service",
+					Namespace: testNS,
+					Labels: map[string]string{
+						networking.IngressLabelKey: "traffic-policy",
+					},
+					OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(ing("traffic-policy"))},
 				},
-				PrivateLoadBalancer: &v1alpha1.LoadBalancerStatus{Ingress: []v1alpha1.LoadBalancerIngressStatus{{MeshOnly: true}}},
-				PublicLoadBalancer:  &v1alpha1.LoadBalancerStatus{Ingress: []v1alpha1.LoadBalancerIngressStatus{{DomainInternal: "test-ingressgateway.istio-system.svc.cluster.local"}}},
 			},
-				[]string{"ingresses.networking.internal.knative.dev"}),
-			meshVirtualServiceWithStatus(context.Background(), insertProbe(ing("ingress-virtualservice-ready")),
-				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil),
-				istiov1alpha1.IstioStatus{
-					Conditions: []*istiov1alpha1.IstioCondition{
-						{
-							Type:   "Reconciled",
-							Status: "True",
-						},
-					},
-				}, 1, 1),
-			ingressVirtualServiceWithStatus(context.Background(), insertProbe(ing("ingress-virtualservice-ready")),
-				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil),
-				istiov1alpha1.IstioStatus{
-					Conditions: []*istiov1alpha1.IstioCondition{
-						{
-							Type:   "Reconciled",
-							Status: "True",
-						},
-					},
-				}, 1, 1),
 		},
-		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: ingressWithStatusAndFinalizers("ingress-virtualservice-ready", v1alpha1.IngressStatus{
-				Status: duckv1.Status{
-					Conditions: duckv1.Conditions{{
-						Type:   v1alpha1.IngressConditionLoadBalancerReady,
-						Status: corev1.ConditionTrue,
-					}, {
-						Type:   v1alpha1.IngressConditionNetworkConfigured,
-						Status: corev1.ConditionTrue,
-					}, {
-						Type:   v1alpha1.IngressConditionReady,
-						Status: corev1.ConditionTrue,
-					}},
-				},
-				PrivateLoadBalancer: &v1alpha1.LoadBalancerStatus{Ingress: []v1alpha1.LoadBalancerIngressStatus{{MeshOnly: true}}},
-				PublicLoadBalancer:  &v1alpha1.LoadBalancerStatus{Ingress: []v1alpha1.LoadBalancerIngressStatus{{DomainInternal: "test-ingressgateway.istio-system.svc.cluster.local"}}},
+		WantCreates: []runtime.Object{
+			destinationRule(ingressWithLoadBalancer(ing("traffic-policy"), "LEAST_CONN"), "test-service"),
+		},
+		WantDeletes: []clientgotesting.DeleteActionImpl{{
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: testNS,
+				Verb:      "delete",
+				Resource:  v1beta1.SchemeGroupVersion.WithResource("destinationrules"),
 			},
-				[]string{"ingresses.networking.internal.knative.dev"})}},
+			Name: "traffic-policy-removed-service",
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created DestinationRule %q", "test-service-traffic-policy"),
+		},
 		PostConditions: []func(*testing.T, *TableRow){proberCalledTimes(0)},
 	}, {
-		Name: "virtualService status not ready should still avoid probing",
-		Key:  "test-ns/ingress-virtualservice-notready",
+		Name: "remove the DestinationRules when the session affinity annotation is removed",
+		Key:  "test-ns/session-affinity",
 		Objects: []runtime.Object{
-			ingressWithStatusAndFinalizers("ingress-virtualservice-notready", v1alpha1.IngressStatus{
-				Status: duckv1.Status{
-					Conditions: duckv1.Conditions{{
-						Type:    v1alpha1.IngressConditionLoadBalancerReady,
-						Reason:  "Uninitialized",
-						Status:  corev1.ConditionUnknown,
-						Message: "Waiting for load balancer to be ready",
-					}, {
-						Type:   v1alpha1.IngressConditionNetworkConfigured,
-						Status: corev1.ConditionTrue,
-					}, {
-						Type:    v1alpha1.IngressConditionReady,
-						Reason:  "Uninitialized",
-						Status:  corev1.ConditionUnknown,
-						Message: "Waiting for load balancer to be ready",
-					}},
-				},
-				PrivateLoadBalancer: &v1alpha1.LoadBalancerStatus{Ingress: []v1alpha1.LoadBalancerIngressStatus{{MeshOnly: true}}},
-				PublicLoadBalancer:  &v1alpha1.LoadBalancerStatus{Ingress: []v1alpha1.LoadBalancerIngressStatus{{DomainInternal: "test-ingressgateway.istio-system.svc.cluster.local"}}},
-			},
-				[]string{"ingresses.networking.internal.knative.dev"}),
-			meshVirtualServiceWithStatus(context.Background(), insertProbe(ing("ingress-virtualservice-notready")),
-				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil),
-				istiov1alpha1.IstioStatus{
-					Conditions: []*istiov1alpha1.IstioCondition{
-						{
-							Type:   "Reconciled",
-							Status: "True",
-						},
+			basicReconciledIngress("session-affinity"),
+			meshVirtualService(context.Background(), insertProbe(ing("session-affinity")),
+				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil)),
+			ingressVirtualService(context.Background(), insertProbe(ing("session-affinity")),
+				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil)),
+			&v1beta1.DestinationRule{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "session-affinity-test-service",
+					Namespace: testNS,
+					Labels: map[string]string{
+						networking.IngressLabelKey: "session-affinity",
 					},
-				}, 1, 1),
-			ingressVirtualServiceWithStatus(context.Background(), insertProbe(ing("ingress-virtualservice-notready")),
-				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil),
-				istiov1alpha1.IstioStatus{
-					Conditions: []*istiov1alpha1.IstioCondition{
-						{
-							Type:   "Reconciled",
-							Status: "False",
+					OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(ing("session-affinity"))},
+				},
+				Spec: istiov1beta1.DestinationRule{
+					Host: pkgnet.GetServiceHostname("test-service", testNS),
+					TrafficPolicy: &istiov1beta1.TrafficPolicy{
+						LoadBalancer: &istiov1beta1.LoadBalancerSettings{
+							LbPolicy: &istiov1beta1.LoadBalancerSettings_ConsistentHash{
+								ConsistentHash: &istiov1beta1.LoadBalancerSettings_ConsistentHashLB{
+									HashKey: &istiov1beta1.LoadBalancerSettings_ConsistentHashLB_HttpHeaderName{
+										HttpHeaderName: "X-User",
+									},
+								},
+							},
 						},
 					},
-				}, 1, 1),
+				},
+			},
 		},
+		WantDeletes: []clientgotesting.DeleteActionImpl{{
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: testNS,
+				Verb:      "delete",
+				Resource:  v1beta1.SchemeGroupVersion.WithResource("destinationrules"),
+			},
+			Name: "session-affinity-test-service",
+		}},
 		PostConditions: []func(*testing.T, *TableRow){proberCalledTimes(0)},
 	}, {
-		Name: "virtualService stale status should be the same as not ready",
-		Key:  "test-ns/ingress-virtualservice-stale",
+		Name: "remove the AuthorizationPolicies when cluster-local authorization is disabled",
+		Key:  "test-ns/cluster-local-authorization",
 		Objects: []runtime.Object{
-			ingressWithStatusAndFinalizers("ingress-virtualservice-stale", v1alpha1.IngressStatus{
-				Status: duckv1.Status{
-					Conditions: duckv1.Conditions{{
-						Type:    v1alpha1.IngressConditionLoadBalancerReady,
-						Reason:  "Uninitialized",
-						Status:  corev1.ConditionUnknown,
-						Message: "Waiting for load balancer to be ready",
-					}, {
-						Type:   v1alpha1.IngressConditionNetworkConfigured,
-						Status: corev1.ConditionTrue,
-					}, {
-						Type:    v1alpha1.IngressConditionReady,
-						Reason:  "Uninitialized",
-						Status:  corev1.ConditionUnknown,
-						Message: "Waiting for load balancer to be ready",
-					}},
+			basicReconciledIngress("cluster-local-authorization"),
+			meshVirtualService(context.Background(), insertProbe(ing("cluster-local-authorization")),
+				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil)),
+			ingressVirtualService(context.Background(), insertProbe(ing("cluster-local-authorization")),
+				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil)),
+			&securityv1beta1.AuthorizationPolicy{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-ns--cluster-local-authorization-istio-ingressgateway-cluster-local",
+					Namespace: "istio-system",
+					Labels:    resources.MakeGatewayPolicyLabels(ing("cluster-local-authorization")),
 				},
-				PrivateLoadBalancer: &v1alpha1.LoadBalancerStatus{Ingress: []v1alpha1.LoadBalancerIngressStatus{{MeshOnly: true}}},
-				PublicLoadBalancer:  &v1alpha1.LoadBalancerStatus{Ingress: []v1alpha1.LoadBalancerIngressStatus{{DomainInternal: "test-ingressgateway.istio-system.svc.cluster.local"}}},
 			},
-				[]string{"ingresses.networking.internal.knative.dev"}),
-			meshVirtualServiceWithStatus(context.Background(), insertProbe(ing("ingress-virtualservice-stale")),
-				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil),
-				istiov1alpha1.IstioStatus{
-					Conditions: []*istiov1alpha1.IstioCondition{
-						{
-							Type:   "Reconciled",
-							Status: "True",
-						},
-					},
-				}, 2, 2),
-			ingressVirtualServiceWithStatus(context.Background(), insertProbe(ing("ingress-virtualservice-stale")),
-				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil),
-				istiov1alpha1.IstioStatus{
-					Conditions: []*istiov1alpha1.IstioCondition{
-						{
-							Type:   "Reconciled",
-							Status: "True",
-						},
-					},
-				}, 2, 1),
 		},
+		WantDeletes: []clientgotesting.DeleteActionImpl{{
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: "istio-system",
+				Verb:      "delete",
+				Resource:  securityv1beta1.SchemeGroupVersion.WithResource("authorizationpolicies"),
+			},
+			Name: "test-ns--cluster-local-authorization-istio-ingressgateway-cluster-local",
+		}},
 		PostConditions: []func(*testing.T, *TableRow){proberCalledTimes(0)},
 	}, {
-		Name: "virtualService status 0 should revert to probers",
-		Key:  "test-ns/ingress-virtualservice-nostatus",
+		Name: "remove the EnvoyFilters when the rate limit annotation is removed",
+		Key:  "test-ns/rate-limit",
 		Objects: []runtime.Object{
-			ingressWithStatusAndFinalizers("ingress-virtualservice-nostatus", v1alpha1.IngressStatus{
-				Status: duckv1.Status{
-					Conditions: duckv1.Conditions{{
-						Type:    v1alpha1.IngressConditionLoadBalancerReady,
-						Reason:  "Uninitialized",
-						Status:  corev1.ConditionUnknown,
-						Message: "Waiting for load balancer to be ready",
-					}, {
-						Type:   v1alpha1.IngressConditionNetworkConfigured,
-						Status: corev1.ConditionTrue,
-					}, {
-						Type:    v1alpha1.IngressConditionReady,
-						Reason:  "Uninitialized",
-						Status:  corev1.ConditionUnknown,
-						Message: "Waiting for load balancer to be ready",
-					}},
+			basicReconciledIngress("rate-limit"),
+			meshVirtualService(context.Background(), insertProbe(ing("rate-limit")),
+				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil)),
+			ingressVirtualService(context.Background(), insertProbe(ing("rate-limit")),
+				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil)),
+			&v1alpha3.EnvoyFilter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-ns--rate-limit-istio-ingressgateway-rate-limit",
+					Namespace: "istio-system",
+					Labels:    resources.MakeGatewayPolicyLabels(ing("rate-limit")),
 				},
-				PrivateLoadBalancer: &v1alpha1.LoadBalancerStatus{Ingress: []v1alpha1.LoadBalancerIngressStatus{{MeshOnly: true}}},
-				PublicLoadBalancer:  &v1alpha1.LoadBalancerStatus{Ingress: []v1alpha1.LoadBalancerIngressStatus{{DomainInternal: "test-ingressgateway.istio-system.svc.cluster.local"}}},
 			},
-				[]string{"ingresses.networking.internal.knative.dev"}),
-			meshVirtualServiceWithStatus(context.Background(), insertProbe(ing("ingress-virtualservice-nostatus")),
-				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil),
-				istiov1alpha1.IstioStatus{
-					Conditions: []*istiov1alpha1.IstioCondition{
+		},
+		WantDeletes: []clientgotesting.DeleteActionImpl{{
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: "istio-system",
+				Verb:      "delete",
+				Resource:  v1alpha3.SchemeGroupVersion.WithResource("envoyfilters"),
+			},
+			Name: "test-ns--rate-limit-istio-ingressgateway-rate-limit",
+		}},
+		PostConditions: []func(*testing.T, *TableRow){proberCalledTimes(0)},
+	}, {
+		Name: "reconcile the JWT policies from the JWT annotations",
+		Key:  "test-ns/jwt",
+		// The policies live in the namespace of the gateways.
+		SkipNamespaceValidation: true,
+		Objects: []runtime.Object{
+			ingressWithJWT(basicReconciledIngress("jwt")),
+			meshVirtualService(context.Background(), insertProbe(ingressWithJWT(ing("jwt"))),
+				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil)),
+			ingressVirtualService(context.Background(), insertProbe(ingressWithJWT(ing("jwt"))),
+				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil)),
+			testIngressService,
+			ingressService,
+			&securityv1beta1.RequestAuthentication{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-ns--jwt-removed-gateway-jwt",
+					Namespace: "istio-system",
+					Labels:    resources.MakeGatewayPolicyLabels(ing("jwt")),
+				},
+			},
+		},
+		WantCreates: []runtime.Object{
+			jwtRequestAuthentication(ing("jwt"), testIngressService),
+			jwtRequestAuthentication(ing("jwt"), ingressService),
+			jwtAuthorizationPolicy(ing("jwt"), testIngressService),
+			jwtAuthorizationPolicy(ing("jwt"), ingressService),
+		},
+		WantDeletes: []clientgotesting.DeleteActionImpl{{
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: "istio-system",
+				Verb:      "delete",
+				Resource:  securityv1beta1.SchemeGroupVersion.WithResource("requestauthentications"),
+			},
+			Name: "test-ns--jwt-removed-gateway-jwt",
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created RequestAuthentication %s", "istio-system/test-ns--jwt-test-ingressgateway-jwt"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created RequestAuthentication %s", "istio-system/test-ns--jwt-istio-ingressgateway-jwt"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created AuthorizationPolicy %s", "istio-system/test-ns--jwt-test-ingressgateway-jwt"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created AuthorizationPolicy %s", "istio-system/test-ns--jwt-istio-ingressgateway-jwt"),
+		},
+		PostConditions: []func(*testing.T, *TableRow){proberCalledTimes(0)},
+	}, {
+		Name: "unknown ext_authz provider withdraws the VirtualServices of the Ingress",
+		Key:  "test-ns/ext-authz",
+		Objects: []runtime.Object{
+			ingressWithExtAuthz(basicReconciledIngress("ext-authz"), "opa"),
+			meshVirtualService(context.Background(), insertProbe(ing("ext-authz")), gateways),
+			ingressVirtualService(context.Background(), insertProbe(ing("ext-authz")), gateways),
+		},
+		WantDeletes: []clientgotesting.DeleteActionImpl{{
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: testNS,
+				Verb:      "delete",
+				Resource:  v1beta1.SchemeGroupVersion.WithResource("virtualservices"),
+			},
+			Name: "ext-authz-ingress",
+		}, {
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: testNS,
+				Verb:      "delete",
+				Resource:  v1beta1.SchemeGroupVersion.WithResource("virtualservices"),
+			},
+			Name: "ext-authz-mesh",
+		}},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: ingressWithExtAuthz(ingressWithStatusAndFinalizers("ext-authz",
+				v1alpha1.IngressStatus{
+					PrivateLoadBalancer: &v1alpha1.LoadBalancerStatus{Ingress: []v1alpha1.LoadBalancerIngressStatus{{MeshOnly: true}}},
+					PublicLoadBalancer:  &v1alpha1.LoadBalancerStatus{Ingress: []v1alpha1.LoadBalancerIngressStatus{{DomainInternal: "test-ingressgateway.istio-system.svc.cluster.local"}}},
+					Status: duckv1.Status{
+						Conditions: duckv1.Conditions{{
+							Type:    v1alpha1.IngressConditionLoadBalancerReady,
+							Status:  corev1.ConditionUnknown,
+							Reason:  "Uninitialized",
+							Message: "Waiting for load balancer to be ready",
+						}, {
+							Type:     v1alpha1.IngressConditionNetworkConfigured,
+							Status:   corev1.ConditionFalse,
+							Severity: apis.ConditionSeverityError,
+							Reason:   extAuthzProviderNotFound,
+							Message:  `unknown external authorization provider "opa" of annotation ` + resources.ExtAuthzProviderAnnotationKey + `, expected one of [ext-authz]`,
+						}, {
+							Type:     v1alpha1.IngressConditionReady,
+							Status:   corev1.ConditionFalse,
+							Severity: apis.ConditionSeverityError,
+							Reason:   extAuthzProviderNotFound,
+							Message:  `unknown external authorization provider "opa" of annotation ` + resources.ExtAuthzProviderAnnotationKey + `, expected one of [ext-authz]`,
+						}},
+					},
+				}, []string{"ingresses.networking.internal.knative.dev"}), "opa"),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeWarning, extAuthzProviderNotFound, `unknown external authorization provider "opa" of annotation %s, expected one of [ext-authz]`,
+				resources.ExtAuthzProviderAnnotationKey),
+		},
+	}, {
+		Name: "failure applying the ext_authz policies withdraws the VirtualServices of the Ingress",
+		Key:  "test-ns/ext-authz",
+		// The policies live in the namespace of the gateways.
+		SkipNamespaceValidation: true,
+		WantErr:                 true,
+		WithReactors: []clientgotesting.ReactionFunc{
+			InduceFailure("create", "authorizationpolicies"),
+		},
+		Objects: []runtime.Object{
+			ingressWithExtAuthz(basicReconciledIngress("ext-authz"), "ext-authz"),
+			meshVirtualService(context.Background(), insertProbe(ing("ext-authz")), gateways),
+			ingressVirtualService(context.Background(), insertProbe(ing("ext-authz")), gateways),
+			testIngressService,
+			ingressService,
+		},
+		WantCreates: []runtime.Object{
+			extAuthzAuthorizationPolicy(ing("ext-authz"), testIngressService, "ext-authz"),
+		},
+		WantDeletes: []clientgotesting.DeleteActionImpl{{
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: testNS,
+				Verb:      "delete",
+				Resource:  v1beta1.SchemeGroupVersion.WithResource("virtualservices"),
+			},
+			Name: "ext-authz-ingress",
+		}, {
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: testNS,
+				Verb:      "delete",
+				Resource:  v1beta1.SchemeGroupVersion.WithResource("virtualservices"),
+			},
+			Name: "ext-authz-mesh",
+		}},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: ingressWithExtAuthz(ingressWithStatusAndFinalizers("ext-authz",
+				v1alpha1.IngressStatus{
+					PrivateLoadBalancer: &v1alpha1.LoadBalancerStatus{Ingress: []v1alpha1.LoadBalancerIngressStatus{{MeshOnly: true}}},
+					PublicLoadBalancer:  &v1alpha1.LoadBalancerStatus{Ingress: []v1alpha1.LoadBalancerIngressStatus{{DomainInternal: "test-ingressgateway.istio-system.svc.cluster.local"}}},
+					Status: duckv1.Status{
+						Conditions: duckv1.Conditions{{
+							Type:    v1alpha1.IngressConditionLoadBalancerReady,
+							Status:  corev1.ConditionUnknown,
+							Reason:  "Uninitialized",
+							Message: "Waiting for load balancer to be ready",
+						}, {
+							Type:   v1alpha1.IngressConditionNetworkConfigured,
+							Status: corev1.ConditionTrue,
+						}, {
+							Type:    v1alpha1.IngressConditionReady,
+							Status:  corev1.ConditionUnknown,
+							Reason:  notReconciledReason,
+							Message: notReconciledMessage,
+						}},
+					},
+				}, []string{"ingresses.networking.internal.knative.dev"}), "ext-authz"),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeWarning, "CreationFailed", "Failed to create AuthorizationPolicy %s: %s",
+				"istio-system/test-ns--ext-authz-test-ingressgateway-ext-authz", "inducing failure for create authorizationpolicies"),
+			Eventf(corev1.EventTypeWarning, "InternalError", "failed to create AuthorizationPolicy: inducing failure for create authorizationpolicies"),
+		},
+	}, {
+		Name: "ingress bound to a Realm uses the Gateways of its Domains",
+		Key:  "test-ns/realm-ingress",
+		Objects: []runtime.Object{
+			ingressWithRealm(basicReconciledIngress("realm-ingress"), "realm"),
+			meshVirtualService(context.Background(), insertProbe(ingressWithRealm(ing("realm-ingress"), "realm")), realmGateways),
+			ingressVirtualService(context.Background(), insertProbe(ingressWithRealm(ing("realm-ingress"), "realm")), realmGateways),
+			realm("realm", "external", "internal"),
+			domain("external", "example.com", ingressService),
+			domain("internal", "", localGatewayService),
+			ingressService,
+			localGatewayService,
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: ingressWithRealm(ingressWithStatusAndFinalizers("realm-ingress",
+				v1alpha1.IngressStatus{
+					PublicLoadBalancer: &v1alpha1.LoadBalancerStatus{
+						Ingress: []v1alpha1.LoadBalancerIngressStatus{
+							{DomainInternal: pkgnet.GetServiceHostname("istio-ingressgateway", "istio-system")},
+						},
+					},
+					PrivateLoadBalancer: &v1alpha1.LoadBalancerStatus{
+						Ingress: []v1alpha1.LoadBalancerIngressStatus{
+							{DomainInternal: pkgnet.GetServiceHostname("knative-local-gateway", "istio-system")},
+						},
+					},
+					Status: duckv1.Status{
+						Conditions: duckv1.Conditions{{
+							Type:   v1alpha1.IngressConditionLoadBalancerReady,
+							Status: corev1.ConditionTrue,
+						}, {
+							Type:   v1alpha1.IngressConditionNetworkConfigured,
+							Status: corev1.ConditionTrue,
+						}, {
+							Type:   v1alpha1.IngressConditionReady,
+							Status: corev1.ConditionTrue,
+						}},
+					},
+				}, []string{"ingresses.networking.internal.knative.dev"}), "realm"),
+		}},
+		PostConditions: []func(*testing.T, *TableRow){proberCalledTimes(0)},
+	}, {
+		Name: "policies of an ingress bound to a Realm select the gateways of its Domains",
+		Key:  "test-ns/realm-jwt",
+		// The policies live in the namespace of the gateways.
+		SkipNamespaceValidation: true,
+		Objects: []runtime.Object{
+			ingressWithRealm(ingressWithJWT(basicReconciledIngress("realm-jwt")), "realm"),
+			meshVirtualService(context.Background(), insertProbe(ingressWithRealm(ingressWithJWT(ing("realm-jwt")), "realm")), realmGateways),
+			ingressVirtualService(context.Background(), insertProbe(ingressWithRealm(ingressWithJWT(ing("realm-jwt")), "realm")), realmGateways),
+			realm("realm", "external", "internal"),
+			domain("external", "example.com", ingressService),
+			domain("internal", "", localGatewayService),
+			ingressService,
+			localGatewayService,
+		},
+		WantCreates: []runtime.Object{
+			jwtRequestAuthentication(ing("realm-jwt"), ingressService),
+			jwtAuthorizationPolicy(ing("realm-jwt"), ingressService),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: ingressWithRealm(ingressWithJWT(ingressWithStatusAndFinalizers("realm-jwt",
+				v1alpha1.IngressStatus{
+					PublicLoadBalancer: &v1alpha1.LoadBalancerStatus{
+						Ingress: []v1alpha1.LoadBalancerIngressStatus{
+							{DomainInternal: pkgnet.GetServiceHostname("istio-ingressgateway", "istio-system")},
+						},
+					},
+					PrivateLoadBalancer: &v1alpha1.LoadBalancerStatus{
+						Ingress: []v1alpha1.LoadBalancerIngressStatus{
+							{DomainInternal: pkgnet.GetServiceHostname("knative-local-gateway", "istio-system")},
+						},
+					},
+					Status: duckv1.Status{
+						Conditions: duckv1.Conditions{{
+							Type:   v1alpha1.IngressConditionLoadBalancerReady,
+							Status: corev1.ConditionTrue,
+						}, {
+							Type:   v1alpha1.IngressConditionNetworkConfigured,
+							Status: corev1.ConditionTrue,
+						}, {
+							Type:   v1alpha1.IngressConditionReady,
+							Status: corev1.ConditionTrue,
+						}},
+					},
+				}, []string{"ingresses.networking.internal.knative.dev"})), "realm"),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created RequestAuthentication %s", "istio-system/test-ns--realm-jwt-istio-ingressgateway-jwt"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created AuthorizationPolicy %s", "istio-system/test-ns--realm-jwt-istio-ingressgateway-jwt"),
+		},
+		PostConditions: []func(*testing.T, *TableRow){proberCalledTimes(0)},
+	}, {
+		Name: "virtualService status ready should make ingress ready without probing",
+		Key:  "test-ns/ingress-virtualservice-ready",
+		Objects: []runtime.Object{
+			ingressWithStatusAndFinalizers("ingress-virtualservice-ready", v1alpha1.IngressStatus{
+				Status: duckv1.Status{
+					Conditions: duckv1.Conditions{{
+						Type:   v1alpha1.IngressConditionLoadBalancerReady,
+						Status: corev1.ConditionFalse,
+					}, {
+						Type:   v1alpha1.IngressConditionNetworkConfigured,
+						Status: corev1.ConditionTrue,
+					}, {
+						Type:   v1alpha1.IngressConditionReady,
+						Status: corev1.ConditionFalse,
+					}},
+				},
+				PrivateLoadBalancer: &v1alpha1.LoadBalancerStatus{Ingress: []v1alpha1.LoadBalancerIngressStatus{{MeshOnly: true}}},
+				PublicLoadBalancer:  &v1alpha1.LoadBalancerStatus{Ingress: []v1alpha1.LoadBalancerIngressStatus{{DomainInternal: "test-ingressgateway.istio-system.svc.cluster.local"}}},
+			},
+				[]string{"ingresses.networking.internal.knative.dev"}),
+			meshVirtualServiceWithStatus(context.Background(), insertProbe(ing("ingress-virtualservice-ready")),
+				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil),
+				istiov1alpha1.IstioStatus{
+					Conditions: []*istiov1alpha1.IstioCondition{
+						{
+							Type:   "Reconciled",
+							Status: "True",
+						},
+					},
+				}, 1, 1),
+			ingressVirtualServiceWithStatus(context.Background(), insertProbe(ing("ingress-virtualservice-ready")),
+				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil),
+				istiov1alpha1.IstioStatus{
+					Conditions: []*istiov1alpha1.IstioCondition{
+						{
+							Type:   "Reconciled",
+							Status: "True",
+						},
+					},
+				}, 1, 1),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: ingressWithStatusAndFinalizers("ingress-virtualservice-ready", v1alpha1.IngressStatus{
+				Status: duckv1.Status{
+					Conditions: duckv1.Conditions{{
+						Type:   v1alpha1.IngressConditionLoadBalancerReady,
+						Status: corev1.ConditionTrue,
+					}, {
+						Type:   v1alpha1.IngressConditionNetworkConfigured,
+						Status: corev1.ConditionTrue,
+					}, {
+						Type:   v1alpha1.IngressConditionReady,
+						Status: corev1.ConditionTrue,
+					}},
+				},
+				PrivateLoadBalancer: &v1alpha1.LoadBalancerStatus{Ingress: []v1alpha1.LoadBalancerIngressStatus{{MeshOnly: true}}},
+				PublicLoadBalancer:  &v1alpha1.LoadBalancerStatus{Ingress: []v1alpha1.LoadBalancerIngressStatus{{DomainInternal: "test-ingressgateway.istio-system.svc.cluster.local"}}},
+			},
+				[]string{"ingresses.networking.internal.knative.dev"})}},
+		PostConditions: []func(*testing.T, *TableRow){proberCalledTimes(0)},
+	}, {
+		Name: "virtualService status not ready should still avoid probing",
+		Key:  "test-ns/ingress-virtualservice-notready",
+		Objects: []runtime.Object{
+			ingressWithStatusAndFinalizers("ingress-virtualservice-notready", v1alpha1.IngressStatus{
+				Status: duckv1.Status{
+					Conditions: duckv1.Conditions{{
+						Type:    v1alpha1.IngressConditionLoadBalancerReady,
+						Reason:  "Uninitialized",
+						Status:  corev1.ConditionUnknown,
+						Message: "Waiting for load balancer to be ready",
+					}, {
+						Type:   v1alpha1.IngressConditionNetworkConfigured,
+						Status: corev1.ConditionTrue,
+					}, {
+						Type:    v1alpha1.IngressConditionReady,
+						Reason:  "Uninitialized",
+						Status:  corev1.ConditionUnknown,
+						Message: "Waiting for load balancer to be ready",
+					}},
+				},
+				PrivateLoadBalancer: &v1alpha1.LoadBalancerStatus{Ingress: []v1alpha1.LoadBalancerIngressStatus{{MeshOnly: true}}},
+				PublicLoadBalancer:  &v1alpha1.LoadBalancerStatus{Ingress: []v1alpha1.LoadBalancerIngressStatus{{DomainInternal: "test-ingressgateway.istio-system.svc.cluster.local"}}},
+			},
+				[]string{"ingresses.networking.internal.knative.dev"}),
+			meshVirtualServiceWithStatus(context.Background(), insertProbe(ing("ingress-virtualservice-notready")),
+				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil),
+				istiov1alpha1.IstioStatus{
+					Conditions: []*istiov1alpha1.IstioCondition{
+						{
+							Type:   "Reconciled",
+							Status: "True",
+						},
+					},
+				}, 1, 1),
+			ingressVirtualServiceWithStatus(context.Background(), insertProbe(ing("ingress-virtualservice-notready")),
+				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil),
+				istiov1alpha1.IstioStatus{
+					Conditions: []*istiov1alpha1.IstioCondition{
+						{
+							Type:   "Reconciled",
+							Status: "False",
+						},
+					},
+				}, 1, 1),
+		},
+		PostConditions: []func(*testing.T, *TableRow){proberCalledTimes(0)},
+	}, {
+		Name: "virtualService stale status should be the same as not ready",
+		Key:  "test-ns/ingress-virtualservice-stale",
+		Objects: []runtime.Object{
+			ingressWithStatusAndFinalizers("ingress-virtualservice-stale", v1alpha1.IngressStatus{
+				Status: duckv1.Status{
+					Conditions: duckv1.Conditions{{
+						Type:    v1alpha1.IngressConditionLoadBalancerReady,
+						Reason:  "Uninitialized",
+						Status:  corev1.ConditionUnknown,
+						Message: "Waiting for load balancer to be ready",
+					}, {
+						Type:   v1alpha1.IngressConditionNetworkConfigured,
+						Status: corev1.ConditionTrue,
+					}, {
+						Type:    v1alpha1.IngressConditionReady,
+						Reason:  "Uninitialized",
+						Status:  corev1.ConditionUnknown,
+						Message: "Waiting for load balancer to be ready",
+					}},
+				},
+				PrivateLoadBalancer: &v1alpha1.LoadBalancerStatus{Ingress: []v1alpha1.LoadBalancerIngressStatus{{MeshOnly: true}}},
+				PublicLoadBalancer:  &v1alpha1.LoadBalancerStatus{Ingress: []v1alpha1.LoadBalancerIngressStatus{{DomainInternal: "test-ingressgateway.istio-system.svc.cluster.local"}}},
+			},
+				[]string{"ingresses.networking.internal.knative.dev"}),
+			meshVirtualServiceWithStatus(context.Background(), insertProbe(ing("ingress-virtualservice-stale")),
+				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil),
+				istiov1alpha1.IstioStatus{
+					Conditions: []*istiov1alpha1.IstioCondition{
+						{
+							Type:   "Reconciled",
+							Status: "True",
+						},
+					},
+				}, 2, 2),
+			ingressVirtualServiceWithStatus(context.Background(), insertProbe(ing("ingress-virtualservice-stale")),
+				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil),
+				istiov1alpha1.IstioStatus{
+					Conditions: []*istiov1alpha1.IstioCondition{
+						{
+							Type:   "Reconciled",
+							Status: "True",
+						},
+					},
+				}, 2, 1),
+		},
+		PostConditions: []func(*testing.T, *TableRow){proberCalledTimes(0)},
+	}, {
+		Name: "virtualService status 0 should revert to probers",
+		Key:  "test-ns/ingress-virtualservice-nostatus",
+		Objects: []runtime.Object{
+			ingressWithStatusAndFinalizers("ingress-virtualservice-nostatus", v1alpha1.IngressStatus{
+				Status: duckv1.Status{
+					Conditions: duckv1.Conditions{{
+						Type:    v1alpha1.IngressConditionLoadBalancerReady,
+						Reason:  "Uninitialized",
+						Status:  corev1.ConditionUnknown,
+						Message: "Waiting for load balancer to be ready",
+					}, {
+						Type:   v1alpha1.IngressConditionNetworkConfigured,
+						Status: corev1.ConditionTrue,
+					}, {
+						Type:    v1alpha1.IngressConditionReady,
+						Reason:  "Uninitialized",
+						Status:  corev1.ConditionUnknown,
+						Message: "Waiting for load balancer to be ready",
+					}},
+				},
+				PrivateLoadBalancer: &v1alpha1.LoadBalancerStatus{Ingress: []v1alpha1.LoadBalancerIngressStatus{{MeshOnly: true}}},
+				PublicLoadBalancer:  &v1alpha1.LoadBalancerStatus{Ingress: []v1alpha1.LoadBalancerIngressStatus{{DomainInternal: "test-ingressgateway.istio-system.svc.cluster.local"}}},
+			},
+				[]string{"ingresses.networking.internal.knative.dev"}),
+			meshVirtualServiceWithStatus(context.Background(), insertProbe(ing("ingress-virtualservice-nostatus")),
+				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil),
+				istiov1alpha1.IstioStatus{
+					Conditions: []*istiov1alpha1.IstioCondition{
+						{
+							Type:   "Reconciled",
+							Status: "True",
+						},
+					},
+				}, 1, 0),
+			ingressVirtualServiceWithStatus(context.Background(), insertProbe(ing("ingress-virtualservice-nostatus")),
+				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil),
+				istiov1alpha1.IstioStatus{
+					Conditions: []*istiov1alpha1.IstioCondition{
 						{
 							Type:   "Reconciled",
 							Status: "True",
 						},
 					},
-				}, 1, 0),
-			ingressVirtualServiceWithStatus(context.Background(), insertProbe(ing("ingress-virtualservice-nostatus")),
-				makeGatewayMap([]string{"knative-testing/knative-test-gateway", "knative-testing/" + config.KnativeIngressGateway}, nil),
-				istiov1alpha1.IstioStatus{
-					Conditions: []*istiov1alpha1.IstioCondition{
-						{
-							Type:   "Reconciled",
-							Status: "True",
-						},
+				}, 1, 0),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: ingressWithStatusAndFinalizers("ingress-virtualservice-nostatus", v1alpha1.IngressStatus{
+				Status: duckv1.Status{
+					Conditions: duckv1.Conditions{{
+						Type:   v1alpha1.IngressConditionLoadBalancerReady,
+						Status: corev1.ConditionTrue,
+					}, {
+						Type:   v1alpha1.IngressConditionNetworkConfigured,
+						Status: corev1.ConditionTrue,
+					}, {
+						Type:   v1alpha1.IngressConditionReady,
+						Status: corev1.ConditionTrue,
+					}},
+				},
+				PrivateLoadBalancer: &v1alpha1.LoadBalancerStatus{Ingress: []v1alpha1.LoadBalancerIngressStatus{{MeshOnly: true}}},
+				PublicLoadBalancer:  &v1alpha1.LoadBalancerStatus{Ingress: []v1alpha1.LoadBalancerIngressStatus{{DomainInternal: "test-ingressgateway.istio-system.svc.cluster.local"}}},
+			},
+				[]string{"ingresses.networking.internal.knative.dev"})}},
+		PostConditions: []func(*testing.T, *TableRow){proberCalledTimes(1)},
+	}}
+
+	table.Test(t, MakeFactory(func(ctx context.Context, listers *Listers, cmw configmap.Watcher) controller.Reconciler {
+		r := &Reconciler{
+			kubeclient:                  kubeclient.Get(ctx),
+			istioClientSet:              istioclient.Get(ctx),
+			ingressLister:               listers.GetIngressLister(),
+			virtualServiceLister:        listers.GetVirtualServiceLister(),
+			destinationRuleLister:       listers.GetDestinationRuleLister(),
+			gatewayLister:               listers.GetGatewayLister(),
+			authorizationPolicyLister:   listers.GetAuthorizationPolicyLister(),
+			requestAuthenticationLister: listers.GetRequestAuthenticationLister(),
+			envoyFilterLister:           listers.GetEnvoyFilterLister(),
+			svcLister:                   listers.GetK8sServiceLister(),
+			realmLister:                 listers.GetRealmLister(),
+			domainLister:                listers.GetDomainLister(),
+			tracker:                     &NullTracker{},
+			statusManager:               ctx.Value(FakeStatusManagerKey).(status.Manager),
+		}
+
+		return ingressreconciler.NewReconciler(ctx, logging.FromContext(ctx), fakenetworkingclient.Get(ctx),
+			listers.GetIngressLister(), controller.GetEventRecorder(ctx), r, network.IstioIngressClassName, controller.Options{
+				ConfigStore: &testConfigStore{
+					config: ReconcilerTestConfig(),
+				}})
+	}))
+}
+
+func TestReconcile_EnableAutoTLS(t *testing.T) {
+	table := TableTest{{
+		Name:                    "create Ingress Gateway to match newly created Ingress",
+		SkipNamespaceValidation: true,
+		Objects: []runtime.Object{
+			ingressWithTLS("reconciling-ingress", ingressTLS),
+			// No Gateway servers match the given TLS of Ingress.
+			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1beta1.Server{irrelevantServer}),
+			originSecret("istio-system", "secret0"),
+			ingressService,
+		},
+		WantCreates: []runtime.Object{
+			// The creation of default global Gateway is triggered when setting up the test.
+			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1beta1.Server{irrelevantServer}),
+
+			// The newly created per-Ingress Gateway.
+			gateway(perIngressGatewayName, testNS, []*istiov1beta1.Server{ingressTLSServer},
+				withOwnerRef(ingressWithTLS("reconciling-ingress", ingressTLS)),
+				withLabels(gwLabels), withSelector(selector)),
+			meshVirtualService(context.Background(), insertProbe(ingressWithTLS("reconciling-ingress", ingressTLS)), ingressGateway),
+			ingressVirtualService(context.Background(), insertProbe(ingressWithTLS("reconciling-ingress", ingressTLS)),
+				makeGatewayMap([]string{"knative-testing/" + config.KnativeIngressGateway, "test-ns/" + perIngressGatewayName}, nil)),
+		},
+		WantPatches: []clientgotesting.PatchActionImpl{
+			patchAddFinalizerAction("reconciling-ingress", ingressFinalizer),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: ingressWithTLSAndStatus("reconciling-ingress",
+				ingressTLS,
+				v1alpha1.IngressStatus{
+					PublicLoadBalancer: &v1alpha1.LoadBalancerStatus{
+						Ingress: []v1alpha1.LoadBalancerIngressStatus{
+							{DomainInternal: pkgnet.GetServiceHostname("istio-ingressgateway", "istio-system")},
+						},
+					},
+					PrivateLoadBalancer: &v1alpha1.LoadBalancerStatus{
+						Ingress: []v1alpha1.LoadBalancerIngressStatus{
+							{MeshOnly: true},
+						},
+					},
+					Status: duckv1.Status{
+						Conditions: duckv1.Conditions{{
+							Type:     v1alpha1.IngressConditionLoadBalancerReady,
+							Status:   corev1.ConditionTrue,
+							Severity: apis.ConditionSeverityError,
+						}, {
+							Type:     v1alpha1.IngressConditionNetworkConfigured,
+							Status:   corev1.ConditionTrue,
+							Severity: apis.ConditionSeverityError,
+						}, {
+							Type:     v1alpha1.IngressConditionReady,
+							Status:   corev1.ConditionTrue,
+							Severity: apis.ConditionSeverityError,
+						}},
+					},
+				},
+			),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "FinalizerUpdate", "Updated %q finalizers", "reconciling-ingress"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created VirtualService %q", "reconciling-ingress-mesh"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created VirtualService %q", "reconciling-ingress-ingress"),
+		},
+		Key: "test-ns/reconciling-ingress",
+	}, {
+		Name:                    "create mutual TLS Ingress Gateway and copy the client CA Secret",
+		SkipNamespaceValidation: true,
+		Objects: []runtime.Object{
+			ingressWithClientCASecret(ingressWithTLS("reconciling-ingress", ingressTLS), "client-ca"),
+			// No Gateway servers match the given TLS of Ingress.
+			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1beta1.Server{irrelevantServer}),
+			originSecret("istio-system", "secret0"),
+			secret(testNS, "client-ca", map[string]string{}),
+			ingressService,
+		},
+		WantCreates: []runtime.Object{
+			// The creation of default global Gateway is triggered when setting up the test.
+			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1beta1.Server{irrelevantServer}),
+
+			// The newly created per-Ingress Gateway requires client certificates.
+			gateway(perIngressGatewayName, testNS, []*istiov1beta1.Server{withMutualTLS(deepCopy(ingressTLSServer))},
+				withOwnerRef(ingressWithTLS("reconciling-ingress", ingressTLS)),
+				withLabels(gwLabels), withSelector(selector)),
+			meshVirtualService(context.Background(), insertProbe(ingressWithClientCASecret(ingressWithTLS("reconciling-ingress", ingressTLS), "client-ca")), ingressGateway),
+			ingressVirtualService(context.Background(), insertProbe(ingressWithClientCASecret(ingressWithTLS("reconciling-ingress", ingressTLS), "client-ca")),
+				makeGatewayMap([]string{"knative-testing/" + config.KnativeIngressGateway, "test-ns/" + perIngressGatewayName}, nil)),
+
+			// The CA copy next to the origin secret in istio-system.
+			targetSecret("istio-system", "secret0-cacert", map[string]string{
+				networking.OriginSecretNameLabelKey:      "secret0",
+				networking.OriginSecretNamespaceLabelKey: "istio-system",
+			}),
+		},
+		WantPatches: []clientgotesting.PatchActionImpl{
+			patchAddFinalizerAction("reconciling-ingress", ingressFinalizer),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: ingressWithClientCASecret(ingressWithTLSAndStatus("reconciling-ingress",
+				ingressTLS,
+				v1alpha1.IngressStatus{
+					PublicLoadBalancer: &v1alpha1.LoadBalancerStatus{
+						Ingress: []v1alpha1.LoadBalancerIngressStatus{
+							{DomainInternal: pkgnet.GetServiceHostname("istio-ingressgateway", "istio-system")},
+						},
+					},
+					PrivateLoadBalancer: &v1alpha1.LoadBalancerStatus{
+						Ingress: []v1alpha1.LoadBalancerIngressStatus{
+							{MeshOnly: true},
+						},
+					},
+					Status: duckv1.Status{
+						Conditions: duckv1.Conditions{{
+							Type:     v1alpha1.IngressConditionLoadBalancerReady,
+							Status:   corev1.ConditionTrue,
+							Severity: apis.ConditionSeverityError,
+						}, {
+							Type:     v1alpha1.IngressConditionNetworkConfigured,
+							Status:   corev1.ConditionTrue,
+							Severity: apis.ConditionSeverityError,
+						}, {
+							Type:     v1alpha1.IngressConditionReady,
+							Status:   corev1.ConditionTrue,
+							Severity: apis.ConditionSeverityError,
+						}},
+					},
+				},
+			), "client-ca"),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "FinalizerUpdate", "Updated %q finalizers", "reconciling-ingress"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created Secret %s/%s", "istio-system", "secret0-cacert"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created VirtualService %q", "reconciling-ingress-mesh"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created VirtualService %q", "reconciling-ingress-ingress"),
+		},
+		Key: "test-ns/reconciling-ingress",
+	}, {
+		Name:                    "Update Ingress Gateway to match Ingress",
+		SkipNamespaceValidation: true,
+		Objects: []runtime.Object{
+			ingressWithTLS("reconciling-ingress", ingressTLS),
+			// The default global Gateway.
+			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1beta1.Server{irrelevantServer}),
+			// The existing Ingress gateway does not have HTTPS server.
+			gateway(perIngressGatewayName, testNS,
+				[]*istiov1beta1.Server{}, withOwnerRef(ingressWithTLS("reconciling-ingress", ingressTLS)),
+				withLabels(gwLabels), withSelector(selector)),
+			originSecret("istio-system", "secret0"),
+			ingressService,
+		},
+		WantCreates: []runtime.Object{
+			// The creation of default global Gateway is triggered when setting up the test.
+			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1beta1.Server{irrelevantServer}),
+			gateway(perIngressGatewayName, testNS,
+				[]*istiov1beta1.Server{}, withOwnerRef(ingressWithTLS("reconciling-ingress", ingressTLS)),
+				withLabels(gwLabels), withSelector(selector)),
+
+			meshVirtualService(context.Background(), insertProbe(ingressWithTLS("reconciling-ingress", ingressTLS)), ingressGateway),
+			ingressVirtualService(context.Background(), insertProbe(ingressWithTLS("reconciling-ingress", ingressTLS)),
+				makeGatewayMap([]string{"knative-testing/" + config.KnativeIngressGateway, "test-ns/" + perIngressGatewayName}, nil)),
+		},
+		WantUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: gateway(perIngressGatewayName, testNS,
+				[]*istiov1beta1.Server{ingressTLSServer}, withOwnerRef(ingressWithTLS("reconciling-ingress", ingressTLS)),
+				withLabels(gwLabels), withSelector(selector)),
+		}},
+		WantPatches: []clientgotesting.PatchActionImpl{
+			patchAddFinalizerAction("reconciling-ingress", ingressFinalizer),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: ingressWithTLSAndStatus("reconciling-ingress",
+				ingressTLS,
+				v1alpha1.IngressStatus{
+					PublicLoadBalancer: &v1alpha1.LoadBalancerStatus{
+						Ingress: []v1alpha1.LoadBalancerIngressStatus{
+							{DomainInternal: pkgnet.GetServiceHostname("istio-ingressgateway", "istio-system")},
+						},
+					},
+					PrivateLoadBalancer: &v1alpha1.LoadBalancerStatus{
+						Ingress: []v1alpha1.LoadBalancerIngressStatus{
+							{MeshOnly: true},
+						},
+					},
+					Status: duckv1.Status{
+						Conditions: duckv1.Conditions{{
+							Type:     v1alpha1.IngressConditionLoadBalancerReady,
+							Status:   corev1.ConditionTrue,
+							Severity: apis.ConditionSeverityError,
+						}, {
+							Type:     v1alpha1.IngressConditionNetworkConfigured,
+							Status:   corev1.ConditionTrue,
+							Severity: apis.ConditionSeverityError,
+						}, {
+							Type:     v1alpha1.IngressConditionReady,
+							Status:   corev1.ConditionTrue,
+							Severity: apis.ConditionSeverityError,
+						}},
+					},
+				},
+			),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "FinalizerUpdate", "Updated %q finalizers", "reconciling-ingress"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created VirtualService %q", "reconciling-ingress-mesh"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created VirtualService %q", "reconciling-ingress-ingress"),
+		},
+		Key: "test-ns/reconciling-ingress",
+	}, {
+		Name:                    "new Ingress using wildcard certificate",
+		SkipNamespaceValidation: true,
+		Objects: []runtime.Object{
+			ingressWithTLS("reconciling-ingress", ingressTLS),
+			// No Gateway servers match the given TLS of Ingress.
+			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1beta1.Server{irrelevantServer}),
+			wildcardCert,
+			ingressService,
+		},
+		WantCreates: []runtime.Object{
+			// The creation of gateways are triggered when setting up the test.
+			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1beta1.Server{irrelevantServer}),
+			wildcardGateway(resources.WildcardGatewayName(wildcardCert.Name, ingressService.Namespace, ingressService.Name), "istio-system",
+				[]*istiov1beta1.Server{wildcardTLSServer}, selector),
+
+			meshVirtualService(context.Background(), insertProbe(ingressWithTLS("reconciling-ingress", ingressTLS)), ingressGateway),
+			ingressVirtualService(context.Background(), insertProbe(ingressWithTLS("reconciling-ingress", ingressTLS)),
+				makeGatewayMap([]string{"knative-testing/" + config.KnativeIngressGateway,
+					"istio-system/" + resources.WildcardGatewayName(wildcardCert.Name, ingressService.Namespace, ingressService.Name)}, nil)),
+		},
+		WantPatches: []clientgotesting.PatchActionImpl{
+			patchAddFinalizerAction("reconciling-ingress", ingressFinalizer),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: ingressWithTLSAndStatus("reconciling-ingress",
+				ingressTLS,
+				v1alpha1.IngressStatus{
+					PublicLoadBalancer: &v1alpha1.LoadBalancerStatus{
+						Ingress: []v1alpha1.LoadBalancerIngressStatus{
+							{DomainInternal: pkgnet.GetServiceHostname("istio-ingressgateway", "istio-system")},
+						},
+					},
+					PrivateLoadBalancer: &v1alpha1.LoadBalancerStatus{
+						Ingress: []v1alpha1.LoadBalancerIngressStatus{
+							{MeshOnly: true},
+						},
+					},
+					Status: duckv1.Status{
+						Conditions: duckv1.Conditions{{
+							Type:     v1alpha1.IngressConditionLoadBalancerReady,
+							Status:   corev1.ConditionTrue,
+							Severity: apis.ConditionSeverityError,
+						}, {
+							Type:     v1alpha1.IngressConditionNetworkConfigured,
+							Status:   corev1.ConditionTrue,
+							Severity: apis.ConditionSeverityError,
+						}, {
+							Type:     v1alpha1.IngressConditionReady,
+							Status:   corev1.ConditionTrue,
+							Severity: apis.ConditionSeverityError,
+						}},
 					},
-				}, 1, 0),
+				},
+			),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "FinalizerUpdate", "Updated %q finalizers", "reconciling-ingress"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created VirtualService %q", "reconciling-ingress-mesh"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created VirtualService %q", "reconciling-ingress-ingress"),
+		},
+		Key: "test-ns/reconciling-ingress",
+	}, {
+		Name:                    "add the HTTP server of the Ingress HTTPOption to the shared Gateway",
+		SkipNamespaceValidation: true,
+		Objects: []runtime.Object{
+			ingressWithHTTPOption("reconciling-ingress", v1alpha1.HTTPOptionRedirected),
+			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1beta1.Server{irrelevantServer}),
+			ingressService,
+		},
+		WantCreates: []runtime.Object{
+			// The creation of default global Gateway is triggered when setting up the test.
+			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1beta1.Server{irrelevantServer}),
+
+			meshVirtualService(context.Background(), insertProbe(ingressWithHTTPOption("reconciling-ingress", v1alpha1.HTTPOptionRedirected)), ingressGateway),
+			ingressVirtualService(context.Background(), insertProbe(ingressWithHTTPOption("reconciling-ingress", v1alpha1.HTTPOptionRedirected)),
+				makeGatewayMap([]string{"knative-testing/" + config.KnativeIngressGateway}, nil)),
+		},
+		WantUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1beta1.Server{ingressHTTPOptionRedirectServer, irrelevantServer}),
+		}},
+		WantPatches: []clientgotesting.PatchActionImpl{
+			patchAddFinalizerAction("reconciling-ingress", ingressFinalizer),
 		},
 		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: ingressWithStatusAndFinalizers("ingress-virtualservice-nostatus", v1alpha1.IngressStatus{
-				Status: duckv1.Status{
-					Conditions: duckv1.Conditions{{
-						Type:   v1alpha1.IngressConditionLoadBalancerReady,
-						Status: corev1.ConditionTrue,
-					}, {
-						Type:   v1alpha1.IngressConditionNetworkConfigured,
-						Status: corev1.ConditionTrue,
-					}, {
-						Type:   v1alpha1.IngressConditionReady,
-						Status: corev1.ConditionTrue,
-					}},
+			Object: ingressWithHTTPOptionAndStatus("reconciling-ingress", v1alpha1.HTTPOptionRedirected,
+				v1alpha1.IngressStatus{
+					PublicLoadBalancer: &v1alpha1.LoadBalancerStatus{
+						Ingress: []v1alpha1.LoadBalancerIngressStatus{
+							{DomainInternal: pkgnet.GetServiceHostname("istio-ingressgateway", "istio-system")},
+						},
+					},
+					PrivateLoadBalancer: &v1alpha1.LoadBalancerStatus{
+						Ingress: []v1alpha1.LoadBalancerIngressStatus{
+							{MeshOnly: true},
+						},
+					},
+					Status: duckv1.Status{
+						Conditions: duckv1.Conditions{{
+							Type:     v1alpha1.IngressConditionLoadBalancerReady,
+							Status:   corev1.ConditionTrue,
+							Severity: apis.ConditionSeverityError,
+						}, {
+							Type:     v1alpha1.IngressConditionNetworkConfigured,
+							Status:   corev1.ConditionTrue,
+							Severity: apis.ConditionSeverityError,
+						}, {
+							Type:     v1alpha1.IngressConditionReady,
+							Status:   corev1.ConditionTrue,
+							Severity: apis.ConditionSeverityError,
+						}},
+					},
 				},
-				PrivateLoadBalancer: &v1alpha1.LoadBalancerStatus{Ingress: []v1alpha1.LoadBalancerIngressStatus{{MeshOnly: true}}},
-				PublicLoadBalancer:  &v1alpha1.LoadBalancerStatus{Ingress: []v1alpha1.LoadBalancerIngressStatus{{DomainInternal: "test-ingressgateway.istio-system.svc.cluster.local"}}},
-			},
-				[]string{"ingresses.networking.internal.knative.dev"})}},
-		PostConditions: []func(*testing.T, *TableRow){proberCalledTimes(1)},
-	}}
-
-	table.Test(t, MakeFactory(func(ctx context.Context, listers *Listers, cmw configmap.Watcher) controller.Reconciler {
-		r := &Reconciler{
-			kubeclient:           kubeclient.Get(ctx),
-			istioClientSet:       istioclient.Get(ctx),
-			virtualServiceLister: listers.GetVirtualServiceLister(),
-			gatewayLister:        listers.GetGatewayLister(),
-			statusManager:        ctx.Value(FakeStatusManagerKey).(status.Manager),
-		}
-
-		return ingressreconciler.NewReconciler(ctx, logging.FromContext(ctx), fakenetworkingclient.Get(ctx),
-			listers.GetIngressLister(), controller.GetEventRecorder(ctx), r, network.IstioIngressClassName, controller.Options{
-				ConfigStore: &testConfigStore{
-					config: ReconcilerTestConfig(),
-				}})
-	}))
-}
-
-func TestReconcile_EnableAutoTLS(t *testing.T) {
-	table := TableTest{{
-		Name:                    "create Ingress Gateway to match newly created Ingress",
+			),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "FinalizerUpdate", "Updated %q finalizers", "reconciling-ingress"),
+			Eventf(corev1.EventTypeNormal, "Updated", "Updated Gateway %s/%s", system.Namespace(), config.KnativeIngressGateway),
+			Eventf(corev1.EventTypeNormal, "Created", "Created VirtualService %q", "reconciling-ingress-mesh"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created VirtualService %q", "reconciling-ingress-ingress"),
+		},
+		Key: "test-ns/reconciling-ingress",
+	}, {
+		Name:                    "create Ingress Gateway with the TLS passthrough server of the Ingress",
 		SkipNamespaceValidation: true,
 		Objects: []runtime.Object{
-			ingressWithTLS("reconciling-ingress", ingressTLS),
-			// No Gateway servers match the given TLS of Ingress.
-			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1alpha3.Server{irrelevantServer}),
-			originSecret("istio-system", "secret0"),
+			ingressWithTLSPassthrough(ingressWithStatus("reconciling-ingress", v1alpha1.IngressStatus{}), "host-tls.example.com"),
+			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1beta1.Server{irrelevantServer}),
 			ingressService,
 		},
 		WantCreates: []runtime.Object{
 			// The creation of default global Gateway is triggered when setting up the test.
-			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1alpha3.Server{irrelevantServer}),
+			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1beta1.Server{irrelevantServer}),
 
-			// The newly created per-Ingress Gateway.
-			gateway(perIngressGatewayName, testNS, []*istiov1alpha3.Server{ingressTLSServer},
-				withOwnerRef(ingressWithTLS("reconciling-ingress", ingressTLS)),
+			// The newly created per-Ingress Gateway only contains the passthrough server.
+			gateway(perIngressGatewayName, testNS, []*istiov1beta1.Server{ingressPassthroughServer},
+				withOwnerRef(ingressWithTLSPassthrough(ingressWithStatus("reconciling-ingress", v1alpha1.IngressStatus{}), "host-tls.example.com")),
 				withLabels(gwLabels), withSelector(selector)),
-			resources.MakeMeshVirtualService(context.Background(), insertProbe(ingressWithTLS("reconciling-ingress", ingressTLS)), ingressGateway),
-			resources.MakeIngressVirtualService(context.Background(), insertProbe(ingressWithTLS("reconciling-ingress", ingressTLS)),
+			meshVirtualService(context.Background(), insertProbe(ingressWithTLSPassthrough(ingressWithStatus("reconciling-ingress", v1alpha1.IngressStatus{}), "host-tls.example.com")), ingressGateway),
+			ingressVirtualService(context.Background(), insertProbe(ingressWithTLSPassthrough(ingressWithStatus("reconciling-ingress", v1alpha1.IngressStatus{}), "host-tls.example.com")),
 				makeGatewayMap([]string{"knative-testing/" + config.KnativeIngressGateway, "test-ns/" + perIngressGatewayName}, nil)),
 		},
 		WantPatches: []clientgotesting.PatchActionImpl{
 			patchAddFinalizerAction("reconciling-ingress", ingressFinalizer),
 		},
 		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: ingressWithTLSAndStatus("reconciling-ingress",
-				ingressTLS,
+			Object: ingressWithTLSPassthrough(ingressWithStatus("reconciling-ingress",
 				v1alpha1.IngressStatus{
 					PublicLoadBalancer: &v1alpha1.LoadBalancerStatus{
 						Ingress: []v1alpha1.LoadBalancerIngressStatus{
@@ -706,7 +1534,7 @@ func TestReconcile_EnableAutoTLS(t *testing.T) {
 						}},
 					},
 				},
-			),
+			), "host-tls.example.com"),
 		}},
 		WantEvents: []string{
 			Eventf(corev1.EventTypeNormal, "FinalizerUpdate", "Updated %q finalizers", "reconciling-ingress"),
@@ -715,41 +1543,30 @@ func TestReconcile_EnableAutoTLS(t *testing.T) {
 		},
 		Key: "test-ns/reconciling-ingress",
 	}, {
-		Name:                    "Update Ingress Gateway to match Ingress",
+		Name:                    "create Ingress Gateway with the TCP servers of the Ingress",
 		SkipNamespaceValidation: true,
 		Objects: []runtime.Object{
-			ingressWithTLS("reconciling-ingress", ingressTLS),
-			// The default global Gateway.
-			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1alpha3.Server{irrelevantServer}),
-			// The existing Ingress gateway does not have HTTPS server.
-			gateway(perIngressGatewayName, testNS,
-				[]*istiov1alpha3.Server{}, withOwnerRef(ingressWithTLS("reconciling-ingress", ingressTLS)),
-				withLabels(gwLabels), withSelector(selector)),
-			originSecret("istio-system", "secret0"),
+			ingressWithTCPPorts(ingressWithStatus("reconciling-ingress", v1alpha1.IngressStatus{}), "host-tls.example.com=9000"),
+			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1beta1.Server{irrelevantServer}),
 			ingressService,
 		},
 		WantCreates: []runtime.Object{
 			// The creation of default global Gateway is triggered when setting up the test.
-			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1alpha3.Server{irrelevantServer}),
-			gateway(perIngressGatewayName, testNS,
-				[]*istiov1alpha3.Server{}, withOwnerRef(ingressWithTLS("reconciling-ingress", ingressTLS)),
-				withLabels(gwLabels), withSelector(selector)),
+			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1beta1.Server{irrelevantServer}),
 
-			resources.MakeMeshVirtualService(context.Background(), insertProbe(ingressWithTLS("reconciling-ingress", ingressTLS)), ingressGateway),
-			resources.MakeIngressVirtualService(context.Background(), insertProbe(ingressWithTLS("reconciling-ingress", ingressTLS)),
+			// The newly created per-Ingress Gateway only contains the TCP server.
+			gateway(perIngressGatewayName, testNS, []*istiov1beta1.Server{ingressTCPServer},
+				withOwnerRef(ingressWithTCPPorts(ingressWithStatus("reconciling-ingress", v1alpha1.IngressStatus{}), "host-tls.example.com=9000")),
+				withLabels(gwLabels), withSelector(selector)),
+			meshVirtualService(context.Background(), insertProbe(ingressWithTCPPorts(ingressWithStatus("reconciling-ingress", v1alpha1.IngressStatus{}), "host-tls.example.com=9000")), ingressGateway),
+			ingressVirtualService(context.Background(), insertProbe(ingressWithTCPPorts(ingressWithStatus("reconciling-ingress", v1alpha1.IngressStatus{}), "host-tls.example.com=9000")),
 				makeGatewayMap([]string{"knative-testing/" + config.KnativeIngressGateway, "test-ns/" + perIngressGatewayName}, nil)),
 		},
-		WantUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: gateway(perIngressGatewayName, testNS,
-				[]*istiov1alpha3.Server{ingressTLSServer}, withOwnerRef(ingressWithTLS("reconciling-ingress", ingressTLS)),
-				withLabels(gwLabels), withSelector(selector)),
-		}},
 		WantPatches: []clientgotesting.PatchActionImpl{
 			patchAddFinalizerAction("reconciling-ingress", ingressFinalizer),
 		},
 		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: ingressWithTLSAndStatus("reconciling-ingress",
-				ingressTLS,
+			Object: ingressWithTCPPorts(ingressWithStatus("reconciling-ingress",
 				v1alpha1.IngressStatus{
 					PublicLoadBalancer: &v1alpha1.LoadBalancerStatus{
 						Ingress: []v1alpha1.LoadBalancerIngressStatus{
@@ -777,7 +1594,7 @@ func TestReconcile_EnableAutoTLS(t *testing.T) {
 						}},
 					},
 				},
-			),
+			), "host-tls.example.com=9000"),
 		}},
 		WantEvents: []string{
 			Eventf(corev1.EventTypeNormal, "FinalizerUpdate", "Updated %q finalizers", "reconciling-ingress"),
@@ -786,32 +1603,40 @@ func TestReconcile_EnableAutoTLS(t *testing.T) {
 		},
 		Key: "test-ns/reconciling-ingress",
 	}, {
-		Name:                    "new Ingress using wildcard certificate",
+		Name:                    "delete Ingress Gateway that is no longer needed",
 		SkipNamespaceValidation: true,
 		Objects: []runtime.Object{
-			ingressWithTLS("reconciling-ingress", ingressTLS),
-			// No Gateway servers match the given TLS of Ingress.
-			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1alpha3.Server{irrelevantServer}),
-			wildcardCert,
+			ing("reconciling-ingress"),
+			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1beta1.Server{irrelevantServer}),
+			gateway(perIngressGatewayName, testNS, []*istiov1beta1.Server{ingressTLSServer},
+				withOwnerRef(ing("reconciling-ingress")),
+				withLabels(gwLabels), withSelector(selector)),
 			ingressService,
 		},
 		WantCreates: []runtime.Object{
 			// The creation of gateways are triggered when setting up the test.
-			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1alpha3.Server{irrelevantServer}),
-			wildcardGateway(resources.WildcardGatewayName(wildcardCert.Name, ingressService.Namespace, ingressService.Name), "istio-system",
-				[]*istiov1alpha3.Server{wildcardTLSServer}, selector),
+			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1beta1.Server{irrelevantServer}),
+			gateway(perIngressGatewayName, testNS, []*istiov1beta1.Server{ingressTLSServer},
+				withOwnerRef(ing("reconciling-ingress")),
+				withLabels(gwLabels), withSelector(selector)),
 
-			resources.MakeMeshVirtualService(context.Background(), insertProbe(ingressWithTLS("reconciling-ingress", ingressTLS)), ingressGateway),
-			resources.MakeIngressVirtualService(context.Background(), insertProbe(ingressWithTLS("reconciling-ingress", ingressTLS)),
-				makeGatewayMap([]string{"knative-testing/" + config.KnativeIngressGateway,
-					"istio-system/" + resources.WildcardGatewayName(wildcardCert.Name, ingressService.Namespace, ingressService.Name)}, nil)),
+			meshVirtualService(context.Background(), insertProbe(ing("reconciling-ingress")), ingressGateway),
+			ingressVirtualService(context.Background(), insertProbe(ing("reconciling-ingress")),
+				makeGatewayMap([]string{"knative-testing/" + config.KnativeIngressGateway}, nil)),
 		},
+		WantDeletes: []clientgotesting.DeleteActionImpl{{
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: testNS,
+				Verb:      "delete",
+				Resource:  v1beta1.SchemeGroupVersion.WithResource("gateways"),
+			},
+			Name: perIngressGatewayName,
+		}},
 		WantPatches: []clientgotesting.PatchActionImpl{
 			patchAddFinalizerAction("reconciling-ingress", ingressFinalizer),
 		},
 		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: ingressWithTLSAndStatus("reconciling-ingress",
-				ingressTLS,
+			Object: ingressWithStatus("reconciling-ingress",
 				v1alpha1.IngressStatus{
 					PublicLoadBalancer: &v1alpha1.LoadBalancerStatus{
 						Ingress: []v1alpha1.LoadBalancerIngressStatus{
@@ -893,14 +1718,14 @@ func TestReconcile_EnableAutoTLS(t *testing.T) {
 		Objects: []runtime.Object{
 			ingressWithFinalizers("reconciling-ingress", ingressTLS, []string{ingressFinalizer}, &deletionTime),
 			// ingressHTTPRedirectServer should not be deleted when deleting ingress related TLS server..
-			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1alpha3.Server{irrelevantServer, ingressTLSServer, ingressHTTPRedirectServer}),
+			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1beta1.Server{irrelevantServer, ingressTLSServer, ingressHTTPRedirectServer}),
 		},
 		WantCreates: []runtime.Object{
 			// The creation of gateways are triggered when setting up the test.
-			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1alpha3.Server{irrelevantServer, ingressTLSServer, ingressHTTPRedirectServer}),
+			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1beta1.Server{irrelevantServer, ingressTLSServer, ingressHTTPRedirectServer}),
 		},
 		WantUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1alpha3.Server{ingressHTTPRedirectServer, irrelevantServer}),
+			Object: gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1beta1.Server{irrelevantServer, ingressHTTPRedirectServer}),
 		}},
 		WantPatches: []clientgotesting.PatchActionImpl{
 			patchAddFinalizerAction("reconciling-ingress", ""),
@@ -916,15 +1741,15 @@ func TestReconcile_EnableAutoTLS(t *testing.T) {
 		Objects: []runtime.Object{
 			ingressWithFinalizers("reconciling-ingress", ingressTLS, []string{ingressFinalizer}, &deletionTime),
 			// ingressHTTPRedirectServer should not be deleted when deleting ingress related TLS server..
-			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1alpha3.Server{irrelevantServer, ingressTLSServer, ingressHTTPRedirectServer}),
+			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1beta1.Server{irrelevantServer, ingressTLSServer, ingressHTTPRedirectServer}),
 			targetSecret("istio-system", "targetSecret", resources.MakeTargetSecretLabels("secret0", "istio-system")),
 		},
 		WantCreates: []runtime.Object{
 			// The creation of gateways are triggered when setting up the test.
-			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1alpha3.Server{irrelevantServer, ingressTLSServer, ingressHTTPRedirectServer}),
+			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1beta1.Server{irrelevantServer, ingressTLSServer, ingressHTTPRedirectServer}),
 		},
 		WantUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1alpha3.Server{ingressHTTPRedirectServer, irrelevantServer}),
+			Object: gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1beta1.Server{irrelevantServer, ingressHTTPRedirectServer}),
 		}},
 		WantPatches: []clientgotesting.PatchActionImpl{
 			patchAddFinalizerAction("reconciling-ingress", ""),
@@ -948,7 +1773,7 @@ func TestReconcile_EnableAutoTLS(t *testing.T) {
 		Objects: []runtime.Object{
 			ingressWithTLS("reconciling-ingress", ingressTLSWithSecretNamespace("knative-serving")),
 			// No Gateway servers match the given TLS of Ingress.
-			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1alpha3.Server{irrelevantServer}),
+			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1beta1.Server{irrelevantServer}),
 			// The namespace (`knative-serving`) of the origin secret is different
 			// from the namespace (`istio-system`) of Istio gateway service.
 			originSecret("knative-serving", "secret0"),
@@ -956,16 +1781,16 @@ func TestReconcile_EnableAutoTLS(t *testing.T) {
 		},
 		WantCreates: []runtime.Object{
 			// The creation of gateways are triggered when setting up the test.
-			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1alpha3.Server{irrelevantServer}),
+			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1beta1.Server{irrelevantServer}),
 
 			// The newly created per-Ingress Gateway.
 			gateway(perIngressGatewayName, testNS,
-				[]*istiov1alpha3.Server{withCredentialName(deepCopy(ingressTLSServer), targetSecretName)},
+				[]*istiov1beta1.Server{withCredentialName(deepCopy(ingressTLSServer), targetSecretName)},
 				withOwnerRef(ingressWithTLS("reconciling-ingress", ingressTLS)),
 				withLabels(gwLabels), withSelector(selector)),
 
-			resources.MakeMeshVirtualService(context.Background(), insertProbe(ingressWithTLS("reconciling-ingress", ingressTLSWithSecretNamespace("knative-serving"))), ingressGateway),
-			resources.MakeIngressVirtualService(context.Background(), insertProbe(ingressWithTLS("reconciling-ingress", ingressTLSWithSecretNamespace("knative-serving"))),
+			meshVirtualService(context.Background(), insertProbe(ingressWithTLS("reconciling-ingress", ingressTLSWithSecretNamespace("knative-serving"))), ingressGateway),
+			ingressVirtualService(context.Background(), insertProbe(ingressWithTLS("reconciling-ingress", ingressTLSWithSecretNamespace("knative-serving"))),
 				makeGatewayMap([]string{"knative-testing/" + config.KnativeIngressGateway, "test-ns/" + perIngressGatewayName}, nil)),
 
 			// The secret copy under istio-system.
@@ -1023,11 +1848,11 @@ func TestReconcile_EnableAutoTLS(t *testing.T) {
 			ingressWithTLS("reconciling-ingress", ingressTLSWithSecretNamespace("knative-serving")),
 
 			// The default global Gateway.
-			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1alpha3.Server{irrelevantServer}),
+			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1beta1.Server{irrelevantServer}),
 
 			// The newly created per-Ingress Gateway.
 			gateway(perIngressGatewayName, testNS,
-				[]*istiov1alpha3.Server{withCredentialName(deepCopy(ingressTLSServer), targetSecretName)},
+				[]*istiov1beta1.Server{withCredentialName(deepCopy(ingressTLSServer), targetSecretName)},
 				withOwnerRef(ingressWithTLS("reconciling-ingress", ingressTLS)),
 				withLabels(gwLabels), withSelector(selector)),
 			ingressService,
@@ -1052,14 +1877,14 @@ func TestReconcile_EnableAutoTLS(t *testing.T) {
 		},
 		WantCreates: []runtime.Object{
 			// The creation of gateways are triggered when setting up the test.
-			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1alpha3.Server{irrelevantServer}),
+			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1beta1.Server{irrelevantServer}),
 			gateway(perIngressGatewayName, testNS,
-				[]*istiov1alpha3.Server{withCredentialName(deepCopy(ingressTLSServer), targetSecretName)},
+				[]*istiov1beta1.Server{withCredentialName(deepCopy(ingressTLSServer), targetSecretName)},
 				withOwnerRef(ingressWithTLS("reconciling-ingress", ingressTLS)),
 				withLabels(gwLabels), withSelector(selector)),
 
-			resources.MakeMeshVirtualService(context.Background(), insertProbe(ingressWithTLS("reconciling-ingress", ingressTLSWithSecretNamespace("knative-serving"))), ingressGateway),
-			resources.MakeIngressVirtualService(context.Background(), insertProbe(ingressWithTLS("reconciling-ingress", ingressTLSWithSecretNamespace("knative-serving"))),
+			meshVirtualService(context.Background(), insertProbe(ingressWithTLS("reconciling-ingress", ingressTLSWithSecretNamespace("knative-serving"))), ingressGateway),
+			ingressVirtualService(context.Background(), insertProbe(ingressWithTLS("reconciling-ingress", ingressTLSWithSecretNamespace("knative-serving"))),
 				makeGatewayMap([]string{"knative-testing/" + config.KnativeIngressGateway, "test-ns/" + perIngressGatewayName}, nil)),
 		},
 		WantUpdates: []clientgotesting.UpdateActionImpl{{
@@ -1124,13 +1949,13 @@ func TestReconcile_EnableAutoTLS(t *testing.T) {
 		Objects: []runtime.Object{
 			ingressWithTLSClusterLocal("reconciling-ingress", ingressTLS),
 			// No Gateway servers match the given TLS of Ingress.
-			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1alpha3.Server{irrelevantServer}),
+			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1beta1.Server{irrelevantServer}),
 			originSecret("istio-system", "secret0"),
 		},
 		WantCreates: []runtime.Object{
 			// The creation of gateways are triggered when setting up the test.
-			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1alpha3.Server{irrelevantServer}),
-			resources.MakeMeshVirtualService(context.Background(), insertProbe(ingressWithTLSClusterLocal("reconciling-ingress", ingressTLS)), ingressGateway),
+			gateway(config.KnativeIngressGateway, system.Namespace(), []*istiov1beta1.Server{irrelevantServer}),
+			meshVirtualService(context.Background(), insertProbe(ingressWithTLSClusterLocal("reconciling-ingress", ingressTLS)), ingressGateway),
 		},
 		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
 			Object: ingressWithTLSAndStatusClusterLocal("reconciling-ingress",
@@ -1181,17 +2006,22 @@ func TestReconcile_EnableAutoTLS(t *testing.T) {
 		// https://github.com/knative/serving/blob/a6852fc3b6cdce72b99c5d578dd64f2e03dabb8b/vendor/k8s.io/client-go/testing/fixture.go#L292
 		gateways := getGatewaysFromObjects(listers.GetIstioObjects())
 		for _, gateway := range gateways {
-			fakeistioclient.Get(ctx).NetworkingV1alpha3().Gateways(gateway.Namespace).Create(ctx, gateway, metav1.CreateOptions{})
+			fakeistioclient.Get(ctx).NetworkingV1beta1().Gateways(gateway.Namespace).Create(ctx, gateway, metav1.CreateOptions{})
 		}
 
 		r := &Reconciler{
-			kubeclient:           kubeclient.Get(ctx),
-			istioClientSet:       istioclient.Get(ctx),
-			virtualServiceLister: listers.GetVirtualServiceLister(),
-			gatewayLister:        listers.GetGatewayLister(),
-			secretLister:         listers.GetSecretLister(),
-			svcLister:            listers.GetK8sServiceLister(),
-			tracker:              &NullTracker{},
+			kubeclient:                  kubeclient.Get(ctx),
+			istioClientSet:              istioclient.Get(ctx),
+			ingressLister:               listers.GetIngressLister(),
+			virtualServiceLister:        listers.GetVirtualServiceLister(),
+			destinationRuleLister:       listers.GetDestinationRuleLister(),
+			gatewayLister:               listers.GetGatewayLister(),
+			authorizationPolicyLister:   listers.GetAuthorizationPolicyLister(),
+			requestAuthenticationLister: listers.GetRequestAuthenticationLister(),
+			envoyFilterLister:           listers.GetEnvoyFilterLister(),
+			secretLister:                listers.GetSecretLister(),
+			svcLister:                   listers.GetK8sServiceLister(),
+			tracker:                     &NullTracker{},
 			statusManager: &fakestatusmanager.FakeStatusManager{
 				FakeIsReady: func(ctx context.Context, ing *v1alpha1.Ingress) (bool, error) {
 					return true, nil
@@ -1287,11 +2117,16 @@ func TestReconcile_DisableStatus(t *testing.T) {
 
 	table.Test(t, MakeFactory(func(ctx context.Context, listers *Listers, cmw configmap.Watcher) controller.Reconciler {
 		r := &Reconciler{
-			kubeclient:           kubeclient.Get(ctx),
-			istioClientSet:       istioclient.Get(ctx),
-			virtualServiceLister: listers.GetVirtualServiceLister(),
-			gatewayLister:        listers.GetGatewayLister(),
-			statusManager:        ctx.Value(FakeStatusManagerKey).(status.Manager),
+			kubeclient:                  kubeclient.Get(ctx),
+			istioClientSet:              istioclient.Get(ctx),
+			ingressLister:               listers.GetIngressLister(),
+			virtualServiceLister:        listers.GetVirtualServiceLister(),
+			destinationRuleLister:       listers.GetDestinationRuleLister(),
+			gatewayLister:               listers.GetGatewayLister(),
+			authorizationPolicyLister:   listers.GetAuthorizationPolicyLister(),
+			requestAuthenticationLister: listers.GetRequestAuthenticationLister(),
+			envoyFilterLister:           listers.GetEnvoyFilterLister(),
+			statusManager:               ctx.Value(FakeStatusManagerKey).(status.Manager),
 		}
 
 		config := ReconcilerTestConfig()
@@ -1305,10 +2140,10 @@ func TestReconcile_DisableStatus(t *testing.T) {
 	}))
 }
 
-func getGatewaysFromObjects(objects []runtime.Object) []*v1alpha3.Gateway {
-	gateways := []*v1alpha3.Gateway{}
+func getGatewaysFromObjects(objects []runtime.Object) []*v1beta1.Gateway {
+	gateways := []*v1beta1.Gateway{}
 	for _, object := range objects {
-		if gateway, ok := object.(*v1alpha3.Gateway); ok {
+		if gateway, ok := object.(*v1beta1.Gateway); ok {
 			gateways = append(gateways, gateway)
 		}
 	}
@@ -1318,15 +2153,15 @@ func getGatewaysFromObjects(objects []runtime.Object) []*v1alpha3.Gateway {
 	return gateways
 }
 
-type GatewayOpt func(*v1alpha3.Gateway)
+type GatewayOpt func(*v1beta1.Gateway)
 
-func gateway(name, namespace string, servers []*istiov1alpha3.Server, opts ...GatewayOpt) *v1alpha3.Gateway {
-	gw := &v1alpha3.Gateway{
+func gateway(name, namespace string, servers []*istiov1beta1.Server, opts ...GatewayOpt) *v1beta1.Gateway {
+	gw := &v1beta1.Gateway{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
 			Namespace: namespace,
 		},
-		Spec: istiov1alpha3.Gateway{
+		Spec: istiov1beta1.Gateway{
 			Servers: servers,
 		},
 	}
@@ -1337,24 +2172,24 @@ func gateway(name, namespace string, servers []*istiov1alpha3.Server, opts ...Ga
 }
 
 func withOwnerRef(ing *v1alpha1.Ingress) GatewayOpt {
-	return func(gw *v1alpha3.Gateway) {
+	return func(gw *v1beta1.Gateway) {
 		gw.OwnerReferences = []metav1.OwnerReference{*kmeta.NewControllerRef(ing)}
 	}
 }
 
 func withLabels(labels map[string]string) GatewayOpt {
-	return func(gw *v1alpha3.Gateway) {
+	return func(gw *v1beta1.Gateway) {
 		gw.Labels = labels
 	}
 }
 
 func withSelector(selector map[string]string) GatewayOpt {
-	return func(gw *v1alpha3.Gateway) {
+	return func(gw *v1beta1.Gateway) {
 		gw.Spec.Selector = selector
 	}
 }
 
-func wildcardGateway(name, namespace string, servers []*istiov1alpha3.Server, selector map[string]string) *v1alpha3.Gateway {
+func wildcardGateway(name, namespace string, servers []*istiov1beta1.Server, selector map[string]string) *v1beta1.Gateway {
 	gw := gateway(name, namespace, servers)
 	gvk := schema.GroupVersionKind{Version: "v1", Kind: "Secret"}
 	gw.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(wildcardCert, gvk)}
@@ -1383,14 +2218,19 @@ func secret(namespace, name string, labels map[string]string) *corev1.Secret {
 	return &secret
 }
 
-func withCredentialName(tlsServer *istiov1alpha3.Server, credentialName string) *istiov1alpha3.Server {
+func withCredentialName(tlsServer *istiov1beta1.Server, credentialName string) *istiov1beta1.Server {
 	tlsServer.Tls.CredentialName = credentialName
 	return tlsServer
 }
 
+func withMutualTLS(tlsServer *istiov1beta1.Server) *istiov1beta1.Server {
+	tlsServer.Tls.Mode = istiov1beta1.ServerTLSSettings_MUTUAL
+	return tlsServer
+}
+
 // Open-coded deepCopy since istio.io/api's Server doesn't provide one currently
-func deepCopy(server *istiov1alpha3.Server) *istiov1alpha3.Server {
-	return proto.Clone(server).(*istiov1alpha3.Server)
+func deepCopy(server *istiov1beta1.Server) *istiov1beta1.Server {
+	return proto.Clone(server).(*istiov1beta1.Server)
 }
 
 func ingressTLSWithSecretNamespace(namespace string) []v1alpha1.IngressTLS {
@@ -1426,6 +2266,217 @@ func addAnnotations(ing *v1alpha1.Ingress, annos map[string]string) *v1alpha1.In
 	return ing
 }
 
+func ingressWithRoutePolicy(ing *v1alpha1.Ingress) *v1alpha1.Ingress {
+	return addAnnotations(ing, map[string]string{resources.RoutePolicyAnnotationKey: "true"})
+}
+
+// ingressWithRetries sets retries on the paths of the given Ingress, which
+// the defaulting of the Ingresses clears.
+func ingressWithRetries(ing *v1alpha1.Ingress) *v1alpha1.Ingress {
+	ing.Spec = *ing.Spec.DeepCopy()
+	for i := range ing.Spec.Rules {
+		for j := range ing.Spec.Rules[i].HTTP.Paths {
+			ing.Spec.Rules[i].HTTP.Paths[j].DeprecatedRetries = &v1alpha1.HTTPRetry{Attempts: 3}
+		}
+	}
+	return ing
+}
+
+// withRouteRetries sets the retries of ingressWithRetries on the routes of the
+// given VirtualService, so that the expected routes spell them out.
+func withRouteRetries(vs *v1beta1.VirtualService) *v1beta1.VirtualService {
+	for _, route := range vs.Spec.Http {
+		route.Retries = &istiov1beta1.HTTPRetry{
+			Attempts: 3,
+			RetryOn:  "connect-failure,refused-stream,unavailable,cancelled,retriable-status-codes",
+		}
+	}
+	return vs
+}
+
+func ingressWithRealm(ing *v1alpha1.Ingress, realm string) *v1alpha1.Ingress {
+	return addAnnotations(ing, map[string]string{resources.RealmAnnotationKey: realm})
+}
+
+func ingressWithLoadBalancer(ing *v1alpha1.Ingress, lb string) *v1alpha1.Ingress {
+	return addAnnotations(ing, map[string]string{resources.LoadBalancerAnnotationKey: lb})
+}
+
+func ingressWithJWT(ing *v1alpha1.Ingress) *v1alpha1.Ingress {
+	return addAnnotations(ing, map[string]string{
+		resources.JWTIssuerAnnotationKey:         "https://issuer.example.com",
+		resources.JWTJwksURIAnnotationKey:        "https://issuer.example.com/keys",
+		resources.JWTRequiredClaimsAnnotationKey: "group=admin",
+	})
+}
+
+func ingressWithTLSPassthrough(ing *v1alpha1.Ingress, hosts string) *v1alpha1.Ingress {
+	return addAnnotations(ing, map[string]string{
+		resources.TLSPassthroughHostsAnnotationKey: hosts,
+	})
+}
+
+func ingressWithTCPPorts(ing *v1alpha1.Ingress, ports string) *v1alpha1.Ingress {
+	return addAnnotations(ing, map[string]string{
+		resources.TCPPortsAnnotationKey: ports,
+	})
+}
+
+func ingressWithExtAuthz(ing *v1alpha1.Ingress, provider string) *v1alpha1.Ingress {
+	return addAnnotations(ing, map[string]string{
+		resources.ExtAuthzProviderAnnotationKey: provider,
+	})
+}
+
+func jwtRequestAuthentication(ing *v1alpha1.Ingress, gatewayService *corev1.Service) *securityv1beta1.RequestAuthentication {
+	return &securityv1beta1.RequestAuthentication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testNS + "--" + ing.Name + "-" + gatewayService.Name + "-jwt",
+			Namespace: gatewayService.Namespace,
+			Labels:    resources.MakeGatewayPolicyLabels(ing),
+		},
+		Spec: istiosecurity.RequestAuthentication{
+			Selector: &istiotype.WorkloadSelector{MatchLabels: gatewayService.Spec.Selector},
+			JwtRules: []*istiosecurity.JWTRule{{
+				Issuer:  "https://issuer.example.com",
+				JwksUri: "https://issuer.example.com/keys",
+			}},
+		},
+	}
+}
+
+// policyHosts are the hosts of the policies of the ing Ingresses.
+var policyHosts = []string{
+	"host-tls.example.com", "host-tls.example.com:*",
+	"host-tls.test-ns", "host-tls.test-ns.svc", "host-tls.test-ns.svc.cluster.local",
+	"host-tls.test-ns.svc.cluster.local:*", "host-tls.test-ns.svc:*", "host-tls.test-ns:*",
+}
+
+// notProbeRules returns the rules matching the requests of the given operation
+// other than the status probes.
+func notProbeRules(op istiosecurity.Operation, from []*istiosecurity.Rule_From, when ...*istiosecurity.Condition) []*istiosecurity.Rule {
+	notProbePath, notGet := op, op
+	notProbePath.NotPaths = []string{network.ProbePath}
+	notGet.NotMethods = []string{http.MethodGet}
+	return []*istiosecurity.Rule{{
+		From: from,
+		To:   []*istiosecurity.Rule_To{{Operation: &notProbePath}, {Operation: &notGet}},
+		When: when,
+	}, {
+		From: from,
+		To:   []*istiosecurity.Rule_To{{Operation: &op}},
+		When: append(when, &istiosecurity.Condition{
+			Key:       "request.headers[" + network.ProbeHeaderName + "]",
+			NotValues: []string{network.ProbeHeaderValue},
+		}),
+	}, {
+		From: from,
+		To:   []*istiosecurity.Rule_To{{Operation: &op}},
+		When: append(when, &istiosecurity.Condition{
+			Key:       "request.headers[" + network.HashHeaderName + "]",
+			NotValues: []string{network.HashHeaderValue},
+		}),
+	}}
+}
+
+func jwtAuthorizationPolicy(ing *v1alpha1.Ingress, gatewayService *corev1.Service) *securityv1beta1.AuthorizationPolicy {
+	op := istiosecurity.Operation{Hosts: policyHosts}
+	return &securityv1beta1.AuthorizationPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testNS + "--" + ing.Name + "-" + gatewayService.Name + "-jwt",
+			Namespace: gatewayService.Namespace,
+			Labels:    resources.MakeGatewayPolicyLabels(ing),
+		},
+		Spec: istiosecurity.AuthorizationPolicy{
+			Selector: &istiotype.WorkloadSelector{MatchLabels: gatewayService.Spec.Selector},
+			Action:   istiosecurity.AuthorizationPolicy_DENY,
+			Rules: append(notProbeRules(op, []*istiosecurity.Rule_From{{
+				Source: &istiosecurity.Source{
+					NotRequestPrincipals: []string{"https://issuer.example.com/*"},
+				},
+			}}), notProbeRules(op, nil, &istiosecurity.Condition{
+				Key:       "request.auth.claims[group]",
+				NotValues: []string{"admin"},
+			})...),
+		},
+	}
+}
+
+func extAuthzAuthorizationPolicy(ing *v1alpha1.Ingress, gatewayService *corev1.Service, provider string) *securityv1beta1.AuthorizationPolicy {
+	return &securityv1beta1.AuthorizationPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testNS + "--" + ing.Name + "-" + gatewayService.Name + "-ext-authz",
+			Namespace: gatewayService.Namespace,
+			Labels:    resources.MakeGatewayPolicyLabels(ing),
+		},
+		Spec: istiosecurity.AuthorizationPolicy{
+			Selector: &istiotype.WorkloadSelector{MatchLabels: gatewayService.Spec.Selector},
+			Action:   istiosecurity.AuthorizationPolicy_CUSTOM,
+			ActionDetail: &istiosecurity.AuthorizationPolicy_Provider{
+				Provider: &istiosecurity.AuthorizationPolicy_ExtensionProvider{Name: provider},
+			},
+			Rules: notProbeRules(istiosecurity.Operation{Hosts: policyHosts}, nil),
+		},
+	}
+}
+
+func destinationRule(ing *v1alpha1.Ingress, service string) *v1beta1.DestinationRule {
+	return &v1beta1.DestinationRule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      service + "-traffic-policy",
+			Namespace: ing.Namespace,
+			Labels: map[string]string{
+				networking.IngressLabelKey: ing.Name,
+			},
+			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(ing)},
+		},
+		Spec: istiov1beta1.DestinationRule{
+			Host: pkgnet.GetServiceHostname(service, ing.Namespace),
+			TrafficPolicy: &istiov1beta1.TrafficPolicy{
+				LoadBalancer: &istiov1beta1.LoadBalancerSettings{
+					LbPolicy: &istiov1beta1.LoadBalancerSettings_Simple{
+						Simple: istiov1beta1.LoadBalancerSettings_SimpleLB(
+							istiov1beta1.LoadBalancerSettings_SimpleLB_value[ing.Annotations[resources.LoadBalancerAnnotationKey]]),
+					},
+				},
+			},
+		},
+	}
+}
+
+func ingressWithClientCASecret(ing *v1alpha1.Ingress, name string) *v1alpha1.Ingress {
+	return addAnnotations(ing, map[string]string{resources.ClientCASecretAnnotationKey: name})
+}
+
+func realm(name, external, internal string) *v1alpha1.Realm {
+	return &v1alpha1.Realm{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: v1alpha1.RealmSpec{
+			External: external,
+			Internal: internal,
+		},
+	}
+}
+
+func domain(name, suffix string, gatewayService *corev1.Service) *v1alpha1.Domain {
+	return &v1alpha1.Domain{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: v1alpha1.DomainSpec{
+			IngressClass: network.IstioIngressClassName,
+			Suffix:       suffix,
+			Configs: []v1alpha1.IngressConfig{{
+				Type:      domainresources.ServiceConfigType,
+				Name:      gatewayService.Name,
+				Namespace: gatewayService.Namespace,
+			}},
+		},
+	}
+}
+
 type testConfigStore struct {
 	config *config.Config
 }
@@ -1449,6 +2500,7 @@ func ReconcilerTestConfig() *config.Config {
 				ServiceURL: pkgnet.GetServiceHostname("istio-ingressgateway", "istio-system"),
 			}},
 			EnableVirtualServiceStatus: true,
+			ExtAuthzProviders:          []string{"ext-authz"},
 		},
 		Network: &network.Config{
 			AutoTLS: false,
@@ -1539,14 +2591,24 @@ func ingressWithTLSAndStatus(name string, tls []v1alpha1.IngressTLS, status v1al
 	return ci
 }
 
+func ingressWithHTTPOption(name string, httpOption v1alpha1.HTTPOption) *v1alpha1.Ingress {
+	return ingressWithHTTPOptionAndStatus(name, httpOption, v1alpha1.IngressStatus{})
+}
+
+func ingressWithHTTPOptionAndStatus(name string, httpOption v1alpha1.HTTPOption, status v1alpha1.IngressStatus) *v1alpha1.Ingress {
+	ci := ingressWithStatus(name, status)
+	ci.Spec.HTTPOption = httpOption
+	return ci
+}
+
 func ingressWithTLSAndStatusClusterLocal(name string, tls []v1alpha1.IngressTLS, status v1alpha1.IngressStatus) *v1alpha1.Ingress {
 	ci := ingressWithTLSClusterLocal(name, tls)
 	ci.Status = status
 	return ci
 }
 
-func meshVirtualServiceWithStatus(ctx context.Context, ing *v1alpha1.Ingress, gateways map[v1alpha1.IngressVisibility]sets.String, status istiov1alpha1.IstioStatus, generation int64, observedGeneration int64) *v1alpha3.VirtualService {
-	vs := resources.MakeMeshVirtualService(ctx, ing, gateways)
+func meshVirtualServiceWithStatus(ctx context.Context, ing *v1alpha1.Ingress, gateways map[v1alpha1.IngressVisibility]sets.String, status istiov1alpha1.IstioStatus, generation int64, observedGeneration int64) *v1beta1.VirtualService {
+	vs := meshVirtualService(ctx, ing, gateways)
 	vs.Status = status
 	vs.ObjectMeta.Generation = generation
 	vs.Status.ObservedGeneration = observedGeneration
@@ -1554,8 +2616,8 @@ func meshVirtualServiceWithStatus(ctx context.Context, ing *v1alpha1.Ingress, ga
 	return vs
 }
 
-func ingressVirtualServiceWithStatus(ctx context.Context, ing *v1alpha1.Ingress, gateways map[v1alpha1.IngressVisibility]sets.String, status istiov1alpha1.IstioStatus, generation int64, observedGeneration int64) *v1alpha3.VirtualService {
-	vs := resources.MakeIngressVirtualService(ctx, ing, gateways)
+func ingressVirtualServiceWithStatus(ctx context.Context, ing *v1alpha1.Ingress, gateways map[v1alpha1.IngressVisibility]sets.String, status istiov1alpha1.IstioStatus, generation int64, observedGeneration int64) *v1beta1.VirtualService {
+	vs := ingressVirtualService(ctx, ing, gateways)
 	vs.Status = status
 	vs.ObjectMeta.Generation = generation
 	vs.Status.ObservedGeneration = observedGeneration
@@ -1720,10 +2782,10 @@ func TestGlobalResyncOnUpdateNetwork(t *testing.T) {
 
 	// Check for Gateway created as a signal that syncHandler ran
 	h.OnUpdate(&istioClient.Fake, "gateways", func(obj runtime.Object) HookResult {
-		createdGateway := obj.(*v1alpha3.Gateway)
+		createdGateway := obj.(*v1beta1.Gateway)
 		// The expected gateway should include the Istio TLS server.
 		expectedGateway := gateway(perIngressGatewayName, testNS,
-			[]*istiov1alpha3.Server{ingressTLSServer}, withOwnerRef(ingressWithTLS("reconciling-ingress", ingressTLS)),
+			[]*istiov1beta1.Server{ingressTLSServer}, withOwnerRef(ingressWithTLS("reconciling-ingress", ingressTLS)),
 			withLabels(gwLabels), withSelector(selector))
 		if diff := cmp.Diff(createdGateway, expectedGateway); diff != "" {
 			t.Log("Unexpected Gateway (-want, +got):", diff)
@@ -1778,16 +2840,16 @@ func TestGlobalResyncOnUpdateNetwork(t *testing.T) {
 	ingressClient := fakenetworkingclient.Get(ctx).NetworkingV1alpha1().Ingresses(testNS)
 	ingressClient.Create(ctx, ingress, metav1.CreateOptions{})
 
-	gatewayClient := istioClient.NetworkingV1alpha3().Gateways(system.Namespace())
+	gatewayClient := istioClient.NetworkingV1beta1().Gateways(system.Namespace())
 	// Create a Gateway
-	if _, err := gatewayClient.Create(ctx, gateway("knative-test-gateway", system.Namespace(), []*istiov1alpha3.Server{}), metav1.CreateOptions{}); err != nil {
+	if _, err := gatewayClient.Create(ctx, gateway("knative-test-gateway", system.Namespace(), []*istiov1beta1.Server{}), metav1.CreateOptions{}); err != nil {
 		t.Fatal("Error creating gateway:", err)
 	}
 
 	// Create an Ingress gateway
-	ingressGatewayClient := istioClient.NetworkingV1alpha3().Gateways(testNS)
+	ingressGatewayClient := istioClient.NetworkingV1beta1().Gateways(testNS)
 	ingressGateway := gateway(perIngressGatewayName, testNS,
-		[]*istiov1alpha3.Server{}, withOwnerRef(ingressWithTLS("reconciling-ingress", ingressTLS)),
+		[]*istiov1beta1.Server{}, withOwnerRef(ingressWithTLS("reconciling-ingress", ingressTLS)),
 		withLabels(gwLabels), withSelector(selector))
 	if _, err := ingressGatewayClient.Create(ctx, ingressGateway, metav1.CreateOptions{}); err != nil {
 		t.Fatal("Error creating gateway:", err)
@@ -1840,3 +2902,19 @@ func proberCalledTimes(n int) func(*testing.T, *TableRow) {
 		}
 	}
 }
+
+func meshVirtualService(ctx context.Context, ing *v1alpha1.Ingress, gateways map[v1alpha1.IngressVisibility]sets.String) *v1beta1.VirtualService {
+	vs, err := resources.MakeMeshVirtualService(ctx, ing, gateways)
+	if err != nil {
+		panic(err)
+	}
+	return vs
+}
+
+func ingressVirtualService(ctx context.Context, ing *v1alpha1.Ingress, gateways map[v1alpha1.IngressVisibility]sets.String) *v1beta1.VirtualService {
+	vs, err := resources.MakeIngressVirtualService(ctx, ing, gateways)
+	if err != nil {
+		panic(err)
+	}
+	return vs
+}
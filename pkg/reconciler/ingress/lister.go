@@ -24,29 +24,53 @@ import (
 	"strconv"
 
 	"go.uber.org/zap"
-	istiov1alpha3 "istio.io/api/networking/v1alpha3"
-	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	"istio.io/client-go/pkg/apis/networking/v1beta1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/sets"
 	corev1listers "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
-	istiolisters "knative.dev/net-istio/pkg/client/istio/listers/networking/v1alpha3"
+	istiolisters "knative.dev/net-istio/pkg/client/istio/listers/networking/v1beta1"
+	"knative.dev/net-istio/pkg/reconciler/ingress/resources"
 	network "knative.dev/networking/pkg"
 	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	networkinglisters "knative.dev/networking/pkg/client/listers/networking/v1alpha1"
 	"knative.dev/networking/pkg/ingress"
 	"knative.dev/networking/pkg/status"
 )
 
+const (
+	// tcpScheme is the scheme of the URLs of the TCP probe targets.
+	tcpScheme = "tcp"
+	// mutualTLSScheme is the scheme of the URLs of the probe targets of the
+	// HTTPS servers in MUTUAL TLS mode.
+	mutualTLSScheme = "mtls"
+)
+
+// ProbeTargetLister lists the targets of the Ingresses probed over HTTP as well
+// as their TCP and MUTUAL TLS targets, which are probed by connecting to them
+// since the prober has no client certificate.
+type ProbeTargetLister interface {
+	status.ProbeTargetLister
+
+	// ListTCPProbeTargets returns the TCP and MUTUAL TLS targets to be probed.
+	ListTCPProbeTargets(ctx context.Context, ing *v1alpha1.Ingress) ([]status.ProbeTarget, error)
+}
+
 func NewProbeTargetLister(
 	logger *zap.SugaredLogger,
 	gatewayLister istiolisters.GatewayLister,
 	endpointsLister corev1listers.EndpointsLister,
-	serviceLister corev1listers.ServiceLister) status.ProbeTargetLister {
+	serviceLister corev1listers.ServiceLister,
+	realmLister networkinglisters.RealmLister,
+	domainLister networkinglisters.DomainLister) ProbeTargetLister {
 	return &gatewayPodTargetLister{
 		logger:          logger,
 		gatewayLister:   gatewayLister,
 		endpointsLister: endpointsLister,
 		serviceLister:   serviceLister,
+		realmLister:     realmLister,
+		domainLister:    domainLister,
 	}
 }
 
@@ -56,11 +80,27 @@ type gatewayPodTargetLister struct {
 	gatewayLister   istiolisters.GatewayLister
 	endpointsLister corev1listers.EndpointsLister
 	serviceLister   corev1listers.ServiceLister
+	realmLister     networkinglisters.RealmLister
+	domainLister    networkinglisters.DomainLister
 }
 
 func (l *gatewayPodTargetLister) ListProbeTargets(ctx context.Context, ing *v1alpha1.Ingress) ([]status.ProbeTarget, error) {
+	return l.listProbeTargets(ctx, ing, false)
+}
+
+func (l *gatewayPodTargetLister) ListTCPProbeTargets(ctx context.Context, ing *v1alpha1.Ingress) ([]status.ProbeTarget, error) {
+	return l.listProbeTargets(ctx, ing, true)
+}
+
+// listProbeTargets returns either the TCP and MUTUAL TLS targets of the given
+// Ingress or its other targets.
+func (l *gatewayPodTargetLister) listProbeTargets(ctx context.Context, ing *v1alpha1.Ingress, tcp bool) ([]status.ProbeTarget, error) {
 	results := []status.ProbeTarget{}
-	hostsByGateway := ingress.HostsPerVisibility(ing, qualifiedGatewayNamesFromContext(ctx))
+	gws, err := resolveGateways(ctx, ing, l.realmLister, l.domainLister, l.serviceLister)
+	if err != nil {
+		return nil, err
+	}
+	hostsByGateway := ingress.HostsPerVisibility(ing, gws.names)
 	gatewayNames := make([]string, 0, len(hostsByGateway))
 	for gatewayName := range hostsByGateway {
 		gatewayNames = append(gatewayNames, gatewayName)
@@ -73,7 +113,7 @@ func (l *gatewayPodTargetLister) ListProbeTargets(ctx context.Context, ing *v1al
 		if err != nil {
 			return nil, fmt.Errorf("failed to get Gateway %q: %w", gatewayName, err)
 		}
-		targets, err := l.listGatewayTargets(gateway)
+		targets, err := l.listGatewayTargets(gateway, ing)
 		if err != nil {
 			return nil, fmt.Errorf("failed to list the probing URLs of Gateway %q: %w", gatewayName, err)
 		}
@@ -81,6 +121,10 @@ func (l *gatewayPodTargetLister) ListProbeTargets(ctx context.Context, ing *v1al
 			continue
 		}
 		for _, target := range targets {
+			scheme := target.URLs[0].Scheme
+			if (scheme == tcpScheme || scheme == mutualTLSScheme) != tcp {
+				continue
+			}
 			qualifiedTarget := status.ProbeTarget{
 				PodIPs:  target.PodIPs,
 				PodPort: target.PodPort,
@@ -99,7 +143,7 @@ func (l *gatewayPodTargetLister) ListProbeTargets(ctx context.Context, ing *v1al
 	return results, nil
 }
 
-func (l *gatewayPodTargetLister) getGateway(name string) (*v1alpha3.Gateway, error) {
+func (l *gatewayPodTargetLister) getGateway(name string) (*v1beta1.Gateway, error) {
 	namespace, name, err := cache.SplitMetaNamespaceKey(name)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse Gateway name %q: %w", name, err)
@@ -110,8 +154,10 @@ func (l *gatewayPodTargetLister) getGateway(name string) (*v1alpha3.Gateway, err
 	return l.gatewayLister.Gateways(namespace).Get(name)
 }
 
-// listGatewayPodsURLs returns a probe targets for a given Gateway.
-func (l *gatewayPodTargetLister) listGatewayTargets(gateway *v1alpha3.Gateway) ([]status.ProbeTarget, error) {
+// listGatewayPodsURLs returns a probe targets for a given Gateway. The TCP
+// servers of other Ingresses are left out since they don't route to the given
+// Ingress.
+func (l *gatewayPodTargetLister) listGatewayTargets(gateway *v1beta1.Gateway, ing *v1alpha1.Ingress) ([]status.ProbeTarget, error) {
 	selector := labels.SelectorFromSet(gateway.Spec.Selector)
 
 	services, err := l.serviceLister.List(selector)
@@ -129,7 +175,16 @@ func (l *gatewayPodTargetLister) listGatewayTargets(gateway *v1alpha3.Gateway) (
 		return nil, fmt.Errorf("failed to get Endpoints: %w", err)
 	}
 
+	owned := sets.NewString()
 	seen := sets.NewString()
+	for _, server := range resources.GetServers(gateway, ing) {
+		owned.Insert(server.Port.Name)
+		if server.Tls != nil && server.Tls.HttpsRedirect {
+			// The hosts of the Ingress are redirected on this port whatever
+			// the server the probes would go through.
+			seen.Insert(server.Port.Protocol + "/" + strconv.Itoa(int(server.Port.Number)))
+		}
+	}
 	targets := []status.ProbeTarget{}
 	for _, server := range gateway.Spec.Servers {
 		tURL := &url.URL{}
@@ -141,11 +196,15 @@ func (l *gatewayPodTargetLister) listGatewayTargets(gateway *v1alpha3.Gateway) (
 			}
 			tURL.Scheme = "http"
 		case "HTTPS":
-			if server.GetTls().GetMode() == istiov1alpha3.ServerTLSSettings_MUTUAL {
-				l.logger.Infof("Skipping Server %q because HTTPS with TLS mode MUTUAL is not supported", server.Port.Name)
+			tURL.Scheme = "https"
+			if server.GetTls().GetMode() == istiov1beta1.ServerTLSSettings_MUTUAL {
+				tURL.Scheme = mutualTLSScheme
+			}
+		case "TCP":
+			if !owned.Has(server.Port.Name) {
 				continue
 			}
-			tURL.Scheme = "https"
+			tURL.Scheme = tcpScheme
 		default:
 			l.logger.Infof("Skipping Server %q because protocol %q is not supported", server.Port.Name, server.Port.Protocol)
 			continue
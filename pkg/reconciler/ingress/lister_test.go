@@ -26,8 +26,8 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
-	istiov1alpha3 "istio.io/api/networking/v1alpha3"
-	v1alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	v1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
 	"knative.dev/net-istio/pkg/reconciler/ingress/config"
 	"knative.dev/networking/pkg/apis/networking/v1alpha1"
 	"knative.dev/networking/pkg/status"
@@ -38,7 +38,7 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/sets"
 	corev1listers "k8s.io/client-go/listers/core/v1"
-	istiolisters "knative.dev/net-istio/pkg/client/istio/listers/networking/v1alpha3"
+	istiolisters "knative.dev/net-istio/pkg/client/istio/listers/networking/v1beta1"
 )
 
 func TestListProbeTargets(t *testing.T) {
@@ -101,15 +101,15 @@ func TestListProbeTargets(t *testing.T) {
 			Namespace: "default",
 		}},
 		gatewayLister: &fakeGatewayLister{
-			gateways: []*v1alpha3.Gateway{{
+			gateways: []*v1beta1.Gateway{{
 				ObjectMeta: metav1.ObjectMeta{
 					Namespace: "default",
 					Name:      "gateway",
 				},
-				Spec: istiov1alpha3.Gateway{
-					Servers: []*istiov1alpha3.Server{{
+				Spec: istiov1beta1.Gateway{
+					Servers: []*istiov1beta1.Server{{
 						Hosts: []string{"*"},
-						Port: &istiov1alpha3.Port{
+						Port: &istiov1beta1.Port{
 							Number:   80,
 							Protocol: "HTTP",
 						},
@@ -143,15 +143,15 @@ func TestListProbeTargets(t *testing.T) {
 			Namespace: "default",
 		}},
 		gatewayLister: &fakeGatewayLister{
-			gateways: []*v1alpha3.Gateway{{
+			gateways: []*v1beta1.Gateway{{
 				ObjectMeta: metav1.ObjectMeta{
 					Namespace: "default",
 					Name:      "gateway",
 				},
-				Spec: istiov1alpha3.Gateway{
-					Servers: []*istiov1alpha3.Server{{
+				Spec: istiov1beta1.Gateway{
+					Servers: []*istiov1beta1.Server{{
 						Hosts: []string{"*"},
-						Port: &istiov1alpha3.Port{
+						Port: &istiov1beta1.Port{
 							Number:   80,
 							Protocol: "HTTP",
 						},
@@ -196,15 +196,15 @@ func TestListProbeTargets(t *testing.T) {
 			Namespace: "default",
 		}},
 		gatewayLister: &fakeGatewayLister{
-			gateways: []*v1alpha3.Gateway{{
+			gateways: []*v1beta1.Gateway{{
 				ObjectMeta: metav1.ObjectMeta{
 					Namespace: "default",
 					Name:      "gateway",
 				},
-				Spec: istiov1alpha3.Gateway{
-					Servers: []*istiov1alpha3.Server{{
+				Spec: istiov1beta1.Gateway{
+					Servers: []*istiov1beta1.Server{{
 						Hosts: []string{"*"},
-						Port: &istiov1alpha3.Port{
+						Port: &istiov1beta1.Port{
 							Number:   80,
 							Protocol: "HTTP",
 						},
@@ -258,15 +258,15 @@ func TestListProbeTargets(t *testing.T) {
 			Namespace: "default",
 		}},
 		gatewayLister: &fakeGatewayLister{
-			gateways: []*v1alpha3.Gateway{{
+			gateways: []*v1beta1.Gateway{{
 				ObjectMeta: metav1.ObjectMeta{
 					Namespace: "default",
 					Name:      "gateway",
 				},
-				Spec: istiov1alpha3.Gateway{
-					Servers: []*istiov1alpha3.Server{{
+				Spec: istiov1beta1.Gateway{
+					Servers: []*istiov1beta1.Server{{
 						Hosts: []string{"*"},
-						Port: &istiov1alpha3.Port{
+						Port: &istiov1beta1.Port{
 							Number:   80,
 							Protocol: "HTTP",
 						},
@@ -321,15 +321,15 @@ func TestListProbeTargets(t *testing.T) {
 			Namespace: "default",
 		}},
 		gatewayLister: &fakeGatewayLister{
-			gateways: []*v1alpha3.Gateway{{
+			gateways: []*v1beta1.Gateway{{
 				ObjectMeta: metav1.ObjectMeta{
 					Namespace: "default",
 					Name:      "gateway",
 				},
-				Spec: istiov1alpha3.Gateway{
-					Servers: []*istiov1alpha3.Server{{
+				Spec: istiov1beta1.Gateway{
+					Servers: []*istiov1beta1.Server{{
 						Hosts: []string{"*"},
-						Port: &istiov1alpha3.Port{
+						Port: &istiov1beta1.Port{
 							Number:   80,
 							Protocol: "HTTP",
 						},
@@ -363,15 +363,15 @@ func TestListProbeTargets(t *testing.T) {
 			Namespace: "default",
 		}},
 		gatewayLister: &fakeGatewayLister{
-			gateways: []*v1alpha3.Gateway{{
+			gateways: []*v1beta1.Gateway{{
 				ObjectMeta: metav1.ObjectMeta{
 					Namespace: "default",
 					Name:      "gateway",
 				},
-				Spec: istiov1alpha3.Gateway{
-					Servers: []*istiov1alpha3.Server{{
+				Spec: istiov1beta1.Gateway{
+					Servers: []*istiov1beta1.Server{{
 						Hosts: []string{"*"},
-						Port: &istiov1alpha3.Port{
+						Port: &istiov1beta1.Port{
 							Number:   80,
 							Protocol: "Mongo",
 						},
@@ -418,15 +418,15 @@ func TestListProbeTargets(t *testing.T) {
 			Namespace: "default",
 		}},
 		gatewayLister: &fakeGatewayLister{
-			gateways: []*v1alpha3.Gateway{{
+			gateways: []*v1beta1.Gateway{{
 				ObjectMeta: metav1.ObjectMeta{
 					Namespace: "default",
 					Name:      "gateway",
 				},
-				Spec: istiov1alpha3.Gateway{
-					Servers: []*istiov1alpha3.Server{{
+				Spec: istiov1beta1.Gateway{
+					Servers: []*istiov1beta1.Server{{
 						Hosts: []string{"*"},
-						Port: &istiov1alpha3.Port{
+						Port: &istiov1beta1.Port{
 							Name:     "http",
 							Number:   8080,
 							Protocol: "HTTP",
@@ -505,15 +505,15 @@ func TestListProbeTargets(t *testing.T) {
 			Namespace: "default",
 		}},
 		gatewayLister: &fakeGatewayLister{
-			gateways: []*v1alpha3.Gateway{{
+			gateways: []*v1beta1.Gateway{{
 				ObjectMeta: metav1.ObjectMeta{
 					Namespace: "default",
 					Name:      "gateway",
 				},
-				Spec: istiov1alpha3.Gateway{
-					Servers: []*istiov1alpha3.Server{{
+				Spec: istiov1beta1.Gateway{
+					Servers: []*istiov1beta1.Server{{
 						Hosts: []string{"*"},
-						Port: &istiov1alpha3.Port{
+						Port: &istiov1beta1.Port{
 							Name:     "https",
 							Number:   8443,
 							Protocol: "HTTPS",
@@ -592,21 +592,21 @@ func TestListProbeTargets(t *testing.T) {
 			Namespace: "default",
 		}},
 		gatewayLister: &fakeGatewayLister{
-			gateways: []*v1alpha3.Gateway{{
+			gateways: []*v1beta1.Gateway{{
 				ObjectMeta: metav1.ObjectMeta{
 					Namespace: "default",
 					Name:      "gateway",
 				},
-				Spec: istiov1alpha3.Gateway{
-					Servers: []*istiov1alpha3.Server{{
+				Spec: istiov1beta1.Gateway{
+					Servers: []*istiov1beta1.Server{{
 						Hosts: []string{"*"},
-						Port: &istiov1alpha3.Port{
+						Port: &istiov1beta1.Port{
 							Name:     "https",
 							Number:   8443,
 							Protocol: "HTTPS",
 						},
-						Tls: &istiov1alpha3.ServerTLSSettings{
-							Mode: istiov1alpha3.ServerTLSSettings_MUTUAL,
+						Tls: &istiov1beta1.ServerTLSSettings{
+							Mode: istiov1beta1.ServerTLSSettings_MUTUAL,
 						},
 					}},
 					Selector: map[string]string{
@@ -670,7 +670,7 @@ func TestListProbeTargets(t *testing.T) {
 			},
 		},
 		results: []status.ProbeTarget{
-			// No results, no client cert available.
+			// The MUTUAL servers are probed by connecting to them.
 		},
 	}, {
 		name: "Different port between endpoint and gateway service",
@@ -679,22 +679,22 @@ func TestListProbeTargets(t *testing.T) {
 			Namespace: "default",
 		}},
 		gatewayLister: &fakeGatewayLister{
-			gateways: []*v1alpha3.Gateway{{
+			gateways: []*v1beta1.Gateway{{
 				ObjectMeta: metav1.ObjectMeta{
 					Namespace: "default",
 					Name:      "gateway",
 				},
-				Spec: istiov1alpha3.Gateway{
-					Servers: []*istiov1alpha3.Server{{
+				Spec: istiov1beta1.Gateway{
+					Servers: []*istiov1beta1.Server{{
 						Hosts: []string{"*"},
-						Port: &istiov1alpha3.Port{
+						Port: &istiov1beta1.Port{
 							Name:     "http",
 							Number:   80,
 							Protocol: "HTTP",
 						},
 					}, {
 						Hosts: []string{"*"},
-						Port: &istiov1alpha3.Port{
+						Port: &istiov1beta1.Port{
 							Name:     "https",
 							Number:   443,
 							Protocol: "HTTPS",
@@ -773,25 +773,126 @@ func TestListProbeTargets(t *testing.T) {
 			Namespace: "default",
 		}},
 		gatewayLister: &fakeGatewayLister{
-			gateways: []*v1alpha3.Gateway{{
+			gateways: []*v1beta1.Gateway{{
 				ObjectMeta: metav1.ObjectMeta{
 					Namespace: "default",
 					Name:      "gateway",
 				},
-				Spec: istiov1alpha3.Gateway{
-					Servers: []*istiov1alpha3.Server{{
+				Spec: istiov1beta1.Gateway{
+					Servers: []*istiov1beta1.Server{{
 						Hosts: []string{"*"},
-						Port: &istiov1alpha3.Port{
+						Port: &istiov1beta1.Port{
 							Name:     "http",
 							Number:   80,
 							Protocol: "HTTP",
 						},
-						Tls: &istiov1alpha3.ServerTLSSettings{
+						Tls: &istiov1beta1.ServerTLSSettings{
 							HttpsRedirect: true,
 						},
 					}, {
 						Hosts: []string{"*"},
-						Port: &istiov1alpha3.Port{
+						Port: &istiov1beta1.Port{
+							Name:     "https",
+							Number:   443,
+							Protocol: "HTTPS",
+						},
+					}},
+					Selector: map[string]string{
+						"gwt": "istio",
+					},
+				},
+			}},
+		},
+		endpointsLister: &fakeEndpointsLister{
+			endpointses: []*v1.Endpoints{{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+					Name:      "gateway",
+				},
+				Subsets: []v1.EndpointSubset{{
+					Ports: []v1.EndpointPort{{
+						Name: "bogus",
+						Port: 8080,
+					}, {
+						Name: "real",
+						Port: 80,
+					}},
+					Addresses: []v1.EndpointAddress{{
+						IP: "1.1.1.1",
+					}},
+				}},
+			}},
+		},
+		serviceLister: &fakeServiceLister{
+			services: []*v1.Service{{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+					Name:      "gateway",
+					Labels: map[string]string{
+						"gwt": "istio",
+					},
+				},
+				Spec: v1.ServiceSpec{
+					Ports: []v1.ServicePort{{
+						Name: "bogus",
+						Port: 8080,
+					}, {
+						Name: "real",
+						Port: 80,
+					}},
+				},
+			}},
+		},
+		ingress: &v1alpha1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "whatever",
+			},
+			Spec: v1alpha1.IngressSpec{
+				Rules: []v1alpha1.IngressRule{{
+					Hosts: []string{
+						"foo.bar.com",
+					},
+					Visibility: v1alpha1.IngressVisibilityExternalIP,
+				}},
+			},
+		},
+		results: []status.ProbeTarget{},
+	}, {
+		name: "one gateway, Ingress specific https redirect",
+		ingressGateways: []config.Gateway{{
+			Name:      "gateway",
+			Namespace: "default",
+		}},
+		gatewayLister: &fakeGatewayLister{
+			gateways: []*v1beta1.Gateway{{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+					Name:      "gateway",
+				},
+				Spec: istiov1beta1.Gateway{
+					// The hosts of the Ingress are redirected even though the
+					// default HTTP server would route the probes.
+					Servers: []*istiov1beta1.Server{{
+						Hosts: []string{"foo.bar.com"},
+						Port: &istiov1beta1.Port{
+							Name:     "default/whatever:http",
+							Number:   80,
+							Protocol: "HTTP",
+						},
+						Tls: &istiov1beta1.ServerTLSSettings{
+							HttpsRedirect: true,
+						},
+					}, {
+						Hosts: []string{"*"},
+						Port: &istiov1beta1.Port{
+							Name:     "http",
+							Number:   80,
+							Protocol: "HTTP",
+						},
+					}, {
+						Hosts: []string{"*"},
+						Port: &istiov1beta1.Port{
 							Name:     "https",
 							Number:   443,
 							Protocol: "HTTPS",
@@ -865,15 +966,15 @@ func TestListProbeTargets(t *testing.T) {
 			Namespace: "default",
 		}},
 		gatewayLister: &fakeGatewayLister{
-			gateways: []*v1alpha3.Gateway{{
+			gateways: []*v1beta1.Gateway{{
 				ObjectMeta: metav1.ObjectMeta{
 					Namespace: "default",
 					Name:      "gateway",
 				},
-				Spec: istiov1alpha3.Gateway{
-					Servers: []*istiov1alpha3.Server{{
+				Spec: istiov1beta1.Gateway{
+					Servers: []*istiov1beta1.Server{{
 						Hosts: []string{"*"},
-						Port: &istiov1alpha3.Port{
+						Port: &istiov1beta1.Port{
 							Name:     "http",
 							Number:   80,
 							Protocol: "GRPC",
@@ -947,15 +1048,15 @@ func TestListProbeTargets(t *testing.T) {
 			Namespace: "default",
 		}},
 		gatewayLister: &fakeGatewayLister{
-			gateways: []*v1alpha3.Gateway{{
+			gateways: []*v1beta1.Gateway{{
 				ObjectMeta: metav1.ObjectMeta{
 					Namespace: "default",
 					Name:      "gateway",
 				},
-				Spec: istiov1alpha3.Gateway{
-					Servers: []*istiov1alpha3.Server{{
+				Spec: istiov1beta1.Gateway{
+					Servers: []*istiov1beta1.Server{{
 						Hosts: []string{"*"},
-						Port: &istiov1alpha3.Port{
+						Port: &istiov1beta1.Port{
 							Name:     "http",
 							Number:   80,
 							Protocol: "HTTP",
@@ -1029,15 +1130,15 @@ func TestListProbeTargets(t *testing.T) {
 			Namespace: "default",
 		}},
 		gatewayLister: &fakeGatewayLister{
-			gateways: []*v1alpha3.Gateway{{
+			gateways: []*v1beta1.Gateway{{
 				ObjectMeta: metav1.ObjectMeta{
 					Namespace: "default",
 					Name:      "gateway",
 				},
-				Spec: istiov1alpha3.Gateway{
-					Servers: []*istiov1alpha3.Server{{
+				Spec: istiov1beta1.Gateway{
+					Servers: []*istiov1beta1.Server{{
 						Hosts: []string{"*"},
-						Port: &istiov1alpha3.Port{
+						Port: &istiov1beta1.Port{
 							Name:     "http",
 							Number:   80,
 							Protocol: "HTTP",
@@ -1052,10 +1153,10 @@ func TestListProbeTargets(t *testing.T) {
 					Namespace: "default",
 					Name:      "gateway-two",
 				},
-				Spec: istiov1alpha3.Gateway{
-					Servers: []*istiov1alpha3.Server{{
+				Spec: istiov1beta1.Gateway{
+					Servers: []*istiov1beta1.Server{{
 						Hosts: []string{"*"},
-						Port: &istiov1alpha3.Port{
+						Port: &istiov1beta1.Port{
 							Name:     "http",
 							Number:   90,
 							Protocol: "HTTP",
@@ -1175,15 +1276,15 @@ func TestListProbeTargets(t *testing.T) {
 			Namespace: "default",
 		}},
 		gatewayLister: &fakeGatewayLister{
-			gateways: []*v1alpha3.Gateway{{
+			gateways: []*v1beta1.Gateway{{
 				ObjectMeta: metav1.ObjectMeta{
 					Namespace: "default",
 					Name:      "ingress-gateway",
 				},
-				Spec: istiov1alpha3.Gateway{
-					Servers: []*istiov1alpha3.Server{{
+				Spec: istiov1beta1.Gateway{
+					Servers: []*istiov1beta1.Server{{
 						Hosts: []string{"*"},
-						Port: &istiov1alpha3.Port{
+						Port: &istiov1beta1.Port{
 							Name:     "http",
 							Number:   80,
 							Protocol: "HTTP",
@@ -1258,22 +1359,22 @@ func TestListProbeTargets(t *testing.T) {
 			Namespace: "default",
 		}},
 		gatewayLister: &fakeGatewayLister{
-			gateways: []*v1alpha3.Gateway{{
+			gateways: []*v1beta1.Gateway{{
 				ObjectMeta: metav1.ObjectMeta{
 					Namespace: "default",
 					Name:      "gateway",
 				},
-				Spec: istiov1alpha3.Gateway{
-					Servers: []*istiov1alpha3.Server{{
+				Spec: istiov1beta1.Gateway{
+					Servers: []*istiov1beta1.Server{{
 						Hosts: []string{"*"},
-						Port: &istiov1alpha3.Port{
+						Port: &istiov1beta1.Port{
 							Name:     "http",
 							Number:   80,
 							Protocol: "HTTP",
 						},
 					}, {
 						Hosts: []string{"*"},
-						Port: &istiov1alpha3.Port{
+						Port: &istiov1beta1.Port{
 							Name:     "https",
 							Number:   443,
 							Protocol: "HTTPS",
@@ -1288,10 +1389,10 @@ func TestListProbeTargets(t *testing.T) {
 					Namespace: "default",
 					Name:      "local-gateway",
 				},
-				Spec: istiov1alpha3.Gateway{
-					Servers: []*istiov1alpha3.Server{{
+				Spec: istiov1beta1.Gateway{
+					Servers: []*istiov1beta1.Server{{
 						Hosts: []string{"*"},
-						Port: &istiov1alpha3.Port{
+						Port: &istiov1beta1.Port{
 							Name:     "http",
 							Number:   80,
 							Protocol: "HTTP",
@@ -1407,22 +1508,22 @@ func TestListProbeTargets(t *testing.T) {
 			Namespace: "default",
 		}},
 		gatewayLister: &fakeGatewayLister{
-			gateways: []*v1alpha3.Gateway{{
+			gateways: []*v1beta1.Gateway{{
 				ObjectMeta: metav1.ObjectMeta{
 					Namespace: "default",
 					Name:      "gateway",
 				},
-				Spec: istiov1alpha3.Gateway{
-					Servers: []*istiov1alpha3.Server{{
+				Spec: istiov1beta1.Gateway{
+					Servers: []*istiov1beta1.Server{{
 						Hosts: []string{"*"},
-						Port: &istiov1alpha3.Port{
+						Port: &istiov1beta1.Port{
 							Name:     "http",
 							Number:   80,
 							Protocol: "HTTP",
 						},
 					}, {
 						Hosts: []string{"foo.bar.com"},
-						Port: &istiov1alpha3.Port{
+						Port: &istiov1beta1.Port{
 							Name:     "http",
 							Number:   80,
 							Protocol: "HTTP",
@@ -1528,8 +1629,166 @@ func TestListProbeTargets(t *testing.T) {
 	}
 }
 
+func TestListTCPProbeTargets(t *testing.T) {
+	lister := gatewayPodTargetLister{
+		logger: zaptest.NewLogger(t).Sugar(),
+		gatewayLister: &fakeGatewayLister{
+			gateways: []*v1beta1.Gateway{{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+					Name:      "gateway",
+				},
+				Spec: istiov1beta1.Gateway{
+					Servers: []*istiov1beta1.Server{{
+						Hosts: []string{"*"},
+						Port: &istiov1beta1.Port{
+							Name:     "http",
+							Number:   80,
+							Protocol: "HTTP",
+						},
+					}, {
+						Hosts: []string{"foo.bar.com"},
+						Port: &istiov1beta1.Port{
+							Name:     "default/whatever:tcp-9000",
+							Number:   9000,
+							Protocol: "TCP",
+						},
+					}, {
+						Hosts: []string{"other.bar.com"},
+						Port: &istiov1beta1.Port{
+							Name:     "default/other:tcp-9001",
+							Number:   9001,
+							Protocol: "TCP",
+						},
+					}, {
+						Hosts: []string{"foo.bar.com"},
+						Port: &istiov1beta1.Port{
+							Name:     "https",
+							Number:   443,
+							Protocol: "HTTPS",
+						},
+						Tls: &istiov1beta1.ServerTLSSettings{
+							Mode: istiov1beta1.ServerTLSSettings_MUTUAL,
+						},
+					}},
+					Selector: map[string]string{
+						"gwt": "istio",
+					},
+				},
+			}},
+		},
+		endpointsLister: &fakeEndpointsLister{
+			endpointses: []*v1.Endpoints{{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+					Name:      "gateway",
+				},
+				Subsets: []v1.EndpointSubset{{
+					Ports: []v1.EndpointPort{{
+						Name: "http",
+						Port: 8080,
+					}, {
+						Name: "tcp-9000",
+						Port: 9000,
+					}, {
+						Name: "tcp-9001",
+						Port: 9001,
+					}, {
+						Name: "https",
+						Port: 8443,
+					}},
+					Addresses: []v1.EndpointAddress{{
+						IP: "1.1.1.1",
+					}},
+				}},
+			}},
+		},
+		serviceLister: &fakeServiceLister{
+			services: []*v1.Service{{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+					Name:      "gateway",
+					Labels: map[string]string{
+						"gwt": "istio",
+					},
+				},
+				Spec: v1.ServiceSpec{
+					Ports: []v1.ServicePort{{
+						Name: "http",
+						Port: 80,
+					}, {
+						Name: "tcp-9000",
+						Port: 9000,
+					}, {
+						Name: "tcp-9001",
+						Port: 9001,
+					}, {
+						Name: "https",
+						Port: 443,
+					}},
+				},
+			}},
+		},
+	}
+	ing := &v1alpha1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "whatever",
+		},
+		Spec: v1alpha1.IngressSpec{
+			Rules: []v1alpha1.IngressRule{{
+				Hosts: []string{
+					"foo.bar.com",
+				},
+				Visibility: v1alpha1.IngressVisibilityExternalIP,
+			}},
+		},
+	}
+	ctx := config.ToContext(context.Background(), &config.Config{
+		Istio: &config.Istio{
+			IngressGateways: []config.Gateway{{
+				Name:      "gateway",
+				Namespace: "default",
+			}},
+		},
+	})
+
+	got, err := lister.ListTCPProbeTargets(ctx, ing)
+	if err != nil {
+		t.Fatal("ListTCPProbeTargets() =", err)
+	}
+	want := []status.ProbeTarget{{
+		PodIPs:  sets.NewString("1.1.1.1"),
+		PodPort: "9000",
+		Port:    "9000",
+		URLs:    []*url.URL{{Scheme: "tcp", Host: "foo.bar.com:9000"}},
+	}, {
+		PodIPs:  sets.NewString("1.1.1.1"),
+		PodPort: "8443",
+		Port:    "443",
+		URLs:    []*url.URL{{Scheme: "mtls", Host: "foo.bar.com:443"}},
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error("Unexpected TCP probe targets (-want +got):", diff)
+	}
+
+	got, err = lister.ListProbeTargets(ctx, ing)
+	if err != nil {
+		t.Fatal("ListProbeTargets() =", err)
+	}
+	want = []status.ProbeTarget{{
+		PodIPs:  sets.NewString("1.1.1.1"),
+		PodPort: "8080",
+		Port:    "80",
+		URLs:    []*url.URL{{Scheme: "http", Host: "foo.bar.com:80"}},
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error("Unexpected probe targets (-want +got):", diff)
+	}
+}
+
 type fakeGatewayLister struct {
-	gateways []*v1alpha3.Gateway
+	gateways []*v1beta1.Gateway
 	fails    bool
 }
 
@@ -1538,7 +1797,7 @@ func (l *fakeGatewayLister) Gateways(namespace string) istiolisters.GatewayNames
 		return &fakeGatewayNamespaceLister{fails: true}
 	}
 
-	var matches []*v1alpha3.Gateway
+	var matches []*v1beta1.Gateway
 	for _, gateway := range l.gateways {
 		if gateway.Namespace == namespace {
 			matches = append(matches, gateway)
@@ -1549,22 +1808,22 @@ func (l *fakeGatewayLister) Gateways(namespace string) istiolisters.GatewayNames
 	}
 }
 
-func (l *fakeGatewayLister) List(selector labels.Selector) ([]*v1alpha3.Gateway, error) {
+func (l *fakeGatewayLister) List(selector labels.Selector) ([]*v1beta1.Gateway, error) {
 	log.Panic("not implemented")
 	return nil, nil
 }
 
 type fakeGatewayNamespaceLister struct {
-	gateways []*v1alpha3.Gateway
+	gateways []*v1beta1.Gateway
 	fails    bool
 }
 
-func (l *fakeGatewayNamespaceLister) List(selector labels.Selector) ([]*v1alpha3.Gateway, error) {
+func (l *fakeGatewayNamespaceLister) List(selector labels.Selector) ([]*v1beta1.Gateway, error) {
 	log.Panic("not implemented")
 	return nil, nil
 }
 
-func (l *fakeGatewayNamespaceLister) Get(name string) (*v1alpha3.Gateway, error) {
+func (l *fakeGatewayNamespaceLister) Get(name string) (*v1beta1.Gateway, error) {
 	if l.fails {
 		return nil, errors.New("failed to get Gateway")
 	}
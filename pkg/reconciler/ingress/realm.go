@@ -0,0 +1,139 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	domainresources "knative.dev/net-istio/pkg/reconciler/domain/resources"
+	"knative.dev/net-istio/pkg/reconciler/ingress/config"
+	"knative.dev/net-istio/pkg/reconciler/ingress/resources"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	networkinglisters "knative.dev/networking/pkg/client/listers/networking/v1alpha1"
+	"knative.dev/pkg/network"
+	"knative.dev/pkg/tracker"
+)
+
+// ingressGateways holds the Gateways an Ingress binds to, the gateways serving
+// them and the URLs of the gateway Services load balancing them, per visibility.
+type ingressGateways struct {
+	names       map[v1alpha1.IngressVisibility]sets.String
+	gateways    map[v1alpha1.IngressVisibility][]config.Gateway
+	serviceURLs map[v1alpha1.IngressVisibility]string
+}
+
+// realmName returns the name of the Realm the given Ingress binds to, if any.
+func realmName(ing *v1alpha1.Ingress) string {
+	return ing.GetAnnotations()[resources.RealmAnnotationKey]
+}
+
+// trackRealm tracks the Realm the given Ingress binds to along with its Domains,
+// whose Gateways the Ingress is programmed on.
+func (r *Reconciler) trackRealm(ing *v1alpha1.Ingress, name string) error {
+	if err := r.tracker.TrackReference(tracker.Reference{
+		APIVersion: v1alpha1.SchemeGroupVersion.String(),
+		Kind:       "Realm",
+		Name:       name,
+	}, ing); err != nil {
+		return fmt.Errorf("failed to track Realm: %w", err)
+	}
+	realm, err := r.realmLister.Get(name)
+	if err != nil {
+		// The Domains are tracked once the Realm is created.
+		return nil
+	}
+	for _, domainName := range []string{realm.Spec.External, realm.Spec.Internal} {
+		if domainName == "" {
+			continue
+		}
+		if err := r.tracker.TrackReference(tracker.Reference{
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+			Kind:       "Domain",
+			Name:       domainName,
+		}, ing); err != nil {
+			return fmt.Errorf("failed to track Domain: %w", err)
+		}
+	}
+	return nil
+}
+
+// gatewaysFromContext returns the Gateways configured in config-istio.
+func gatewaysFromContext(ctx context.Context) *ingressGateways {
+	cfg := config.FromContext(ctx).Istio
+	return &ingressGateways{
+		names: qualifiedGatewayNamesFromContext(ctx),
+		gateways: map[v1alpha1.IngressVisibility][]config.Gateway{
+			v1alpha1.IngressVisibilityExternalIP:   cfg.IngressGateways,
+			v1alpha1.IngressVisibilityClusterLocal: cfg.LocalGateways,
+		},
+		serviceURLs: map[v1alpha1.IngressVisibility]string{
+			v1alpha1.IngressVisibilityExternalIP:   publicGatewayServiceURLFromContext(ctx),
+			v1alpha1.IngressVisibilityClusterLocal: privateGatewayServiceURLFromContext(ctx),
+		},
+	}
+}
+
+// resolveGateways returns the Gateways the given Ingress binds to. These are the
+// Gateways of the Domains of its Realm when it has one, and the Gateways
+// configured in config-istio otherwise.
+func resolveGateways(ctx context.Context, ing *v1alpha1.Ingress, realmLister networkinglisters.RealmLister,
+	domainLister networkinglisters.DomainLister, svcLister corev1listers.ServiceLister) (*ingressGateways, error) {
+	name := realmName(ing)
+	if name == "" {
+		return gatewaysFromContext(ctx), nil
+	}
+	realm, err := realmLister.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Realm %s: %w", name, err)
+	}
+
+	gws := &ingressGateways{
+		names: map[v1alpha1.IngressVisibility]sets.String{
+			v1alpha1.IngressVisibilityExternalIP:   sets.NewString(),
+			v1alpha1.IngressVisibilityClusterLocal: sets.NewString(),
+		},
+		gateways:    map[v1alpha1.IngressVisibility][]config.Gateway{},
+		serviceURLs: map[v1alpha1.IngressVisibility]string{},
+	}
+	for visibility, domainName := range map[v1alpha1.IngressVisibility]string{
+		v1alpha1.IngressVisibilityExternalIP:   realm.Spec.External,
+		v1alpha1.IngressVisibilityClusterLocal: realm.Spec.Internal,
+	} {
+		if domainName == "" {
+			continue
+		}
+		domain, err := domainLister.Get(domainName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get Domain %s: %w", domainName, err)
+		}
+		svc, err := domainresources.GatewayService(ctx, domain, svcLister)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the gateway Service of Domain %s: %w", domainName, err)
+		}
+		gws.names[visibility].Insert(domainresources.QualifiedGatewayName(domain, svc))
+		gws.gateways[visibility] = []config.Gateway{{
+			Namespace:  svc.Namespace,
+			Name:       domainresources.GatewayName(domain),
+			ServiceURL: network.GetServiceHostname(svc.Name, svc.Namespace),
+		}}
+		gws.serviceURLs[visibility] = network.GetServiceHostname(svc.Name, svc.Namespace)
+	}
+	return gws, nil
+}
@@ -0,0 +1,725 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"knative.dev/net-istio/pkg/reconciler/ingress/config"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/pkg/network"
+)
+
+const (
+	// annotationPrefix is the prefix of the Ingress annotations that customize
+	// the Istio resources generated for the Ingress.
+	annotationPrefix = "istio.networking.knative.dev/"
+
+	// RoutePolicyAnnotationKey is the annotation key to opt in or out of the
+	// translation of the timeout and retries of the Ingress paths into the
+	// VirtualService routes. It overrides the `enable-route-policy` setting
+	// of config-istio.
+	RoutePolicyAnnotationKey = annotationPrefix + "route-policy"
+
+	// HeaderMatchTypesAnnotationKey is the annotation key to set the match type
+	// of the headers of the Ingress paths. The value is a comma separated list of
+	// `<header>=<type>` pairs, where type is one of exact, prefix, regex or
+	// present. The header value of the Ingress path is used as the prefix or the
+	// regex, and is ignored for present. Headers not listed are matched exactly.
+	HeaderMatchTypesAnnotationKey = annotationPrefix + "header-match-types"
+
+	// PathMatchTypeAnnotationKey is the annotation key to set the match type of
+	// the paths of an Ingress, one of prefix, exact, segment-prefix or regex.
+	// The paths are matched as prefixes when not set. A segment-prefix path
+	// matches itself and its subpaths only, and a regex path must be at most
+	// 100 bytes long.
+	PathMatchTypeAnnotationKey = annotationPrefix + "path-match-type"
+
+	// QueryRoutesAnnotationKey is the annotation key to route the requests
+	// carrying some query parameters to the splits of a backend Service of the
+	// Ingress paths. The value is a comma separated list of
+	// `<service>?<query>` routes, e.g. `rev-2?canary=true`, where a parameter
+	// without value only needs to be present. The first route whose
+	// parameters all match wins, and the requests matching no route are split
+	// as usual.
+	QueryRoutesAnnotationKey = annotationPrefix + "query-routes"
+
+	// URIPrefixRewritesAnnotationKey is the annotation key to rewrite the
+	// prefix of the request paths matched by the Ingress paths before
+	// forwarding them to the backends. The value is a comma separated list of
+	// `[<host>]<path>=<prefix>` pairs, e.g. `/billing/=/`, where the matched
+	// part of the request path is replaced with the given prefix. A pair
+	// prefixed with a host only applies to the paths of the rule of the host,
+	// and takes precedence over the unprefixed pairs. The Ingress paths not
+	// listed are not rewritten, and neither are the probes. It works with the
+	// exact, prefix and segment-prefix path match types.
+	URIPrefixRewritesAnnotationKey = annotationPrefix + "uri-prefix-rewrites"
+
+	// RedirectsAnnotationKey is the annotation key to redirect the requests
+	// for some hosts and paths of an Ingress. The value is a comma separated
+	// list of `<match> <target> [<code>]` redirects, e.g.
+	// `old.example.com/* new.example.com 301` or `/docs /docs/`. The match is
+	// `[<host>]<path>`, where the host defaults to all the hosts of the Ingress
+	// and the path is matched exactly, or as a prefix when it ends with `*`.
+	// The target is `[<host>][<path>]`, replacing the host, the whole path,
+	// or both. The code is one of 301, 302, 303, 307 or 308 and defaults to
+	// 301.
+	RedirectsAnnotationKey = annotationPrefix + "redirects"
+
+	// DirectResponsesAnnotationKey is the annotation key to answer the
+	// requests for some hosts and paths of an Ingress with a status code,
+	// e.g. 503 during maintenance or 410 for removed paths, instead of
+	// forwarding them to the backends. The value is a comma separated list of
+	// `<match> <status>` direct responses, where the match is the same as for
+	// RedirectsAnnotationKey. The vendored Istio API has no direct response,
+	// so the requests are aborted with the status by the Envoy fault filter:
+	// the responses carry its "fault filter abort" body and are counted in
+	// its fault statistics, and the routes keep the first backend of the
+	// first path of their rule as destination. The redirects take precedence
+	// over the direct responses, and both over the Ingress paths, except for
+	// the probes of the Ingress and its ACME HTTP-01 challenge paths.
+	DirectResponsesAnnotationKey = annotationPrefix + "direct-responses"
+
+	// TLSPassthroughHostsAnnotationKey is the annotation key to serve some
+	// public hosts of an Ingress in TLS passthrough mode, for backends that
+	// terminate TLS themselves. The value is a comma separated list of hosts.
+	// The TLS connections for these hosts are routed by SNI to the splits of
+	// their rule instead of being terminated at the gateways, so the hosts are
+	// left out of the TLS servers of the Ingress.
+	TLSPassthroughHostsAnnotationKey = annotationPrefix + "tls-passthrough-hosts"
+
+	// TCPPortsAnnotationKey is the annotation key to route the TCP connections
+	// to some ports of the gateways to the splits of the rules of an Ingress,
+	// for backends that do not speak HTTP. The value is a comma separated list
+	// of `<host>=<port>` pairs, e.g. `mqtt.example.com=1883`, where the host is
+	// a public host of the rule. The ports must be exposed by the Services of
+	// the gateways, and cannot be shared by the hosts of different rules.
+	TCPPortsAnnotationKey = annotationPrefix + "tcp-ports"
+
+	// RealmAnnotationKey is the annotation key to bind an Ingress to the
+	// Gateways of the Domains of a Realm instead of the Gateways configured in
+	// config-istio. The value is the name of the Realm.
+	RealmAnnotationKey = annotationPrefix + "realm"
+
+	// ClientCASecretAnnotationKey is the annotation key to require client
+	// certificates on the TLS servers of an Ingress. The value is the name of
+	// the Secret in the Ingress namespace holding the CA bundle used to verify
+	// them. It overrides the `client-ca-secret` setting of config-istio, and an
+	// empty value opts the Ingress out of client certificates. Lacking a client
+	// certificate, the prober only checks that the gateways request one for
+	// the hosts of the Ingress.
+	ClientCASecretAnnotationKey = annotationPrefix + "client-ca-secret"
+
+	// MirrorAnnotationKey is the annotation key to mirror the traffic of the
+	// Ingress paths to a shadow backend, whose responses are discarded. The
+	// value is the name of a Service in the Ingress namespace, optionally
+	// followed by `:<port>`. The port defaults to 80.
+	MirrorAnnotationKey = annotationPrefix + "mirror"
+
+	// MirrorPercentageAnnotationKey is the annotation key to set the percentage
+	// of the traffic mirrored to the backend of MirrorAnnotationKey, in the
+	// (0, 100] range. All the traffic is mirrored when not set.
+	MirrorPercentageAnnotationKey = annotationPrefix + "mirror-percentage"
+
+	// FaultDelayAnnotationKey is the annotation key to inject a fixed delay,
+	// e.g. "5s", before forwarding the requests of the Ingress paths to their
+	// backends. The probes of the Ingress are never delayed.
+	FaultDelayAnnotationKey = annotationPrefix + "fault-delay"
+
+	// FaultDelayPercentageAnnotationKey is the annotation key to set the
+	// percentage of the requests delayed per FaultDelayAnnotationKey, in the
+	// (0, 100] range. All the requests are delayed when not set.
+	FaultDelayPercentageAnnotationKey = annotationPrefix + "fault-delay-percentage"
+
+	// FaultAbortStatusAnnotationKey is the annotation key to abort the
+	// requests of the Ingress paths with the given HTTP status code instead of
+	// forwarding them to their backends. The probes of the Ingress are never
+	// aborted.
+	FaultAbortStatusAnnotationKey = annotationPrefix + "fault-abort-status"
+
+	// FaultAbortPercentageAnnotationKey is the annotation key to set the
+	// percentage of the requests aborted per FaultAbortStatusAnnotationKey, in
+	// the (0, 100] range. All the requests are aborted when not set.
+	FaultAbortPercentageAnnotationKey = annotationPrefix + "fault-abort-percentage"
+
+	// CORSAllowOriginsAnnotationKey is the annotation key to enable CORS on the
+	// Ingress paths for the given comma separated list of origins. An origin
+	// is either `*` for any origin, `<scheme>://<host>[:<port>]`, or
+	// `<scheme>://*.<domain>[:<port>]` for the subdomains of a domain. The
+	// other CORS annotations require it.
+	CORSAllowOriginsAnnotationKey = annotationPrefix + "cors-allow-origins"
+
+	// CORSAllowMethodsAnnotationKey is the annotation key to set the comma
+	// separated list of HTTP methods allowed in the CORS requests.
+	CORSAllowMethodsAnnotationKey = annotationPrefix + "cors-allow-methods"
+
+	// CORSAllowHeadersAnnotationKey is the annotation key to set the comma
+	// separated list of headers allowed in the CORS requests.
+	CORSAllowHeadersAnnotationKey = annotationPrefix + "cors-allow-headers"
+
+	// CORSExposeHeadersAnnotationKey is the annotation key to set the comma
+	// separated list of response headers exposed to the CORS clients.
+	CORSExposeHeadersAnnotationKey = annotationPrefix + "cors-expose-headers"
+
+	// CORSAllowCredentialsAnnotationKey is the annotation key to allow the CORS
+	// requests with credentials. It cannot be set along with the `*` origin.
+	CORSAllowCredentialsAnnotationKey = annotationPrefix + "cors-allow-credentials"
+
+	// CORSMaxAgeAnnotationKey is the annotation key to set how long the results
+	// of the preflight requests can be cached, e.g. "24h".
+	CORSMaxAgeAnnotationKey = annotationPrefix + "cors-max-age"
+
+	// JWTIssuerAnnotationKey is the annotation key to require a JWT from the
+	// given issuer on the requests for the hosts of an Ingress. The tokens are
+	// verified at the gateways serving the Ingress.
+	JWTIssuerAnnotationKey = annotationPrefix + "jwt-issuer"
+
+	// JWTJwksURIAnnotationKey is the annotation key to set the URI of the JSON
+	// Web Key Set used to verify the tokens of JWTIssuerAnnotationKey. It is
+	// discovered from the issuer when neither this nor JWTJwksSecretAnnotationKey
+	// is set.
+	JWTJwksURIAnnotationKey = annotationPrefix + "jwt-jwks-uri"
+
+	// JWTJwksSecretAnnotationKey is the annotation key to set the name of the
+	// Secret in the Ingress namespace holding the JSON Web Key Set used to verify
+	// the tokens of JWTIssuerAnnotationKey, under the `jwks` key.
+	JWTJwksSecretAnnotationKey = annotationPrefix + "jwt-jwks-secret"
+
+	// JWTRequiredClaimsAnnotationKey is the annotation key to require claims
+	// in the tokens of JWTIssuerAnnotationKey. The value is a comma separated
+	// list of `<claim>=<value>` pairs.
+	JWTRequiredClaimsAnnotationKey = annotationPrefix + "jwt-required-claims"
+
+	// JWKSSecretKey is the key of the JSON Web Key Set in the Secret of
+	// JWTJwksSecretAnnotationKey.
+	JWKSSecretKey = "jwks"
+
+	// ExtAuthzProviderAnnotationKey is the annotation key to delegate the
+	// authorization of the requests for the hosts and paths of an Ingress to
+	// an external authorization provider. The value is the name of one of the
+	// `ext-authz-providers` of config-istio. With the regex path match type,
+	// the requests for any path of the hosts are delegated. It requires Istio
+	// 1.9 or later, the first release enforcing the CUSTOM action of the
+	// AuthorizationPolicies: the Ingress is not ready, and its hosts are not
+	// routed, while the policies cannot be applied.
+	ExtAuthzProviderAnnotationKey = annotationPrefix + "ext-authz-provider"
+
+	// The following annotation keys set the header operations of the routes
+	// of an Ingress. They override the `header-policy.*` settings of
+	// config-istio. The `<key>.<service>` variants set the header operations
+	// of the splits routing to the given backend Service instead.
+
+	// RequestHeadersRemoveAnnotationKey sets the comma separated list of the
+	// request headers removed before forwarding the requests.
+	RequestHeadersRemoveAnnotationKey = annotationPrefix + requestHeadersRemoveSetting
+	// ResponseHeadersSetAnnotationKey sets the comma separated list of
+	// `<header>=<value>` response headers to set.
+	ResponseHeadersSetAnnotationKey = annotationPrefix + responseHeadersSetSetting
+	// ResponseHeadersAddAnnotationKey sets the comma separated list of
+	// `<header>=<value>` response headers to append.
+	ResponseHeadersAddAnnotationKey = annotationPrefix + responseHeadersAddSetting
+	// ResponseHeadersRemoveAnnotationKey sets the comma separated list of the
+	// response headers to remove.
+	ResponseHeadersRemoveAnnotationKey = annotationPrefix + responseHeadersRemoveSetting
+
+	// The following annotation keys set the traffic policy of the backends of
+	// an Ingress, which is applied through a DestinationRule per backend
+	// Service. They override the `traffic-policy.*` settings of config-istio.
+
+	// LoadBalancerAnnotationKey sets the load balancing algorithm, one of
+	// ROUND_ROBIN, LEAST_CONN, RANDOM or PASSTHROUGH.
+	LoadBalancerAnnotationKey = annotationPrefix + loadBalancerSetting
+	// MaxConnectionsAnnotationKey sets the maximum number of connections to
+	// each backend.
+	MaxConnectionsAnnotationKey = annotationPrefix + maxConnectionsSetting
+	// MaxPendingRequestsAnnotationKey sets the maximum number of pending
+	// HTTP requests to each backend.
+	MaxPendingRequestsAnnotationKey = annotationPrefix + maxPendingRequestsSetting
+	// MaxRequestsAnnotationKey sets the maximum number of concurrent HTTP
+	// requests to each backend.
+	MaxRequestsAnnotationKey = annotationPrefix + maxRequestsSetting
+	// OutlierConsecutiveErrorsAnnotationKey sets the number of consecutive
+	// 5xx errors before a host is ejected from the load balancing pool.
+	OutlierConsecutiveErrorsAnnotationKey = annotationPrefix + outlierConsecutiveErrorsSetting
+	// OutlierBaseEjectionTimeAnnotationKey sets the minimum ejection duration
+	// of a host, e.g. "30s".
+	OutlierBaseEjectionTimeAnnotationKey = annotationPrefix + outlierBaseEjectionTimeSetting
+	// UpstreamTLSAnnotationKey sets the TLS mode of the connections to the
+	// backends, one of DISABLE, SIMPLE or ISTIO_MUTUAL.
+	UpstreamTLSAnnotationKey = annotationPrefix + upstreamTLSSetting
+
+	// The following annotation keys pin the requests of a client to the same
+	// backend pod with a consistent hash load balancer, for stateful backends.
+	// At most one of them can be set, and it conflicts with
+	// LoadBalancerAnnotationKey but overrides its default of config-istio.
+
+	// SessionAffinityCookieAnnotationKey sets the name of the cookie whose
+	// value is hashed. The gateways set the cookie when the requests lack it.
+	SessionAffinityCookieAnnotationKey = annotationPrefix + "session-affinity-cookie"
+	// SessionAffinityCookieTTLAnnotationKey sets the lifetime of the cookie of
+	// SessionAffinityCookieAnnotationKey, e.g. "1h". The cookie lasts for the
+	// browser session by default.
+	SessionAffinityCookieTTLAnnotationKey = annotationPrefix + "session-affinity-cookie-ttl"
+	// SessionAffinityHeaderAnnotationKey sets the name of the request header
+	// whose value is hashed.
+	SessionAffinityHeaderAnnotationKey = annotationPrefix + "session-affinity-header"
+	// SessionAffinitySourceIPAnnotationKey hashes the source IP of the
+	// requests when "true".
+	SessionAffinitySourceIPAnnotationKey = annotationPrefix + "session-affinity-source-ip"
+
+	// RateLimitAnnotationKey is the annotation key to limit the rate of the
+	// requests for the hosts of an Ingress at each gateway pod. The value is
+	// `<tokens>/<fill interval>`, e.g. `100/1m` lets in 100 requests a minute,
+	// and the fill interval must be at least 50ms. The requests over the limit
+	// are answered with 429 by the gateways. The limit is enforced by the local
+	// rate limit filter of the knative-local-rate-limit EnvoyFilter, which
+	// must be installed.
+	RateLimitAnnotationKey = annotationPrefix + "rate-limit"
+
+	// RateLimitScopeAnnotationKey is the annotation key to set what the rate
+	// limit of RateLimitAnnotationKey applies to, one of host or path. All the
+	// paths of a host share their limit by default, while each path of the
+	// Ingress has its own with path.
+	RateLimitScopeAnnotationKey = annotationPrefix + "rate-limit-scope"
+
+	// RateLimitPerConnectionAnnotationKey is the annotation key to apply the
+	// rate limit of RateLimitAnnotationKey to each client connection instead
+	// of each gateway pod when "true".
+	RateLimitPerConnectionAnnotationKey = annotationPrefix + "rate-limit-per-connection"
+)
+
+// routeOptions holds the route customizations of an Ingress that are not
+// expressible through the Ingress API.
+type routeOptions struct {
+	// routePolicy specifies whether the timeout and retries of the Ingress
+	// paths are translated into the VirtualService routes.
+	routePolicy bool
+
+	// headerMatchTypes maps the lower-cased header names to their match type.
+	headerMatchTypes map[string]headerMatchType
+
+	// pathMatchType is the match type of the Ingress paths.
+	pathMatchType pathMatchType
+
+	// queryRoutes are the query routes of the Ingress paths, in order of
+	// precedence.
+	queryRoutes []queryRoute
+
+	// uriPrefixRewrites maps the Ingress paths to the prefix replacing the
+	// part of the request paths they match.
+	uriPrefixRewrites map[rewriteKey]string
+
+	// staticRoutes are the redirects and direct responses of the Ingress, in
+	// order of precedence.
+	staticRoutes []staticRoute
+
+	// tlsPassthroughHosts are the hosts served in TLS passthrough mode.
+	tlsPassthroughHosts sets.String
+
+	// tcpPorts are the TCP ports of the rules of the Ingress.
+	tcpPorts []tcpPort
+
+	// mirror is the destination the traffic of the Ingress paths is mirrored
+	// to, if any.
+	mirror *istiov1beta1.Destination
+
+	// mirrorPercentage is the percentage of the traffic that is mirrored. All
+	// the traffic is mirrored when nil.
+	mirrorPercentage *istiov1beta1.Percent
+
+	// fault is the fault injected in the traffic of the Ingress paths, if any.
+	fault *istiov1beta1.HTTPFaultInjection
+
+	// corsPolicy is the CORS policy of the Ingress paths, if any.
+	corsPolicy *istiov1beta1.CorsPolicy
+
+	// pathHeaders holds the header operations of the Ingress paths, if any.
+	pathHeaders *istiov1beta1.Headers
+
+	// splitHeaders maps the backend Services to the header operations of
+	// their splits.
+	splitHeaders map[string]*istiov1beta1.Headers
+
+	// rateLimit is the rate limit of the Ingress, if any.
+	rateLimit *rateLimit
+}
+
+// makeRouteOptions parses the route customizations from the annotations of the
+// given Ingress, falling back to the defaults configured in config-istio.
+func makeRouteOptions(ctx context.Context, ing *v1alpha1.Ingress) (*routeOptions, error) {
+	opts := &routeOptions{
+		routePolicy: config.FromContextOrDefaults(ctx).Istio.EnableRoutePolicy,
+	}
+	annotations := ing.GetAnnotations()
+	if v, ok := annotations[RoutePolicyAnnotationKey]; ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, annotationError(RoutePolicyAnnotationKey, v, err)
+		}
+		opts.routePolicy = b
+	}
+	if v, ok := annotations[HeaderMatchTypesAnnotationKey]; ok {
+		types, err := parseHeaderMatchTypes(v)
+		if err != nil {
+			return nil, annotationError(HeaderMatchTypesAnnotationKey, v, err)
+		}
+		if err := validateHeaderRegexes(ing, types); err != nil {
+			return nil, annotationError(HeaderMatchTypesAnnotationKey, v, err)
+		}
+		opts.headerMatchTypes = types
+	}
+	if v, ok := annotations[PathMatchTypeAnnotationKey]; ok {
+		switch t := pathMatchType(v); t {
+		case pathMatchPrefix, pathMatchExact, pathMatchSegmentPrefix:
+			opts.pathMatchType = t
+		case pathMatchRegex:
+			if err := validatePathRegexes(ing); err != nil {
+				return nil, annotationError(PathMatchTypeAnnotationKey, v, err)
+			}
+			opts.pathMatchType = t
+		default:
+			return nil, annotationError(PathMatchTypeAnnotationKey, v, errors.New("expected one of prefix, exact, segment-prefix or regex"))
+		}
+	}
+	if v, ok := annotations[URIPrefixRewritesAnnotationKey]; ok {
+		if opts.pathMatchType == pathMatchRegex {
+			return nil, fmt.Errorf("annotation %s does not support the %s path match type", URIPrefixRewritesAnnotationKey, pathMatchRegex)
+		}
+		rewrites, err := parseURIPrefixRewrites(ing, v)
+		if err != nil {
+			return nil, annotationError(URIPrefixRewritesAnnotationKey, v, err)
+		}
+		opts.uriPrefixRewrites = rewrites
+	}
+	if v, ok := annotations[QueryRoutesAnnotationKey]; ok {
+		routes, err := parseQueryRoutes(v)
+		if err != nil {
+			return nil, annotationError(QueryRoutesAnnotationKey, v, err)
+		}
+		opts.queryRoutes = routes
+	}
+	if v, ok := annotations[RedirectsAnnotationKey]; ok {
+		routes, err := parseRedirects(v)
+		if err != nil {
+			return nil, annotationError(RedirectsAnnotationKey, v, err)
+		}
+		opts.staticRoutes = append(opts.staticRoutes, routes...)
+	}
+	if v, ok := annotations[DirectResponsesAnnotationKey]; ok {
+		routes, err := parseDirectResponses(v)
+		if err != nil {
+			return nil, annotationError(DirectResponsesAnnotationKey, v, err)
+		}
+		opts.staticRoutes = append(opts.staticRoutes, routes...)
+	}
+	passthrough, err := parseTLSPassthroughHosts(ing)
+	if err != nil {
+		return nil, err
+	}
+	opts.tlsPassthroughHosts = passthrough
+	if opts.tcpPorts, err = parseTCPPorts(ing); err != nil {
+		return nil, err
+	}
+	if v, ok := annotations[MirrorAnnotationKey]; ok {
+		mirror, err := parseMirror(v, ing.Namespace)
+		if err != nil {
+			return nil, annotationError(MirrorAnnotationKey, v, err)
+		}
+		opts.mirror = mirror
+	}
+	if v, ok := annotations[MirrorPercentageAnnotationKey]; ok {
+		if opts.mirror == nil {
+			return nil, fmt.Errorf("annotation %s requires annotation %s", MirrorPercentageAnnotationKey, MirrorAnnotationKey)
+		}
+		p, err := parsePercentage(v)
+		if err != nil {
+			return nil, annotationError(MirrorPercentageAnnotationKey, v, err)
+		}
+		opts.mirrorPercentage = p
+	}
+	fault, err := makeFault(annotations)
+	if err != nil {
+		return nil, err
+	}
+	opts.fault = fault
+	cors, err := makeCORSPolicy(annotations)
+	if err != nil {
+		return nil, err
+	}
+	opts.corsPolicy = cors
+	if opts.pathHeaders, opts.splitHeaders, err = makeHeaderPolicies(ctx, annotations); err != nil {
+		return nil, err
+	}
+	if opts.rateLimit, err = parseRateLimit(annotations); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// makeFault parses the fault injected in the traffic of an Ingress from the
+// given annotations. It returns nil when no fault is injected.
+func makeFault(annotations map[string]string) (*istiov1beta1.HTTPFaultInjection, error) {
+	var fault istiov1beta1.HTTPFaultInjection
+	if v, ok := annotations[FaultDelayAnnotationKey]; ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, annotationError(FaultDelayAnnotationKey, v, err)
+		}
+		if d <= 0 {
+			return nil, annotationError(FaultDelayAnnotationKey, v, errors.New("expected a positive duration"))
+		}
+		fault.Delay = &istiov1beta1.HTTPFaultInjection_Delay{
+			HttpDelayType: &istiov1beta1.HTTPFaultInjection_Delay_FixedDelay{
+				FixedDelay: types.DurationProto(d),
+			},
+		}
+	}
+	if v, ok := annotations[FaultDelayPercentageAnnotationKey]; ok {
+		if fault.Delay == nil {
+			return nil, fmt.Errorf("annotation %s requires annotation %s", FaultDelayPercentageAnnotationKey, FaultDelayAnnotationKey)
+		}
+		p, err := parsePercentage(v)
+		if err != nil {
+			return nil, annotationError(FaultDelayPercentageAnnotationKey, v, err)
+		}
+		fault.Delay.Percentage = p
+	}
+	if v, ok := annotations[FaultAbortStatusAnnotationKey]; ok {
+		status, err := strconv.ParseInt(v, 10, 32)
+		if err != nil {
+			return nil, annotationError(FaultAbortStatusAnnotationKey, v, err)
+		}
+		if status < 200 || status > 599 {
+			return nil, annotationError(FaultAbortStatusAnnotationKey, v, errors.New("expected an HTTP status code in the [200, 599] range"))
+		}
+		fault.Abort = &istiov1beta1.HTTPFaultInjection_Abort{
+			ErrorType: &istiov1beta1.HTTPFaultInjection_Abort_HttpStatus{
+				HttpStatus: int32(status),
+			},
+		}
+	}
+	if v, ok := annotations[FaultAbortPercentageAnnotationKey]; ok {
+		if fault.Abort == nil {
+			return nil, fmt.Errorf("annotation %s requires annotation %s", FaultAbortPercentageAnnotationKey, FaultAbortStatusAnnotationKey)
+		}
+		p, err := parsePercentage(v)
+		if err != nil {
+			return nil, annotationError(FaultAbortPercentageAnnotationKey, v, err)
+		}
+		fault.Abort.Percentage = p
+	}
+	if fault.Delay == nil && fault.Abort == nil {
+		return nil, nil
+	}
+	return &fault, nil
+}
+
+// parsePercentage parses a percentage in the (0, 100] range.
+func parsePercentage(v string) (*istiov1beta1.Percent, error) {
+	p, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return nil, err
+	}
+	if p <= 0 || p > 100 {
+		return nil, errors.New("expected a percentage in the (0, 100] range")
+	}
+	return &istiov1beta1.Percent{Value: p}, nil
+}
+
+// parseMirror parses the `<service>[:<port>]` mirror destination in the given
+// namespace.
+func parseMirror(v, namespace string) (*istiov1beta1.Destination, error) {
+	name, port := v, uint64(80)
+	if i := strings.LastIndex(v, ":"); i >= 0 {
+		var err error
+		if port, err = strconv.ParseUint(v[i+1:], 10, 16); err != nil || port == 0 {
+			return nil, fmt.Errorf("invalid port %q", v[i+1:])
+		}
+		name = v[:i]
+	}
+	if errs := validation.IsDNS1035Label(name); len(errs) != 0 {
+		return nil, fmt.Errorf("invalid Service name %q: %s", name, strings.Join(errs, ", "))
+	}
+	return &istiov1beta1.Destination{
+		Host: network.GetServiceHostname(name, namespace),
+		Port: &istiov1beta1.PortSelector{
+			Number: uint32(port),
+		},
+	}, nil
+}
+
+// rewriteKey identifies an Ingress path by the index of its rule and its path.
+type rewriteKey struct {
+	rule int
+	path string
+}
+
+func parseURIPrefixRewrites(ing *v1alpha1.Ingress, v string) (map[rewriteKey]string, error) {
+	rewrites := map[rewriteKey]string{}
+	scoped := map[rewriteKey]string{}
+	for _, pair := range splitList(v) {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("expected [<host>]<path>=<prefix>, got %q", pair)
+		}
+		path, prefix := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		slash := strings.Index(path, "/")
+		if slash < 0 || !strings.HasPrefix(prefix, "/") {
+			return nil, fmt.Errorf("expected absolute paths, got %q", pair)
+		}
+		if host := path[:slash]; host != "" {
+			rule := ruleIndex(ing, host)
+			if rule < 0 {
+				return nil, fmt.Errorf("%q is not a host of the Ingress", host)
+			}
+			scoped[rewriteKey{rule: rule, path: path[slash:]}] = prefix
+			continue
+		}
+		for i, rule := range ing.Spec.Rules {
+			if rule.HTTP == nil {
+				continue
+			}
+			for _, p := range rule.HTTP.Paths {
+				if p.Path == path {
+					rewrites[rewriteKey{rule: i, path: path}] = prefix
+				}
+			}
+		}
+	}
+	for key, prefix := range scoped {
+		rewrites[key] = prefix
+	}
+	return rewrites, nil
+}
+
+// ruleIndex returns the index of the rule of the given Ingress with the given
+// host, or -1.
+func ruleIndex(ing *v1alpha1.Ingress, host string) int {
+	for i, rule := range ing.Spec.Rules {
+		for _, h := range rule.Hosts {
+			if h == host {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func parseHeaderMatchTypes(v string) (map[string]headerMatchType, error) {
+	types := map[string]headerMatchType{}
+	for _, pair := range strings.Split(v, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("expected <header>=<type>, got %q", pair)
+		}
+		header := strings.ToLower(strings.TrimSpace(parts[0]))
+		switch t := headerMatchType(strings.TrimSpace(parts[1])); t {
+		case headerMatchExact, headerMatchPrefix, headerMatchRegex, headerMatchPresent:
+			types[header] = t
+		default:
+			return nil, fmt.Errorf("unknown match type %q for header %s", t, header)
+		}
+	}
+	return types, nil
+}
+
+// jwtOptions holds the JWT authentication settings of an Ingress.
+type jwtOptions struct {
+	issuer  string
+	jwksURI string
+	// jwksSecret is the name of the Secret holding the JSON Web Key Set.
+	jwksSecret string
+	// requiredClaims maps the required claims to their value.
+	requiredClaims map[string]string
+}
+
+// makeJWTOptions parses the JWT authentication settings from the annotations
+// of the given Ingress. It returns nil when JWT authentication is not required.
+func makeJWTOptions(ing *v1alpha1.Ingress) (*jwtOptions, error) {
+	annotations := ing.GetAnnotations()
+	opts := &jwtOptions{
+		issuer:     annotations[JWTIssuerAnnotationKey],
+		jwksURI:    annotations[JWTJwksURIAnnotationKey],
+		jwksSecret: annotations[JWTJwksSecretAnnotationKey],
+	}
+	v, hasClaims := annotations[JWTRequiredClaimsAnnotationKey]
+	if opts.issuer == "" {
+		if opts.jwksURI != "" || opts.jwksSecret != "" || hasClaims {
+			return nil, fmt.Errorf("the JWT annotations require annotation %s", JWTIssuerAnnotationKey)
+		}
+		return nil, nil
+	}
+	if opts.jwksURI != "" && opts.jwksSecret != "" {
+		return nil, fmt.Errorf("annotations %s and %s are mutually exclusive", JWTJwksURIAnnotationKey, JWTJwksSecretAnnotationKey)
+	}
+	if opts.jwksURI != "" {
+		if u, err := url.Parse(opts.jwksURI); err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, annotationError(JWTJwksURIAnnotationKey, opts.jwksURI, errors.New("expected an absolute URI"))
+		}
+	}
+	if opts.jwksSecret != "" {
+		if errs := validation.IsDNS1123Subdomain(opts.jwksSecret); len(errs) != 0 {
+			return nil, annotationError(JWTJwksSecretAnnotationKey, opts.jwksSecret, errors.New(strings.Join(errs, ", ")))
+		}
+	}
+	if hasClaims {
+		claims, err := parseRequiredClaims(v)
+		if err != nil {
+			return nil, annotationError(JWTRequiredClaimsAnnotationKey, v, err)
+		}
+		opts.requiredClaims = claims
+	}
+	return opts, nil
+}
+
+func parseRequiredClaims(v string) (map[string]string, error) {
+	claims := map[string]string{}
+	for _, pair := range strings.Split(v, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("expected <claim>=<value>, got %q", pair)
+		}
+		claim := strings.TrimSpace(parts[0])
+		if strings.ContainsAny(claim, "[]") {
+			return nil, fmt.Errorf("invalid claim name %q", claim)
+		}
+		claims[claim] = strings.TrimSpace(parts[1])
+	}
+	return claims, nil
+}
+
+func annotationError(key, value string, err error) error {
+	return fmt.Errorf("invalid value %q for annotation %s: %w", value, key, err)
+}
@@ -0,0 +1,282 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/google/go-cmp/cmp"
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/net-istio/pkg/reconciler/ingress/config"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+)
+
+func TestMakeRouteOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		paths       []string
+		headers     map[string]string
+		config      *config.Istio
+		want        *routeOptions
+		wantErr     bool
+	}{{
+		name: "defaults",
+		want: &routeOptions{},
+	}, {
+		name:   "route policy enabled in config",
+		config: &config.Istio{EnableRoutePolicy: true},
+		want:   &routeOptions{routePolicy: true},
+	}, {
+		name:        "route policy enabled by annotation",
+		annotations: map[string]string{RoutePolicyAnnotationKey: "true"},
+		want:        &routeOptions{routePolicy: true},
+	}, {
+		name:        "route policy disabled by annotation",
+		annotations: map[string]string{RoutePolicyAnnotationKey: "false"},
+		config:      &config.Istio{EnableRoutePolicy: true},
+		want:        &routeOptions{},
+	}, {
+		name:        "invalid route policy annotation",
+		annotations: map[string]string{RoutePolicyAnnotationKey: "yes please"},
+		wantErr:     true,
+	}, {
+		name: "header match types",
+		annotations: map[string]string{
+			HeaderMatchTypesAnnotationKey: "X-Canary=prefix, x-version=regex,x-debug=present,x-user=exact,",
+		},
+		want: &routeOptions{
+			headerMatchTypes: map[string]headerMatchType{
+				"x-canary":  headerMatchPrefix,
+				"x-version": headerMatchRegex,
+				"x-debug":   headerMatchPresent,
+				"x-user":    headerMatchExact,
+			},
+		},
+	}, {
+		name:        "unknown header match type",
+		annotations: map[string]string{HeaderMatchTypesAnnotationKey: "x-canary=suffix"},
+		wantErr:     true,
+	}, {
+		name:        "invalid header regex",
+		annotations: map[string]string{HeaderMatchTypesAnnotationKey: "x-version=regex"},
+		headers:     map[string]string{"X-Version": "v(1"},
+		wantErr:     true,
+	}, {
+		name:        "header regex too long",
+		annotations: map[string]string{HeaderMatchTypesAnnotationKey: "x-version=regex"},
+		headers:     map[string]string{"X-Version": strings.Repeat("v", 101)},
+		wantErr:     true,
+	}, {
+		name:        "header regex not checked with other match types",
+		annotations: map[string]string{HeaderMatchTypesAnnotationKey: "x-version=prefix"},
+		headers:     map[string]string{"X-Version": "v(1"},
+		want: &routeOptions{
+			headerMatchTypes: map[string]headerMatchType{"x-version": headerMatchPrefix},
+		},
+	}, {
+		name:        "malformed header match types",
+		annotations: map[string]string{HeaderMatchTypesAnnotationKey: "x-canary"},
+		wantErr:     true,
+	}, {
+		name:        "path match type",
+		annotations: map[string]string{PathMatchTypeAnnotationKey: "segment-prefix"},
+		want:        &routeOptions{pathMatchType: pathMatchSegmentPrefix},
+	}, {
+		name:        "regex path match type",
+		annotations: map[string]string{PathMatchTypeAnnotationKey: "regex"},
+		paths:       []string{"/api/v[0-9]+/.*"},
+		want:        &routeOptions{pathMatchType: pathMatchRegex},
+	}, {
+		name:        "invalid path regex",
+		annotations: map[string]string{PathMatchTypeAnnotationKey: "regex"},
+		paths:       []string{"/api/(v1"},
+		wantErr:     true,
+	}, {
+		name:        "path regex too long",
+		annotations: map[string]string{PathMatchTypeAnnotationKey: "regex"},
+		paths:       []string{"/" + strings.Repeat("a", 100)},
+		wantErr:     true,
+	}, {
+		name:        "unknown path match type",
+		annotations: map[string]string{PathMatchTypeAnnotationKey: "suffix"},
+		wantErr:     true,
+	}, {
+		name:        "URI prefix rewrites",
+		annotations: map[string]string{URIPrefixRewritesAnnotationKey: "/billing/=/, /orders=/v2/orders, /unknown=/"},
+		paths:       []string{"/billing/", "/orders"},
+		want: &routeOptions{
+			uriPrefixRewrites: map[rewriteKey]string{
+				{rule: 0, path: "/billing/"}: "/",
+				{rule: 1, path: "/orders"}:   "/v2/orders",
+			},
+		},
+	}, {
+		name:        "URI prefix rewrite of an unknown host",
+		annotations: map[string]string{URIPrefixRewritesAnnotationKey: "example.com/billing=/"},
+		paths:       []string{"/billing"},
+		wantErr:     true,
+	}, {
+		name:        "relative URI prefix rewrite",
+		annotations: map[string]string{URIPrefixRewritesAnnotationKey: "/billing=v2"},
+		wantErr:     true,
+	}, {
+		name:        "malformed URI prefix rewrites",
+		annotations: map[string]string{URIPrefixRewritesAnnotationKey: "/billing"},
+		wantErr:     true,
+	}, {
+		name:        "mirror",
+		annotations: map[string]string{MirrorAnnotationKey: "shadow"},
+		want: &routeOptions{
+			mirror: &istiov1beta1.Destination{
+				Host: "shadow.test-ns.svc.cluster.local",
+				Port: &istiov1beta1.PortSelector{Number: 80},
+			},
+		},
+	}, {
+		name: "mirror with port and percentage",
+		annotations: map[string]string{
+			MirrorAnnotationKey:           "shadow:8080",
+			MirrorPercentageAnnotationKey: "12.5",
+		},
+		want: &routeOptions{
+			mirror: &istiov1beta1.Destination{
+				Host: "shadow.test-ns.svc.cluster.local",
+				Port: &istiov1beta1.PortSelector{Number: 8080},
+			},
+			mirrorPercentage: &istiov1beta1.Percent{Value: 12.5},
+		},
+	}, {
+		name:        "invalid mirror Service",
+		annotations: map[string]string{MirrorAnnotationKey: "shadow.other-ns"},
+		wantErr:     true,
+	}, {
+		name:        "invalid mirror port",
+		annotations: map[string]string{MirrorAnnotationKey: "shadow:http"},
+		wantErr:     true,
+	}, {
+		name: "mirror percentage out of range",
+		annotations: map[string]string{
+			MirrorAnnotationKey:           "shadow",
+			MirrorPercentageAnnotationKey: "120",
+		},
+		wantErr: true,
+	}, {
+		name:        "mirror percentage without mirror",
+		annotations: map[string]string{MirrorPercentageAnnotationKey: "50"},
+		wantErr:     true,
+	}, {
+		name:        "fault delay",
+		annotations: map[string]string{FaultDelayAnnotationKey: "5s"},
+		want: &routeOptions{
+			fault: &istiov1beta1.HTTPFaultInjection{
+				Delay: &istiov1beta1.HTTPFaultInjection_Delay{
+					HttpDelayType: &istiov1beta1.HTTPFaultInjection_Delay_FixedDelay{
+						FixedDelay: &types.Duration{Seconds: 5},
+					},
+				},
+			},
+		},
+	}, {
+		name: "fault delay and abort with percentages",
+		annotations: map[string]string{
+			FaultDelayAnnotationKey:           "500ms",
+			FaultDelayPercentageAnnotationKey: "50",
+			FaultAbortStatusAnnotationKey:     "503",
+			FaultAbortPercentageAnnotationKey: "0.5",
+		},
+		want: &routeOptions{
+			fault: &istiov1beta1.HTTPFaultInjection{
+				Delay: &istiov1beta1.HTTPFaultInjection_Delay{
+					HttpDelayType: &istiov1beta1.HTTPFaultInjection_Delay_FixedDelay{
+						FixedDelay: &types.Duration{Nanos: 500000000},
+					},
+					Percentage: &istiov1beta1.Percent{Value: 50},
+				},
+				Abort: &istiov1beta1.HTTPFaultInjection_Abort{
+					ErrorType: &istiov1beta1.HTTPFaultInjection_Abort_HttpStatus{
+						HttpStatus: 503,
+					},
+					Percentage: &istiov1beta1.Percent{Value: 0.5},
+				},
+			},
+		},
+	}, {
+		name:        "invalid fault delay",
+		annotations: map[string]string{FaultDelayAnnotationKey: "-1s"},
+		wantErr:     true,
+	}, {
+		name:        "invalid fault abort status",
+		annotations: map[string]string{FaultAbortStatusAnnotationKey: "999"},
+		wantErr:     true,
+	}, {
+		name:        "fault delay percentage without delay",
+		annotations: map[string]string{FaultDelayPercentageAnnotationKey: "50"},
+		wantErr:     true,
+	}, {
+		name: "fault abort percentage out of range",
+		annotations: map[string]string{
+			FaultAbortStatusAnnotationKey:     "500",
+			FaultAbortPercentageAnnotationKey: "0",
+		},
+		wantErr: true,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.config != nil {
+				ctx = config.ToContext(ctx, &config.Config{Istio: tc.config})
+			}
+			ing := &v1alpha1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   "test-ns",
+					Annotations: tc.annotations,
+				},
+			}
+			for _, path := range tc.paths {
+				ing.Spec.Rules = append(ing.Spec.Rules, v1alpha1.IngressRule{
+					HTTP: &v1alpha1.HTTPIngressRuleValue{
+						Paths: []v1alpha1.HTTPIngressPath{{Path: path}},
+					},
+				})
+			}
+			if tc.headers != nil {
+				headers := make(map[string]v1alpha1.HeaderMatch, len(tc.headers))
+				for k, v := range tc.headers {
+					headers[k] = v1alpha1.HeaderMatch{Exact: v}
+				}
+				ing.Spec.Rules = append(ing.Spec.Rules, v1alpha1.IngressRule{
+					HTTP: &v1alpha1.HTTPIngressRuleValue{
+						Paths: []v1alpha1.HTTPIngressPath{{Headers: headers}},
+					},
+				})
+			}
+			got, err := makeRouteOptions(ctx, ing)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("makeRouteOptions() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(routeOptions{}, rewriteKey{})); diff != "" {
+				t.Error("Unexpected route options (-want +got):", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,396 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	istiosecurity "istio.io/api/security/v1beta1"
+	istiotype "istio.io/api/type/v1beta1"
+	"istio.io/client-go/pkg/apis/networking/v1beta1"
+	securityv1beta1 "istio.io/client-go/pkg/apis/security/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"knative.dev/net-istio/pkg/reconciler/ingress/config"
+	"knative.dev/net-istio/pkg/reconciler/ingress/resources/names"
+	network "knative.dev/networking/pkg"
+	"knative.dev/networking/pkg/apis/networking"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/networking/pkg/ingress"
+	"knative.dev/pkg/system"
+)
+
+// IngressNamespaceLabelKey is the label key attached to the resources
+// generated for an Ingress outside of its namespace, e.g. in the namespaces
+// of the gateways, to identify the namespace of the Ingress. Along with
+// networking.IngressLabelKey it identifies the Ingress, since such resources
+// cannot be owned by it.
+const IngressNamespaceLabelKey = networking.GroupName + "/ingressNamespace"
+
+// MakeGatewayPolicyLabels returns the labels of the resources generated for
+// the given Ingress in the namespaces of the gateways.
+func MakeGatewayPolicyLabels(ing *v1alpha1.Ingress) map[string]string {
+	return map[string]string{
+		networking.IngressLabelKey: ing.Name,
+		IngressNamespaceLabelKey:   ing.Namespace,
+	}
+}
+
+// MakeClusterLocalAuthorizationPolicies creates the AuthorizationPolicies
+// restricting the access to the cluster-local hosts of the given Ingress, when
+// enabled in config-istio. On the ports of the gateways serving the
+// cluster-local hosts, i.e. the local gateways or the public gateways when
+// there is no local gateway, only the workloads of the mesh are let in, from
+// the allowed namespaces when configured. Since the peers are only identified
+// on the servers in ISTIO_MUTUAL TLS mode, the other ports are left open. The
+// public gateways deny the cluster-local hosts altogether on their other ports
+// when there are local gateways, the local and public gateways often being the
+// same workloads. The status probes are let in so that the Ingress can become
+// ready. It also returns the `<namespace>/<name>` of the local gateway Services
+// leaving the cluster-local hosts open on some ports.
+func MakeClusterLocalAuthorizationPolicies(ctx context.Context, ing *v1alpha1.Ingress, gateways *PolicyGateways, svcLister corev1listers.ServiceLister) ([]*securityv1beta1.AuthorizationPolicy, []string, error) {
+	cfg := config.FromContext(ctx).Istio
+	if !cfg.EnableClusterLocalAuthorization {
+		return nil, nil, nil
+	}
+	hosts := clusterLocalHosts(ing)
+	if len(hosts) == 0 {
+		return nil, nil, nil
+	}
+
+	publicServices, err := gatewayServices(gateways.Services[v1alpha1.IngressVisibilityExternalIP], svcLister)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get the public gateway Services: %w", err)
+	}
+	localServices, err := gatewayServices(gateways.Services[v1alpha1.IngressVisibilityClusterLocal], svcLister)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get the local gateway Services: %w", err)
+	}
+	localVisibility := v1alpha1.IngressVisibilityClusterLocal
+	if len(localServices) == 0 {
+		localServices, publicServices, localVisibility = publicServices, nil, v1alpha1.IngressVisibilityExternalIP
+	}
+
+	var allowed []string
+	if len(cfg.ClusterLocalAllowedNamespaces) != 0 {
+		allowed = sets.NewString(cfg.ClusterLocalAllowedNamespaces...).Insert(ing.Namespace, system.Namespace()).List()
+	}
+
+	var policies []*securityv1beta1.AuthorizationPolicy
+	var open []string
+	local := sets.NewString()
+	// localPorts are the ports serving the cluster-local hosts per workload.
+	localPorts := map[string]sets.String{}
+	for _, svc := range localServices {
+		local.Insert(svc.Namespace + "/" + svc.Name)
+		mutual, plain, err := localGatewayPorts(svc, gateways.servers(localVisibility, svc))
+		if err != nil {
+			return nil, nil, err
+		}
+		if plain.Len() != 0 {
+			open = append(open, svc.Namespace+"/"+svc.Name)
+		}
+		key := workloadKey(svc)
+		if localPorts[key] == nil {
+			localPorts[key] = sets.NewString()
+		}
+		localPorts[key].Insert(mutual.UnsortedList()...)
+		localPorts[key].Insert(plain.UnsortedList()...)
+		if mutual.Len() == 0 {
+			continue
+		}
+
+		op := &istiosecurity.Operation{Hosts: hosts, Ports: mutual.List()}
+		rules := policyRules(op, &istiosecurity.Source{NotPrincipals: []string{"*"}})
+		if len(allowed) != 0 {
+			rules = append(rules, policyRules(op, &istiosecurity.Source{NotNamespaces: allowed})...)
+		}
+		policies = append(policies, makeClusterLocalAuthorizationPolicy(ing, svc, rules))
+	}
+	for _, svc := range publicServices {
+		if local.Has(svc.Namespace + "/" + svc.Name) {
+			// The gateway serves the cluster-local hosts too.
+			continue
+		}
+		ports, err := gatewayPorts(svc)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ports = ports.Difference(localPorts[workloadKey(svc)]); ports.Len() == 0 {
+			continue
+		}
+		policies = append(policies, makeClusterLocalAuthorizationPolicy(ing, svc, []*istiosecurity.Rule{{
+			To: []*istiosecurity.Rule_To{{
+				Operation: &istiosecurity.Operation{Hosts: hosts, Ports: ports.List()},
+			}},
+		}}))
+	}
+	return policies, open, nil
+}
+
+// localGatewayPorts returns the ports the workloads of the given local gateway
+// Service serve the given servers on, split between the servers in ISTIO_MUTUAL
+// TLS mode and the others. Without servers, these are all the ports of the
+// Service.
+func localGatewayPorts(svc *corev1.Service, servers []*istiov1beta1.Server) (mutual, plain sets.String, err error) {
+	mutual, plain = sets.NewString(), sets.NewString()
+	if len(servers) == 0 {
+		plain, err = gatewayPorts(svc)
+		return mutual, plain, err
+	}
+	for _, server := range servers {
+		port, err := workloadPort(svc, server.Port.Number)
+		if err != nil {
+			return nil, nil, err
+		}
+		if server.Tls != nil && server.Tls.Mode == istiov1beta1.ServerTLSSettings_ISTIO_MUTUAL {
+			mutual.Insert(port)
+		} else {
+			plain.Insert(port)
+		}
+	}
+	return mutual, plain.Difference(mutual), nil
+}
+
+// gatewayPorts returns the ports the workloads of the given gateway Service
+// listen on, i.e. the target ports of the Service, which the AuthorizationPolicies
+// match.
+func gatewayPorts(svc *corev1.Service) (sets.String, error) {
+	ports := sets.NewString()
+	for _, port := range svc.Spec.Ports {
+		p, err := targetPort(svc, port)
+		if err != nil {
+			return nil, err
+		}
+		ports.Insert(p)
+	}
+	return ports, nil
+}
+
+// workloadPort returns the port the workloads of the given gateway Service
+// listen on for a Gateway server on the given port, which is the target port
+// of the Service port of that number if any, and the port itself otherwise.
+func workloadPort(svc *corev1.Service, number uint32) (string, error) {
+	for _, port := range svc.Spec.Ports {
+		if uint32(port.Port) == number {
+			return targetPort(svc, port)
+		}
+	}
+	return strconv.FormatUint(uint64(number), 10), nil
+}
+
+func targetPort(svc *corev1.Service, port corev1.ServicePort) (string, error) {
+	switch {
+	case port.TargetPort.Type == intstr.String:
+		return "", fmt.Errorf("the named target port %q of Service %s/%s is not supported", port.TargetPort.StrVal, svc.Namespace, svc.Name)
+	case port.TargetPort.IntVal == 0:
+		return strconv.Itoa(int(port.Port)), nil
+	default:
+		return strconv.Itoa(int(port.TargetPort.IntVal)), nil
+	}
+}
+
+// workloadKey identifies the workloads selected by the given gateway Service.
+func workloadKey(svc *corev1.Service) string {
+	return svc.Namespace + "/" + labels.SelectorFromSet(svc.Spec.Selector).String()
+}
+
+func makeClusterLocalAuthorizationPolicy(ing *v1alpha1.Ingress, gatewayService *corev1.Service, rules []*istiosecurity.Rule) *securityv1beta1.AuthorizationPolicy {
+	return &securityv1beta1.AuthorizationPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      names.ClusterLocalAuthorizationPolicy(ing, gatewayService.Name),
+			Namespace: gatewayService.Namespace,
+			Labels:    MakeGatewayPolicyLabels(ing),
+		},
+		Spec: istiosecurity.AuthorizationPolicy{
+			Selector: &istiotype.WorkloadSelector{
+				MatchLabels: gatewayService.Spec.Selector,
+			},
+			Action: istiosecurity.AuthorizationPolicy_DENY,
+			Rules:  rules,
+		},
+	}
+}
+
+// policyRules returns the rules matching the requests of the given operation
+// from the given source, if any, under the given conditions, except the status
+// probes. The probes are told apart by all of what the prober sends, i.e. GET
+// requests for the probe path with the probe and hash headers, since the probe
+// routes of the VirtualServices forward such requests to the probe handlers of
+// the backends. As the rules of a policy match when any of them does, one rule
+// is returned per way of not being a probe.
+func policyRules(op *istiosecurity.Operation, source *istiosecurity.Source, when ...*istiosecurity.Condition) []*istiosecurity.Rule {
+	notProbePath, notGet := *op, *op
+	notProbePath.NotPaths = append(append([]string{}, op.NotPaths...), network.ProbePath)
+	notGet.NotMethods = append(append([]string{}, op.NotMethods...), http.MethodGet)
+	withHeader := func(name, value string) []*istiosecurity.Condition {
+		return append(append([]*istiosecurity.Condition{}, when...), &istiosecurity.Condition{
+			Key:       "request.headers[" + name + "]",
+			NotValues: []string{value},
+		})
+	}
+	rules := []*istiosecurity.Rule{{
+		To:   []*istiosecurity.Rule_To{{Operation: &notProbePath}, {Operation: &notGet}},
+		When: when,
+	}, {
+		To:   []*istiosecurity.Rule_To{{Operation: op}},
+		When: withHeader(network.ProbeHeaderName, network.ProbeHeaderValue),
+	}, {
+		To:   []*istiosecurity.Rule_To{{Operation: op}},
+		When: withHeader(network.HashHeaderName, network.HashHeaderValue),
+	}}
+	if source != nil {
+		for _, rule := range rules {
+			rule.From = []*istiosecurity.Rule_From{{
+				Source: source,
+			}}
+		}
+	}
+	return rules
+}
+
+// clusterLocalHosts returns the policy hosts of the cluster-local rules of the
+// given Ingress.
+func clusterLocalHosts(ing *v1alpha1.Ingress) []string {
+	hosts := sets.NewString()
+	for _, rule := range getClusterLocalIngressRules(ing) {
+		hosts.Insert(rule.Hosts...)
+	}
+	return policyHosts(hosts)
+}
+
+// policyHosts returns the given hosts along with their short names, with and
+// without port since the hosts of the AuthorizationPolicies match the Host
+// header exactly.
+func policyHosts(hosts sets.String) []string {
+	expanded := ingress.ExpandedHosts(hosts)
+	for _, host := range expanded.List() {
+		expanded.Insert(host + ":*")
+	}
+	return expanded.List()
+}
+
+// PolicyGateways are the gateways serving an Ingress, which the policies of the
+// Ingress select.
+type PolicyGateways struct {
+	// Services are the gateways the Ingress binds to per visibility, i.e. the
+	// gateways of its Realm or of config-istio, along with their Service.
+	Services map[v1alpha1.IngressVisibility][]config.Gateway
+	// Gateways are the Gateways programming these gateways for the Ingress
+	// per visibility, including the Knative generated Gateways of the Ingress.
+	Gateways map[v1alpha1.IngressVisibility][]*v1beta1.Gateway
+}
+
+// AddGeneratedGateways adds the given Knative generated Gateways of an Ingress,
+// which select the public gateways of config-istio.
+func (pg *PolicyGateways) AddGeneratedGateways(ctx context.Context, gateways []*v1beta1.Gateway) {
+	if len(gateways) == 0 {
+		return
+	}
+	if pg.Services == nil {
+		pg.Services = map[v1alpha1.IngressVisibility][]config.Gateway{}
+	}
+	if pg.Gateways == nil {
+		pg.Gateways = map[v1alpha1.IngressVisibility][]*v1beta1.Gateway{}
+	}
+	public := v1alpha1.IngressVisibilityExternalIP
+	pg.Gateways[public] = append(pg.Gateways[public], gateways...)
+	known := sets.NewString()
+	for _, gw := range pg.Services[public] {
+		known.Insert(gw.ServiceURL)
+	}
+	for _, gw := range config.FromContext(ctx).Istio.IngressGateways {
+		if !known.Has(gw.ServiceURL) {
+			known.Insert(gw.ServiceURL)
+			pg.Services[public] = append(pg.Services[public], gw)
+		}
+	}
+}
+
+// servers returns the servers of the Gateways of the given visibility that
+// select the workloads of the given gateway Service.
+func (pg *PolicyGateways) servers(visibility v1alpha1.IngressVisibility, svc *corev1.Service) []*istiov1beta1.Server {
+	var servers []*istiov1beta1.Server
+	for _, gw := range pg.Gateways[visibility] {
+		if equality.Semantic.DeepEqual(gw.Spec.Selector, svc.Spec.Selector) {
+			servers = append(servers, gw.Spec.Servers...)
+		}
+	}
+	return servers
+}
+
+// gatewayHosts holds the Service of a gateway and the hosts and rules of an
+// Ingress it serves, along with the servers of its Gateways.
+type gatewayHosts struct {
+	service *corev1.Service
+	hosts   sets.String
+	rules   []v1alpha1.IngressRule
+	servers []*istiov1beta1.Server
+}
+
+// getGatewayHosts returns the given gateways that serve the given Ingress,
+// along with the hosts they serve. The cluster-local hosts are served by the
+// local gateways, or by the public gateways when there is no local gateway.
+func getGatewayHosts(gateways *PolicyGateways, ing *v1alpha1.Ingress, svcLister corev1listers.ServiceLister) ([]*gatewayHosts, error) {
+	publicServices, err := gatewayServices(gateways.Services[v1alpha1.IngressVisibilityExternalIP], svcLister)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the public gateway Services: %w", err)
+	}
+	localServices, err := gatewayServices(gateways.Services[v1alpha1.IngressVisibilityClusterLocal], svcLister)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the local gateway Services: %w", err)
+	}
+	localVisibility := v1alpha1.IngressVisibilityClusterLocal
+	if len(localServices) == 0 {
+		localServices, localVisibility = publicServices, v1alpha1.IngressVisibilityExternalIP
+	}
+
+	var result []*gatewayHosts
+	byKey := map[string]*gatewayHosts{}
+	withServers := sets.NewString()
+	add := func(services []*corev1.Service, visibility v1alpha1.IngressVisibility, rules []v1alpha1.IngressRule) {
+		for _, rule := range rules {
+			for _, svc := range services {
+				key := svc.Namespace + "/" + svc.Name
+				gh, ok := byKey[key]
+				if !ok {
+					gh = &gatewayHosts{service: svc, hosts: sets.NewString()}
+					byKey[key] = gh
+					result = append(result, gh)
+				}
+				if !withServers.Has(string(visibility) + "/" + key) {
+					withServers.Insert(string(visibility) + "/" + key)
+					gh.servers = append(gh.servers, gateways.servers(visibility, svc)...)
+				}
+				gh.hosts.Insert(rule.Hosts...)
+				gh.rules = append(gh.rules, rule)
+			}
+		}
+	}
+	add(publicServices, v1alpha1.IngressVisibilityExternalIP, getPublicIngressRules(ing))
+	add(localServices, localVisibility, getClusterLocalIngressRules(ing))
+	return result, nil
+}
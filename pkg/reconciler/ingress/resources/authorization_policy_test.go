@@ -0,0 +1,530 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	istiosecurity "istio.io/api/security/v1beta1"
+	istiotype "istio.io/api/type/v1beta1"
+	"istio.io/client-go/pkg/apis/networking/v1beta1"
+	securityv1beta1 "istio.io/client-go/pkg/apis/security/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/net-istio/pkg/reconciler/ingress/config"
+	"knative.dev/networking/pkg/apis/networking"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/pkg/system"
+	"sigs.k8s.io/yaml"
+
+	rtesting "knative.dev/pkg/reconciler/testing"
+)
+
+func TestMakeClusterLocalAuthorizationPolicies(t *testing.T) {
+	ctx, cancel, _ := rtesting.SetupFakeContextWithCancel(t)
+	defer cancel()
+	publicService := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "istio-ingressgateway",
+			Namespace: "istio-system",
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: selector,
+			Ports: []corev1.ServicePort{{
+				Port:       80,
+				TargetPort: intstr.FromInt(8080),
+			}, {
+				Port:       443,
+				TargetPort: intstr.FromInt(8443),
+			}},
+		},
+	}
+	localSelector := map[string]string{"istio": "local-gateway"}
+	localService := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "knative-local-gateway",
+			Namespace: "istio-system",
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: localSelector,
+			Ports: []corev1.ServicePort{{
+				Port:       80,
+				TargetPort: intstr.FromInt(8081),
+			}},
+		},
+	}
+	svcLister := serviceLister(ctx, publicService, localService)
+
+	publicGateways := []config.Gateway{{
+		Namespace:  system.Namespace(),
+		Name:       config.KnativeIngressGateway,
+		ServiceURL: "istio-ingressgateway.istio-system.svc.cluster.local",
+	}}
+	localGateways := []config.Gateway{{
+		Namespace:  system.Namespace(),
+		Name:       config.KnativeLocalGateway,
+		ServiceURL: "knative-local-gateway.istio-system.svc.cluster.local",
+	}}
+	localGateway := func(tls *istiov1beta1.ServerTLSSettings) *v1beta1.Gateway {
+		return &v1beta1.Gateway{
+			Spec: istiov1beta1.Gateway{
+				Selector: localSelector,
+				Servers: []*istiov1beta1.Server{{
+					Hosts: []string{"*"},
+					Port:  &istiov1beta1.Port{Name: "http", Number: 80, Protocol: "HTTP"},
+					Tls:   tls,
+				}},
+			},
+		}
+	}
+	mutual := &istiov1beta1.ServerTLSSettings{Mode: istiov1beta1.ServerTLSSettings_ISTIO_MUTUAL}
+
+	ing := &v1alpha1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ingress",
+			Namespace: "test-ns",
+		},
+		Spec: v1alpha1.IngressSpec{
+			Rules: []v1alpha1.IngressRule{{
+				Hosts:      []string{"foo.example.com"},
+				Visibility: v1alpha1.IngressVisibilityExternalIP,
+			}, {
+				Hosts:      []string{"foo.test-ns.svc.cluster.local"},
+				Visibility: v1alpha1.IngressVisibilityClusterLocal,
+			}},
+		},
+	}
+	hosts := []string{
+		"foo.test-ns", "foo.test-ns.svc", "foo.test-ns.svc.cluster.local",
+		"foo.test-ns.svc.cluster.local:*", "foo.test-ns.svc:*", "foo.test-ns:*",
+	}
+	to := func(ports ...string) []*istiosecurity.Rule_To {
+		return []*istiosecurity.Rule_To{{
+			Operation: &istiosecurity.Operation{Hosts: hosts, Ports: ports},
+		}}
+	}
+	meshOnly := policyRules(&istiosecurity.Operation{Hosts: hosts, Ports: []string{"8081"}},
+		&istiosecurity.Source{NotPrincipals: []string{"*"}})
+	policy := func(svc *corev1.Service, rules ...*istiosecurity.Rule) *securityv1beta1.AuthorizationPolicy {
+		return &securityv1beta1.AuthorizationPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-ns--ingress-" + svc.Name + "-cluster-local",
+				Namespace: "istio-system",
+				Labels: map[string]string{
+					networking.IngressLabelKey: "ingress",
+					IngressNamespaceLabelKey:   "test-ns",
+				},
+			},
+			Spec: istiosecurity.AuthorizationPolicy{
+				Selector: &istiotype.WorkloadSelector{
+					MatchLabels: svc.Spec.Selector,
+				},
+				Action: istiosecurity.AuthorizationPolicy_DENY,
+				Rules:  rules,
+			},
+		}
+	}
+
+	cases := []struct {
+		name          string
+		config        *config.Istio
+		local         bool
+		localGateway  *v1beta1.Gateway
+		publicGateway *v1beta1.Gateway
+		ing           *v1alpha1.Ingress
+		want          []*securityv1beta1.AuthorizationPolicy
+		wantOpen      []string
+	}{{
+		name:   "disabled",
+		config: &config.Istio{},
+		local:  true,
+		ing:    ing,
+	}, {
+		name:   "no cluster-local host",
+		config: &config.Istio{EnableClusterLocalAuthorization: true},
+		local:  true,
+		ing: &v1alpha1.Ingress{
+			ObjectMeta: ing.ObjectMeta,
+			Spec: v1alpha1.IngressSpec{
+				Rules: ing.Spec.Rules[:1],
+			},
+		},
+	}, {
+		name:         "plain HTTP local gateways",
+		config:       &config.Istio{EnableClusterLocalAuthorization: true},
+		local:        true,
+		localGateway: localGateway(nil),
+		ing:          ing,
+		want: []*securityv1beta1.AuthorizationPolicy{
+			policy(publicService, &istiosecurity.Rule{To: to("8080", "8443")}),
+		},
+		wantOpen: []string{"istio-system/knative-local-gateway"},
+	}, {
+		name:         "ISTIO_MUTUAL local gateways",
+		config:       &config.Istio{EnableClusterLocalAuthorization: true},
+		local:        true,
+		localGateway: localGateway(mutual),
+		ing:          ing,
+		want: []*securityv1beta1.AuthorizationPolicy{
+			policy(localService, meshOnly...),
+			policy(publicService, &istiosecurity.Rule{To: to("8080", "8443")}),
+		},
+	}, {
+		name:   "no local gateway",
+		config: &config.Istio{EnableClusterLocalAuthorization: true},
+		publicGateway: &v1beta1.Gateway{
+			Spec: istiov1beta1.Gateway{
+				Selector: selector,
+				Servers: []*istiov1beta1.Server{{
+					Hosts: []string{"*"},
+					Port:  &istiov1beta1.Port{Name: "http", Number: 80, Protocol: "HTTP"},
+				}, {
+					Hosts: []string{"*"},
+					Port:  &istiov1beta1.Port{Name: "https", Number: 443, Protocol: "HTTPS"},
+					Tls:   mutual,
+				}},
+			},
+		},
+		ing: ing,
+		want: []*securityv1beta1.AuthorizationPolicy{
+			policy(publicService, policyRules(&istiosecurity.Operation{Hosts: hosts, Ports: []string{"8443"}},
+				&istiosecurity.Source{NotPrincipals: []string{"*"}})...),
+		},
+		wantOpen: []string{"istio-system/istio-ingressgateway"},
+	}, {
+		name: "allowed namespaces",
+		config: &config.Istio{
+			EnableClusterLocalAuthorization: true,
+			ClusterLocalAllowedNamespaces:   []string{"monitoring"},
+		},
+		local:        true,
+		localGateway: localGateway(mutual),
+		ing:          ing,
+		want: []*securityv1beta1.AuthorizationPolicy{
+			policy(localService, append(meshOnly, policyRules(&istiosecurity.Operation{Hosts: hosts, Ports: []string{"8081"}},
+				&istiosecurity.Source{NotNamespaces: []string{system.Namespace(), "monitoring", "test-ns"}})...)...),
+			policy(publicService, &istiosecurity.Rule{To: to("8080", "8443")}),
+		},
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := config.ToContext(context.Background(), &config.Config{
+				Istio: c.config,
+			})
+			gateways := &PolicyGateways{
+				Services: map[v1alpha1.IngressVisibility][]config.Gateway{
+					v1alpha1.IngressVisibilityExternalIP: publicGateways,
+				},
+				Gateways: map[v1alpha1.IngressVisibility][]*v1beta1.Gateway{},
+			}
+			if c.local {
+				gateways.Services[v1alpha1.IngressVisibilityClusterLocal] = localGateways
+			}
+			if c.localGateway != nil {
+				gateways.Gateways[v1alpha1.IngressVisibilityClusterLocal] = []*v1beta1.Gateway{c.localGateway}
+			}
+			if c.publicGateway != nil {
+				gateways.Gateways[v1alpha1.IngressVisibilityExternalIP] = []*v1beta1.Gateway{c.publicGateway}
+			}
+			got, open, err := MakeClusterLocalAuthorizationPolicies(ctx, c.ing, gateways, svcLister)
+			if err != nil {
+				t.Fatal("MakeClusterLocalAuthorizationPolicies() =", err)
+			}
+			if diff := cmp.Diff(c.want, got); diff != "" {
+				t.Error("Unexpected AuthorizationPolicies (-want, +got):", diff)
+			}
+			if diff := cmp.Diff(c.wantOpen, open); diff != "" {
+				t.Error("Unexpected open gateways (-want, +got):", diff)
+			}
+		})
+	}
+}
+
+// TestMakeClusterLocalAuthorizationPoliciesSharedWorkloads checks the policies
+// on the local gateway shipped in config/203-local-gateway.yaml, which selects
+// the workloads of the public gateway of config/202-gateway.yaml.
+func TestMakeClusterLocalAuthorizationPoliciesSharedWorkloads(t *testing.T) {
+	ctx, cancel, _ := rtesting.SetupFakeContextWithCancel(t)
+	defer cancel()
+	var localGateway, publicGateway *v1beta1.Gateway
+	var localService *corev1.Service
+	for _, path := range []string{"../../../../config/202-gateway.yaml", "../../../../config/203-local-gateway.yaml"} {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal("Failed to read the shipped gateways:", err)
+		}
+		for _, doc := range strings.Split(string(b), "\n---\n") {
+			switch {
+			case strings.Contains(doc, "kind: Gateway"):
+				gw := &v1beta1.Gateway{}
+				if err := yaml.Unmarshal([]byte(doc), gw); err != nil {
+					t.Fatal("Failed to parse the shipped Gateway:", err)
+				}
+				if gw.Name == config.KnativeLocalGateway {
+					localGateway = gw
+				} else {
+					publicGateway = gw
+				}
+			case strings.Contains(doc, "kind: Service"):
+				localService = &corev1.Service{}
+				if err := yaml.Unmarshal([]byte(doc), localService); err != nil {
+					t.Fatal("Failed to parse the shipped Service:", err)
+				}
+			}
+		}
+	}
+	// The public gateway Service is shipped with Istio.
+	publicService := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "istio-ingressgateway",
+			Namespace: "istio-system",
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: publicGateway.Spec.Selector,
+			Ports: []corev1.ServicePort{{
+				Port:       80,
+				TargetPort: intstr.FromInt(8080),
+			}, {
+				Port:       443,
+				TargetPort: intstr.FromInt(8443),
+			}},
+		},
+	}
+	svcLister := serviceLister(ctx, publicService, localService)
+	gateways := &PolicyGateways{
+		Services: map[v1alpha1.IngressVisibility][]config.Gateway{
+			v1alpha1.IngressVisibilityExternalIP: {{
+				Namespace:  publicGateway.Namespace,
+				Name:       publicGateway.Name,
+				ServiceURL: "istio-ingressgateway.istio-system.svc.cluster.local",
+			}},
+			v1alpha1.IngressVisibilityClusterLocal: {{
+				Namespace:  localGateway.Namespace,
+				Name:       localGateway.Name,
+				ServiceURL: "knative-local-gateway.istio-system.svc.cluster.local",
+			}},
+		},
+		Gateways: map[v1alpha1.IngressVisibility][]*v1beta1.Gateway{
+			v1alpha1.IngressVisibilityExternalIP:   {publicGateway},
+			v1alpha1.IngressVisibilityClusterLocal: {localGateway},
+		},
+	}
+	ctx = config.ToContext(context.Background(), &config.Config{
+		Istio: &config.Istio{EnableClusterLocalAuthorization: true},
+	})
+	ing := &v1alpha1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ingress",
+			Namespace: "test-ns",
+		},
+		Spec: v1alpha1.IngressSpec{
+			Rules: []v1alpha1.IngressRule{{
+				Hosts:      []string{"foo.test-ns.svc.cluster.local"},
+				Visibility: v1alpha1.IngressVisibilityClusterLocal,
+			}},
+		},
+	}
+
+	got, _, err := MakeClusterLocalAuthorizationPolicies(ctx, ing, gateways, svcLister)
+	if err != nil {
+		t.Fatal("MakeClusterLocalAuthorizationPolicies() =", err)
+	}
+	// The plain HTTP port of the local gateway is left open, while the ports
+	// of the public gateway on the same workloads deny the cluster-local hosts.
+	want := []*securityv1beta1.AuthorizationPolicy{{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ns--ingress-istio-ingressgateway-cluster-local",
+			Namespace: "istio-system",
+			Labels:    MakeGatewayPolicyLabels(ing),
+		},
+		Spec: istiosecurity.AuthorizationPolicy{
+			Selector: &istiotype.WorkloadSelector{
+				MatchLabels: map[string]string{"istio": "ingressgateway"},
+			},
+			Action: istiosecurity.AuthorizationPolicy_DENY,
+			Rules: []*istiosecurity.Rule{{
+				To: []*istiosecurity.Rule_To{{
+					Operation: &istiosecurity.Operation{
+						Hosts: []string{
+							"foo.test-ns", "foo.test-ns.svc", "foo.test-ns.svc.cluster.local",
+							"foo.test-ns.svc.cluster.local:*", "foo.test-ns.svc:*", "foo.test-ns:*",
+						},
+						Ports: []string{"8080", "8443"},
+					},
+				}},
+			}},
+		},
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error("Unexpected AuthorizationPolicies (-want, +got):", diff)
+	}
+}
+
+func TestGetGatewayHosts(t *testing.T) {
+	ctx, cancel, _ := rtesting.SetupFakeContextWithCancel(t)
+	defer cancel()
+	domainSelector := map[string]string{"istio": "domain-gateway"}
+	domainService := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "domain-gateway",
+			Namespace: "istio-system",
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: domainSelector,
+		},
+	}
+	publicService := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "istio-ingressgateway",
+			Namespace: "istio-system",
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: selector,
+		},
+	}
+	svcLister := serviceLister(ctx, domainService, publicService)
+	ctx = config.ToContext(context.Background(), &config.Config{
+		Istio: &config.Istio{
+			IngressGateways: []config.Gateway{{
+				Namespace:  system.Namespace(),
+				Name:       config.KnativeIngressGateway,
+				ServiceURL: "istio-ingressgateway.istio-system.svc.cluster.local",
+			}},
+		},
+	})
+
+	httpServer := &istiov1beta1.Server{
+		Hosts: []string{"*.example.com"},
+		Port:  &istiov1beta1.Port{Name: "http", Number: 80, Protocol: "HTTP"},
+	}
+	httpsServer := &istiov1beta1.Server{
+		Hosts: []string{"foo.example.com"},
+		Port:  &istiov1beta1.Port{Name: "test-ns/ingress:0", Number: 443, Protocol: "HTTPS"},
+	}
+	// The Ingress is bound to the gateway of a Realm, and has a Knative
+	// generated Gateway on the gateway of config-istio.
+	gateways := &PolicyGateways{
+		Services: map[v1alpha1.IngressVisibility][]config.Gateway{
+			v1alpha1.IngressVisibilityExternalIP: {{
+				Namespace:  "istio-system",
+				Name:       "example-domain",
+				ServiceURL: "domain-gateway.istio-system.svc.cluster.local",
+			}},
+		},
+		Gateways: map[v1alpha1.IngressVisibility][]*v1beta1.Gateway{
+			v1alpha1.IngressVisibilityExternalIP: {{
+				Spec: istiov1beta1.Gateway{
+					Selector: domainSelector,
+					Servers:  []*istiov1beta1.Server{httpServer},
+				},
+			}},
+		},
+	}
+	gateways.AddGeneratedGateways(ctx, []*v1beta1.Gateway{{
+		Spec: istiov1beta1.Gateway{
+			Selector: selector,
+			Servers:  []*istiov1beta1.Server{httpsServer},
+		},
+	}})
+
+	ing := &v1alpha1.Ingress{
+		Spec: v1alpha1.IngressSpec{
+			Rules: []v1alpha1.IngressRule{{
+				Hosts:      []string{"foo.example.com"},
+				Visibility: v1alpha1.IngressVisibilityExternalIP,
+			}},
+		},
+	}
+	got, err := getGatewayHosts(gateways, ing, svcLister)
+	if err != nil {
+		t.Fatal("getGatewayHosts() =", err)
+	}
+	want := []*gatewayHosts{{
+		service: domainService,
+		hosts:   sets.NewString("foo.example.com"),
+		rules:   ing.Spec.Rules,
+		servers: []*istiov1beta1.Server{httpServer},
+	}, {
+		service: publicService,
+		hosts:   sets.NewString("foo.example.com"),
+		rules:   ing.Spec.Rules,
+		servers: []*istiov1beta1.Server{httpsServer},
+	}}
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(gatewayHosts{})); diff != "" {
+		t.Error("Unexpected gateway hosts (-want, +got):", diff)
+	}
+}
+
+func TestPolicyRules(t *testing.T) {
+	op := &istiosecurity.Operation{
+		Hosts: []string{"foo.example.com"},
+		Paths: []string{"/api/*"},
+	}
+	source := &istiosecurity.Source{NotPrincipals: []string{"*"}}
+	from := []*istiosecurity.Rule_From{{Source: source}}
+	claim := &istiosecurity.Condition{
+		Key:       "request.auth.claims[group]",
+		NotValues: []string{"admins"},
+	}
+
+	got := policyRules(op, source, claim)
+	want := []*istiosecurity.Rule{{
+		From: from,
+		To: []*istiosecurity.Rule_To{{
+			Operation: &istiosecurity.Operation{
+				Hosts:    []string{"foo.example.com"},
+				Paths:    []string{"/api/*"},
+				NotPaths: []string{"/healthz"},
+			},
+		}, {
+			Operation: &istiosecurity.Operation{
+				Hosts:      []string{"foo.example.com"},
+				Paths:      []string{"/api/*"},
+				NotMethods: []string{"GET"},
+			},
+		}},
+		When: []*istiosecurity.Condition{claim},
+	}, {
+		From: from,
+		To:   []*istiosecurity.Rule_To{{Operation: op}},
+		When: []*istiosecurity.Condition{claim, {
+			Key:       "request.headers[K-Network-Probe]",
+			NotValues: []string{"probe"},
+		}},
+	}, {
+		From: from,
+		To:   []*istiosecurity.Rule_To{{Operation: op}},
+		When: []*istiosecurity.Condition{claim, {
+			Key:       "request.headers[K-Network-Hash]",
+			NotValues: []string{"override"},
+		}},
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error("Unexpected rules (-want, +got):", diff)
+	}
+	if len(op.NotPaths) != 0 || len(op.NotMethods) != 0 {
+		t.Errorf("policyRules() modified the operation: %v", op)
+	}
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// corsMethods are the HTTP methods that can be allowed in CORS requests.
+var corsMethods = sets.NewString("GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS", "CONNECT", "TRACE")
+
+// makeCORSPolicy parses the CORS policy of an Ingress from the given
+// annotations. It returns nil when CORS is not enabled.
+func makeCORSPolicy(annotations map[string]string) (*istiov1beta1.CorsPolicy, error) {
+	v, ok := annotations[CORSAllowOriginsAnnotationKey]
+	if !ok {
+		for _, key := range []string{CORSAllowMethodsAnnotationKey, CORSAllowHeadersAnnotationKey,
+			CORSExposeHeadersAnnotationKey, CORSAllowCredentialsAnnotationKey, CORSMaxAgeAnnotationKey} {
+			if _, ok := annotations[key]; ok {
+				return nil, fmt.Errorf("annotation %s requires annotation %s", key, CORSAllowOriginsAnnotationKey)
+			}
+		}
+		return nil, nil
+	}
+
+	policy := &istiov1beta1.CorsPolicy{}
+	anyOrigin := false
+	for _, origin := range splitList(v) {
+		match, err := parseCORSOrigin(origin)
+		if err != nil {
+			return nil, annotationError(CORSAllowOriginsAnnotationKey, v, err)
+		}
+		anyOrigin = anyOrigin || origin == "*"
+		policy.AllowOrigins = append(policy.AllowOrigins, match)
+	}
+	if len(policy.AllowOrigins) == 0 {
+		return nil, annotationError(CORSAllowOriginsAnnotationKey, v, errors.New("expected at least one origin"))
+	}
+
+	if v, ok := annotations[CORSAllowMethodsAnnotationKey]; ok {
+		for _, method := range splitList(v) {
+			if !corsMethods.Has(method) {
+				return nil, annotationError(CORSAllowMethodsAnnotationKey, v, fmt.Errorf("unknown method %q", method))
+			}
+			policy.AllowMethods = append(policy.AllowMethods, method)
+		}
+	}
+	if v, ok := annotations[CORSAllowHeadersAnnotationKey]; ok {
+		headers, err := parseHeaderNames(v)
+		if err != nil {
+			return nil, annotationError(CORSAllowHeadersAnnotationKey, v, err)
+		}
+		policy.AllowHeaders = headers
+	}
+	if v, ok := annotations[CORSExposeHeadersAnnotationKey]; ok {
+		headers, err := parseHeaderNames(v)
+		if err != nil {
+			return nil, annotationError(CORSExposeHeadersAnnotationKey, v, err)
+		}
+		policy.ExposeHeaders = headers
+	}
+	if v, ok := annotations[CORSAllowCredentialsAnnotationKey]; ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, annotationError(CORSAllowCredentialsAnnotationKey, v, err)
+		}
+		if b && anyOrigin {
+			return nil, fmt.Errorf("annotation %s cannot be set along with the * origin", CORSAllowCredentialsAnnotationKey)
+		}
+		policy.AllowCredentials = &types.BoolValue{Value: b}
+	}
+	if v, ok := annotations[CORSMaxAgeAnnotationKey]; ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, annotationError(CORSMaxAgeAnnotationKey, v, err)
+		}
+		if d < 0 {
+			return nil, annotationError(CORSMaxAgeAnnotationKey, v, errors.New("expected a non-negative duration"))
+		}
+		policy.MaxAge = types.DurationProto(d)
+	}
+	return policy, nil
+}
+
+// parseCORSOrigin parses an allowed origin into the match of the Origin
+// header of the CORS requests.
+func parseCORSOrigin(origin string) (*istiov1beta1.StringMatch, error) {
+	if origin == "*" {
+		return &istiov1beta1.StringMatch{
+			MatchType: &istiov1beta1.StringMatch_Regex{Regex: ".*"},
+		}, nil
+	}
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+		u.User != nil || u.Path != "" || u.RawQuery != "" || u.Fragment != "" {
+		return nil, fmt.Errorf("invalid origin %q, expected <scheme>://<host>[:<port>]", origin)
+	}
+	host := u.Hostname()
+	if domain := strings.TrimPrefix(host, "*."); domain != host {
+		if errs := validation.IsDNS1123Subdomain(domain); len(errs) != 0 {
+			return nil, fmt.Errorf("invalid origin %q: %s", origin, strings.Join(errs, ", "))
+		}
+		// The wildcard matches any subdomain, of any depth.
+		return &istiov1beta1.StringMatch{
+			MatchType: &istiov1beta1.StringMatch_Regex{
+				Regex: regexp.QuoteMeta(u.Scheme+"://") + `[a-z0-9.-]+` + regexp.QuoteMeta(strings.TrimPrefix(u.Host, "*")),
+			},
+		}, nil
+	}
+	if errs := validation.IsDNS1123Subdomain(host); len(errs) != 0 && net.ParseIP(host) == nil {
+		return nil, fmt.Errorf("invalid origin %q: %s", origin, strings.Join(errs, ", "))
+	}
+	return &istiov1beta1.StringMatch{
+		MatchType: &istiov1beta1.StringMatch_Exact{Exact: origin},
+	}, nil
+}
+
+// parseHeaderNames parses a comma separated list of header names.
+func parseHeaderNames(v string) ([]string, error) {
+	var headers []string
+	for _, header := range splitList(v) {
+		if errs := validation.IsHTTPHeaderName(header); len(errs) != 0 {
+			return nil, fmt.Errorf("invalid header %q: %s", header, strings.Join(errs, ", "))
+		}
+		headers = append(headers, header)
+	}
+	return headers, nil
+}
+
+// splitList splits a comma separated list, trimming its items and skipping
+// the empty ones.
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
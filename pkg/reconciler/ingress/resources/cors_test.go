@@ -0,0 +1,157 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/google/go-cmp/cmp"
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+)
+
+func TestMakeCORSPolicy(t *testing.T) {
+	exact := func(origin string) *istiov1beta1.StringMatch {
+		return &istiov1beta1.StringMatch{
+			MatchType: &istiov1beta1.StringMatch_Exact{Exact: origin},
+		}
+	}
+	regex := func(regex string) *istiov1beta1.StringMatch {
+		return &istiov1beta1.StringMatch{
+			MatchType: &istiov1beta1.StringMatch_Regex{Regex: regex},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        *istiov1beta1.CorsPolicy
+		wantErr     bool
+	}{{
+		name: "no CORS",
+	}, {
+		name:        "any origin",
+		annotations: map[string]string{CORSAllowOriginsAnnotationKey: "*"},
+		want: &istiov1beta1.CorsPolicy{
+			AllowOrigins: []*istiov1beta1.StringMatch{regex(".*")},
+		},
+	}, {
+		name: "full policy",
+		annotations: map[string]string{
+			CORSAllowOriginsAnnotationKey:     "https://app.example.com, http://*.example.com:8080,http://10.0.0.1",
+			CORSAllowMethodsAnnotationKey:     "GET,POST, OPTIONS",
+			CORSAllowHeadersAnnotationKey:     "Authorization, Content-Type",
+			CORSExposeHeadersAnnotationKey:    "X-Request-Id",
+			CORSAllowCredentialsAnnotationKey: "true",
+			CORSMaxAgeAnnotationKey:           "24h",
+		},
+		want: &istiov1beta1.CorsPolicy{
+			AllowOrigins: []*istiov1beta1.StringMatch{
+				exact("https://app.example.com"),
+				regex(`http://[a-z0-9.-]+\.example\.com:8080`),
+				exact("http://10.0.0.1"),
+			},
+			AllowMethods:     []string{"GET", "POST", "OPTIONS"},
+			AllowHeaders:     []string{"Authorization", "Content-Type"},
+			ExposeHeaders:    []string{"X-Request-Id"},
+			AllowCredentials: &types.BoolValue{Value: true},
+			MaxAge:           &types.Duration{Seconds: 24 * 60 * 60},
+		},
+	}, {
+		name:        "origin with path",
+		annotations: map[string]string{CORSAllowOriginsAnnotationKey: "https://example.com/app"},
+		wantErr:     true,
+	}, {
+		name:        "origin without scheme",
+		annotations: map[string]string{CORSAllowOriginsAnnotationKey: "example.com"},
+		wantErr:     true,
+	}, {
+		name:        "invalid wildcard origin",
+		annotations: map[string]string{CORSAllowOriginsAnnotationKey: "https://*"},
+		wantErr:     true,
+	}, {
+		name:        "no origin",
+		annotations: map[string]string{CORSAllowOriginsAnnotationKey: " , "},
+		wantErr:     true,
+	}, {
+		name: "unknown method",
+		annotations: map[string]string{
+			CORSAllowOriginsAnnotationKey: "*",
+			CORSAllowMethodsAnnotationKey: "get",
+		},
+		wantErr: true,
+	}, {
+		name: "invalid header",
+		annotations: map[string]string{
+			CORSAllowOriginsAnnotationKey: "*",
+			CORSAllowHeadersAnnotationKey: "X Header",
+		},
+		wantErr: true,
+	}, {
+		name: "credentials with any origin",
+		annotations: map[string]string{
+			CORSAllowOriginsAnnotationKey:     "*",
+			CORSAllowCredentialsAnnotationKey: "true",
+		},
+		wantErr: true,
+	}, {
+		name: "invalid max age",
+		annotations: map[string]string{
+			CORSAllowOriginsAnnotationKey: "*",
+			CORSMaxAgeAnnotationKey:       "1 day",
+		},
+		wantErr: true,
+	}, {
+		name:        "CORS setting without origins",
+		annotations: map[string]string{CORSAllowMethodsAnnotationKey: "GET"},
+		wantErr:     true,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := makeCORSPolicy(tc.annotations)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("makeCORSPolicy() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Error("Unexpected CORS policy (-want +got):", diff)
+			}
+		})
+	}
+}
+
+func TestParseCORSOrigin_Wildcard(t *testing.T) {
+	match, err := parseCORSOrigin("https://*.example.com")
+	if err != nil {
+		t.Fatal("parseCORSOrigin() =", err)
+	}
+	// Envoy matches the whole Origin header against the regex.
+	re := regexp.MustCompile("^(?:" + match.GetRegex() + ")$")
+	for origin, want := range map[string]bool{
+		"https://app.example.com":      true,
+		"https://a.b.example.com":      true,
+		"https://example.com":          false,
+		"http://app.example.com":       false,
+		"https://app.example.com.evil": false,
+		"https://appxexample.com":      false,
+	} {
+		if got := re.MatchString(origin); got != want {
+			t.Errorf("Origin %q matches = %v, wanted %v", origin, got, want)
+		}
+	}
+}
@@ -0,0 +1,349 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	"istio.io/client-go/pkg/apis/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"knative.dev/net-istio/pkg/reconciler/ingress/config"
+	"knative.dev/net-istio/pkg/reconciler/ingress/resources/names"
+	"knative.dev/networking/pkg/apis/networking"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/pkg/network"
+)
+
+// The names of the traffic policy settings. They are the suffixes of both the
+// Ingress annotations and the `traffic-policy.*` keys of config-istio.
+const (
+	loadBalancerSetting             = "load-balancer"
+	maxConnectionsSetting           = "max-connections"
+	maxPendingRequestsSetting       = "max-pending-requests"
+	maxRequestsSetting              = "max-requests"
+	outlierConsecutiveErrorsSetting = "outlier-consecutive-errors"
+	outlierBaseEjectionTimeSetting  = "outlier-base-ejection-time"
+	upstreamTLSSetting              = "upstream-tls"
+)
+
+// trafficPolicySettings lists the traffic policy settings along with the
+// function applying their value to a TrafficPolicy.
+var trafficPolicySettings = []struct {
+	name  string
+	apply func(*istiov1beta1.TrafficPolicy, string) error
+}{
+	{loadBalancerSetting, applyLoadBalancer},
+	{maxConnectionsSetting, applyMaxConnections},
+	{maxPendingRequestsSetting, applyMaxPendingRequests},
+	{maxRequestsSetting, applyMaxRequests},
+	{outlierConsecutiveErrorsSetting, applyOutlierConsecutiveErrors},
+	{outlierBaseEjectionTimeSetting, applyOutlierBaseEjectionTime},
+	{upstreamTLSSetting, applyUpstreamTLS},
+}
+
+// MakeDestinationRules creates a DestinationRule applying the traffic policy
+// of the given Ingress to each of its backend Services. No DestinationRule is
+// created when neither the Ingress nor config-istio set a traffic policy.
+//
+// Owner references cannot cross namespaces, so only the backends living next
+// to the Ingress get a DestinationRule: the `<namespace>/<name>` of the others
+// are returned as skipped. The DestinationRules are named after their backend,
+// so that the Ingresses routing to the same backend cannot program competing
+// DestinationRules for it: the first Ingress owns it, and the others are
+// reported as not owning it.
+func MakeDestinationRules(ctx context.Context, ing *v1alpha1.Ingress) ([]*v1beta1.DestinationRule, []string, error) {
+	policy, err := makeTrafficPolicy(ctx, ing)
+	if err != nil || policy == nil {
+		return nil, nil, err
+	}
+
+	services, skipped := sets.NewString(), sets.NewString()
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			for _, split := range path.Splits {
+				if split.ServiceNamespace != ing.Namespace {
+					skipped.Insert(split.ServiceNamespace + "/" + split.ServiceName)
+					continue
+				}
+				services.Insert(split.ServiceName)
+			}
+		}
+	}
+
+	drs := make([]*v1beta1.DestinationRule, 0, services.Len())
+	for _, service := range services.List() {
+		drs = append(drs, &v1beta1.DestinationRule{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            names.DestinationRule(service),
+				Namespace:       ing.Namespace,
+				OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(ing)},
+				Labels: map[string]string{
+					networking.IngressLabelKey: ing.Name,
+				},
+			},
+			Spec: istiov1beta1.DestinationRule{
+				Host:          network.GetServiceHostname(service, ing.Namespace),
+				TrafficPolicy: proto.Clone(policy).(*istiov1beta1.TrafficPolicy),
+			},
+		})
+	}
+	return drs, skipped.List(), nil
+}
+
+// makeTrafficPolicy parses the traffic policy from the annotations of the given
+// Ingress, falling back to the defaults configured in config-istio. An empty
+// annotation value opts the Ingress out of the default of that setting.
+func makeTrafficPolicy(ctx context.Context, ing *v1alpha1.Ingress) (*istiov1beta1.TrafficPolicy, error) {
+	defaults := config.FromContextOrDefaults(ctx).Istio.TrafficPolicy
+	annotations := ing.GetAnnotations()
+
+	var policy *istiov1beta1.TrafficPolicy
+	for _, setting := range trafficPolicySettings {
+		key := annotationPrefix + setting.name
+		v, annotated := annotations[key]
+		if !annotated {
+			v = defaults[setting.name]
+		}
+		if v == "" {
+			continue
+		}
+		if policy == nil {
+			policy = &istiov1beta1.TrafficPolicy{}
+		}
+		if err := setting.apply(policy, v); err != nil {
+			if annotated {
+				return nil, annotationError(key, v, err)
+			}
+			return nil, fmt.Errorf("invalid value %q for traffic-policy.%s in config-istio: %w", v, setting.name, err)
+		}
+	}
+
+	hash, err := makeConsistentHash(annotations)
+	if err != nil || hash == nil {
+		return policy, err
+	}
+	if v := annotations[LoadBalancerAnnotationKey]; v != "" {
+		return nil, annotationError(LoadBalancerAnnotationKey, v, errors.New("conflicts with the session affinity"))
+	}
+	if policy == nil {
+		policy = &istiov1beta1.TrafficPolicy{}
+	}
+	policy.LoadBalancer = &istiov1beta1.LoadBalancerSettings{
+		LbPolicy: &istiov1beta1.LoadBalancerSettings_ConsistentHash{
+			ConsistentHash: hash,
+		},
+	}
+	return policy, nil
+}
+
+// makeConsistentHash returns the consistent hash load balancer of the session
+// affinity annotations of an Ingress, or nil when none is set.
+func makeConsistentHash(annotations map[string]string) (*istiov1beta1.LoadBalancerSettings_ConsistentHashLB, error) {
+	var hash *istiov1beta1.LoadBalancerSettings_ConsistentHashLB
+	var hashKey string
+	setHash := func(key string, h *istiov1beta1.LoadBalancerSettings_ConsistentHashLB) error {
+		if hash != nil {
+			return annotationError(key, annotations[key], fmt.Errorf("conflicts with %s", hashKey))
+		}
+		hash, hashKey = h, key
+		return nil
+	}
+
+	if v := annotations[SessionAffinityCookieAnnotationKey]; v != "" {
+		ttl := time.Duration(0)
+		if ttlValue := annotations[SessionAffinityCookieTTLAnnotationKey]; ttlValue != "" {
+			d, err := time.ParseDuration(ttlValue)
+			if err != nil {
+				return nil, annotationError(SessionAffinityCookieTTLAnnotationKey, ttlValue, err)
+			}
+			if d <= 0 {
+				return nil, annotationError(SessionAffinityCookieTTLAnnotationKey, ttlValue, errors.New("expected a positive duration"))
+			}
+			ttl = d
+		}
+		if err := setHash(SessionAffinityCookieAnnotationKey, &istiov1beta1.LoadBalancerSettings_ConsistentHashLB{
+			HashKey: &istiov1beta1.LoadBalancerSettings_ConsistentHashLB_HttpCookie{
+				HttpCookie: &istiov1beta1.LoadBalancerSettings_ConsistentHashLB_HTTPCookie{
+					Name: v,
+					Path: "/",
+					// A zero TTL makes the gateways set a session cookie.
+					Ttl: types.DurationProto(ttl),
+				},
+			},
+		}); err != nil {
+			return nil, err
+		}
+	} else if v := annotations[SessionAffinityCookieTTLAnnotationKey]; v != "" {
+		return nil, annotationError(SessionAffinityCookieTTLAnnotationKey, v,
+			fmt.Errorf("requires %s", SessionAffinityCookieAnnotationKey))
+	}
+
+	if v := annotations[SessionAffinityHeaderAnnotationKey]; v != "" {
+		if errs := validation.IsHTTPHeaderName(v); len(errs) != 0 {
+			return nil, annotationError(SessionAffinityHeaderAnnotationKey, v, errors.New(strings.Join(errs, ", ")))
+		}
+		if err := setHash(SessionAffinityHeaderAnnotationKey, &istiov1beta1.LoadBalancerSettings_ConsistentHashLB{
+			HashKey: &istiov1beta1.LoadBalancerSettings_ConsistentHashLB_HttpHeaderName{
+				HttpHeaderName: v,
+			},
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if v := annotations[SessionAffinitySourceIPAnnotationKey]; v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, annotationError(SessionAffinitySourceIPAnnotationKey, v, err)
+		}
+		if b {
+			if err := setHash(SessionAffinitySourceIPAnnotationKey, &istiov1beta1.LoadBalancerSettings_ConsistentHashLB{
+				HashKey: &istiov1beta1.LoadBalancerSettings_ConsistentHashLB_UseSourceIp{
+					UseSourceIp: true,
+				},
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return hash, nil
+}
+
+func applyLoadBalancer(policy *istiov1beta1.TrafficPolicy, v string) error {
+	lb, ok := istiov1beta1.LoadBalancerSettings_SimpleLB_value[v]
+	if !ok {
+		return errors.New("expected one of ROUND_ROBIN, LEAST_CONN, RANDOM or PASSTHROUGH")
+	}
+	policy.LoadBalancer = &istiov1beta1.LoadBalancerSettings{
+		LbPolicy: &istiov1beta1.LoadBalancerSettings_Simple{
+			Simple: istiov1beta1.LoadBalancerSettings_SimpleLB(lb),
+		},
+	}
+	return nil
+}
+
+func applyMaxConnections(policy *istiov1beta1.TrafficPolicy, v string) error {
+	n, err := parsePositiveInt32(v)
+	if err != nil {
+		return err
+	}
+	pool := connectionPool(policy)
+	if pool.Tcp == nil {
+		pool.Tcp = &istiov1beta1.ConnectionPoolSettings_TCPSettings{}
+	}
+	pool.Tcp.MaxConnections = n
+	return nil
+}
+
+func applyMaxPendingRequests(policy *istiov1beta1.TrafficPolicy, v string) error {
+	n, err := parsePositiveInt32(v)
+	if err != nil {
+		return err
+	}
+	httpSettings(policy).Http1MaxPendingRequests = n
+	return nil
+}
+
+func applyMaxRequests(policy *istiov1beta1.TrafficPolicy, v string) error {
+	n, err := parsePositiveInt32(v)
+	if err != nil {
+		return err
+	}
+	httpSettings(policy).Http2MaxRequests = n
+	return nil
+}
+
+func applyOutlierConsecutiveErrors(policy *istiov1beta1.TrafficPolicy, v string) error {
+	n, err := strconv.ParseUint(v, 10, 32)
+	if err != nil {
+		return err
+	}
+	outlierDetection(policy).Consecutive_5XxErrors = &types.UInt32Value{Value: uint32(n)}
+	return nil
+}
+
+func applyOutlierBaseEjectionTime(policy *istiov1beta1.TrafficPolicy, v string) error {
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return err
+	}
+	if d <= 0 {
+		return errors.New("expected a positive duration")
+	}
+	outlierDetection(policy).BaseEjectionTime = types.DurationProto(d)
+	return nil
+}
+
+func applyUpstreamTLS(policy *istiov1beta1.TrafficPolicy, v string) error {
+	// MUTUAL is left out as it needs client certificates that the Ingress
+	// cannot reference.
+	switch v {
+	case "DISABLE", "SIMPLE", "ISTIO_MUTUAL":
+		policy.Tls = &istiov1beta1.ClientTLSSettings{
+			Mode: istiov1beta1.ClientTLSSettings_TLSmode(istiov1beta1.ClientTLSSettings_TLSmode_value[v]),
+		}
+		return nil
+	}
+	return errors.New("expected one of DISABLE, SIMPLE or ISTIO_MUTUAL")
+}
+
+func connectionPool(policy *istiov1beta1.TrafficPolicy) *istiov1beta1.ConnectionPoolSettings {
+	if policy.ConnectionPool == nil {
+		policy.ConnectionPool = &istiov1beta1.ConnectionPoolSettings{}
+	}
+	return policy.ConnectionPool
+}
+
+func httpSettings(policy *istiov1beta1.TrafficPolicy) *istiov1beta1.ConnectionPoolSettings_HTTPSettings {
+	pool := connectionPool(policy)
+	if pool.Http == nil {
+		pool.Http = &istiov1beta1.ConnectionPoolSettings_HTTPSettings{}
+	}
+	return pool.Http
+}
+
+func outlierDetection(policy *istiov1beta1.TrafficPolicy) *istiov1beta1.OutlierDetection {
+	if policy.OutlierDetection == nil {
+		policy.OutlierDetection = &istiov1beta1.OutlierDetection{}
+	}
+	return policy.OutlierDetection
+}
+
+func parsePositiveInt32(v string) (int32, error) {
+	n, err := strconv.ParseInt(v, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	if n <= 0 {
+		return 0, errors.New("expected a positive integer")
+	}
+	return int32(n), nil
+}
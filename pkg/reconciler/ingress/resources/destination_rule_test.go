@@ -0,0 +1,328 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/google/go-cmp/cmp"
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	"istio.io/client-go/pkg/apis/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/net-istio/pkg/reconciler/ingress/config"
+	"knative.dev/networking/pkg/apis/networking"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/pkg/kmeta"
+	. "knative.dev/pkg/logging/testing"
+)
+
+func TestMakeDestinationRules(t *testing.T) {
+	ing := &v1alpha1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ingress",
+			Namespace: "test-ns",
+		},
+		Spec: v1alpha1.IngressSpec{
+			Rules: []v1alpha1.IngressRule{{
+				Hosts: []string{"foo.example.com"},
+				HTTP: &v1alpha1.HTTPIngressRuleValue{
+					Paths: []v1alpha1.HTTPIngressPath{{
+						Splits: []v1alpha1.IngressBackendSplit{{
+							IngressBackend: v1alpha1.IngressBackend{
+								ServiceNamespace: "test-ns",
+								ServiceName:      "v2-service",
+							},
+							Percent: 50,
+						}, {
+							IngressBackend: v1alpha1.IngressBackend{
+								ServiceNamespace: "test-ns",
+								ServiceName:      "v1-service",
+							},
+							Percent: 50,
+						}},
+					}, {
+						Path: "/other",
+						Splits: []v1alpha1.IngressBackendSplit{{
+							IngressBackend: v1alpha1.IngressBackend{
+								ServiceNamespace: "test-ns",
+								ServiceName:      "v1-service",
+							},
+							Percent: 100,
+						}},
+					}},
+				},
+			}, {
+				Hosts: []string{"bar.example.com"},
+				HTTP: &v1alpha1.HTTPIngressRuleValue{
+					Paths: []v1alpha1.HTTPIngressPath{{
+						Splits: []v1alpha1.IngressBackendSplit{{
+							IngressBackend: v1alpha1.IngressBackend{
+								ServiceNamespace: "other-ns",
+								ServiceName:      "elsewhere",
+							},
+							Percent: 100,
+						}},
+					}},
+				},
+			}},
+		},
+	}
+
+	leastConn := &istiov1beta1.TrafficPolicy{
+		LoadBalancer: &istiov1beta1.LoadBalancerSettings{
+			LbPolicy: &istiov1beta1.LoadBalancerSettings_Simple{
+				Simple: istiov1beta1.LoadBalancerSettings_LEAST_CONN,
+			},
+		},
+	}
+	destinationRule := func(service string, policy *istiov1beta1.TrafficPolicy) *v1beta1.DestinationRule {
+		return &v1beta1.DestinationRule{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            service + "-traffic-policy",
+				Namespace:       "test-ns",
+				OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(ing)},
+				Labels: map[string]string{
+					networking.IngressLabelKey: "ingress",
+				},
+			},
+			Spec: istiov1beta1.DestinationRule{
+				Host:          service + ".test-ns.svc.cluster.local",
+				TrafficPolicy: policy,
+			},
+		}
+	}
+
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		defaults    map[string]string
+		want        []*v1beta1.DestinationRule
+		wantErr     bool
+	}{{
+		name: "no traffic policy",
+	}, {
+		name:     "default traffic policy",
+		defaults: map[string]string{"load-balancer": "LEAST_CONN"},
+		want: []*v1beta1.DestinationRule{
+			destinationRule("v1-service", leastConn),
+			destinationRule("v2-service", leastConn),
+		},
+	}, {
+		name:        "annotation opts out of the default",
+		annotations: map[string]string{LoadBalancerAnnotationKey: ""},
+		defaults:    map[string]string{"load-balancer": "LEAST_CONN"},
+	}, {
+		name: "annotations override the defaults",
+		annotations: map[string]string{
+			LoadBalancerAnnotationKey:             "RANDOM",
+			MaxConnectionsAnnotationKey:           "100",
+			MaxPendingRequestsAnnotationKey:       "10",
+			MaxRequestsAnnotationKey:              "1000",
+			OutlierConsecutiveErrorsAnnotationKey: "5",
+			OutlierBaseEjectionTimeAnnotationKey:  "30s",
+			UpstreamTLSAnnotationKey:              "ISTIO_MUTUAL",
+		},
+		defaults: map[string]string{
+			"load-balancer":   "LEAST_CONN",
+			"max-connections": "10",
+		},
+		want: func() []*v1beta1.DestinationRule {
+			policy := &istiov1beta1.TrafficPolicy{
+				LoadBalancer: &istiov1beta1.LoadBalancerSettings{
+					LbPolicy: &istiov1beta1.LoadBalancerSettings_Simple{
+						Simple: istiov1beta1.LoadBalancerSettings_RANDOM,
+					},
+				},
+				ConnectionPool: &istiov1beta1.ConnectionPoolSettings{
+					Tcp: &istiov1beta1.ConnectionPoolSettings_TCPSettings{
+						MaxConnections: 100,
+					},
+					Http: &istiov1beta1.ConnectionPoolSettings_HTTPSettings{
+						Http1MaxPendingRequests: 10,
+						Http2MaxRequests:        1000,
+					},
+				},
+				OutlierDetection: &istiov1beta1.OutlierDetection{
+					Consecutive_5XxErrors: &types.UInt32Value{Value: 5},
+					BaseEjectionTime:      types.DurationProto(30 * time.Second),
+				},
+				Tls: &istiov1beta1.ClientTLSSettings{
+					Mode: istiov1beta1.ClientTLSSettings_ISTIO_MUTUAL,
+				},
+			}
+			return []*v1beta1.DestinationRule{
+				destinationRule("v1-service", policy),
+				destinationRule("v2-service", policy),
+			}
+		}(),
+	}, {
+		name: "cookie session affinity overrides the default load balancer",
+		annotations: map[string]string{
+			SessionAffinityCookieAnnotationKey:    "session",
+			SessionAffinityCookieTTLAnnotationKey: "1h",
+			MaxConnectionsAnnotationKey:           "100",
+		},
+		defaults: map[string]string{"load-balancer": "LEAST_CONN"},
+		want: func() []*v1beta1.DestinationRule {
+			policy := &istiov1beta1.TrafficPolicy{
+				LoadBalancer: consistentHash(&istiov1beta1.LoadBalancerSettings_ConsistentHashLB{
+					HashKey: &istiov1beta1.LoadBalancerSettings_ConsistentHashLB_HttpCookie{
+						HttpCookie: &istiov1beta1.LoadBalancerSettings_ConsistentHashLB_HTTPCookie{
+							Name: "session",
+							Path: "/",
+							Ttl:  types.DurationProto(time.Hour),
+						},
+					},
+				}),
+				ConnectionPool: &istiov1beta1.ConnectionPoolSettings{
+					Tcp: &istiov1beta1.ConnectionPoolSettings_TCPSettings{
+						MaxConnections: 100,
+					},
+				},
+			}
+			return []*v1beta1.DestinationRule{
+				destinationRule("v1-service", policy),
+				destinationRule("v2-service", policy),
+			}
+		}(),
+	}, {
+		name:        "session cookie",
+		annotations: map[string]string{SessionAffinityCookieAnnotationKey: "session"},
+		want: func() []*v1beta1.DestinationRule {
+			policy := &istiov1beta1.TrafficPolicy{
+				LoadBalancer: consistentHash(&istiov1beta1.LoadBalancerSettings_ConsistentHashLB{
+					HashKey: &istiov1beta1.LoadBalancerSettings_ConsistentHashLB_HttpCookie{
+						HttpCookie: &istiov1beta1.LoadBalancerSettings_ConsistentHashLB_HTTPCookie{
+							Name: "session",
+							Path: "/",
+							Ttl:  types.DurationProto(0),
+						},
+					},
+				}),
+			}
+			return []*v1beta1.DestinationRule{
+				destinationRule("v1-service", policy),
+				destinationRule("v2-service", policy),
+			}
+		}(),
+	}, {
+		name:        "header session affinity",
+		annotations: map[string]string{SessionAffinityHeaderAnnotationKey: "X-User"},
+		want: func() []*v1beta1.DestinationRule {
+			policy := &istiov1beta1.TrafficPolicy{
+				LoadBalancer: consistentHash(&istiov1beta1.LoadBalancerSettings_ConsistentHashLB{
+					HashKey: &istiov1beta1.LoadBalancerSettings_ConsistentHashLB_HttpHeaderName{
+						HttpHeaderName: "X-User",
+					},
+				}),
+			}
+			return []*v1beta1.DestinationRule{
+				destinationRule("v1-service", policy),
+				destinationRule("v2-service", policy),
+			}
+		}(),
+	}, {
+		name:        "source IP session affinity",
+		annotations: map[string]string{SessionAffinitySourceIPAnnotationKey: "true"},
+		want: func() []*v1beta1.DestinationRule {
+			policy := &istiov1beta1.TrafficPolicy{
+				LoadBalancer: consistentHash(&istiov1beta1.LoadBalancerSettings_ConsistentHashLB{
+					HashKey: &istiov1beta1.LoadBalancerSettings_ConsistentHashLB_UseSourceIp{
+						UseSourceIp: true,
+					},
+				}),
+			}
+			return []*v1beta1.DestinationRule{
+				destinationRule("v1-service", policy),
+				destinationRule("v2-service", policy),
+			}
+		}(),
+	}, {
+		name:        "no source IP session affinity",
+		annotations: map[string]string{SessionAffinitySourceIPAnnotationKey: "false"},
+	}, {
+		name: "several session affinities",
+		annotations: map[string]string{
+			SessionAffinityCookieAnnotationKey: "session",
+			SessionAffinityHeaderAnnotationKey: "X-User",
+		},
+		wantErr: true,
+	}, {
+		name: "session affinity conflicts with the load balancer annotation",
+		annotations: map[string]string{
+			SessionAffinityHeaderAnnotationKey: "X-User",
+			LoadBalancerAnnotationKey:          "RANDOM",
+		},
+		wantErr: true,
+	}, {
+		name:        "cookie TTL without cookie",
+		annotations: map[string]string{SessionAffinityCookieTTLAnnotationKey: "1h"},
+		wantErr:     true,
+	}, {
+		name:        "invalid session affinity header",
+		annotations: map[string]string{SessionAffinityHeaderAnnotationKey: "X User"},
+		wantErr:     true,
+	}, {
+		name:        "invalid annotation",
+		annotations: map[string]string{MaxConnectionsAnnotationKey: "0"},
+		wantErr:     true,
+	}, {
+		name:        "mutual upstream TLS is not supported",
+		annotations: map[string]string{UpstreamTLSAnnotationKey: "MUTUAL"},
+		wantErr:     true,
+	}, {
+		name:     "invalid default",
+		defaults: map[string]string{"outlier-base-ejection-time": "forever"},
+		wantErr:  true,
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := config.ToContext(TestContextWithLogger(t), &config.Config{
+				Istio: &config.Istio{
+					TrafficPolicy: c.defaults,
+				},
+			})
+			ing := ing.DeepCopy()
+			ing.Annotations = c.annotations
+			got, skipped, err := MakeDestinationRules(ctx, ing)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("MakeDestinationRules() = %v, wantErr = %v", err, c.wantErr)
+			}
+			if len(got) == 0 && len(c.want) == 0 {
+				return
+			}
+			if diff := cmp.Diff(c.want, got); diff != "" {
+				t.Error("Unexpected DestinationRules (-want, +got):", diff)
+			}
+			// The backend living in another namespace is skipped.
+			if want := []string{"other-ns/elsewhere"}; !cmp.Equal(skipped, want) {
+				t.Errorf("MakeDestinationRules() skipped %v, wanted %v", skipped, want)
+			}
+		})
+	}
+}
+
+func consistentHash(hash *istiov1beta1.LoadBalancerSettings_ConsistentHashLB) *istiov1beta1.LoadBalancerSettings {
+	return &istiov1beta1.LoadBalancerSettings{
+		LbPolicy: &istiov1beta1.LoadBalancerSettings_ConsistentHash{
+			ConsistentHash: hash,
+		},
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	istiosecurity "istio.io/api/security/v1beta1"
+	istiotype "istio.io/api/type/v1beta1"
+	securityv1beta1 "istio.io/client-go/pkg/apis/security/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"knative.dev/net-istio/pkg/reconciler/ingress/config"
+	"knative.dev/net-istio/pkg/reconciler/ingress/resources/names"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+)
+
+// errUnknownExtAuthzProvider is returned when an Ingress refers to an
+// external authorization provider that is not configured in config-istio.
+var errUnknownExtAuthzProvider = errors.New("unknown external authorization provider")
+
+// IsUnknownExtAuthzProvider returns whether the given error reports an
+// external authorization provider that is not configured in config-istio.
+func IsUnknownExtAuthzProvider(err error) bool {
+	return errors.Is(err, errUnknownExtAuthzProvider)
+}
+
+// CheckExtAuthzProvider returns an error satisfying IsUnknownExtAuthzProvider
+// when the external authorization provider of the given Ingress, if any, is
+// not configured in config-istio.
+func CheckExtAuthzProvider(ctx context.Context, ing *v1alpha1.Ingress) error {
+	_, err := extAuthzProvider(ctx, ing)
+	return err
+}
+
+// extAuthzProvider returns the external authorization provider of the given
+// Ingress, if any.
+func extAuthzProvider(ctx context.Context, ing *v1alpha1.Ingress) (string, error) {
+	provider := ing.GetAnnotations()[ExtAuthzProviderAnnotationKey]
+	if provider == "" {
+		return "", nil
+	}
+	cfg := config.FromContext(ctx).Istio
+	if !sets.NewString(cfg.ExtAuthzProviders...).Has(provider) {
+		return "", fmt.Errorf("%w %q of annotation %s, expected one of %v", errUnknownExtAuthzProvider, provider,
+			ExtAuthzProviderAnnotationKey, cfg.ExtAuthzProviders)
+	}
+	return provider, nil
+}
+
+// MakeExtAuthzPolicies creates the CUSTOM AuthorizationPolicies delegating the
+// authorization of the requests for the hosts and paths of the given Ingress
+// to the external authorization provider of ExtAuthzProviderAnnotationKey. They
+// select the gateways serving the Ingress. The status probes are not delegated
+// so that the Ingress can become ready. The CUSTOM action requires Istio 1.9.
+//
+// Istio only supports a single external authorization provider per workload,
+// hence the Ingresses sharing a gateway should agree on their provider.
+func MakeExtAuthzPolicies(ctx context.Context, ing *v1alpha1.Ingress, gateways *PolicyGateways, svcLister corev1listers.ServiceLister) ([]*securityv1beta1.AuthorizationPolicy, error) {
+	provider, err := extAuthzProvider(ctx, ing)
+	if err != nil || provider == "" {
+		return nil, err
+	}
+	opts, err := makeRouteOptions(ctx, ing)
+	if err != nil {
+		return nil, err
+	}
+	gatewayHosts, err := getGatewayHosts(gateways, ing, svcLister)
+	if err != nil {
+		return nil, err
+	}
+
+	policies := make([]*securityv1beta1.AuthorizationPolicy, 0, len(gatewayHosts))
+	for _, gw := range gatewayHosts {
+		var rules []*istiosecurity.Rule
+		for _, rule := range gw.rules {
+			rules = append(rules, policyRules(&istiosecurity.Operation{
+				Hosts: policyHosts(sets.NewString(rule.Hosts...)),
+				Paths: policyPaths(rule, opts.pathMatchType),
+			}, nil)...)
+		}
+		policies = append(policies, &securityv1beta1.AuthorizationPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      names.ExtAuthzPolicy(ing, gw.service.Name),
+				Namespace: gw.service.Namespace,
+				Labels:    MakeGatewayPolicyLabels(ing),
+			},
+			Spec: istiosecurity.AuthorizationPolicy{
+				Selector: &istiotype.WorkloadSelector{
+					MatchLabels: gw.service.Spec.Selector,
+				},
+				Action: istiosecurity.AuthorizationPolicy_CUSTOM,
+				ActionDetail: &istiosecurity.AuthorizationPolicy_Provider{
+					Provider: &istiosecurity.AuthorizationPolicy_ExtensionProvider{
+						Name: provider,
+					},
+				},
+				Rules: rules,
+			},
+		})
+	}
+	return policies, nil
+}
+
+// policyPaths returns the paths of the given Ingress rule as matched by the
+// given match type, or nil when the rule matches any path. Since the
+// AuthorizationPolicies cannot match regexes, the regex paths are widened to
+// any path, thereby delegating the authorization of the whole hosts.
+func policyPaths(rule v1alpha1.IngressRule, matchType pathMatchType) []string {
+	if rule.HTTP == nil || matchType == pathMatchRegex {
+		return nil
+	}
+	paths := sets.NewString()
+	for _, path := range rule.HTTP.Paths {
+		if path.Path == "" || (path.Path == "/" && matchType != pathMatchExact) {
+			return nil
+		}
+		switch {
+		case matchType == pathMatchExact:
+			paths.Insert(path.Path)
+		case matchType == pathMatchSegmentPrefix && !strings.HasSuffix(path.Path, "/"):
+			paths.Insert(path.Path, path.Path+"/*")
+		default:
+			paths.Insert(path.Path + "*")
+		}
+	}
+	return paths.List()
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	istiosecurity "istio.io/api/security/v1beta1"
+	istiotype "istio.io/api/type/v1beta1"
+	securityv1beta1 "istio.io/client-go/pkg/apis/security/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/net-istio/pkg/reconciler/ingress/config"
+	"knative.dev/networking/pkg/apis/networking"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/pkg/system"
+
+	rtesting "knative.dev/pkg/reconciler/testing"
+)
+
+func TestMakeExtAuthzPolicies(t *testing.T) {
+	ctx, cancel, _ := rtesting.SetupFakeContextWithCancel(t)
+	defer cancel()
+	gatewayService := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "istio-ingressgateway",
+			Namespace: "istio-system",
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: selector,
+		},
+	}
+	svcLister := serviceLister(ctx, gatewayService)
+	ctx = config.ToContext(context.Background(), &config.Config{
+		Istio: &config.Istio{
+			ExtAuthzProviders: []string{"oauth2-proxy", "opa"},
+		},
+	})
+	gateways := &PolicyGateways{
+		Services: map[v1alpha1.IngressVisibility][]config.Gateway{
+			v1alpha1.IngressVisibilityExternalIP: {{
+				Namespace:  system.Namespace(),
+				Name:       config.KnativeIngressGateway,
+				ServiceURL: "istio-ingressgateway.istio-system.svc.cluster.local",
+			}},
+		},
+	}
+
+	ing := &v1alpha1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ingress",
+			Namespace: "test-ns",
+		},
+		Spec: v1alpha1.IngressSpec{
+			Rules: []v1alpha1.IngressRule{{
+				Hosts:      []string{"foo.example.com"},
+				Visibility: v1alpha1.IngressVisibilityExternalIP,
+				HTTP: &v1alpha1.HTTPIngressRuleValue{
+					Paths: []v1alpha1.HTTPIngressPath{{
+						Path: "/api",
+					}, {
+						Path: "/admin",
+					}},
+				},
+			}, {
+				Hosts:      []string{"bar.example.com"},
+				Visibility: v1alpha1.IngressVisibilityExternalIP,
+				HTTP: &v1alpha1.HTTPIngressRuleValue{
+					Paths: []v1alpha1.HTTPIngressPath{{}},
+				},
+			}},
+		},
+	}
+	policy := &securityv1beta1.AuthorizationPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ns--ingress-istio-ingressgateway-ext-authz",
+			Namespace: "istio-system",
+			Labels: map[string]string{
+				networking.IngressLabelKey: "ingress",
+				IngressNamespaceLabelKey:   "test-ns",
+			},
+		},
+		Spec: istiosecurity.AuthorizationPolicy{
+			Selector: &istiotype.WorkloadSelector{
+				MatchLabels: selector,
+			},
+			Action: istiosecurity.AuthorizationPolicy_CUSTOM,
+			ActionDetail: &istiosecurity.AuthorizationPolicy_Provider{
+				Provider: &istiosecurity.AuthorizationPolicy_ExtensionProvider{
+					Name: "opa",
+				},
+			},
+			Rules: append(policyRules(&istiosecurity.Operation{
+				Hosts: []string{"foo.example.com", "foo.example.com:*"},
+				Paths: []string{"/admin*", "/api*"},
+			}, nil), policyRules(&istiosecurity.Operation{
+				Hosts: []string{"bar.example.com", "bar.example.com:*"},
+			}, nil)...),
+		},
+	}
+
+	cases := []struct {
+		name     string
+		provider string
+		want     []*securityv1beta1.AuthorizationPolicy
+		wantErr  bool
+	}{{
+		name: "no provider",
+	}, {
+		name:     "provider",
+		provider: "opa",
+		want:     []*securityv1beta1.AuthorizationPolicy{policy},
+	}, {
+		name:     "unknown provider",
+		provider: "ext-authz",
+		wantErr:  true,
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ing := ing.DeepCopy()
+			if c.provider != "" {
+				ing.Annotations = map[string]string{ExtAuthzProviderAnnotationKey: c.provider}
+			}
+			got, err := MakeExtAuthzPolicies(ctx, ing, gateways, svcLister)
+			if IsUnknownExtAuthzProvider(err) != c.wantErr {
+				t.Fatalf("MakeExtAuthzPolicies() = %v, wantErr = %v", err, c.wantErr)
+			}
+			if diff := cmp.Diff(c.want, got); diff != "" {
+				t.Error("Unexpected AuthorizationPolicies (-want, +got):", diff)
+			}
+		})
+	}
+}
+
+func TestPolicyPaths(t *testing.T) {
+	rule := func(paths ...string) v1alpha1.IngressRule {
+		r := v1alpha1.IngressRule{HTTP: &v1alpha1.HTTPIngressRuleValue{}}
+		for _, p := range paths {
+			r.HTTP.Paths = append(r.HTTP.Paths, v1alpha1.HTTPIngressPath{Path: p})
+		}
+		return r
+	}
+
+	cases := []struct {
+		name      string
+		rule      v1alpha1.IngressRule
+		matchType pathMatchType
+		want      []string
+	}{{
+		name: "no HTTP",
+	}, {
+		name: "default",
+		rule: rule("/api", "/admin/"),
+		want: []string{"/admin/*", "/api*"},
+	}, {
+		name:      "prefix matching any path",
+		rule:      rule("/api", "/"),
+		matchType: pathMatchPrefix,
+	}, {
+		name:      "exact",
+		rule:      rule("/api", "/"),
+		matchType: pathMatchExact,
+		want:      []string{"/", "/api"},
+	}, {
+		name:      "segment-prefix",
+		rule:      rule("/api", "/admin/"),
+		matchType: pathMatchSegmentPrefix,
+		want:      []string{"/admin/*", "/api", "/api/*"},
+	}, {
+		name:      "regex",
+		rule:      rule("/api/v[0-9]+"),
+		matchType: pathMatchRegex,
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := policyPaths(c.rule, c.matchType); !cmp.Equal(got, c.want) {
+				t.Errorf("policyPaths() = %v, want: %v", got, c.want)
+			}
+		})
+	}
+}
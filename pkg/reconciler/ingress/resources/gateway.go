@@ -24,8 +24,8 @@ import (
 	"sort"
 	"strings"
 
-	istiov1alpha3 "istio.io/api/networking/v1alpha3"
-	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	"istio.io/client-go/pkg/apis/networking/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -41,22 +41,23 @@ import (
 	"knative.dev/pkg/tracker"
 )
 
-// GatewayHTTPPort is the HTTP port the gateways listen on.
+// GatewayHTTPPort and GatewayHTTPSPort are the HTTP and HTTPS ports the gateways listen on.
 const (
 	GatewayHTTPPort       = 80
+	GatewayHTTPSPort      = 443
 	dns1123LabelMaxLength = 63 // Public for testing only.
 	dns1123LabelFmt       = "[a-zA-Z0-9](?:[-a-zA-Z0-9]*[a-zA-Z0-9])?"
 )
 
 var httpServerPortName = "http-server"
 
-var gatewayGvk = v1alpha3.SchemeGroupVersion.WithKind("Gateway")
+var gatewayGvk = v1beta1.SchemeGroupVersion.WithKind("Gateway")
 
 // Istio Gateway requires to have at least one server. This placeholderServer is used when
 // all of the real servers are deleted.
-var placeholderServer = istiov1alpha3.Server{
+var placeholderServer = istiov1beta1.Server{
 	Hosts: []string{"place-holder.place-holder"},
-	Port: &istiov1alpha3.Port{
+	Port: &istiov1beta1.Port{
 		Name:     "place-holder",
 		Number:   9999,
 		Protocol: "HTTP",
@@ -66,8 +67,8 @@ var placeholderServer = istiov1alpha3.Server{
 var dns1123LabelRegexp = regexp.MustCompile("^" + dns1123LabelFmt + "$")
 
 // GetServers gets the `Servers` from `Gateway` that belongs to the given Ingress.
-func GetServers(gateway *v1alpha3.Gateway, ing *v1alpha1.Ingress) []*istiov1alpha3.Server {
-	servers := []*istiov1alpha3.Server{}
+func GetServers(gateway *v1beta1.Gateway, ing *v1alpha1.Ingress) []*istiov1beta1.Server {
+	servers := []*istiov1beta1.Server{}
 	for i := range gateway.Spec.Servers {
 		if belongsToIngress(gateway.Spec.Servers[i], ing) {
 			servers = append(servers, gateway.Spec.Servers[i])
@@ -77,17 +78,33 @@ func GetServers(gateway *v1alpha3.Gateway, ing *v1alpha1.Ingress) []*istiov1alph
 }
 
 // GetHTTPServer gets the HTTP `Server` from `Gateway`.
-func GetHTTPServer(gateway *v1alpha3.Gateway) *istiov1alpha3.Server {
+func GetHTTPServer(gateway *v1beta1.Gateway) *istiov1beta1.Server {
 	for _, server := range gateway.Spec.Servers {
-		// The server with "http" port is the default HTTP server.
-		if server.Port.Name == httpServerPortName || server.Port.Name == "http" {
+		if isDefaultHTTPServer(server) {
 			return server
 		}
 	}
 	return nil
 }
 
-func belongsToIngress(server *istiov1alpha3.Server, ing *v1alpha1.Ingress) bool {
+// GetIngressServers gets the `Servers` from `Gateway` that belong to any Ingress.
+func GetIngressServers(gateway *v1beta1.Gateway) []*istiov1beta1.Server {
+	servers := []*istiov1beta1.Server{}
+	for _, server := range gateway.Spec.Servers {
+		portNameSplits := strings.Split(server.Port.Name, ":")
+		if len(portNameSplits) == 2 && strings.Contains(portNameSplits[0], "/") {
+			servers = append(servers, server)
+		}
+	}
+	return servers
+}
+
+func isDefaultHTTPServer(server *istiov1beta1.Server) bool {
+	// The server with "http" port is the default HTTP server.
+	return server.Port.Name == httpServerPortName || server.Port.Name == "http"
+}
+
+func belongsToIngress(server *istiov1beta1.Server, ing *v1alpha1.Ingress) bool {
 	// The format of the portName should be "<namespace>/<ingress_name>:<number>".
 	// For example, default/routetest:0.
 	portNameSplits := strings.Split(server.Port.Name, ":")
@@ -97,30 +114,39 @@ func belongsToIngress(server *istiov1alpha3.Server, ing *v1alpha1.Ingress) bool
 	return portNameSplits[0] == portNamePrefix(ing.GetNamespace(), ing.GetName())
 }
 
-// SortServers sorts `Server` according to its port name.
-func SortServers(servers []*istiov1alpha3.Server) []*istiov1alpha3.Server {
+// SortServers sorts `Server` according to its port name. The default HTTP server
+// goes last: Istio programs a host with the first server of the Gateway matching
+// it on a port, so the Ingress specific HTTP servers take precedence over it.
+func SortServers(servers []*istiov1beta1.Server) []*istiov1beta1.Server {
 	sort.Slice(servers, func(i, j int) bool {
+		if isDefaultI, isDefaultJ := isDefaultHTTPServer(servers[i]), isDefaultHTTPServer(servers[j]); isDefaultI != isDefaultJ {
+			return isDefaultJ
+		}
 		return strings.Compare(servers[i].Port.Name, servers[j].Port.Name) < 0
 	})
 	return servers
 }
 
 // MakeIngressTLSGateways creates Gateways for a given Ingress.
-func MakeIngressTLSGateways(ctx context.Context, ing *v1alpha1.Ingress, ingressTLS []v1alpha1.IngressTLS, originSecrets map[string]*corev1.Secret, svcLister corev1listers.ServiceLister) ([]*v1alpha3.Gateway, error) {
+func MakeIngressTLSGateways(ctx context.Context, ing *v1alpha1.Ingress, ingressTLS []v1alpha1.IngressTLS, originSecrets map[string]*corev1.Secret, svcLister corev1listers.ServiceLister) ([]*v1beta1.Gateway, error) {
 	// No need to create Gateway if there is no related ingress TLS.
 	if len(ingressTLS) == 0 {
-		return []*v1alpha3.Gateway{}, nil
+		return []*v1beta1.Gateway{}, nil
 	}
 	gatewayServices, err := getGatewayServices(ctx, svcLister)
 	if err != nil {
 		return nil, err
 	}
-	gateways := make([]*v1alpha3.Gateway, len(gatewayServices))
+	mutual := ClientCASecretKey(ctx, ing) != ""
+	gateways := make([]*v1beta1.Gateway, len(gatewayServices))
 	for i, gatewayService := range gatewayServices {
 		gateway, err := makeIngressTLSGateway(ing, originSecrets, gatewayService.Spec.Selector, gatewayService)
 		if err != nil {
 			return nil, err
 		}
+		if mutual {
+			requireClientCertificates(gateway.Spec.Servers)
+		}
 		gateways[i] = gateway
 	}
 	return gateways, nil
@@ -129,15 +155,15 @@ func MakeIngressTLSGateways(ctx context.Context, ing *v1alpha1.Ingress, ingressT
 // MakeWildcardGateways creates gateways with wildcard hosts based on the wildcard secret information.
 // For each public ingress service, we will create a list of Gateways. Each Gateway of the list corresponds to a wildcard cert secret.
 func MakeWildcardGateways(ctx context.Context, originWildcardSecrets map[string]*corev1.Secret,
-	svcLister corev1listers.ServiceLister) ([]*v1alpha3.Gateway, error) {
+	svcLister corev1listers.ServiceLister) ([]*v1beta1.Gateway, error) {
 	if len(originWildcardSecrets) == 0 {
-		return []*v1alpha3.Gateway{}, nil
+		return []*v1beta1.Gateway{}, nil
 	}
 	gatewayServices, err := getGatewayServices(ctx, svcLister)
 	if err != nil {
 		return nil, err
 	}
-	gateways := []*v1alpha3.Gateway{}
+	gateways := []*v1beta1.Gateway{}
 	for _, gatewayService := range gatewayServices {
 		gws, err := makeWildcardGateways(ctx, originWildcardSecrets, gatewayService)
 		if err != nil {
@@ -149,8 +175,8 @@ func MakeWildcardGateways(ctx context.Context, originWildcardSecrets map[string]
 }
 
 func makeWildcardGateways(ctx context.Context, originWildcardSecrets map[string]*corev1.Secret,
-	gatewayService *corev1.Service) ([]*v1alpha3.Gateway, error) {
-	gateways := make([]*v1alpha3.Gateway, 0, len(originWildcardSecrets))
+	gatewayService *corev1.Service) ([]*v1beta1.Gateway, error) {
+	gateways := make([]*v1beta1.Gateway, 0, len(originWildcardSecrets))
 	for _, secret := range originWildcardSecrets {
 		hosts, err := GetHostsFromCertSecret(secret)
 		if err != nil {
@@ -162,32 +188,37 @@ func makeWildcardGateways(ctx context.Context, originWildcardSecrets map[string]
 		if secret.Namespace == gatewayService.Namespace {
 			credentialName = secret.Name
 		}
-		servers := []*istiov1alpha3.Server{{
+		servers := []*istiov1beta1.Server{{
 			Hosts: hosts,
-			Port: &istiov1alpha3.Port{
+			Port: &istiov1beta1.Port{
 				Name:     "https",
 				Number:   443,
 				Protocol: "HTTPS",
 			},
-			Tls: &istiov1alpha3.ServerTLSSettings{
-				Mode:              istiov1alpha3.ServerTLSSettings_SIMPLE,
+			Tls: &istiov1beta1.ServerTLSSettings{
+				Mode:              istiov1beta1.ServerTLSSettings_SIMPLE,
 				ServerCertificate: corev1.TLSCertKey,
 				PrivateKey:        corev1.TLSPrivateKeyKey,
 				CredentialName:    credentialName,
 			},
 		}}
+		// Wildcard Gateways are shared by Ingresses, so only the config-istio
+		// setting applies to them.
+		if config.FromContext(ctx).Istio.ClientCASecret != "" {
+			requireClientCertificates(servers)
+		}
 		httpServer := MakeHTTPServer(config.FromContext(ctx).Network.HTTPProtocol, hosts)
 		if httpServer != nil {
 			servers = append(servers, httpServer)
 		}
 		gvk := schema.GroupVersionKind{Version: "v1", Kind: "Secret"}
-		gateways = append(gateways, &v1alpha3.Gateway{
+		gateways = append(gateways, &v1beta1.Gateway{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:            WildcardGatewayName(secret.Name, gatewayService.Namespace, gatewayService.Name),
 				Namespace:       secret.Namespace,
 				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(secret, gvk)},
 			},
-			Spec: istiov1alpha3.Gateway{
+			Spec: istiov1beta1.Gateway{
 				Selector: gatewayService.Spec.Selector,
 				Servers:  servers,
 			},
@@ -212,7 +243,7 @@ func WildcardGatewayName(secretName, gatewayServiceNamespace, gatewayServiceName
 }
 
 // GetQualifiedGatewayNames return the qualified Gateway names for the given Gateways.
-func GetQualifiedGatewayNames(gateways []*v1alpha3.Gateway) []string {
+func GetQualifiedGatewayNames(gateways []*v1beta1.Gateway) []string {
 	result := make([]string, 0, len(gateways))
 	for _, gw := range gateways {
 		result = append(result, gw.Namespace+"/"+gw.Name)
@@ -221,7 +252,7 @@ func GetQualifiedGatewayNames(gateways []*v1alpha3.Gateway) []string {
 }
 
 // GatewayRef returns the Reference for a give Gateway.
-func GatewayRef(gw *v1alpha3.Gateway) tracker.Reference {
+func GatewayRef(gw *v1beta1.Gateway) tracker.Reference {
 	apiVersion, kind := gatewayGvk.ToAPIVersionAndKind()
 	return tracker.Reference{
 		APIVersion: apiVersion,
@@ -231,20 +262,33 @@ func GatewayRef(gw *v1alpha3.Gateway) tracker.Reference {
 	}
 }
 
-func makeIngressTLSGateway(ing *v1alpha1.Ingress, originSecrets map[string]*corev1.Secret, selector map[string]string, gatewayService *corev1.Service) (*v1alpha3.Gateway, error) {
-	ns := ing.GetNamespace()
-	if len(ns) == 0 {
-		ns = system.Namespace()
+// MakeIngressGateways creates Gateways with the given Servers for a given Ingress.
+func MakeIngressGateways(ctx context.Context, ing *v1alpha1.Ingress, servers []*istiov1beta1.Server, svcLister corev1listers.ServiceLister) ([]*v1beta1.Gateway, error) {
+	gatewayServices, err := getGatewayServices(ctx, svcLister)
+	if err != nil {
+		return nil, err
 	}
+	gateways := make([]*v1beta1.Gateway, len(gatewayServices))
+	for i, gatewayService := range gatewayServices {
+		gateways[i] = makeIngressGateway(ing, gatewayService.Spec.Selector, servers, gatewayService)
+	}
+	return gateways, nil
+}
+
+func makeIngressTLSGateway(ing *v1alpha1.Ingress, originSecrets map[string]*corev1.Secret, selector map[string]string, gatewayService *corev1.Service) (*v1beta1.Gateway, error) {
 	servers, err := MakeTLSServers(ing, ing.Spec.TLS, gatewayService.Namespace, originSecrets)
 	if err != nil {
 		return nil, err
 	}
-	hosts := sets.String{}
-	for _, rule := range ing.Spec.Rules {
-		hosts.Insert(rule.Hosts...)
+	return makeIngressGateway(ing, selector, servers, gatewayService), nil
+}
+
+func makeIngressGateway(ing *v1alpha1.Ingress, selector map[string]string, servers []*istiov1beta1.Server, gatewayService *corev1.Service) *v1beta1.Gateway {
+	ns := ing.GetNamespace()
+	if len(ns) == 0 {
+		ns = system.Namespace()
 	}
-	return &v1alpha3.Gateway{
+	return &v1beta1.Gateway{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            GatewayName(ing, gatewayService),
 			Namespace:       ns,
@@ -254,15 +298,20 @@ func makeIngressTLSGateway(ing *v1alpha1.Ingress, originSecrets map[string]*core
 				networking.IngressLabelKey: ing.GetName(),
 			},
 		},
-		Spec: istiov1alpha3.Gateway{
+		Spec: istiov1beta1.Gateway{
 			Selector: selector,
 			Servers:  servers,
 		},
-	}, nil
+	}
 }
 
 func getGatewayServices(ctx context.Context, svcLister corev1listers.ServiceLister) ([]*corev1.Service, error) {
-	ingressSvcMetas, err := GetIngressGatewaySvcNameNamespaces(ctx)
+	return gatewayServices(config.FromContext(ctx).Istio.IngressGateways, svcLister)
+}
+
+// gatewayServices returns the Services of the given gateways.
+func gatewayServices(gateways []config.Gateway, svcLister corev1listers.ServiceLister) ([]*corev1.Service, error) {
+	ingressSvcMetas, err := gatewaySvcNameNamespaces(gateways)
 	if err != nil {
 		return nil, err
 	}
@@ -289,11 +338,25 @@ func GatewayName(accessor kmeta.Accessor, gatewaySvc *corev1.Service) string {
 }
 
 // MakeTLSServers creates the expected Gateway TLS `Servers` based on the given IngressTLS.
-func MakeTLSServers(ing *v1alpha1.Ingress, ingressTLS []v1alpha1.IngressTLS, gatewayServiceNamespace string, originSecrets map[string]*corev1.Secret) ([]*istiov1alpha3.Server, error) {
-	servers := make([]*istiov1alpha3.Server, len(ingressTLS))
+func MakeTLSServers(ing *v1alpha1.Ingress, ingressTLS []v1alpha1.IngressTLS, gatewayServiceNamespace string, originSecrets map[string]*corev1.Secret) ([]*istiov1beta1.Server, error) {
+	passthrough, err := parseTLSPassthroughHosts(ing)
+	if err != nil {
+		return nil, err
+	}
+	servers := make([]*istiov1beta1.Server, 0, len(ingressTLS))
 	// TODO(zhiminx): for the hosts that does not included in the IngressTLS but listed in the IngressRule,
 	// do we consider them as hosts for HTTP?
 	for i, tls := range ingressTLS {
+		// The TLS of the passthrough hosts is terminated by their backends.
+		hosts := make([]string, 0, len(tls.Hosts))
+		for _, host := range tls.Hosts {
+			if !passthrough.Has(host) {
+				hosts = append(hosts, host)
+			}
+		}
+		if len(hosts) == 0 {
+			continue
+		}
 		credentialName := tls.SecretName
 		// If the origin secret is not in the target namespace, then it should have been
 		// copied into the target namespace. So we use the name of the copy.
@@ -305,24 +368,35 @@ func MakeTLSServers(ing *v1alpha1.Ingress, ingressTLS []v1alpha1.IngressTLS, gat
 			credentialName = targetSecret(originSecret, ing)
 		}
 
-		servers[i] = &istiov1alpha3.Server{
-			Hosts: tls.Hosts,
-			Port: &istiov1alpha3.Port{
+		servers = append(servers, &istiov1beta1.Server{
+			Hosts: hosts,
+			Port: &istiov1beta1.Port{
 				Name:     fmt.Sprintf(portNamePrefix(ing.GetNamespace(), ing.GetName())+":%d", i),
-				Number:   443,
+				Number:   GatewayHTTPSPort,
 				Protocol: "HTTPS",
 			},
-			Tls: &istiov1alpha3.ServerTLSSettings{
-				Mode:              istiov1alpha3.ServerTLSSettings_SIMPLE,
+			Tls: &istiov1beta1.ServerTLSSettings{
+				Mode:              istiov1beta1.ServerTLSSettings_SIMPLE,
 				ServerCertificate: corev1.TLSCertKey,
 				PrivateKey:        corev1.TLSPrivateKeyKey,
 				CredentialName:    credentialName,
 			},
-		}
+		})
 	}
 	return SortServers(servers), nil
 }
 
+// requireClientCertificates switches the given TLS servers to MUTUAL TLS. Istio
+// verifies the client certificates against the CA bundle of the
+// `<credentialName>-cacert` Secret.
+func requireClientCertificates(servers []*istiov1beta1.Server) {
+	for _, server := range servers {
+		if server.Tls != nil && server.Tls.Mode == istiov1beta1.ServerTLSSettings_SIMPLE {
+			server.Tls.Mode = istiov1beta1.ServerTLSSettings_MUTUAL
+		}
+	}
+}
+
 func portNamePrefix(prefix, suffix string) string {
 	if !isDNS1123Label(suffix) {
 		suffix = fmt.Sprint(adler32.Checksum([]byte(suffix)))
@@ -332,26 +406,55 @@ func portNamePrefix(prefix, suffix string) string {
 
 // MakeHTTPServer creates a HTTP Gateway `Server` based on the HTTPProtocol
 // configuration.
-func MakeHTTPServer(httpProtocol network.HTTPProtocol, hosts []string) *istiov1alpha3.Server {
+func MakeHTTPServer(httpProtocol network.HTTPProtocol, hosts []string) *istiov1beta1.Server {
 	if httpProtocol == network.HTTPDisabled {
 		return nil
 	}
-	server := &istiov1alpha3.Server{
+	server := &istiov1beta1.Server{
 		Hosts: hosts,
-		Port: &istiov1alpha3.Port{
+		Port: &istiov1beta1.Port{
 			Name:     httpServerPortName,
 			Number:   GatewayHTTPPort,
 			Protocol: "HTTP",
 		},
 	}
 	if httpProtocol == network.HTTPRedirected {
-		server.Tls = &istiov1alpha3.ServerTLSSettings{
+		server.Tls = &istiov1beta1.ServerTLSSettings{
 			HttpsRedirect: true,
 		}
 	}
 	return server
 }
 
+// MakeIngressHTTPServers creates the HTTP Gateway `Servers` for the public hosts
+// of the given Ingress based on its HTTPOption, or on the global HTTPProtocol
+// when it redirects to HTTPS. It returns nil otherwise, and when the Ingress
+// enables HTTP while the default HTTP server already serves plain HTTP. The
+// servers are added to the shared Gateways next to their default HTTP server,
+// which they precede for the Ingress hosts.
+//
+// The redirects apply to every path of the hosts, ACME HTTP-01 challenges
+// included, since the VirtualServices of Istio 1.8 cannot redirect a route to
+// HTTPS. The ACME servers follow the redirects to HTTPS without verifying the
+// certificate, so the challenges are still answered as long as the hosts are
+// served over HTTPS, e.g. while renewing their certificate.
+func MakeIngressHTTPServers(ctx context.Context, ing *v1alpha1.Ingress) []*istiov1beta1.Server {
+	hosts := getPublicHosts(ing)
+	if hosts.Len() == 0 {
+		return nil
+	}
+	httpProtocol := network.HTTPRedirected
+	if !isHTTPSRedirected(ctx, ing) {
+		if ing.Spec.HTTPOption != v1alpha1.HTTPOptionEnabled || servesPlainHTTP(ctx) {
+			return nil
+		}
+		httpProtocol = network.HTTPEnabled
+	}
+	server := MakeHTTPServer(httpProtocol, hosts.List())
+	server.Port.Name = portNamePrefix(ing.GetNamespace(), ing.GetName()) + ":http"
+	return []*istiov1beta1.Server{server}
+}
+
 // GetNonWildcardIngressTLS gets Ingress TLS that do not reference wildcard certificates.
 func GetNonWildcardIngressTLS(ingressTLS []v1alpha1.IngressTLS, nonWildcardSecrest map[string]*corev1.Secret) []v1alpha1.IngressTLS {
 	result := []v1alpha1.IngressTLS{}
@@ -376,9 +479,12 @@ func ServiceNamespaceFromURL(svc string) (string, error) {
 // TODO(nghia):  Remove this by parsing at config parsing time.
 // GetIngressGatewaySvcNameNamespaces gets the Istio ingress namespaces from ConfigMap.
 func GetIngressGatewaySvcNameNamespaces(ctx context.Context) ([]metav1.ObjectMeta, error) {
-	cfg := config.FromContext(ctx).Istio
-	nameNamespaces := make([]metav1.ObjectMeta, len(cfg.IngressGateways))
-	for i, ingressgateway := range cfg.IngressGateways {
+	return gatewaySvcNameNamespaces(config.FromContext(ctx).Istio.IngressGateways)
+}
+
+func gatewaySvcNameNamespaces(gateways []config.Gateway) ([]metav1.ObjectMeta, error) {
+	nameNamespaces := make([]metav1.ObjectMeta, len(gateways))
+	for i, ingressgateway := range gateways {
 		parts := strings.SplitN(ingressgateway.ServiceURL, ".", 3)
 		if len(parts) != 3 {
 			return nil, fmt.Errorf("unexpected service URL form: %s", ingressgateway.ServiceURL)
@@ -392,13 +498,13 @@ func GetIngressGatewaySvcNameNamespaces(ctx context.Context) ([]metav1.ObjectMet
 }
 
 // UpdateGateway replaces the existing servers with the wanted servers.
-func UpdateGateway(gateway *v1alpha3.Gateway, want []*istiov1alpha3.Server, existing []*istiov1alpha3.Server) *v1alpha3.Gateway {
+func UpdateGateway(gateway *v1beta1.Gateway, want []*istiov1beta1.Server, existing []*istiov1beta1.Server) *v1beta1.Gateway {
 	existingServers := sets.String{}
 	for i := range existing {
 		existingServers.Insert(existing[i].Port.Name)
 	}
 
-	servers := []*istiov1alpha3.Server{}
+	servers := []*istiov1beta1.Server{}
 	for _, server := range gateway.Spec.Servers {
 		// We remove
 		//  1) the existing servers
@@ -420,6 +526,6 @@ func UpdateGateway(gateway *v1alpha3.Gateway, want []*istiov1alpha3.Server, exis
 	return gateway
 }
 
-func isPlaceHolderServer(server *istiov1alpha3.Server) bool {
+func isPlaceHolderServer(server *istiov1beta1.Server) bool {
 	return equality.Semantic.DeepEqual(server, &placeholderServer)
 }
@@ -30,8 +30,8 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	corev1listers "k8s.io/client-go/listers/core/v1"
 
-	istiov1alpha3 "istio.io/api/networking/v1alpha3"
-	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	"istio.io/client-go/pkg/apis/networking/v1beta1"
 	"knative.dev/net-istio/pkg/reconciler/ingress/config"
 	network "knative.dev/networking/pkg"
 	"knative.dev/networking/pkg/apis/networking"
@@ -69,68 +69,68 @@ var selector = map[string]string{
 	"istio": "ingressgateway",
 }
 
-var gateway = v1alpha3.Gateway{
-	Spec: istiov1alpha3.Gateway{
+var gateway = v1beta1.Gateway{
+	Spec: istiov1beta1.Gateway{
 		Servers: servers,
 	},
 }
 
-var servers = []*istiov1alpha3.Server{{
+var servers = []*istiov1beta1.Server{{
 	Hosts: []string{"host1.example.com"},
-	Port: &istiov1alpha3.Port{
+	Port: &istiov1beta1.Port{
 		Name:     "test-ns/ingress:0",
 		Number:   443,
 		Protocol: "HTTPS",
 	},
-	Tls: &istiov1alpha3.ServerTLSSettings{
-		Mode:              istiov1alpha3.ServerTLSSettings_SIMPLE,
+	Tls: &istiov1beta1.ServerTLSSettings{
+		Mode:              istiov1beta1.ServerTLSSettings_SIMPLE,
 		ServerCertificate: corev1.TLSCertKey,
 		PrivateKey:        corev1.TLSPrivateKeyKey,
 	},
 }, {
 	Hosts: []string{"host2.example.com"},
-	Port: &istiov1alpha3.Port{
+	Port: &istiov1beta1.Port{
 		Name:     "test-ns/non-ingress:0",
 		Number:   443,
 		Protocol: "HTTPS",
 	},
-	Tls: &istiov1alpha3.ServerTLSSettings{
-		Mode:              istiov1alpha3.ServerTLSSettings_SIMPLE,
+	Tls: &istiov1beta1.ServerTLSSettings{
+		Mode:              istiov1beta1.ServerTLSSettings_SIMPLE,
 		ServerCertificate: corev1.TLSCertKey,
 		PrivateKey:        corev1.TLSPrivateKeyKey,
 	},
 }}
 
-var httpServer = istiov1alpha3.Server{
+var httpServer = istiov1beta1.Server{
 	Hosts: []string{"*"},
-	Port: &istiov1alpha3.Port{
+	Port: &istiov1beta1.Port{
 		Name:     httpServerPortName,
 		Number:   80,
 		Protocol: "HTTP",
 	},
 }
 
-var gatewayWithPlaceholderServer = v1alpha3.Gateway{
-	Spec: istiov1alpha3.Gateway{
-		Servers: []*istiov1alpha3.Server{&placeholderServer},
+var gatewayWithPlaceholderServer = v1beta1.Gateway{
+	Spec: istiov1beta1.Gateway{
+		Servers: []*istiov1beta1.Server{&placeholderServer},
 	},
 }
 
-var gatewayWithModifiedWildcardTLSServer = v1alpha3.Gateway{
-	Spec: istiov1alpha3.Gateway{
-		Servers: []*istiov1alpha3.Server{&modifiedDefaultTLSServer},
+var gatewayWithModifiedWildcardTLSServer = v1beta1.Gateway{
+	Spec: istiov1beta1.Gateway{
+		Servers: []*istiov1beta1.Server{&modifiedDefaultTLSServer},
 	},
 }
 
-var modifiedDefaultTLSServer = istiov1alpha3.Server{
+var modifiedDefaultTLSServer = istiov1beta1.Server{
 	Hosts: []string{"added.by.user.example.com"},
-	Port: &istiov1alpha3.Port{
+	Port: &istiov1beta1.Port{
 		Name:     "https",
 		Number:   443,
 		Protocol: "HTTPS",
 	},
-	Tls: &istiov1alpha3.ServerTLSSettings{
-		Mode:              istiov1alpha3.ServerTLSSettings_SIMPLE,
+	Tls: &istiov1beta1.ServerTLSSettings{
+		Mode:              istiov1beta1.ServerTLSSettings_SIMPLE,
 		ServerCertificate: corev1.TLSCertKey,
 		PrivateKey:        corev1.TLSPrivateKeyKey,
 	},
@@ -165,15 +165,15 @@ var ingressResourceWithDotName = v1alpha1.Ingress{
 
 func TestGetServers(t *testing.T) {
 	servers := GetServers(&gateway, &ingressResource)
-	expected := []*istiov1alpha3.Server{{
+	expected := []*istiov1beta1.Server{{
 		Hosts: []string{"host1.example.com"},
-		Port: &istiov1alpha3.Port{
+		Port: &istiov1beta1.Port{
 			Name:     "test-ns/ingress:0",
 			Number:   443,
 			Protocol: "HTTPS",
 		},
-		Tls: &istiov1alpha3.ServerTLSSettings{
-			Mode:              istiov1alpha3.ServerTLSSettings_SIMPLE,
+		Tls: &istiov1beta1.ServerTLSSettings{
+			Mode:              istiov1beta1.ServerTLSSettings_SIMPLE,
 			ServerCertificate: corev1.TLSCertKey,
 			PrivateKey:        corev1.TLSPrivateKeyKey,
 		},
@@ -188,9 +188,9 @@ func TestGetHTTPServer(t *testing.T) {
 	newGateway := gateway
 	newGateway.Spec.Servers = append(newGateway.Spec.Servers, &httpServer)
 	server := GetHTTPServer(&newGateway)
-	expected := &istiov1alpha3.Server{
+	expected := &istiov1beta1.Server{
 		Hosts: []string{"*"},
-		Port: &istiov1alpha3.Port{
+		Port: &istiov1beta1.Port{
 			Name:     httpServerPortName,
 			Number:   80,
 			Protocol: "HTTP",
@@ -201,13 +201,30 @@ func TestGetHTTPServer(t *testing.T) {
 	}
 }
 
+func TestSortServers(t *testing.T) {
+	ingressHTTPServer := &istiov1beta1.Server{
+		Hosts: []string{"host1.example.com"},
+		Port: &istiov1beta1.Port{
+			Name:     "test-ns/ingress:http",
+			Number:   80,
+			Protocol: "HTTP",
+		},
+	}
+	servers := SortServers([]*istiov1beta1.Server{&httpServer, ingressHTTPServer, &modifiedDefaultTLSServer})
+	// The default HTTP server goes last for the Ingress specific HTTP servers to take precedence.
+	expected := []*istiov1beta1.Server{&modifiedDefaultTLSServer, ingressHTTPServer, &httpServer}
+	if diff := cmp.Diff(expected, servers); diff != "" {
+		t.Error("Unexpected servers (-want +got):", diff)
+	}
+}
+
 func TestMakeTLSServers(t *testing.T) {
 	cases := []struct {
 		name                    string
 		ci                      *v1alpha1.Ingress
 		gatewayServiceNamespace string
 		originSecrets           map[string]*corev1.Secret
-		expected                []*istiov1alpha3.Server
+		expected                []*istiov1beta1.Server
 		wantErr                 bool
 	}{{
 		name: "secret namespace is the different from the gateway service namespace",
@@ -215,15 +232,15 @@ func TestMakeTLSServers(t *testing.T) {
 		// gateway service namespace is "istio-system", while the secret namespace is system.Namespace()("knative-testing").
 		gatewayServiceNamespace: "istio-system",
 		originSecrets:           originSecrets,
-		expected: []*istiov1alpha3.Server{{
+		expected: []*istiov1beta1.Server{{
 			Hosts: []string{"host1.example.com"},
-			Port: &istiov1alpha3.Port{
+			Port: &istiov1beta1.Port{
 				Name:     "test-ns/ingress:0",
 				Number:   443,
 				Protocol: "HTTPS",
 			},
-			Tls: &istiov1alpha3.ServerTLSSettings{
-				Mode:              istiov1alpha3.ServerTLSSettings_SIMPLE,
+			Tls: &istiov1beta1.ServerTLSSettings{
+				Mode:              istiov1beta1.ServerTLSSettings_SIMPLE,
 				ServerCertificate: corev1.TLSCertKey,
 				PrivateKey:        corev1.TLSPrivateKeyKey,
 				CredentialName:    targetSecret(&secret, &ingressResource),
@@ -235,15 +252,15 @@ func TestMakeTLSServers(t *testing.T) {
 		// gateway service namespace and the secret namespace are both in system.Namespace().
 		gatewayServiceNamespace: system.Namespace(),
 		originSecrets:           originSecrets,
-		expected: []*istiov1alpha3.Server{{
+		expected: []*istiov1beta1.Server{{
 			Hosts: []string{"host1.example.com"},
-			Port: &istiov1alpha3.Port{
+			Port: &istiov1beta1.Port{
 				Name:     "test-ns/ingress:0",
 				Number:   443,
 				Protocol: "HTTPS",
 			},
-			Tls: &istiov1alpha3.ServerTLSSettings{
-				Mode:              istiov1alpha3.ServerTLSSettings_SIMPLE,
+			Tls: &istiov1beta1.ServerTLSSettings{
+				Mode:              istiov1beta1.ServerTLSSettings_SIMPLE,
 				ServerCertificate: corev1.TLSCertKey,
 				PrivateKey:        corev1.TLSPrivateKeyKey,
 				CredentialName:    "secret0",
@@ -254,21 +271,36 @@ func TestMakeTLSServers(t *testing.T) {
 		ci:                      &ingressResource,
 		gatewayServiceNamespace: system.Namespace(),
 		originSecrets:           originSecrets,
-		expected: []*istiov1alpha3.Server{{
+		expected: []*istiov1beta1.Server{{
 			Hosts: []string{"host1.example.com"},
-			Port: &istiov1alpha3.Port{
+			Port: &istiov1beta1.Port{
 				// port name is created with <namespace>/<name>
 				Name:     "test-ns/ingress:0",
 				Number:   443,
 				Protocol: "HTTPS",
 			},
-			Tls: &istiov1alpha3.ServerTLSSettings{
-				Mode:              istiov1alpha3.ServerTLSSettings_SIMPLE,
+			Tls: &istiov1beta1.ServerTLSSettings{
+				Mode:              istiov1beta1.ServerTLSSettings_SIMPLE,
 				ServerCertificate: corev1.TLSCertKey,
 				PrivateKey:        corev1.TLSPrivateKeyKey,
 				CredentialName:    "secret0",
 			},
 		}},
+	}, {
+		name: "passthrough hosts are left out",
+		ci: &v1alpha1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "ingress",
+				Namespace: "test-ns",
+				Annotations: map[string]string{
+					TLSPassthroughHostsAnnotationKey: "host1.example.com",
+				},
+			},
+			Spec: ingressSpec,
+		},
+		gatewayServiceNamespace: system.Namespace(),
+		originSecrets:           originSecrets,
+		expected:                []*istiov1beta1.Server{},
 	}, {
 		name:                    "error to make servers because of incorrect originSecrets",
 		ci:                      &ingressResource,
@@ -293,7 +325,7 @@ func TestMakeHTTPServer(t *testing.T) {
 	cases := []struct {
 		name         string
 		httpProtocol network.HTTPProtocol
-		expected     *istiov1alpha3.Server
+		expected     *istiov1beta1.Server
 	}{{
 		name:         "nil HTTP Server",
 		httpProtocol: network.HTTPDisabled,
@@ -301,9 +333,9 @@ func TestMakeHTTPServer(t *testing.T) {
 	}, {
 		name:         "HTTP server",
 		httpProtocol: network.HTTPEnabled,
-		expected: &istiov1alpha3.Server{
+		expected: &istiov1beta1.Server{
 			Hosts: []string{"*"},
-			Port: &istiov1alpha3.Port{
+			Port: &istiov1beta1.Port{
 				Name:     httpServerPortName,
 				Number:   80,
 				Protocol: "HTTP",
@@ -312,14 +344,14 @@ func TestMakeHTTPServer(t *testing.T) {
 	}, {
 		name:         "Redirect HTTP server",
 		httpProtocol: network.HTTPRedirected,
-		expected: &istiov1alpha3.Server{
+		expected: &istiov1beta1.Server{
 			Hosts: []string{"*"},
-			Port: &istiov1alpha3.Port{
+			Port: &istiov1beta1.Port{
 				Name:     httpServerPortName,
 				Number:   80,
 				Protocol: "HTTP",
 			},
-			Tls: &istiov1alpha3.ServerTLSSettings{
+			Tls: &istiov1beta1.ServerTLSSettings{
 				HttpsRedirect: true,
 			},
 		},
@@ -334,66 +366,193 @@ func TestMakeHTTPServer(t *testing.T) {
 	}
 }
 
+func TestMakeIngressHTTPServers(t *testing.T) {
+	rules := []v1alpha1.IngressRule{{
+		Hosts:      []string{"host1.example.com", "host1.test-ns.svc.cluster.local"},
+		Visibility: v1alpha1.IngressVisibilityExternalIP,
+		HTTP:       &v1alpha1.HTTPIngressRuleValue{},
+	}, {
+		Hosts:      []string{"private.test-ns.svc.cluster.local"},
+		Visibility: v1alpha1.IngressVisibilityClusterLocal,
+		HTTP:       &v1alpha1.HTTPIngressRuleValue{},
+	}}
+	acmeRule := v1alpha1.IngressRule{
+		Hosts:      []string{"host2.example.com"},
+		Visibility: v1alpha1.IngressVisibilityExternalIP,
+		HTTP: &v1alpha1.HTTPIngressRuleValue{
+			Paths: []v1alpha1.HTTPIngressPath{{
+				Path: "/.well-known/acme-challenge/token",
+			}},
+		},
+	}
+	httpServer := &istiov1beta1.Server{
+		Hosts: []string{"host1.example.com"},
+		Port: &istiov1beta1.Port{
+			Name:     "test-ns/ingress:http",
+			Number:   80,
+			Protocol: "HTTP",
+		},
+	}
+	redirectServer := &istiov1beta1.Server{
+		Hosts: []string{"host1.example.com"},
+		Port: &istiov1beta1.Port{
+			Name:     "test-ns/ingress:http",
+			Number:   80,
+			Protocol: "HTTP",
+		},
+		Tls: &istiov1beta1.ServerTLSSettings{
+			HttpsRedirect: true,
+		},
+	}
+	redirectedNetwork := &network.Config{
+		AutoTLS:      true,
+		HTTPProtocol: network.HTTPRedirected,
+	}
+	cases := []struct {
+		name       string
+		httpOption v1alpha1.HTTPOption
+		network    *network.Config
+		rules      []v1alpha1.IngressRule
+		expected   []*istiov1beta1.Server
+	}{{
+		name:  "no HTTPOption",
+		rules: rules,
+	}, {
+		name:       "HTTPOption enabled",
+		httpOption: v1alpha1.HTTPOptionEnabled,
+		rules:      rules,
+	}, {
+		name:       "HTTPOption enabled with HTTPProtocol disabled",
+		httpOption: v1alpha1.HTTPOptionEnabled,
+		network: &network.Config{
+			AutoTLS:      true,
+			HTTPProtocol: network.HTTPDisabled,
+		},
+		rules:    rules,
+		expected: []*istiov1beta1.Server{httpServer},
+	}, {
+		name:       "HTTPOption redirected",
+		httpOption: v1alpha1.HTTPOptionRedirected,
+		rules:      rules,
+		expected:   []*istiov1beta1.Server{redirectServer},
+	}, {
+		name:     "HTTPProtocol redirected",
+		network:  redirectedNetwork,
+		rules:    rules,
+		expected: []*istiov1beta1.Server{redirectServer},
+	}, {
+		name: "HTTPProtocol redirected without Auto TLS",
+		network: &network.Config{
+			HTTPProtocol: network.HTTPRedirected,
+		},
+		rules: rules,
+	}, {
+		name:       "HTTPOption enabled takes precedence",
+		httpOption: v1alpha1.HTTPOptionEnabled,
+		network:    redirectedNetwork,
+		rules:      rules,
+		expected:   []*istiov1beta1.Server{httpServer},
+	}, {
+		name:       "ACME challenge hosts are redirected too",
+		httpOption: v1alpha1.HTTPOptionRedirected,
+		rules:      append([]v1alpha1.IngressRule{acmeRule}, rules...),
+		expected: []*istiov1beta1.Server{{
+			Hosts: []string{"host1.example.com", "host2.example.com"},
+			Port:  redirectServer.Port,
+			Tls:   redirectServer.Tls,
+		}},
+	}, {
+		name:       "cluster local Ingress",
+		httpOption: v1alpha1.HTTPOptionRedirected,
+		rules:      rules[1:],
+	}}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.Background()
+			if c.network != nil {
+				ctx = config.ToContext(ctx, &config.Config{
+					Istio:   &config.Istio{},
+					Network: c.network,
+				})
+			}
+			ing := &v1alpha1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "ingress",
+					Namespace: "test-ns",
+				},
+				Spec: v1alpha1.IngressSpec{
+					Rules:      c.rules,
+					HTTPOption: c.httpOption,
+				},
+			}
+			got := MakeIngressHTTPServers(ctx, ing)
+			if diff := cmp.Diff(c.expected, got); diff != "" {
+				t.Error("Unexpected HTTP Servers (-want, +got):", diff)
+			}
+		})
+	}
+}
+
 func TestUpdateGateway(t *testing.T) {
 	cases := []struct {
 		name            string
-		existingServers []*istiov1alpha3.Server
-		newServers      []*istiov1alpha3.Server
-		original        v1alpha3.Gateway
-		expected        v1alpha3.Gateway
+		existingServers []*istiov1beta1.Server
+		newServers      []*istiov1beta1.Server
+		original        v1beta1.Gateway
+		expected        v1beta1.Gateway
 	}{{
 		name: "Update Gateway servers.",
-		existingServers: []*istiov1alpha3.Server{{
+		existingServers: []*istiov1beta1.Server{{
 			Hosts: []string{"host1.example.com"},
-			Port: &istiov1alpha3.Port{
+			Port: &istiov1beta1.Port{
 				Name:     "test-ns/ingress:0",
 				Number:   443,
 				Protocol: "HTTPS",
 			},
-			Tls: &istiov1alpha3.ServerTLSSettings{
-				Mode:              istiov1alpha3.ServerTLSSettings_SIMPLE,
+			Tls: &istiov1beta1.ServerTLSSettings{
+				Mode:              istiov1beta1.ServerTLSSettings_SIMPLE,
 				ServerCertificate: corev1.TLSCertKey,
 				PrivateKey:        corev1.TLSPrivateKeyKey,
 			},
 		}},
-		newServers: []*istiov1alpha3.Server{{
+		newServers: []*istiov1beta1.Server{{
 			Hosts: []string{"host-new.example.com"},
-			Port: &istiov1alpha3.Port{
+			Port: &istiov1beta1.Port{
 				Name:     "test-ns/ingress:0",
 				Number:   443,
 				Protocol: "HTTPS",
 			},
-			Tls: &istiov1alpha3.ServerTLSSettings{
-				Mode:              istiov1alpha3.ServerTLSSettings_SIMPLE,
+			Tls: &istiov1beta1.ServerTLSSettings{
+				Mode:              istiov1beta1.ServerTLSSettings_SIMPLE,
 				ServerCertificate: corev1.TLSCertKey,
 				PrivateKey:        corev1.TLSPrivateKeyKey,
 			},
 		}},
 		original: gateway,
-		expected: v1alpha3.Gateway{
-			Spec: istiov1alpha3.Gateway{
-				Servers: []*istiov1alpha3.Server{{
+		expected: v1beta1.Gateway{
+			Spec: istiov1beta1.Gateway{
+				Servers: []*istiov1beta1.Server{{
 					// The host name was updated to the one in "newServers".
 					Hosts: []string{"host-new.example.com"},
-					Port: &istiov1alpha3.Port{
+					Port: &istiov1beta1.Port{
 						Name:     "test-ns/ingress:0",
 						Number:   443,
 						Protocol: "HTTPS",
 					},
-					Tls: &istiov1alpha3.ServerTLSSettings{
-						Mode:              istiov1alpha3.ServerTLSSettings_SIMPLE,
+					Tls: &istiov1beta1.ServerTLSSettings{
+						Mode:              istiov1beta1.ServerTLSSettings_SIMPLE,
 						ServerCertificate: corev1.TLSCertKey,
 						PrivateKey:        corev1.TLSPrivateKeyKey,
 					},
 				}, {
 					Hosts: []string{"host2.example.com"},
-					Port: &istiov1alpha3.Port{
+					Port: &istiov1beta1.Port{
 						Name:     "test-ns/non-ingress:0",
 						Number:   443,
 						Protocol: "HTTPS",
 					},
-					Tls: &istiov1alpha3.ServerTLSSettings{
-						Mode:              istiov1alpha3.ServerTLSSettings_SIMPLE,
+					Tls: &istiov1beta1.ServerTLSSettings{
+						Mode:              istiov1beta1.ServerTLSSettings_SIMPLE,
 						ServerCertificate: corev1.TLSCertKey,
 						PrivateKey:        corev1.TLSPrivateKeyKey,
 					},
@@ -402,33 +561,33 @@ func TestUpdateGateway(t *testing.T) {
 		},
 	}, {
 		name: "Delete servers from Gateway",
-		existingServers: []*istiov1alpha3.Server{{
+		existingServers: []*istiov1beta1.Server{{
 			Hosts: []string{"host1.example.com"},
-			Port: &istiov1alpha3.Port{
+			Port: &istiov1beta1.Port{
 				Name:     "test-ns/ingress:0",
 				Number:   443,
 				Protocol: "HTTPS",
 			},
-			Tls: &istiov1alpha3.ServerTLSSettings{
-				Mode:              istiov1alpha3.ServerTLSSettings_SIMPLE,
+			Tls: &istiov1beta1.ServerTLSSettings{
+				Mode:              istiov1beta1.ServerTLSSettings_SIMPLE,
 				ServerCertificate: corev1.TLSCertKey,
 				PrivateKey:        corev1.TLSPrivateKeyKey,
 			},
 		}},
-		newServers: []*istiov1alpha3.Server{},
+		newServers: []*istiov1beta1.Server{},
 		original:   gateway,
-		expected: v1alpha3.Gateway{
-			Spec: istiov1alpha3.Gateway{
+		expected: v1beta1.Gateway{
+			Spec: istiov1beta1.Gateway{
 				// Only one server is left. The other one is deleted.
-				Servers: []*istiov1alpha3.Server{{
+				Servers: []*istiov1beta1.Server{{
 					Hosts: []string{"host2.example.com"},
-					Port: &istiov1alpha3.Port{
+					Port: &istiov1beta1.Port{
 						Name:     "test-ns/non-ingress:0",
 						Number:   443,
 						Protocol: "HTTPS",
 					},
-					Tls: &istiov1alpha3.ServerTLSSettings{
-						Mode:              istiov1alpha3.ServerTLSSettings_SIMPLE,
+					Tls: &istiov1beta1.ServerTLSSettings{
+						Mode:              istiov1beta1.ServerTLSSettings_SIMPLE,
 						ServerCertificate: corev1.TLSCertKey,
 						PrivateKey:        corev1.TLSPrivateKeyKey,
 					},
@@ -439,63 +598,63 @@ func TestUpdateGateway(t *testing.T) {
 		name: "Delete servers from Gateway and no real servers are left",
 
 		// All of the servers in the original gateway will be deleted.
-		existingServers: []*istiov1alpha3.Server{{
+		existingServers: []*istiov1beta1.Server{{
 			Hosts: []string{"host1.example.com"},
-			Port: &istiov1alpha3.Port{
+			Port: &istiov1beta1.Port{
 				Name:     "test-ns/ingress:0",
 				Number:   443,
 				Protocol: "HTTPS",
 			},
-			Tls: &istiov1alpha3.ServerTLSSettings{
-				Mode:              istiov1alpha3.ServerTLSSettings_SIMPLE,
+			Tls: &istiov1beta1.ServerTLSSettings{
+				Mode:              istiov1beta1.ServerTLSSettings_SIMPLE,
 				ServerCertificate: corev1.TLSCertKey,
 				PrivateKey:        corev1.TLSPrivateKeyKey,
 			},
 		}, {
 			Hosts: []string{"host2.example.com"},
-			Port: &istiov1alpha3.Port{
+			Port: &istiov1beta1.Port{
 				Name:     "test-ns/non-ingress:0",
 				Number:   443,
 				Protocol: "HTTPS",
 			},
-			Tls: &istiov1alpha3.ServerTLSSettings{
-				Mode:              istiov1alpha3.ServerTLSSettings_SIMPLE,
+			Tls: &istiov1beta1.ServerTLSSettings{
+				Mode:              istiov1beta1.ServerTLSSettings_SIMPLE,
 				ServerCertificate: corev1.TLSCertKey,
 				PrivateKey:        corev1.TLSPrivateKeyKey,
 			},
 		}},
-		newServers: []*istiov1alpha3.Server{},
+		newServers: []*istiov1beta1.Server{},
 		original:   gateway,
 		expected:   gatewayWithPlaceholderServer,
 	}, {
 		name:            "Add servers to the gateway with only placeholder server",
-		existingServers: []*istiov1alpha3.Server{},
-		newServers: []*istiov1alpha3.Server{{
+		existingServers: []*istiov1beta1.Server{},
+		newServers: []*istiov1beta1.Server{{
 			Hosts: []string{"host1.example.com"},
-			Port: &istiov1alpha3.Port{
+			Port: &istiov1beta1.Port{
 				Name:     "test-ns/ingress:0",
 				Number:   443,
 				Protocol: "HTTPS",
 			},
-			Tls: &istiov1alpha3.ServerTLSSettings{
-				Mode:              istiov1alpha3.ServerTLSSettings_SIMPLE,
+			Tls: &istiov1beta1.ServerTLSSettings{
+				Mode:              istiov1beta1.ServerTLSSettings_SIMPLE,
 				ServerCertificate: corev1.TLSCertKey,
 				PrivateKey:        corev1.TLSPrivateKeyKey,
 			},
 		}},
 		original: gatewayWithPlaceholderServer,
 		// The placeholder server should be deleted.
-		expected: v1alpha3.Gateway{
-			Spec: istiov1alpha3.Gateway{
-				Servers: []*istiov1alpha3.Server{{
+		expected: v1beta1.Gateway{
+			Spec: istiov1beta1.Gateway{
+				Servers: []*istiov1beta1.Server{{
 					Hosts: []string{"host1.example.com"},
-					Port: &istiov1alpha3.Port{
+					Port: &istiov1beta1.Port{
 						Name:     "test-ns/ingress:0",
 						Number:   443,
 						Protocol: "HTTPS",
 					},
-					Tls: &istiov1alpha3.ServerTLSSettings{
-						Mode:              istiov1alpha3.ServerTLSSettings_SIMPLE,
+					Tls: &istiov1beta1.ServerTLSSettings{
+						Mode:              istiov1beta1.ServerTLSSettings_SIMPLE,
 						ServerCertificate: corev1.TLSCertKey,
 						PrivateKey:        corev1.TLSPrivateKeyKey,
 					},
@@ -504,33 +663,33 @@ func TestUpdateGateway(t *testing.T) {
 		},
 	}, {
 		name:            "Do not delete modified wildcard servers from gateway",
-		existingServers: []*istiov1alpha3.Server{},
-		newServers: []*istiov1alpha3.Server{{
+		existingServers: []*istiov1beta1.Server{},
+		newServers: []*istiov1beta1.Server{{
 			Hosts: []string{"host1.example.com"},
-			Port: &istiov1alpha3.Port{
+			Port: &istiov1beta1.Port{
 				Name:     "clusteringress:0",
 				Number:   443,
 				Protocol: "HTTPS",
 			},
-			Tls: &istiov1alpha3.ServerTLSSettings{
-				Mode:              istiov1alpha3.ServerTLSSettings_SIMPLE,
+			Tls: &istiov1beta1.ServerTLSSettings{
+				Mode:              istiov1beta1.ServerTLSSettings_SIMPLE,
 				ServerCertificate: corev1.TLSCertKey,
 				PrivateKey:        corev1.TLSPrivateKeyKey,
 			},
 		}},
 		original: gatewayWithModifiedWildcardTLSServer,
-		expected: v1alpha3.Gateway{
-			Spec: istiov1alpha3.Gateway{
-				Servers: []*istiov1alpha3.Server{
+		expected: v1beta1.Gateway{
+			Spec: istiov1beta1.Gateway{
+				Servers: []*istiov1beta1.Server{
 					{
 						Hosts: []string{"host1.example.com"},
-						Port: &istiov1alpha3.Port{
+						Port: &istiov1beta1.Port{
 							Name:     "clusteringress:0",
 							Number:   443,
 							Protocol: "HTTPS",
 						},
-						Tls: &istiov1alpha3.ServerTLSSettings{
-							Mode:              istiov1alpha3.ServerTLSSettings_SIMPLE,
+						Tls: &istiov1beta1.ServerTLSSettings{
+							Mode:              istiov1beta1.ServerTLSSettings_SIMPLE,
 							ServerCertificate: corev1.TLSCertKey,
 							PrivateKey:        corev1.TLSPrivateKeyKey,
 						},
@@ -556,7 +715,8 @@ func TestMakeWildcardGateways(t *testing.T) {
 		name            string
 		wildcardSecrets map[string]*corev1.Secret
 		gatewayService  *corev1.Service
-		want            []*v1alpha3.Gateway
+		clientCASecret  string
+		want            []*v1beta1.Gateway
 		wantErr         bool
 	}{{
 		name:            "happy path: secret namespace is the different from the gateway service namespace",
@@ -570,30 +730,30 @@ func TestMakeWildcardGateways(t *testing.T) {
 				Selector: selector,
 			},
 		},
-		want: []*v1alpha3.Gateway{{
+		want: []*v1beta1.Gateway{{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:            WildcardGatewayName(wildcardSecret.Name, "istio-system", "istio-ingressgateway"),
 				Namespace:       system.Namespace(),
 				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(wildcardSecret, secretGVK)},
 			},
-			Spec: istiov1alpha3.Gateway{
+			Spec: istiov1beta1.Gateway{
 				Selector: selector,
-				Servers: []*istiov1alpha3.Server{{
+				Servers: []*istiov1beta1.Server{{
 					Hosts: []string{"*.example.com"},
-					Port: &istiov1alpha3.Port{
+					Port: &istiov1beta1.Port{
 						Name:     "https",
 						Number:   443,
 						Protocol: "HTTPS",
 					},
-					Tls: &istiov1alpha3.ServerTLSSettings{
-						Mode:              istiov1alpha3.ServerTLSSettings_SIMPLE,
+					Tls: &istiov1beta1.ServerTLSSettings{
+						Mode:              istiov1beta1.ServerTLSSettings_SIMPLE,
 						ServerCertificate: corev1.TLSCertKey,
 						PrivateKey:        corev1.TLSPrivateKeyKey,
 						CredentialName:    targetWildcardSecretName(wildcardSecret.Name, wildcardSecret.Namespace),
 					},
 				}, {
 					Hosts: []string{"*.example.com"},
-					Port: &istiov1alpha3.Port{
+					Port: &istiov1beta1.Port{
 						Name:     httpServerPortName,
 						Number:   80,
 						Protocol: "HTTP",
@@ -613,30 +773,74 @@ func TestMakeWildcardGateways(t *testing.T) {
 				Selector: selector,
 			},
 		},
-		want: []*v1alpha3.Gateway{{
+		want: []*v1beta1.Gateway{{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:            WildcardGatewayName(wildcardSecret.Name, system.Namespace(), "istio-ingressgateway"),
 				Namespace:       system.Namespace(),
 				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(wildcardSecret, secretGVK)},
 			},
-			Spec: istiov1alpha3.Gateway{
+			Spec: istiov1beta1.Gateway{
 				Selector: selector,
-				Servers: []*istiov1alpha3.Server{{
+				Servers: []*istiov1beta1.Server{{
 					Hosts: []string{"*.example.com"},
-					Port: &istiov1alpha3.Port{
+					Port: &istiov1beta1.Port{
 						Name:     "https",
 						Number:   443,
 						Protocol: "HTTPS",
 					},
-					Tls: &istiov1alpha3.ServerTLSSettings{
-						Mode:              istiov1alpha3.ServerTLSSettings_SIMPLE,
+					Tls: &istiov1beta1.ServerTLSSettings{
+						Mode:              istiov1beta1.ServerTLSSettings_SIMPLE,
 						ServerCertificate: corev1.TLSCertKey,
 						PrivateKey:        corev1.TLSPrivateKeyKey,
 						CredentialName:    wildcardSecret.Name,
 					},
 				}, {
 					Hosts: []string{"*.example.com"},
-					Port: &istiov1alpha3.Port{
+					Port: &istiov1beta1.Port{
+						Name:     httpServerPortName,
+						Number:   80,
+						Protocol: "HTTP",
+					},
+				}},
+			},
+		}},
+	}, {
+		name:            "client certificates required by config-istio",
+		wildcardSecrets: wildcardSecrets,
+		clientCASecret:  "istio-system/client-ca",
+		gatewayService: &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "istio-ingressgateway",
+				Namespace: "istio-system",
+			},
+			Spec: corev1.ServiceSpec{
+				Selector: selector,
+			},
+		},
+		want: []*v1beta1.Gateway{{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            WildcardGatewayName(wildcardSecret.Name, "istio-system", "istio-ingressgateway"),
+				Namespace:       system.Namespace(),
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(wildcardSecret, secretGVK)},
+			},
+			Spec: istiov1beta1.Gateway{
+				Selector: selector,
+				Servers: []*istiov1beta1.Server{{
+					Hosts: []string{"*.example.com"},
+					Port: &istiov1beta1.Port{
+						Name:     "https",
+						Number:   443,
+						Protocol: "HTTPS",
+					},
+					Tls: &istiov1beta1.ServerTLSSettings{
+						Mode:              istiov1beta1.ServerTLSSettings_MUTUAL,
+						ServerCertificate: corev1.TLSCertKey,
+						PrivateKey:        corev1.TLSPrivateKeyKey,
+						CredentialName:    targetWildcardSecretName(wildcardSecret.Name, wildcardSecret.Namespace),
+					},
+				}, {
+					Hosts: []string{"*.example.com"},
+					Port: &istiov1beta1.Port{
 						Name:     httpServerPortName,
 						Number:   80,
 						Protocol: "HTTP",
@@ -669,6 +873,7 @@ func TestMakeWildcardGateways(t *testing.T) {
 					Name:       config.KnativeIngressGateway,
 					ServiceURL: fmt.Sprintf("%s.%s.svc.cluster.local", tc.gatewayService.Name, tc.gatewayService.Namespace),
 				}},
+				ClientCASecret: tc.clientCASecret,
 			},
 			Network: &network.Config{
 				HTTPProtocol: network.HTTPEnabled,
@@ -687,14 +892,14 @@ func TestMakeWildcardGateways(t *testing.T) {
 }
 
 func TestGatewayRef(t *testing.T) {
-	gw := &v1alpha3.Gateway{
+	gw := &v1beta1.Gateway{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "istio-ingress-gateway",
 			Namespace: "knative-serving",
 		},
 	}
 	want := tracker.Reference{
-		APIVersion: "networking.istio.io/v1alpha3",
+		APIVersion: "networking.istio.io/v1beta1",
 		Kind:       "Gateway",
 		Name:       "istio-ingress-gateway",
 		Namespace:  "knative-serving",
@@ -706,7 +911,7 @@ func TestGatewayRef(t *testing.T) {
 }
 
 func TestGetQualifiedGatewayNames(t *testing.T) {
-	gateways := []*v1alpha3.Gateway{{
+	gateways := []*v1beta1.Gateway{{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "istio-ingress-gateway",
 			Namespace: "knative-serving",
@@ -724,7 +929,7 @@ func TestMakeIngressTLSGateways(t *testing.T) {
 		ia             *v1alpha1.Ingress
 		originSecrets  map[string]*corev1.Secret
 		gatewayService *corev1.Service
-		want           []*v1alpha3.Gateway
+		want           []*v1beta1.Gateway
 		wantErr        bool
 	}{{
 		name:          "happy path: secret namespace is the different from the gateway service namespace",
@@ -739,7 +944,7 @@ func TestMakeIngressTLSGateways(t *testing.T) {
 				Selector: selector,
 			},
 		},
-		want: []*v1alpha3.Gateway{{
+		want: []*v1beta1.Gateway{{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:            fmt.Sprintf("ingress-%d", adler32.Checksum([]byte("istio-system/istio-ingressgateway"))),
 				Namespace:       "test-ns",
@@ -748,17 +953,17 @@ func TestMakeIngressTLSGateways(t *testing.T) {
 					networking.IngressLabelKey: "ingress",
 				},
 			},
-			Spec: istiov1alpha3.Gateway{
+			Spec: istiov1beta1.Gateway{
 				Selector: selector,
-				Servers: []*istiov1alpha3.Server{{
+				Servers: []*istiov1beta1.Server{{
 					Hosts: []string{"host1.example.com"},
-					Port: &istiov1alpha3.Port{
+					Port: &istiov1beta1.Port{
 						Name:     "test-ns/ingress:0",
 						Number:   443,
 						Protocol: "HTTPS",
 					},
-					Tls: &istiov1alpha3.ServerTLSSettings{
-						Mode:              istiov1alpha3.ServerTLSSettings_SIMPLE,
+					Tls: &istiov1beta1.ServerTLSSettings{
+						Mode:              istiov1beta1.ServerTLSSettings_SIMPLE,
 						ServerCertificate: corev1.TLSCertKey,
 						PrivateKey:        corev1.TLSPrivateKeyKey,
 						CredentialName:    targetSecret(&secret, &ingressResource),
@@ -780,7 +985,7 @@ func TestMakeIngressTLSGateways(t *testing.T) {
 				Selector: selector,
 			},
 		},
-		want: []*v1alpha3.Gateway{{
+		want: []*v1beta1.Gateway{{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:            fmt.Sprintf("ingress-%d", adler32.Checksum([]byte(system.Namespace()+"/istio-ingressgateway"))),
 				Namespace:       "test-ns",
@@ -789,17 +994,17 @@ func TestMakeIngressTLSGateways(t *testing.T) {
 					networking.IngressLabelKey: "ingress",
 				},
 			},
-			Spec: istiov1alpha3.Gateway{
+			Spec: istiov1beta1.Gateway{
 				Selector: selector,
-				Servers: []*istiov1alpha3.Server{{
+				Servers: []*istiov1beta1.Server{{
 					Hosts: []string{"host1.example.com"},
-					Port: &istiov1alpha3.Port{
+					Port: &istiov1beta1.Port{
 						Name:     "test-ns/ingress:0",
 						Number:   443,
 						Protocol: "HTTPS",
 					},
-					Tls: &istiov1alpha3.ServerTLSSettings{
-						Mode:              istiov1alpha3.ServerTLSSettings_SIMPLE,
+					Tls: &istiov1beta1.ServerTLSSettings{
+						Mode:              istiov1beta1.ServerTLSSettings_SIMPLE,
 						ServerCertificate: corev1.TLSCertKey,
 						PrivateKey:        corev1.TLSPrivateKeyKey,
 						CredentialName:    secret.Name,
@@ -807,6 +1012,46 @@ func TestMakeIngressTLSGateways(t *testing.T) {
 				}},
 			},
 		}},
+	}, {
+		name:          "client certificates required by the Ingress",
+		ia:            withClientCASecret(&ingressResource, "client-ca"),
+		originSecrets: originSecrets,
+		gatewayService: &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "istio-ingressgateway",
+				Namespace: "istio-system",
+			},
+			Spec: corev1.ServiceSpec{
+				Selector: selector,
+			},
+		},
+		want: []*v1beta1.Gateway{{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            fmt.Sprintf("ingress-%d", adler32.Checksum([]byte("istio-system/istio-ingressgateway"))),
+				Namespace:       "test-ns",
+				OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(&ingressResource)},
+				Labels: map[string]string{
+					networking.IngressLabelKey: "ingress",
+				},
+			},
+			Spec: istiov1beta1.Gateway{
+				Selector: selector,
+				Servers: []*istiov1beta1.Server{{
+					Hosts: []string{"host1.example.com"},
+					Port: &istiov1beta1.Port{
+						Name:     "test-ns/ingress:0",
+						Number:   443,
+						Protocol: "HTTPS",
+					},
+					Tls: &istiov1beta1.ServerTLSSettings{
+						Mode:              istiov1beta1.ServerTLSSettings_MUTUAL,
+						ServerCertificate: corev1.TLSCertKey,
+						PrivateKey:        corev1.TLSPrivateKeyKey,
+						CredentialName:    targetSecret(&secret, &ingressResource),
+					},
+				}},
+			},
+		}},
 	}, {
 		name: "ingress name has dot",
 
@@ -821,7 +1066,7 @@ func TestMakeIngressTLSGateways(t *testing.T) {
 				Selector: selector,
 			},
 		},
-		want: []*v1alpha3.Gateway{{
+		want: []*v1beta1.Gateway{{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:            fmt.Sprintf("%d-%d", adler32.Checksum([]byte("ingress.com")), adler32.Checksum([]byte("istio-system/istio-ingressgateway"))),
 				Namespace:       "test-ns",
@@ -830,17 +1075,17 @@ func TestMakeIngressTLSGateways(t *testing.T) {
 					networking.IngressLabelKey: "ingress.com",
 				},
 			},
-			Spec: istiov1alpha3.Gateway{
+			Spec: istiov1beta1.Gateway{
 				Selector: selector,
-				Servers: []*istiov1alpha3.Server{{
+				Servers: []*istiov1beta1.Server{{
 					Hosts: []string{"host1.example.com"},
-					Port: &istiov1alpha3.Port{
+					Port: &istiov1beta1.Port{
 						Name:     fmt.Sprintf("test-ns/%d:0", adler32.Checksum([]byte("ingress.com"))),
 						Number:   443,
 						Protocol: "HTTPS",
 					},
-					Tls: &istiov1alpha3.ServerTLSSettings{
-						Mode:              istiov1alpha3.ServerTLSSettings_SIMPLE,
+					Tls: &istiov1beta1.ServerTLSSettings{
+						Mode:              istiov1beta1.ServerTLSSettings_SIMPLE,
 						ServerCertificate: corev1.TLSCertKey,
 						PrivateKey:        corev1.TLSPrivateKeyKey,
 						CredentialName:    targetSecret(&secret, &ingressResourceWithDotName),
@@ -891,6 +1136,57 @@ func TestMakeIngressTLSGateways(t *testing.T) {
 	}
 }
 
+func withClientCASecret(ing *v1alpha1.Ingress, name string) *v1alpha1.Ingress {
+	ing = ing.DeepCopy()
+	ing.Annotations = map[string]string{ClientCASecretAnnotationKey: name}
+	return ing
+}
+
+func TestMakeIngressGateways(t *testing.T) {
+	ctx, cancel, _ := rtesting.SetupFakeContextWithCancel(t)
+	defer cancel()
+	gatewayService := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "istio-ingressgateway",
+			Namespace: "istio-system",
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: selector,
+		},
+	}
+	svcLister := serviceLister(ctx, gatewayService)
+	ctx = config.ToContext(context.Background(), &config.Config{
+		Istio: &config.Istio{
+			IngressGateways: []config.Gateway{{
+				Name:       config.KnativeIngressGateway,
+				ServiceURL: "istio-ingressgateway.istio-system.svc.cluster.local",
+			}},
+		},
+	})
+
+	want := []*v1beta1.Gateway{{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            fmt.Sprintf("ingress-%d", adler32.Checksum([]byte("istio-system/istio-ingressgateway"))),
+			Namespace:       "test-ns",
+			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(&ingressResource)},
+			Labels: map[string]string{
+				networking.IngressLabelKey: "ingress",
+			},
+		},
+		Spec: istiov1beta1.Gateway{
+			Selector: selector,
+			Servers:  []*istiov1beta1.Server{&httpServer},
+		},
+	}}
+	got, err := MakeIngressGateways(ctx, &ingressResource, []*istiov1beta1.Server{&httpServer}, svcLister)
+	if err != nil {
+		t.Fatal("MakeIngressGateways() =", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error("Unexpected Gateways (-want, +got):", diff)
+	}
+}
+
 func serviceLister(ctx context.Context, svcs ...*corev1.Service) corev1listers.ServiceLister {
 	fake := fakekubeclient.Get(ctx)
 	informer := fakeserviceinformer.Get(ctx)
@@ -0,0 +1,184 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"knative.dev/net-istio/pkg/reconciler/ingress/config"
+)
+
+// The names of the header policy settings. They are the suffixes of both the
+// Ingress annotations and the `header-policy.*` keys of config-istio.
+const (
+	requestHeadersRemoveSetting  = "request-headers-remove"
+	responseHeadersSetSetting    = "response-headers-set"
+	responseHeadersAddSetting    = "response-headers-add"
+	responseHeadersRemoveSetting = "response-headers-remove"
+)
+
+// headerPolicySettings lists the header policy settings along with the
+// function applying their value to the header operations of a route.
+var headerPolicySettings = []struct {
+	name  string
+	apply func(*istiov1beta1.Headers, string) error
+}{
+	{requestHeadersRemoveSetting, applyRequestHeadersRemove},
+	{responseHeadersSetSetting, applyResponseHeadersSet},
+	{responseHeadersAddSetting, applyResponseHeadersAdd},
+	{responseHeadersRemoveSetting, applyResponseHeadersRemove},
+}
+
+// makeHeaderPolicies parses the header operations of the paths of the given
+// Ingress from its annotations, falling back to the defaults configured in
+// config-istio, along with the header operations of its splits keyed by the
+// name of their backend Service. An empty annotation value opts the Ingress
+// out of the default of that setting. It returns nil when there is none.
+func makeHeaderPolicies(ctx context.Context, annotations map[string]string) (*istiov1beta1.Headers, map[string]*istiov1beta1.Headers, error) {
+	defaults := config.FromContextOrDefaults(ctx).Istio.HeaderPolicy
+
+	var pathHeaders *istiov1beta1.Headers
+	var splitHeaders map[string]*istiov1beta1.Headers
+	for _, setting := range headerPolicySettings {
+		key := annotationPrefix + setting.name
+		v, annotated := annotations[key]
+		if !annotated {
+			v = defaults[setting.name]
+		}
+		if v != "" {
+			if pathHeaders == nil {
+				pathHeaders = &istiov1beta1.Headers{}
+			}
+			if err := setting.apply(pathHeaders, v); err != nil {
+				if annotated {
+					return nil, nil, annotationError(key, v, err)
+				}
+				return nil, nil, fmt.Errorf("invalid value %q for header-policy.%s in config-istio: %w", v, setting.name, err)
+			}
+		}
+
+		// The `<setting>.<service>` annotations target the splits of the
+		// given backend Service.
+		for k, v := range annotations {
+			if !strings.HasPrefix(k, key+".") {
+				continue
+			}
+			service := strings.TrimPrefix(k, key+".")
+			if errs := validation.IsDNS1035Label(service); len(errs) != 0 {
+				return nil, nil, annotationError(k, v, fmt.Errorf("invalid Service name %q: %s", service, strings.Join(errs, ", ")))
+			}
+			if splitHeaders == nil {
+				splitHeaders = map[string]*istiov1beta1.Headers{}
+			}
+			if splitHeaders[service] == nil {
+				splitHeaders[service] = &istiov1beta1.Headers{}
+			}
+			if err := setting.apply(splitHeaders[service], v); err != nil {
+				return nil, nil, annotationError(k, v, err)
+			}
+		}
+	}
+	return pathHeaders, splitHeaders, nil
+}
+
+// makeHeaders returns the header operations setting the given request headers
+// along with the given header policy, or nil when there is none.
+func makeHeaders(requestSet map[string]string, policy *istiov1beta1.Headers) *istiov1beta1.Headers {
+	var h *istiov1beta1.Headers
+	if policy != nil {
+		h = policy.DeepCopy()
+	}
+	if len(requestSet) > 0 {
+		if h == nil {
+			h = &istiov1beta1.Headers{}
+		}
+		if h.Request == nil {
+			h.Request = &istiov1beta1.Headers_HeaderOperations{}
+		}
+		h.Request.Set = requestSet
+	}
+	return h
+}
+
+func applyRequestHeadersRemove(h *istiov1beta1.Headers, v string) error {
+	names, err := parseHeaderNames(v)
+	if err != nil {
+		return err
+	}
+	if h.Request == nil {
+		h.Request = &istiov1beta1.Headers_HeaderOperations{}
+	}
+	h.Request.Remove = names
+	return nil
+}
+
+func applyResponseHeadersSet(h *istiov1beta1.Headers, v string) error {
+	headers, err := parseHeaderValues(v)
+	if err != nil {
+		return err
+	}
+	if h.Response == nil {
+		h.Response = &istiov1beta1.Headers_HeaderOperations{}
+	}
+	h.Response.Set = headers
+	return nil
+}
+
+func applyResponseHeadersAdd(h *istiov1beta1.Headers, v string) error {
+	headers, err := parseHeaderValues(v)
+	if err != nil {
+		return err
+	}
+	if h.Response == nil {
+		h.Response = &istiov1beta1.Headers_HeaderOperations{}
+	}
+	h.Response.Add = headers
+	return nil
+}
+
+func applyResponseHeadersRemove(h *istiov1beta1.Headers, v string) error {
+	names, err := parseHeaderNames(v)
+	if err != nil {
+		return err
+	}
+	if h.Response == nil {
+		h.Response = &istiov1beta1.Headers_HeaderOperations{}
+	}
+	h.Response.Remove = names
+	return nil
+}
+
+// parseHeaderValues parses a comma separated list of `<header>=<value>` pairs.
+func parseHeaderValues(v string) (map[string]string, error) {
+	headers := map[string]string{}
+	for _, pair := range splitList(v) {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("expected <header>=<value>, got %q", pair)
+		}
+		header := strings.TrimSpace(parts[0])
+		if errs := validation.IsHTTPHeaderName(header); len(errs) != 0 {
+			return nil, fmt.Errorf("invalid header %q: %s", header, strings.Join(errs, ", "))
+		}
+		headers[header] = strings.TrimSpace(parts[1])
+	}
+	return headers, nil
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	"knative.dev/net-istio/pkg/reconciler/ingress/config"
+)
+
+func TestMakeHeaderPolicies(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		defaults    map[string]string
+		wantPath    *istiov1beta1.Headers
+		wantSplits  map[string]*istiov1beta1.Headers
+		wantErr     bool
+	}{{
+		name: "no header policy",
+	}, {
+		name: "annotations",
+		annotations: map[string]string{
+			RequestHeadersRemoveAnnotationKey:  "Cookie",
+			ResponseHeadersSetAnnotationKey:    "Strict-Transport-Security=max-age=31536000, X-Frame-Options=DENY",
+			ResponseHeadersAddAnnotationKey:    "Via=knative",
+			ResponseHeadersRemoveAnnotationKey: "server,x-envoy-upstream-service-time",
+		},
+		wantPath: &istiov1beta1.Headers{
+			Request: &istiov1beta1.Headers_HeaderOperations{
+				Remove: []string{"Cookie"},
+			},
+			Response: &istiov1beta1.Headers_HeaderOperations{
+				Set: map[string]string{
+					"Strict-Transport-Security": "max-age=31536000",
+					"X-Frame-Options":           "DENY",
+				},
+				Add:    map[string]string{"Via": "knative"},
+				Remove: []string{"server", "x-envoy-upstream-service-time"},
+			},
+		},
+	}, {
+		name: "defaults overridden by annotations",
+		annotations: map[string]string{
+			ResponseHeadersSetAnnotationKey:    "X-Frame-Options=SAMEORIGIN",
+			ResponseHeadersRemoveAnnotationKey: "",
+		},
+		defaults: map[string]string{
+			responseHeadersSetSetting:    "X-Frame-Options=DENY",
+			responseHeadersRemoveSetting: "server",
+			requestHeadersRemoveSetting:  "Cookie",
+		},
+		wantPath: &istiov1beta1.Headers{
+			Request: &istiov1beta1.Headers_HeaderOperations{
+				Remove: []string{"Cookie"},
+			},
+			Response: &istiov1beta1.Headers_HeaderOperations{
+				Set: map[string]string{"X-Frame-Options": "SAMEORIGIN"},
+			},
+		},
+	}, {
+		name: "splits",
+		annotations: map[string]string{
+			ResponseHeadersSetAnnotationKey + ".rev-1":    "X-Revision=rev-1",
+			RequestHeadersRemoveAnnotationKey + ".rev-2":  "X-Debug",
+			ResponseHeadersRemoveAnnotationKey + ".rev-2": "server",
+		},
+		wantSplits: map[string]*istiov1beta1.Headers{
+			"rev-1": {
+				Response: &istiov1beta1.Headers_HeaderOperations{
+					Set: map[string]string{"X-Revision": "rev-1"},
+				},
+			},
+			"rev-2": {
+				Request: &istiov1beta1.Headers_HeaderOperations{
+					Remove: []string{"X-Debug"},
+				},
+				Response: &istiov1beta1.Headers_HeaderOperations{
+					Remove: []string{"server"},
+				},
+			},
+		},
+	}, {
+		name:        "malformed header value",
+		annotations: map[string]string{ResponseHeadersSetAnnotationKey: "X-Frame-Options"},
+		wantErr:     true,
+	}, {
+		name:        "invalid header name",
+		annotations: map[string]string{ResponseHeadersRemoveAnnotationKey: "x envoy"},
+		wantErr:     true,
+	}, {
+		name:        "invalid split Service",
+		annotations: map[string]string{ResponseHeadersRemoveAnnotationKey + ".Rev_1": "server"},
+		wantErr:     true,
+	}, {
+		name:     "invalid default",
+		defaults: map[string]string{responseHeadersAddSetting: "Via"},
+		wantErr:  true,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := config.ToContext(context.Background(), &config.Config{
+				Istio: &config.Istio{HeaderPolicy: tc.defaults},
+			})
+			path, splits, err := makeHeaderPolicies(ctx, tc.annotations)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("makeHeaderPolicies() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.wantPath, path); diff != "" {
+				t.Error("Unexpected path headers (-want +got):", diff)
+			}
+			if diff := cmp.Diff(tc.wantSplits, splits); diff != "" {
+				t.Error("Unexpected split headers (-want +got):", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+	"strings"
+
+	"knative.dev/net-istio/pkg/reconciler/ingress/config"
+	net "knative.dev/networking/pkg"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+)
+
+// acmeChallengePathPrefix is the path prefix of the ACME HTTP-01 challenges,
+// which must be answered over plain HTTP.
+const acmeChallengePathPrefix = "/.well-known/acme-challenge/"
+
+// isACMEChallengePath returns whether the given Ingress path answers ACME
+// HTTP-01 challenges.
+func isACMEChallengePath(http *v1alpha1.HTTPIngressPath) bool {
+	return strings.HasPrefix(http.Path, acmeChallengePathPrefix)
+}
+
+// isHTTPSRedirected returns whether the plain HTTP requests for the public
+// hosts of the given Ingress are redirected to HTTPS. The HTTPOption of the
+// Ingress takes precedence over the global HTTPProtocol, which is only
+// effective when Auto TLS is enabled.
+func isHTTPSRedirected(ctx context.Context, ing *v1alpha1.Ingress) bool {
+	switch ing.Spec.HTTPOption {
+	case v1alpha1.HTTPOptionRedirected:
+		return true
+	case "":
+		cfg := config.FromContextOrDefaults(ctx)
+		return cfg.Network != nil && cfg.Network.AutoTLS && cfg.Network.HTTPProtocol == net.HTTPRedirected
+	}
+	return false
+}
+
+// servesPlainHTTP returns whether the default HTTP server of the shared
+// Gateways serves plain HTTP. The global HTTPProtocol only applies with
+// AutoTLS, see the reconciliation of the default HTTP server.
+func servesPlainHTTP(ctx context.Context) bool {
+	cfg := config.FromContextOrDefaults(ctx)
+	return cfg.Network == nil || !cfg.Network.AutoTLS || cfg.Network.HTTPProtocol == net.HTTPEnabled
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+
+	istiosecurity "istio.io/api/security/v1beta1"
+	istiotype "istio.io/api/type/v1beta1"
+	securityv1beta1 "istio.io/client-go/pkg/apis/security/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"knative.dev/net-istio/pkg/reconciler/ingress/resources/names"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+)
+
+// MakeJWTPolicies creates the RequestAuthentications and AuthorizationPolicies
+// requiring a JWT on the hosts of the given Ingress, per the JWT annotations of
+// the Ingress. They select the gateways serving the Ingress. The given jwks is
+// the JSON Web Key Set read from the Secret of JWTJwksSecretAnnotationKey, if
+// any.
+//
+// The RequestAuthentication verifies the tokens of the issuer, and the DENY
+// AuthorizationPolicy rejects the requests for the hosts of the Ingress without
+// a valid token or with a token missing one of the required claims.
+func MakeJWTPolicies(ing *v1alpha1.Ingress, jwks string, gateways *PolicyGateways, svcLister corev1listers.ServiceLister) (
+	[]*securityv1beta1.RequestAuthentication, []*securityv1beta1.AuthorizationPolicy, error) {
+	opts, err := makeJWTOptions(ing)
+	if err != nil || opts == nil {
+		return nil, nil, err
+	}
+	gatewayHosts, err := getGatewayHosts(gateways, ing, svcLister)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	jwtRule := &istiosecurity.JWTRule{
+		Issuer:  opts.issuer,
+		JwksUri: opts.jwksURI,
+	}
+	if opts.jwksSecret != "" {
+		if jwks == "" {
+			return nil, nil, fmt.Errorf("the Secret %s/%s of annotation %s has no %q key", ing.Namespace, opts.jwksSecret,
+				JWTJwksSecretAnnotationKey, JWKSSecretKey)
+		}
+		jwtRule.Jwks = jwks
+	}
+
+	ras := make([]*securityv1beta1.RequestAuthentication, 0, len(gatewayHosts))
+	aps := make([]*securityv1beta1.AuthorizationPolicy, 0, len(gatewayHosts))
+	for _, gw := range gatewayHosts {
+		meta := metav1.ObjectMeta{
+			Name:      names.JWTPolicy(ing, gw.service.Name),
+			Namespace: gw.service.Namespace,
+			Labels:    MakeGatewayPolicyLabels(ing),
+		}
+		selector := &istiotype.WorkloadSelector{
+			MatchLabels: gw.service.Spec.Selector,
+		}
+		ras = append(ras, &securityv1beta1.RequestAuthentication{
+			ObjectMeta: *meta.DeepCopy(),
+			Spec: istiosecurity.RequestAuthentication{
+				Selector: selector,
+				JwtRules: []*istiosecurity.JWTRule{jwtRule},
+			},
+		})
+
+		op := &istiosecurity.Operation{Hosts: policyHosts(gw.hosts)}
+		rules := policyRules(op, &istiosecurity.Source{NotRequestPrincipals: []string{opts.issuer + "/*"}})
+		for _, claim := range sets.StringKeySet(opts.requiredClaims).List() {
+			rules = append(rules, policyRules(op, nil, &istiosecurity.Condition{
+				Key:       "request.auth.claims[" + claim + "]",
+				NotValues: []string{opts.requiredClaims[claim]},
+			})...)
+		}
+		aps = append(aps, &securityv1beta1.AuthorizationPolicy{
+			ObjectMeta: *meta.DeepCopy(),
+			Spec: istiosecurity.AuthorizationPolicy{
+				Selector: selector,
+				Action:   istiosecurity.AuthorizationPolicy_DENY,
+				Rules:    rules,
+			},
+		})
+	}
+	return ras, aps, nil
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	istiosecurity "istio.io/api/security/v1beta1"
+	istiotype "istio.io/api/type/v1beta1"
+	securityv1beta1 "istio.io/client-go/pkg/apis/security/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/net-istio/pkg/reconciler/ingress/config"
+	"knative.dev/networking/pkg/apis/networking"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/pkg/system"
+
+	rtesting "knative.dev/pkg/reconciler/testing"
+)
+
+func TestMakeJWTPolicies(t *testing.T) {
+	ctx, cancel, _ := rtesting.SetupFakeContextWithCancel(t)
+	defer cancel()
+	gatewayService := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "istio-ingressgateway",
+			Namespace: "istio-system",
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: selector,
+		},
+	}
+	svcLister := serviceLister(ctx, gatewayService)
+	gateways := &PolicyGateways{
+		Services: map[v1alpha1.IngressVisibility][]config.Gateway{
+			v1alpha1.IngressVisibilityExternalIP: {{
+				Namespace:  system.Namespace(),
+				Name:       config.KnativeIngressGateway,
+				ServiceURL: "istio-ingressgateway.istio-system.svc.cluster.local",
+			}},
+		},
+	}
+
+	ing := &v1alpha1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ingress",
+			Namespace: "test-ns",
+		},
+		Spec: v1alpha1.IngressSpec{
+			Rules: []v1alpha1.IngressRule{{
+				Hosts:      []string{"foo.example.com"},
+				Visibility: v1alpha1.IngressVisibilityExternalIP,
+			}},
+		},
+	}
+	meta := metav1.ObjectMeta{
+		Name:      "test-ns--ingress-istio-ingressgateway-jwt",
+		Namespace: "istio-system",
+		Labels: map[string]string{
+			networking.IngressLabelKey: "ingress",
+			IngressNamespaceLabelKey:   "test-ns",
+		},
+	}
+	requestAuthentication := func(rule *istiosecurity.JWTRule) []*securityv1beta1.RequestAuthentication {
+		return []*securityv1beta1.RequestAuthentication{{
+			ObjectMeta: meta,
+			Spec: istiosecurity.RequestAuthentication{
+				Selector: &istiotype.WorkloadSelector{MatchLabels: selector},
+				JwtRules: []*istiosecurity.JWTRule{rule},
+			},
+		}}
+	}
+	op := &istiosecurity.Operation{Hosts: []string{"foo.example.com", "foo.example.com:*"}}
+	authorizationPolicy := func(rules ...*istiosecurity.Rule) []*securityv1beta1.AuthorizationPolicy {
+		return []*securityv1beta1.AuthorizationPolicy{{
+			ObjectMeta: meta,
+			Spec: istiosecurity.AuthorizationPolicy{
+				Selector: &istiotype.WorkloadSelector{MatchLabels: selector},
+				Action:   istiosecurity.AuthorizationPolicy_DENY,
+				Rules:    rules,
+			},
+		}}
+	}
+	requirePrincipal := policyRules(op,
+		&istiosecurity.Source{NotRequestPrincipals: []string{"https://issuer.example.com/*"}})
+
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		jwks        string
+		wantRAs     []*securityv1beta1.RequestAuthentication
+		wantAPs     []*securityv1beta1.AuthorizationPolicy
+		wantErr     bool
+	}{{
+		name: "no JWT",
+	}, {
+		name: "JWKS URI",
+		annotations: map[string]string{
+			JWTIssuerAnnotationKey:  "https://issuer.example.com",
+			JWTJwksURIAnnotationKey: "https://issuer.example.com/keys",
+		},
+		wantRAs: requestAuthentication(&istiosecurity.JWTRule{
+			Issuer:  "https://issuer.example.com",
+			JwksUri: "https://issuer.example.com/keys",
+		}),
+		wantAPs: authorizationPolicy(requirePrincipal...),
+	}, {
+		name: "JWKS Secret and required claims",
+		annotations: map[string]string{
+			JWTIssuerAnnotationKey:         "https://issuer.example.com",
+			JWTJwksSecretAnnotationKey:     "jwks",
+			JWTRequiredClaimsAnnotationKey: "group=admin, aud=knative",
+		},
+		jwks: `{"keys":[]}`,
+		wantRAs: requestAuthentication(&istiosecurity.JWTRule{
+			Issuer: "https://issuer.example.com",
+			Jwks:   `{"keys":[]}`,
+		}),
+		wantAPs: authorizationPolicy(append(append(requirePrincipal,
+			policyRules(op, nil, &istiosecurity.Condition{
+				Key:       "request.auth.claims[aud]",
+				NotValues: []string{"knative"},
+			})...),
+			policyRules(op, nil, &istiosecurity.Condition{
+				Key:       "request.auth.claims[group]",
+				NotValues: []string{"admin"},
+			})...)...),
+	}, {
+		name: "JWKS Secret without key",
+		annotations: map[string]string{
+			JWTIssuerAnnotationKey:     "https://issuer.example.com",
+			JWTJwksSecretAnnotationKey: "jwks",
+		},
+		wantErr: true,
+	}, {
+		name: "missing issuer",
+		annotations: map[string]string{
+			JWTJwksURIAnnotationKey: "https://issuer.example.com/keys",
+		},
+		wantErr: true,
+	}, {
+		name: "JWKS URI and Secret",
+		annotations: map[string]string{
+			JWTIssuerAnnotationKey:     "https://issuer.example.com",
+			JWTJwksURIAnnotationKey:    "https://issuer.example.com/keys",
+			JWTJwksSecretAnnotationKey: "jwks",
+		},
+		wantErr: true,
+	}, {
+		name: "relative JWKS URI",
+		annotations: map[string]string{
+			JWTIssuerAnnotationKey:  "https://issuer.example.com",
+			JWTJwksURIAnnotationKey: "/keys",
+		},
+		wantErr: true,
+	}, {
+		name: "invalid required claims",
+		annotations: map[string]string{
+			JWTIssuerAnnotationKey:         "https://issuer.example.com",
+			JWTRequiredClaimsAnnotationKey: "group",
+		},
+		wantErr: true,
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ing := ing.DeepCopy()
+			ing.Annotations = c.annotations
+			ras, aps, err := MakeJWTPolicies(ing, c.jwks, gateways, svcLister)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("MakeJWTPolicies() = %v, wantErr = %v", err, c.wantErr)
+			}
+			if diff := cmp.Diff(c.wantRAs, ras); diff != "" {
+				t.Error("Unexpected RequestAuthentications (-want, +got):", diff)
+			}
+			if diff := cmp.Diff(c.wantAPs, aps); diff != "" {
+				t.Error("Unexpected AuthorizationPolicies (-want, +got):", diff)
+			}
+		})
+	}
+}
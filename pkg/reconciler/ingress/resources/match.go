@@ -0,0 +1,204 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+)
+
+// headerMatchType is the type of matching applied to a header of an Ingress path.
+type headerMatchType string
+
+const (
+	// headerMatchExact matches the header value exactly. This is the default.
+	headerMatchExact headerMatchType = "exact"
+	// headerMatchPrefix matches the prefix of the header value.
+	headerMatchPrefix headerMatchType = "prefix"
+	// headerMatchRegex matches the header value against an RE2 regex.
+	headerMatchRegex headerMatchType = "regex"
+	// headerMatchPresent matches when the header is present, whatever its value.
+	headerMatchPresent headerMatchType = "present"
+)
+
+// pathMatchType is the type of matching applied to the paths of an Ingress.
+type pathMatchType string
+
+const (
+	// pathMatchPrefix matches the prefix of the request path. This is the
+	// default, so "/api" matches "/apiv2" too.
+	pathMatchPrefix pathMatchType = "prefix"
+	// pathMatchExact matches the request path exactly.
+	pathMatchExact pathMatchType = "exact"
+	// pathMatchSegmentPrefix matches the request path and its subpaths, so
+	// "/api" matches "/api" and "/api/v2" but not "/apiv2".
+	pathMatchSegmentPrefix pathMatchType = "segment-prefix"
+	// pathMatchRegex matches the request path against an RE2 regex.
+	pathMatchRegex pathMatchType = "regex"
+)
+
+// maxRegexLength is the maximum length of the regexes of the matches, since
+// Istio 1.4 or later rejects longer regexes.
+const maxRegexLength = 100
+
+// presentRegex matches any value. Envoy does not match a regex against
+// absent headers, so it effectively checks for the presence of a header.
+const presentRegex = ".*"
+
+// makeMatches returns the matches of the given host and Ingress path. A
+// segment-prefix path needs a pair of matches, one for the path itself and one
+// for its subpaths, to avoid a regex.
+func makeMatches(host, path string, headers map[string]v1alpha1.HeaderMatch, gateways sets.String, opts *routeOptions) []*istiov1beta1.HTTPMatchRequest {
+	match := makeMatch(host, path, headers, gateways, opts)
+	if opts.pathMatchType != pathMatchSegmentPrefix || path == "" || strings.HasSuffix(path, "/") {
+		return []*istiov1beta1.HTTPMatchRequest{match}
+	}
+	subpaths := match.DeepCopy()
+	subpaths.Uri = &istiov1beta1.StringMatch{
+		MatchType: &istiov1beta1.StringMatch_Prefix{Prefix: path + "/"},
+	}
+	return []*istiov1beta1.HTTPMatchRequest{match, subpaths}
+}
+
+func makeMatch(host, path string, headers map[string]v1alpha1.HeaderMatch, gateways sets.String, opts *routeOptions) *istiov1beta1.HTTPMatchRequest {
+	match := &istiov1beta1.HTTPMatchRequest{
+		Gateways: gateways.List(),
+		Authority: &istiov1beta1.StringMatch{
+			// Do not use Regex as Istio 1.4 or later has 100 bytes limitation.
+			MatchType: &istiov1beta1.StringMatch_Prefix{Prefix: hostPrefix(host)},
+		},
+	}
+	// Empty path is considered match all path. We only need to consider path
+	// when it's non-empty.
+	if path != "" {
+		match.Uri = makePathMatch(path, opts.pathMatchType)
+	}
+	match.Headers = makeHeaderMatches(headers, opts.headerMatchTypes)
+	return match
+}
+
+// makePathMatch converts a non-empty Ingress path into an Istio string match
+// of the given type. The subpaths of a segment-prefix path are matched
+// separately, see makeMatches.
+func makePathMatch(path string, matchType pathMatchType) *istiov1beta1.StringMatch {
+	switch matchType {
+	case pathMatchExact:
+		return &istiov1beta1.StringMatch{
+			MatchType: &istiov1beta1.StringMatch_Exact{Exact: path},
+		}
+	case pathMatchSegmentPrefix:
+		if strings.HasSuffix(path, "/") {
+			return &istiov1beta1.StringMatch{
+				MatchType: &istiov1beta1.StringMatch_Prefix{Prefix: path},
+			}
+		}
+		return &istiov1beta1.StringMatch{
+			MatchType: &istiov1beta1.StringMatch_Exact{Exact: path},
+		}
+	case pathMatchRegex:
+		return &istiov1beta1.StringMatch{
+			MatchType: &istiov1beta1.StringMatch_Regex{Regex: path},
+		}
+	default:
+		return &istiov1beta1.StringMatch{
+			MatchType: &istiov1beta1.StringMatch_Prefix{Prefix: path},
+		}
+	}
+}
+
+// validatePathRegexes checks that the paths of the given Ingress are valid
+// regexes that Istio accepts.
+func validatePathRegexes(ing *v1alpha1.Ingress) error {
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			if len(path.Path) > maxRegexLength {
+				return fmt.Errorf("path regex %q is longer than %d bytes", path.Path, maxRegexLength)
+			}
+			if _, err := regexp.Compile(path.Path); err != nil {
+				return fmt.Errorf("invalid path regex: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateHeaderRegexes checks that the values of the headers of the given
+// Ingress matched with the regex match type are valid regexes that Istio
+// accepts.
+func validateHeaderRegexes(ing *v1alpha1.Ingress, types map[string]headerMatchType) error {
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			for k, v := range path.Headers {
+				if types[strings.ToLower(k)] != headerMatchRegex {
+					continue
+				}
+				if len(v.Exact) > maxRegexLength {
+					return fmt.Errorf("header %s regex %q is longer than %d bytes", k, v.Exact, maxRegexLength)
+				}
+				if _, err := regexp.Compile(v.Exact); err != nil {
+					return fmt.Errorf("invalid header %s regex: %w", k, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// makeHeaderMatches converts the header matches of an Ingress path into Istio
+// string matches, using the match type configured for each header.
+func makeHeaderMatches(headers map[string]v1alpha1.HeaderMatch, types map[string]headerMatchType) map[string]*istiov1beta1.StringMatch {
+	if len(headers) == 0 {
+		return nil
+	}
+	matches := make(map[string]*istiov1beta1.StringMatch, len(headers))
+	for k, v := range headers {
+		matches[k] = makeHeaderMatch(v.Exact, types[strings.ToLower(k)])
+	}
+	return matches
+}
+
+func makeHeaderMatch(value string, matchType headerMatchType) *istiov1beta1.StringMatch {
+	switch matchType {
+	case headerMatchPrefix:
+		return &istiov1beta1.StringMatch{
+			MatchType: &istiov1beta1.StringMatch_Prefix{Prefix: value},
+		}
+	case headerMatchRegex:
+		return &istiov1beta1.StringMatch{
+			MatchType: &istiov1beta1.StringMatch_Regex{Regex: value},
+		}
+	case headerMatchPresent:
+		return &istiov1beta1.StringMatch{
+			MatchType: &istiov1beta1.StringMatch_Regex{Regex: presentRegex},
+		}
+	default:
+		return &istiov1beta1.StringMatch{
+			MatchType: &istiov1beta1.StringMatch_Exact{Exact: value},
+		}
+	}
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+)
+
+func TestMakeMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		host    string
+		path    string
+		headers map[string]v1alpha1.HeaderMatch
+		opts    *routeOptions
+		want    *istiov1beta1.HTTPMatchRequest
+	}{{
+		name: "authority only",
+		host: "foo.example.com",
+		opts: &routeOptions{},
+		want: &istiov1beta1.HTTPMatchRequest{
+			Gateways:  []string{"gateway"},
+			Authority: prefixMatch("foo.example.com"),
+		},
+	}, {
+		name: "cluster local authority and path",
+		host: "foo.bar.svc.cluster.local",
+		path: "/pets",
+		opts: &routeOptions{},
+		want: &istiov1beta1.HTTPMatchRequest{
+			Gateways:  []string{"gateway"},
+			Authority: prefixMatch("foo.bar"),
+			Uri:       prefixMatch("/pets"),
+		},
+	}, {
+		name: "multiple exact headers",
+		host: "foo.example.com",
+		headers: map[string]v1alpha1.HeaderMatch{
+			"x-canary": {Exact: "true"},
+			"x-user":   {Exact: "alice"},
+		},
+		opts: &routeOptions{},
+		want: &istiov1beta1.HTTPMatchRequest{
+			Gateways:  []string{"gateway"},
+			Authority: prefixMatch("foo.example.com"),
+			Headers: map[string]*istiov1beta1.StringMatch{
+				"x-canary": exactMatch("true"),
+				"x-user":   exactMatch("alice"),
+			},
+		},
+	}, {
+		name: "headers with every match type and path",
+		host: "foo.example.com",
+		path: "/pets",
+		headers: map[string]v1alpha1.HeaderMatch{
+			"x-canary":  {Exact: "true"},
+			"x-user":    {Exact: "ali"},
+			"x-version": {Exact: "v[0-9]+"},
+			"X-Debug":   {Exact: "ignored"},
+		},
+		opts: &routeOptions{
+			headerMatchTypes: map[string]headerMatchType{
+				"x-canary":  headerMatchExact,
+				"x-user":    headerMatchPrefix,
+				"x-version": headerMatchRegex,
+				"x-debug":   headerMatchPresent,
+			},
+		},
+		want: &istiov1beta1.HTTPMatchRequest{
+			Gateways:  []string{"gateway"},
+			Authority: prefixMatch("foo.example.com"),
+			Uri:       prefixMatch("/pets"),
+			Headers: map[string]*istiov1beta1.StringMatch{
+				"x-canary": exactMatch("true"),
+				"x-user":   prefixMatch("ali"),
+				"x-version": {
+					MatchType: &istiov1beta1.StringMatch_Regex{Regex: "v[0-9]+"},
+				},
+				"X-Debug": {
+					MatchType: &istiov1beta1.StringMatch_Regex{Regex: presentRegex},
+				},
+			},
+		},
+	}, {
+		name: "match type of a header that is not matched",
+		host: "foo.example.com",
+		headers: map[string]v1alpha1.HeaderMatch{
+			"x-canary": {Exact: "true"},
+		},
+		opts: &routeOptions{
+			headerMatchTypes: map[string]headerMatchType{
+				"x-user": headerMatchPrefix,
+			},
+		},
+		want: &istiov1beta1.HTTPMatchRequest{
+			Gateways:  []string{"gateway"},
+			Authority: prefixMatch("foo.example.com"),
+			Headers: map[string]*istiov1beta1.StringMatch{
+				"x-canary": exactMatch("true"),
+			},
+		},
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := makeMatch(tc.host, tc.path, tc.headers, sets.NewString("gateway"), tc.opts)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Error("Unexpected match (-want +got):", diff)
+			}
+		})
+	}
+}
+
+func prefixMatch(prefix string) *istiov1beta1.StringMatch {
+	return &istiov1beta1.StringMatch{
+		MatchType: &istiov1beta1.StringMatch_Prefix{Prefix: prefix},
+	}
+}
+
+func exactMatch(exact string) *istiov1beta1.StringMatch {
+	return &istiov1beta1.StringMatch{
+		MatchType: &istiov1beta1.StringMatch_Exact{Exact: exact},
+	}
+}
+
+func TestMakeMatches(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		matchType pathMatchType
+		want      []*istiov1beta1.StringMatch
+	}{{
+		name: "default",
+		path: "/api",
+		want: []*istiov1beta1.StringMatch{prefixMatch("/api")},
+	}, {
+		name:      "prefix",
+		path:      "/api",
+		matchType: pathMatchPrefix,
+		want:      []*istiov1beta1.StringMatch{prefixMatch("/api")},
+	}, {
+		name:      "exact",
+		path:      "/api",
+		matchType: pathMatchExact,
+		want:      []*istiov1beta1.StringMatch{exactMatch("/api")},
+	}, {
+		name:      "segment prefix",
+		path:      "/api",
+		matchType: pathMatchSegmentPrefix,
+		want:      []*istiov1beta1.StringMatch{exactMatch("/api"), prefixMatch("/api/")},
+	}, {
+		name:      "segment prefix with trailing slash",
+		path:      "/api/",
+		matchType: pathMatchSegmentPrefix,
+		want:      []*istiov1beta1.StringMatch{prefixMatch("/api/")},
+	}, {
+		name:      "regex",
+		path:      "/api/v[0-9]+",
+		matchType: pathMatchRegex,
+		want: []*istiov1beta1.StringMatch{{
+			MatchType: &istiov1beta1.StringMatch_Regex{Regex: "/api/v[0-9]+"},
+		}},
+	}, {
+		name:      "empty path",
+		matchType: pathMatchSegmentPrefix,
+		want:      []*istiov1beta1.StringMatch{nil},
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			headers := map[string]v1alpha1.HeaderMatch{"x-canary": {Exact: "true"}}
+			got := makeMatches("foo.example.com", tc.path, headers, sets.NewString("gateway"), &routeOptions{pathMatchType: tc.matchType})
+			if len(got) != len(tc.want) {
+				t.Fatalf("makeMatches() = %d matches, wanted %d", len(got), len(tc.want))
+			}
+			for i, match := range got {
+				want := &istiov1beta1.HTTPMatchRequest{
+					Gateways:  []string{"gateway"},
+					Authority: prefixMatch("foo.example.com"),
+					Uri:       tc.want[i],
+					Headers: map[string]*istiov1beta1.StringMatch{
+						"x-canary": exactMatch("true"),
+					},
+				}
+				if diff := cmp.Diff(want, match); diff != "" {
+					t.Errorf("Unexpected match %d (-want +got): %s", i, diff)
+				}
+			}
+		})
+	}
+}
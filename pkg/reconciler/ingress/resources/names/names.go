@@ -33,3 +33,39 @@ func IngressVirtualService(i kmeta.Accessor) string {
 func MeshVirtualService(i kmeta.Accessor) string {
 	return kmeta.ChildName(i.GetName(), "-mesh")
 }
+
+// DestinationRule returns the name of the DestinationRule child
+// resource applying the traffic policy of an Ingress to the given
+// backend Service. It only depends on the backend, since Istio
+// applies a single DestinationRule per host.
+func DestinationRule(service string) string {
+	return kmeta.ChildName(service, "-traffic-policy")
+}
+
+// ClusterLocalAuthorizationPolicy returns the name of the AuthorizationPolicy
+// restricting the access to the cluster-local hosts of the given Ingress on the
+// given gateway Service. As it lives in the namespace of the gateway Service,
+// the name is qualified with the namespace of the Ingress.
+func ClusterLocalAuthorizationPolicy(i kmeta.Accessor, gatewayService string) string {
+	return kmeta.ChildName(i.GetNamespace()+"--"+i.GetName()+"-"+gatewayService, "-cluster-local")
+}
+
+// JWTPolicy returns the name of the RequestAuthentication and of the
+// AuthorizationPolicy requiring a JWT on the hosts of the given Ingress on the
+// given gateway Service.
+func JWTPolicy(i kmeta.Accessor, gatewayService string) string {
+	return kmeta.ChildName(i.GetNamespace()+"--"+i.GetName()+"-"+gatewayService, "-jwt")
+}
+
+// ExtAuthzPolicy returns the name of the AuthorizationPolicy delegating the
+// authorization of the requests for the given Ingress on the given gateway
+// Service to an external authorization provider.
+func ExtAuthzPolicy(i kmeta.Accessor, gatewayService string) string {
+	return kmeta.ChildName(i.GetNamespace()+"--"+i.GetName()+"-"+gatewayService, "-ext-authz")
+}
+
+// RateLimitEnvoyFilter returns the name of the EnvoyFilter rate limiting the
+// requests for the given Ingress on the given gateway Service.
+func RateLimitEnvoyFilter(i kmeta.Accessor, gatewayService string) string {
+	return kmeta.ChildName(i.GetNamespace()+"--"+i.GetName()+"-"+gatewayService, "-rate-limit")
+}
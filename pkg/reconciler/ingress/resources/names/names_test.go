@@ -80,3 +80,57 @@ func TestNamer(t *testing.T) {
 		})
 	}
 }
+
+func TestDestinationRule(t *testing.T) {
+	if got, want := DestinationRule("bar"), "bar-traffic-policy"; got != want {
+		t.Errorf("DestinationRule() = %v, wanted %v", got, want)
+	}
+}
+
+func TestClusterLocalAuthorizationPolicy(t *testing.T) {
+	ing := &v1alpha1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "ns1",
+		},
+	}
+	if got, want := ClusterLocalAuthorizationPolicy(ing, "istio-ingressgateway"), "ns1--foo-istio-ingressgateway-cluster-local"; got != want {
+		t.Errorf("ClusterLocalAuthorizationPolicy() = %v, wanted %v", got, want)
+	}
+}
+
+func TestJWTPolicy(t *testing.T) {
+	ing := &v1alpha1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "ns1",
+		},
+	}
+	if got, want := JWTPolicy(ing, "istio-ingressgateway"), "ns1--foo-istio-ingressgateway-jwt"; got != want {
+		t.Errorf("JWTPolicy() = %v, wanted %v", got, want)
+	}
+}
+
+func TestExtAuthzPolicy(t *testing.T) {
+	ing := &v1alpha1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "ns1",
+		},
+	}
+	if got, want := ExtAuthzPolicy(ing, "istio-ingressgateway"), "ns1--foo-istio-ingressgateway-ext-authz"; got != want {
+		t.Errorf("ExtAuthzPolicy() = %v, wanted %v", got, want)
+	}
+}
+
+func TestRateLimitEnvoyFilter(t *testing.T) {
+	ing := &v1alpha1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "ns1",
+		},
+	}
+	if got, want := RateLimitEnvoyFilter(ing, "istio-ingressgateway"), "ns1--foo-istio-ingressgateway-rate-limit"; got != want {
+		t.Errorf("RateLimitEnvoyFilter() = %v, wanted %v", got, want)
+	}
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+)
+
+// parseTLSPassthroughHosts returns the hosts of the given Ingress served in TLS
+// passthrough mode, see TLSPassthroughHostsAnnotationKey.
+func parseTLSPassthroughHosts(ing *v1alpha1.Ingress) (sets.String, error) {
+	v, ok := ing.GetAnnotations()[TLSPassthroughHostsAnnotationKey]
+	if !ok {
+		return nil, nil
+	}
+	public := getPublicHosts(ing)
+	hosts := sets.NewString()
+	for _, host := range splitList(v) {
+		if !public.Has(host) {
+			return nil, annotationError(TLSPassthroughHostsAnnotationKey, v,
+				fmt.Errorf("%q is not a public host of the Ingress", host))
+		}
+		hosts.Insert(host)
+	}
+	return hosts, nil
+}
+
+// MakeIngressPassthroughServer creates the Gateway `Server` of the hosts of the
+// given Ingress served in TLS passthrough mode. It returns nil when there is
+// none.
+func MakeIngressPassthroughServer(ing *v1alpha1.Ingress) (*istiov1beta1.Server, error) {
+	hosts, err := parseTLSPassthroughHosts(ing)
+	if err != nil || hosts.Len() == 0 {
+		return nil, err
+	}
+	return &istiov1beta1.Server{
+		Hosts: hosts.List(),
+		Port: &istiov1beta1.Port{
+			Name:     portNamePrefix(ing.GetNamespace(), ing.GetName()) + ":passthrough",
+			Number:   GatewayHTTPSPort,
+			Protocol: "TLS",
+		},
+		Tls: &istiov1beta1.ServerTLSSettings{
+			Mode: istiov1beta1.ServerTLSSettings_PASSTHROUGH,
+		},
+	}, nil
+}
+
+// makeTLSRoute returns the route of the TLS connections for the hosts of the
+// given rule served in TLS passthrough mode, which are routed by SNI to the
+// splits of the rule. It returns nil when the rule has no such host.
+func makeTLSRoute(hosts sets.String, rule *v1alpha1.IngressRule, gateways map[v1alpha1.IngressVisibility]sets.String, passthrough sets.String) *istiov1beta1.TLSRoute {
+	hosts = hosts.Intersection(passthrough)
+	g := gateways[rule.Visibility]
+	if hosts.Len() == 0 || g.Len() == 0 {
+		return nil
+	}
+	// The connections are forwarded as is, so the probes are left to the
+	// HTTP servers.
+	splits := ruleSplits(rule)
+	if len(splits) == 0 {
+		return nil
+	}
+	return &istiov1beta1.TLSRoute{
+		Match: []*istiov1beta1.TLSMatchAttributes{{
+			SniHosts: hosts.List(),
+			Gateways: g.List(),
+			Port:     GatewayHTTPSPort,
+		}},
+		Route: makeRouteDestinations(splits),
+	}
+}
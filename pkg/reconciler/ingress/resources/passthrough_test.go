@@ -0,0 +1,156 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+)
+
+func passthroughIngress(hosts string) *v1alpha1.Ingress {
+	ing := &v1alpha1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "test-ns",
+		},
+		Spec: v1alpha1.IngressSpec{
+			Rules: []v1alpha1.IngressRule{{
+				Hosts:      []string{"secure.example.com", "test-ingress.test-ns.svc.cluster.local"},
+				Visibility: v1alpha1.IngressVisibilityExternalIP,
+				HTTP: &v1alpha1.HTTPIngressRuleValue{
+					Paths: []v1alpha1.HTTPIngressPath{{
+						Splits: []v1alpha1.IngressBackendSplit{{
+							IngressBackend: v1alpha1.IngressBackend{
+								ServiceNamespace: "test-ns",
+								ServiceName:      "rev-1",
+								ServicePort:      intstr.FromInt(8443),
+							},
+							Percent: 80,
+						}, {
+							IngressBackend: v1alpha1.IngressBackend{
+								ServiceNamespace: "test-ns",
+								ServiceName:      "rev-2",
+								ServicePort:      intstr.FromInt(8443),
+							},
+							Percent: 20,
+						}},
+					}},
+				},
+			}, {
+				Hosts:      []string{"private.test-ns.svc.cluster.local"},
+				Visibility: v1alpha1.IngressVisibilityClusterLocal,
+				HTTP:       &v1alpha1.HTTPIngressRuleValue{},
+			}},
+		},
+	}
+	if hosts != "" {
+		ing.Annotations = map[string]string{TLSPassthroughHostsAnnotationKey: hosts}
+	}
+	return ing
+}
+
+func TestMakeIngressPassthroughServer(t *testing.T) {
+	tests := []struct {
+		name    string
+		hosts   string
+		want    *istiov1beta1.Server
+		wantErr bool
+	}{{
+		name: "no passthrough host",
+	}, {
+		name:  "passthrough host",
+		hosts: "secure.example.com",
+		want: &istiov1beta1.Server{
+			Hosts: []string{"secure.example.com"},
+			Port: &istiov1beta1.Port{
+				Name:     "test-ns/test-ingress:passthrough",
+				Number:   443,
+				Protocol: "TLS",
+			},
+			Tls: &istiov1beta1.ServerTLSSettings{
+				Mode: istiov1beta1.ServerTLSSettings_PASSTHROUGH,
+			},
+		},
+	}, {
+		name:    "cluster-local host",
+		hosts:   "private.test-ns.svc.cluster.local",
+		wantErr: true,
+	}, {
+		name:    "unknown host",
+		hosts:   "secure.example.com, other.example.com",
+		wantErr: true,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := MakeIngressPassthroughServer(passthroughIngress(tc.hosts))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("MakeIngressPassthroughServer() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Error("Unexpected server (-want +got):", diff)
+			}
+		})
+	}
+}
+
+func TestMakeVirtualServices_TLSPassthrough(t *testing.T) {
+	ing := passthroughIngress("secure.example.com")
+	vses, err := MakeVirtualServices(context.Background(), ing, makeGatewayMap([]string{"gateway-1"}, []string{"private-gateway"}))
+	if err != nil {
+		t.Fatal("MakeVirtualServices() =", err)
+	}
+	if len(vses) != 2 {
+		t.Fatalf("MakeVirtualServices() = %d VirtualServices, wanted 2", len(vses))
+	}
+
+	if got := vses[0].Spec.Tls; got != nil {
+		t.Errorf("Mesh VirtualService has TLS routes %v", got)
+	}
+	want := []*istiov1beta1.TLSRoute{{
+		Match: []*istiov1beta1.TLSMatchAttributes{{
+			SniHosts: []string{"secure.example.com"},
+			Gateways: []string{"gateway-1"},
+			Port:     443,
+		}},
+		Route: []*istiov1beta1.RouteDestination{{
+			Destination: &istiov1beta1.Destination{
+				Host: "rev-1.test-ns.svc.cluster.local",
+				Port: &istiov1beta1.PortSelector{Number: 8443},
+			},
+			Weight: 80,
+		}, {
+			Destination: &istiov1beta1.Destination{
+				Host: "rev-2.test-ns.svc.cluster.local",
+				Port: &istiov1beta1.PortSelector{Number: 8443},
+			},
+			Weight: 20,
+		}},
+	}}
+	if diff := cmp.Diff(want, vses[1].Spec.Tls); diff != "" {
+		t.Error("Unexpected TLS routes (-want +got):", diff)
+	}
+	if len(vses[1].Spec.Http) == 0 {
+		t.Error("Ingress VirtualService has no HTTP route")
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+)
+
+// queryRoute routes the requests carrying some query parameters to the splits
+// of a backend Service.
+type queryRoute struct {
+	// service is the name of the backend Service.
+	service string
+	// params maps the query parameters to their match.
+	params map[string]*istiov1beta1.StringMatch
+}
+
+// parseQueryRoutes parses the comma separated list of `<service>?<query>`
+// query routes, e.g. `rev-2?canary=true&tier=gold`. A parameter without value
+// only needs to be present.
+func parseQueryRoutes(v string) ([]queryRoute, error) {
+	var routes []queryRoute
+	for _, item := range splitList(v) {
+		parts := strings.SplitN(item, "?", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return nil, fmt.Errorf("expected <service>?<query>, got %q", item)
+		}
+		if errs := validation.IsDNS1035Label(parts[0]); len(errs) != 0 {
+			return nil, fmt.Errorf("invalid Service name %q: %s", parts[0], strings.Join(errs, ", "))
+		}
+		query, err := url.ParseQuery(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid query %q: %w", parts[1], err)
+		}
+		route := queryRoute{
+			service: parts[0],
+			params:  make(map[string]*istiov1beta1.StringMatch, len(query)),
+		}
+		for param, values := range query {
+			if param == "" {
+				return nil, errors.New("empty query parameter name")
+			}
+			if len(values) != 1 {
+				return nil, fmt.Errorf("query parameter %q is set more than once", param)
+			}
+			if values[0] == "" {
+				route.params[param] = &istiov1beta1.StringMatch{
+					MatchType: &istiov1beta1.StringMatch_Regex{Regex: presentRegex},
+				}
+			} else {
+				route.params[param] = &istiov1beta1.StringMatch{
+					MatchType: &istiov1beta1.StringMatch_Exact{Exact: values[0]},
+				}
+			}
+		}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+// makeQueryRoutes returns the routes sending the requests of the given route
+// that carry the query parameters of a query route to the first split of its
+// backend Service, in the order of the query routes. They must precede the
+// given route.
+func makeQueryRoutes(route *istiov1beta1.HTTPRoute, http *v1alpha1.HTTPIngressPath, queryRoutes []queryRoute) []*istiov1beta1.HTTPRoute {
+	var routes []*istiov1beta1.HTTPRoute
+	for _, qr := range queryRoutes {
+		var destination *istiov1beta1.HTTPRouteDestination
+		for i, split := range http.Splits {
+			if split.ServiceName == qr.service {
+				// The destination takes all the traffic of the route.
+				destination = route.Route[i].DeepCopy()
+				destination.Weight = 0
+				break
+			}
+		}
+		if destination == nil {
+			continue
+		}
+		r := route.DeepCopy()
+		r.Route = []*istiov1beta1.HTTPRouteDestination{destination}
+		for _, m := range r.Match {
+			m.QueryParams = qr.params
+		}
+		routes = append(routes, r)
+	}
+	return routes
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+)
+
+func TestParseQueryRoutes(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []queryRoute
+		wantErr bool
+	}{{
+		name:  "query routes",
+		value: "rev-2?canary=true&tier=gold, rev-3?debug",
+		want: []queryRoute{{
+			service: "rev-2",
+			params: map[string]*istiov1beta1.StringMatch{
+				"canary": exactMatch("true"),
+				"tier":   exactMatch("gold"),
+			},
+		}, {
+			service: "rev-3",
+			params: map[string]*istiov1beta1.StringMatch{
+				"debug": {
+					MatchType: &istiov1beta1.StringMatch_Regex{Regex: presentRegex},
+				},
+			},
+		}},
+	}, {
+		name:    "missing query",
+		value:   "rev-2",
+		wantErr: true,
+	}, {
+		name:    "invalid Service",
+		value:   "Rev_2?canary=true",
+		wantErr: true,
+	}, {
+		name:    "repeated parameter",
+		value:   "rev-2?canary=true&canary=false",
+		wantErr: true,
+	}, {
+		name:    "invalid query",
+		value:   "rev-2?canary=%zz",
+		wantErr: true,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseQueryRoutes(tc.value)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parseQueryRoutes() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(queryRoute{})); diff != "" {
+				t.Error("Unexpected query routes (-want +got):", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,301 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/types"
+	istiov1alpha3 "istio.io/api/networking/v1alpha3"
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"knative.dev/net-istio/pkg/reconciler/ingress/resources/names"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/networking/pkg/ingress"
+)
+
+const (
+	// localRateLimitFilterName is the name of the Envoy local rate limit HTTP
+	// filter, as inserted in the gateways by the knative-local-rate-limit
+	// EnvoyFilter of config/204-local-rate-limit.yaml.
+	localRateLimitFilterName = "envoy.filters.http.local_ratelimit"
+	localRateLimitTypeURL    = "type.googleapis.com/envoy.extensions.filters.http.local_ratelimit.v3.LocalRateLimit"
+	localRateLimitStatPrefix = "http_local_rate_limiter"
+	typedStructTypeURL       = "type.googleapis.com/udpa.type.v1.TypedStruct"
+
+	// minRateLimitFillInterval is the shortest fill interval accepted by Envoy.
+	minRateLimitFillInterval = 50 * time.Millisecond
+
+	rateLimitScopeHost = "host"
+	rateLimitScopePath = "path"
+)
+
+// rateLimit is the local rate limit of the hosts or paths of an Ingress.
+type rateLimit struct {
+	// tokens is the number of requests let in per fill interval.
+	tokens uint32
+	// fillInterval is the interval at which the tokens are refilled.
+	fillInterval time.Duration
+	// perPath specifies whether each path of the Ingress is limited on its
+	// own rather than all the paths of a host together.
+	perPath bool
+	// perConnection specifies whether the limit applies to each downstream
+	// connection rather than to each gateway pod.
+	perConnection bool
+}
+
+// parseRateLimit parses the rate limit from the given annotations of an
+// Ingress. It returns nil when the Ingress is not rate limited.
+func parseRateLimit(annotations map[string]string) (*rateLimit, error) {
+	v, ok := annotations[RateLimitAnnotationKey]
+	if !ok {
+		for _, key := range []string{RateLimitScopeAnnotationKey, RateLimitPerConnectionAnnotationKey} {
+			if _, ok := annotations[key]; ok {
+				return nil, fmt.Errorf("annotation %s requires annotation %s", key, RateLimitAnnotationKey)
+			}
+		}
+		return nil, nil
+	}
+
+	parts := strings.Split(v, "/")
+	if len(parts) != 2 {
+		return nil, annotationError(RateLimitAnnotationKey, v, errors.New("expected <tokens>/<fill interval>"))
+	}
+	tokens, err := strconv.ParseUint(strings.TrimSpace(parts[0]), 10, 32)
+	if err != nil {
+		return nil, annotationError(RateLimitAnnotationKey, v, err)
+	}
+	if tokens == 0 {
+		return nil, annotationError(RateLimitAnnotationKey, v, errors.New("expected a positive number of tokens"))
+	}
+	interval, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return nil, annotationError(RateLimitAnnotationKey, v, err)
+	}
+	if interval < minRateLimitFillInterval {
+		return nil, annotationError(RateLimitAnnotationKey, v,
+			fmt.Errorf("expected a fill interval of at least %v", minRateLimitFillInterval))
+	}
+	rl := &rateLimit{tokens: uint32(tokens), fillInterval: interval}
+
+	if v, ok := annotations[RateLimitScopeAnnotationKey]; ok {
+		switch v {
+		case rateLimitScopeHost:
+		case rateLimitScopePath:
+			rl.perPath = true
+		default:
+			return nil, annotationError(RateLimitScopeAnnotationKey, v, errors.New("expected one of host or path"))
+		}
+	}
+	if v, ok := annotations[RateLimitPerConnectionAnnotationKey]; ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, annotationError(RateLimitPerConnectionAnnotationKey, v, err)
+		}
+		rl.perConnection = b
+	}
+	return rl, nil
+}
+
+// MakeRateLimitEnvoyFilters creates the EnvoyFilters applying the rate limit
+// of RateLimitAnnotationKey to the hosts or paths of the given Ingress. They
+// select the gateways serving the Ingress, and configure the local rate limit
+// filter shared by the gateways on the virtual hosts of the Ingress, or on its
+// routes when limited per path. Each virtual host and route has a limit of its
+// own.
+func MakeRateLimitEnvoyFilters(ing *v1alpha1.Ingress, gateways *PolicyGateways, svcLister corev1listers.ServiceLister) ([]*v1alpha3.EnvoyFilter, error) {
+	rl, err := parseRateLimit(ing.GetAnnotations())
+	if err != nil || rl == nil {
+		return nil, err
+	}
+	gatewayHosts, err := getGatewayHosts(gateways, ing, svcLister)
+	if err != nil {
+		return nil, err
+	}
+
+	limitPatch, err := makePatchValue(map[string]interface{}{
+		"typed_per_filter_config": map[string]interface{}{
+			localRateLimitFilterName: localRateLimitConfig(rl.config()),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	filters := make([]*v1alpha3.EnvoyFilter, 0, len(gatewayHosts))
+	for _, gw := range gatewayHosts {
+		var patches []*istiov1alpha3.EnvoyFilter_EnvoyConfigObjectPatch
+		limit := func(applyTo istiov1alpha3.EnvoyFilter_ApplyTo, vhost *istiov1alpha3.EnvoyFilter_RouteConfigurationMatch_VirtualHostMatch) {
+			patches = append(patches, &istiov1alpha3.EnvoyFilter_EnvoyConfigObjectPatch{
+				ApplyTo: applyTo,
+				Match: &istiov1alpha3.EnvoyFilter_EnvoyConfigObjectMatch{
+					Context: istiov1alpha3.EnvoyFilter_GATEWAY,
+					ObjectTypes: &istiov1alpha3.EnvoyFilter_EnvoyConfigObjectMatch_RouteConfiguration{
+						RouteConfiguration: &istiov1alpha3.EnvoyFilter_RouteConfigurationMatch{
+							Vhost: vhost,
+						},
+					},
+				},
+				Patch: &istiov1alpha3.EnvoyFilter_Patch{
+					Operation: istiov1alpha3.EnvoyFilter_Patch_MERGE,
+					Value:     limitPatch,
+				},
+			})
+		}
+		if rl.perPath {
+			for i, rule := range ing.Spec.Rules {
+				if rule.HTTP == nil || !gw.hosts.HasAny(rule.Hosts...) {
+					continue
+				}
+				for j := range nonProbePaths(&rule) {
+					limit(istiov1alpha3.EnvoyFilter_HTTP_ROUTE, &istiov1alpha3.EnvoyFilter_RouteConfigurationMatch_VirtualHostMatch{
+						Route: &istiov1alpha3.EnvoyFilter_RouteConfigurationMatch_RouteMatch{
+							Name: rateLimitRouteName(ing, i, j),
+						},
+					})
+				}
+			}
+		} else {
+			ports := vhostPorts(gw)
+			for _, host := range ingress.ExpandedHosts(gw.hosts).List() {
+				for _, port := range ports {
+					limit(istiov1alpha3.EnvoyFilter_VIRTUAL_HOST, &istiov1alpha3.EnvoyFilter_RouteConfigurationMatch_VirtualHostMatch{
+						Name: fmt.Sprintf("%s:%d", host, port),
+					})
+				}
+			}
+		}
+
+		filters = append(filters, &v1alpha3.EnvoyFilter{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      names.RateLimitEnvoyFilter(ing, gw.service.Name),
+				Namespace: gw.service.Namespace,
+				Labels:    MakeGatewayPolicyLabels(ing),
+			},
+			Spec: istiov1alpha3.EnvoyFilter{
+				WorkloadSelector: &istiov1alpha3.WorkloadSelector{
+					Labels: gw.service.Spec.Selector,
+				},
+				ConfigPatches: patches,
+			},
+		})
+	}
+	return filters, nil
+}
+
+// vhostPorts returns the ports the virtual hosts of the given gateway are named
+// after along with their host, i.e. the ports of its Gateway servers routing
+// HTTP requests. When the servers are unknown, these are the ports of its
+// Service, which the Gateway servers usually share.
+func vhostPorts(gw *gatewayHosts) []int32 {
+	ports := sets.NewInt32()
+	for _, server := range gw.servers {
+		switch server.GetPort().GetProtocol() {
+		case "HTTP", "HTTP2":
+			ports.Insert(int32(server.Port.Number))
+		case "HTTPS":
+			if server.GetTls().GetMode() != istiov1beta1.ServerTLSSettings_PASSTHROUGH {
+				ports.Insert(int32(server.Port.Number))
+			}
+		}
+	}
+	if len(gw.servers) == 0 {
+		for _, port := range gw.service.Spec.Ports {
+			ports.Insert(port.Port)
+		}
+	}
+	return ports.List()
+}
+
+// config returns the local rate limit configuration enforcing the limit.
+func (rl *rateLimit) config() map[string]interface{} {
+	full := func(runtimeKey string) map[string]interface{} {
+		return map[string]interface{}{
+			"runtime_key": runtimeKey,
+			"default_value": map[string]interface{}{
+				"numerator":   100,
+				"denominator": "HUNDRED",
+			},
+		}
+	}
+	cfg := map[string]interface{}{
+		"stat_prefix": localRateLimitStatPrefix,
+		"token_bucket": map[string]interface{}{
+			"max_tokens":      rl.tokens,
+			"tokens_per_fill": rl.tokens,
+			"fill_interval":   strconv.FormatFloat(rl.fillInterval.Seconds(), 'f', -1, 64) + "s",
+		},
+		"filter_enabled":  full("local_rate_limit_enabled"),
+		"filter_enforced": full("local_rate_limit_enforced"),
+	}
+	if rl.perConnection {
+		cfg["local_rate_limit_per_downstream_connection"] = true
+	}
+	return cfg
+}
+
+// localRateLimitConfig wraps the given local rate limit configuration into a
+// typed struct, which Istio passes through to Envoy as is.
+func localRateLimitConfig(value map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"@type":    typedStructTypeURL,
+		"type_url": localRateLimitTypeURL,
+		"value":    value,
+	}
+}
+
+// makePatchValue converts the given value into the Struct of an EnvoyFilter
+// patch.
+func makePatchValue(value map[string]interface{}) (*types.Struct, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the EnvoyFilter patch: %w", err)
+	}
+	s := &types.Struct{}
+	if err := jsonpb.UnmarshalString(string(b), s); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the EnvoyFilter patch: %w", err)
+	}
+	return s, nil
+}
+
+// nonProbePaths returns the paths of the given rule except the paths inserted
+// to probe the Ingress.
+func nonProbePaths(rule *v1alpha1.IngressRule) []*v1alpha1.HTTPIngressPath {
+	var paths []*v1alpha1.HTTPIngressPath
+	for i := range rule.HTTP.Paths {
+		if p := &rule.HTTP.Paths[i]; !isProbePath(p) {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// rateLimitRouteName returns the name of the VirtualService routes of the
+// given path of the given rule of an Ingress limited per path. The path is
+// indexed among the paths of the rule that are not probes.
+func rateLimitRouteName(ing *v1alpha1.Ingress, rule, path int) string {
+	return portNamePrefix(ing.Namespace, ing.Name) + fmt.Sprintf(":rate-limit-%d-%d", rule, path)
+}
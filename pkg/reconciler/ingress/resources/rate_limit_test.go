@@ -0,0 +1,359 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/google/go-cmp/cmp"
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	"istio.io/client-go/pkg/apis/networking/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"knative.dev/net-istio/pkg/reconciler/ingress/config"
+	"knative.dev/networking/pkg/apis/networking"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/pkg/system"
+
+	rtesting "knative.dev/pkg/reconciler/testing"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		want        *rateLimit
+		wantErr     bool
+	}{{
+		name: "no rate limit",
+	}, {
+		name:        "per host",
+		annotations: map[string]string{RateLimitAnnotationKey: "100/1m"},
+		want:        &rateLimit{tokens: 100, fillInterval: time.Minute},
+	}, {
+		name: "per path and connection",
+		annotations: map[string]string{
+			RateLimitAnnotationKey:              "10 / 500ms",
+			RateLimitScopeAnnotationKey:         "path",
+			RateLimitPerConnectionAnnotationKey: "true",
+		},
+		want: &rateLimit{tokens: 10, fillInterval: 500 * time.Millisecond, perPath: true, perConnection: true},
+	}, {
+		name:        "no fill interval",
+		annotations: map[string]string{RateLimitAnnotationKey: "100"},
+		wantErr:     true,
+	}, {
+		name:        "no token",
+		annotations: map[string]string{RateLimitAnnotationKey: "0/1s"},
+		wantErr:     true,
+	}, {
+		name:        "short fill interval",
+		annotations: map[string]string{RateLimitAnnotationKey: "1/10ms"},
+		wantErr:     true,
+	}, {
+		name: "unknown scope",
+		annotations: map[string]string{
+			RateLimitAnnotationKey:      "100/1m",
+			RateLimitScopeAnnotationKey: "ingress",
+		},
+		wantErr: true,
+	}, {
+		name:        "scope without rate limit",
+		annotations: map[string]string{RateLimitScopeAnnotationKey: "path"},
+		wantErr:     true,
+	}, {
+		name: "invalid per connection",
+		annotations: map[string]string{
+			RateLimitAnnotationKey:              "100/1m",
+			RateLimitPerConnectionAnnotationKey: "yes please",
+		},
+		wantErr: true,
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := parseRateLimit(c.annotations)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("parseRateLimit() = %v, wantErr = %v", err, c.wantErr)
+			}
+			if diff := cmp.Diff(c.want, got, cmp.AllowUnexported(rateLimit{})); diff != "" {
+				t.Error("Unexpected rate limit (-want, +got):", diff)
+			}
+		})
+	}
+}
+
+func TestMakeRateLimitEnvoyFilters(t *testing.T) {
+	ctx, cancel, _ := rtesting.SetupFakeContextWithCancel(t)
+	defer cancel()
+	gatewayService := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "istio-ingressgateway",
+			Namespace: "istio-system",
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: selector,
+			Ports: []corev1.ServicePort{{
+				Port:       80,
+				TargetPort: intstr.FromInt(8080),
+			}, {
+				Port:       443,
+				TargetPort: intstr.FromString("https"),
+			}},
+		},
+	}
+	svcLister := serviceLister(ctx, gatewayService)
+	services := map[v1alpha1.IngressVisibility][]config.Gateway{
+		v1alpha1.IngressVisibilityExternalIP: {{
+			Namespace:  system.Namespace(),
+			Name:       config.KnativeIngressGateway,
+			ServiceURL: "istio-ingressgateway.istio-system.svc.cluster.local",
+		}},
+	}
+	server := func(protocol string, number uint32, mode istiov1beta1.ServerTLSSettings_TLSmode) *istiov1beta1.Server {
+		s := &istiov1beta1.Server{
+			Hosts: []string{"*"},
+			Port:  &istiov1beta1.Port{Name: fmt.Sprint(protocol, "-", number), Number: number, Protocol: protocol},
+		}
+		if protocol == "HTTPS" {
+			s.Tls = &istiov1beta1.ServerTLSSettings{Mode: mode}
+		}
+		return s
+	}
+	gateway := &v1beta1.Gateway{
+		Spec: istiov1beta1.Gateway{
+			Selector: selector,
+			Servers: []*istiov1beta1.Server{
+				server("HTTP", 8081, 0),
+				server("HTTPS", 443, istiov1beta1.ServerTLSSettings_SIMPLE),
+				server("HTTPS", 8443, istiov1beta1.ServerTLSSettings_PASSTHROUGH),
+				server("TCP", 9000, 0),
+			},
+		},
+	}
+
+	ing := &v1alpha1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ingress",
+			Namespace: "test-ns",
+		},
+		Spec: v1alpha1.IngressSpec{
+			Rules: []v1alpha1.IngressRule{{
+				Hosts:      []string{"foo.example.com"},
+				Visibility: v1alpha1.IngressVisibilityExternalIP,
+				HTTP: &v1alpha1.HTTPIngressRuleValue{
+					Paths: []v1alpha1.HTTPIngressPath{{
+						Path: "/api",
+					}, {
+						Path: "/admin",
+					}},
+				},
+			}, {
+				Hosts:      []string{"private.test-ns.svc.cluster.local"},
+				Visibility: v1alpha1.IngressVisibilityClusterLocal,
+				HTTP: &v1alpha1.HTTPIngressRuleValue{
+					Paths: []v1alpha1.HTTPIngressPath{{}},
+				},
+			}},
+		},
+	}
+
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		gateways    []*v1beta1.Gateway
+		wantVhosts  []string
+		wantRoutes  []string
+	}{{
+		name: "no rate limit",
+	}, {
+		name:        "per host",
+		annotations: map[string]string{RateLimitAnnotationKey: "100/1m"},
+		gateways:    []*v1beta1.Gateway{gateway},
+		wantVhosts: []string{
+			"foo.example.com:443", "foo.example.com:8081",
+			"private.test-ns:443", "private.test-ns:8081",
+			"private.test-ns.svc:443", "private.test-ns.svc:8081",
+			"private.test-ns.svc.cluster.local:443", "private.test-ns.svc.cluster.local:8081",
+		},
+	}, {
+		name:        "per host without Gateway servers",
+		annotations: map[string]string{RateLimitAnnotationKey: "100/1m"},
+		wantVhosts: []string{
+			"foo.example.com:80", "foo.example.com:443",
+			"private.test-ns:80", "private.test-ns:443",
+			"private.test-ns.svc:80", "private.test-ns.svc:443",
+			"private.test-ns.svc.cluster.local:80", "private.test-ns.svc.cluster.local:443",
+		},
+	}, {
+		name: "per path",
+		annotations: map[string]string{
+			RateLimitAnnotationKey:      "100/1m",
+			RateLimitScopeAnnotationKey: "path",
+		},
+		wantRoutes: []string{
+			"test-ns/ingress:rate-limit-0-0", "test-ns/ingress:rate-limit-0-1",
+			"test-ns/ingress:rate-limit-1-0",
+		},
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ing := ing.DeepCopy()
+			ing.Annotations = c.annotations
+			gateways := &PolicyGateways{
+				Services: services,
+				Gateways: map[v1alpha1.IngressVisibility][]*v1beta1.Gateway{
+					v1alpha1.IngressVisibilityExternalIP: c.gateways,
+				},
+			}
+			got, err := MakeRateLimitEnvoyFilters(ing, gateways, svcLister)
+			if err != nil {
+				t.Fatal("MakeRateLimitEnvoyFilters() =", err)
+			}
+			if c.annotations == nil {
+				if len(got) != 0 {
+					t.Errorf("MakeRateLimitEnvoyFilters() = %v, wanted no EnvoyFilter", got)
+				}
+				return
+			}
+			if len(got) != 1 {
+				t.Fatalf("MakeRateLimitEnvoyFilters() = %d EnvoyFilters, wanted 1", len(got))
+			}
+
+			ef := got[0]
+			wantMeta := metav1.ObjectMeta{
+				Name:      "test-ns--ingress-istio-ingressgateway-rate-limit",
+				Namespace: "istio-system",
+				Labels: map[string]string{
+					networking.IngressLabelKey: "ingress",
+					IngressNamespaceLabelKey:   "test-ns",
+				},
+			}
+			if diff := cmp.Diff(wantMeta, ef.ObjectMeta); diff != "" {
+				t.Error("Unexpected ObjectMeta (-want, +got):", diff)
+			}
+			if diff := cmp.Diff(selector, ef.Spec.WorkloadSelector.Labels); diff != "" {
+				t.Error("Unexpected workload selector (-want, +got):", diff)
+			}
+
+			patches := ef.Spec.ConfigPatches
+			var gotVhosts, gotRoutes []string
+			for _, patch := range patches {
+				vhost := patch.Match.GetRouteConfiguration().Vhost
+				if vhost.Route != nil {
+					gotRoutes = append(gotRoutes, vhost.Route.Name)
+				} else {
+					gotVhosts = append(gotVhosts, vhost.Name)
+				}
+			}
+			if diff := cmp.Diff(c.wantVhosts, gotVhosts); diff != "" {
+				t.Error("Unexpected virtual hosts (-want, +got):", diff)
+			}
+			if diff := cmp.Diff(c.wantRoutes, gotRoutes); diff != "" {
+				t.Error("Unexpected routes (-want, +got):", diff)
+			}
+
+			want := map[string]interface{}{
+				"typed_per_filter_config": map[string]interface{}{
+					"envoy.filters.http.local_ratelimit": map[string]interface{}{
+						"@type":    "type.googleapis.com/udpa.type.v1.TypedStruct",
+						"type_url": "type.googleapis.com/envoy.extensions.filters.http.local_ratelimit.v3.LocalRateLimit",
+						"value": map[string]interface{}{
+							"stat_prefix": "http_local_rate_limiter",
+							"token_bucket": map[string]interface{}{
+								"max_tokens":      100.0,
+								"tokens_per_fill": 100.0,
+								"fill_interval":   "60s",
+							},
+							"filter_enabled": map[string]interface{}{
+								"runtime_key": "local_rate_limit_enabled",
+								"default_value": map[string]interface{}{
+									"numerator":   100.0,
+									"denominator": "HUNDRED",
+								},
+							},
+							"filter_enforced": map[string]interface{}{
+								"runtime_key": "local_rate_limit_enforced",
+								"default_value": map[string]interface{}{
+									"numerator":   100.0,
+									"denominator": "HUNDRED",
+								},
+							},
+						},
+					},
+				},
+			}
+			s, err := (&jsonpb.Marshaler{}).MarshalToString(patches[len(patches)-1].Patch.Value)
+			if err != nil {
+				t.Fatal("MarshalToString() =", err)
+			}
+			var value map[string]interface{}
+			if err := json.Unmarshal([]byte(s), &value); err != nil {
+				t.Fatal("Unmarshal() =", err)
+			}
+			if diff := cmp.Diff(want, value); diff != "" {
+				t.Error("Unexpected patch value (-want, +got):", diff)
+			}
+		})
+	}
+}
+
+func TestMakeVirtualServices_RateLimitPerPath(t *testing.T) {
+	ing := &v1alpha1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ingress",
+			Namespace: "test-ns",
+			Annotations: map[string]string{
+				RateLimitAnnotationKey:      "100/1m",
+				RateLimitScopeAnnotationKey: "path",
+			},
+		},
+		Spec: v1alpha1.IngressSpec{
+			Rules: []v1alpha1.IngressRule{{
+				Hosts:      []string{"foo.example.com"},
+				Visibility: v1alpha1.IngressVisibilityExternalIP,
+				HTTP: &v1alpha1.HTTPIngressRuleValue{
+					Paths: []v1alpha1.HTTPIngressPath{{
+						Path: "/api",
+					}, {
+						Path: "/admin",
+					}},
+				},
+			}},
+		},
+	}
+	vses, err := MakeVirtualServices(context.Background(), ing, makeGatewayMap([]string{"gateway-1"}, nil))
+	if err != nil {
+		t.Fatal("MakeVirtualServices() =", err)
+	}
+	var got []string
+	for _, route := range vses[len(vses)-1].Spec.Http {
+		got = append(got, route.Name)
+	}
+	// The probes come first and are not limited.
+	want := []string{"", "", "test-ns/ingress:rate-limit-0-0", "test-ns/ingress:rate-limit-0-1"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error("Unexpected route names (-want, +got):", diff)
+	}
+}
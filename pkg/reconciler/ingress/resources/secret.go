@@ -26,6 +26,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	corev1listers "k8s.io/client-go/listers/core/v1"
+	"knative.dev/net-istio/pkg/reconciler/ingress/config"
 	"knative.dev/networking/pkg/apis/networking"
 	"knative.dev/networking/pkg/apis/networking/v1alpha1"
 	"knative.dev/pkg/kmeta"
@@ -71,6 +72,66 @@ func MakeSecrets(ctx context.Context, originSecrets map[string]*corev1.Secret, a
 	return secrets, nil
 }
 
+// ClientCASecretKey returns the `<namespace>/<name>` of the Secret holding the CA bundle
+// used to verify the client certificates presented to the TLS servers of the given Ingress,
+// or the empty string when the Ingress does not require client certificates.
+func ClientCASecretKey(ctx context.Context, ing *v1alpha1.Ingress) string {
+	if name, ok := ing.GetAnnotations()[ClientCASecretAnnotationKey]; ok {
+		if name == "" {
+			return ""
+		}
+		return ing.GetNamespace() + "/" + name
+	}
+	return config.FromContext(ctx).Istio.ClientCASecret
+}
+
+// MakeClientCASecrets makes copies of the given CA Secret under the namespace of Istio gateway
+// service, next to the certificates of the origin Secrets, so that Istio can verify client certificates.
+func MakeClientCASecrets(ctx context.Context, caSecret *corev1.Secret, originSecrets map[string]*corev1.Secret, accessor kmeta.OwnerRefableAccessor) ([]*corev1.Secret, error) {
+	nameNamespaces, err := GetIngressGatewaySvcNameNamespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	secrets := []*corev1.Secret{}
+	for _, originSecret := range originSecrets {
+		for _, meta := range nameNamespaces {
+			credentialName := originSecret.Name
+			if meta.Namespace != originSecret.Namespace {
+				credentialName = targetSecret(originSecret, accessor)
+			}
+			secrets = append(secrets, makeSecret(caSecret, clientCASecretName(credentialName), meta.Namespace,
+				MakeTargetSecretLabels(originSecret.Name, originSecret.Namespace)))
+		}
+	}
+	return secrets, nil
+}
+
+// MakeWildcardClientCASecrets makes copies of the given CA Secret under the namespace of Istio
+// gateway service, next to the wildcard certificates, so that Istio can verify client certificates.
+func MakeWildcardClientCASecrets(ctx context.Context, caSecret *corev1.Secret, originWildcardCerts map[string]*corev1.Secret) ([]*corev1.Secret, error) {
+	nameNamespaces, err := GetIngressGatewaySvcNameNamespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	secrets := []*corev1.Secret{}
+	for _, secret := range originWildcardCerts {
+		for _, meta := range nameNamespaces {
+			credentialName := secret.Name
+			if meta.Namespace != secret.Namespace {
+				credentialName = targetWildcardSecretName(secret.Name, secret.Namespace)
+			}
+			secrets = append(secrets, makeSecret(caSecret, clientCASecretName(credentialName), meta.Namespace, map[string]string{}))
+		}
+	}
+	return secrets, nil
+}
+
+// clientCASecretName returns the name of the Secret Istio looks the CA bundle of the given
+// credential up in.
+func clientCASecretName(credentialName string) string {
+	return credentialName + "-cacert"
+}
+
 // MakeWildcardSecrets copies wildcard certificates from origin namespace to the namespace of gateway servicess so they could
 // consumed by Istio ingress.
 func MakeWildcardSecrets(ctx context.Context, originWildcardCerts map[string]*corev1.Secret) ([]*corev1.Secret, error) {
@@ -184,6 +184,128 @@ func TestMakeSecrets(t *testing.T) {
 	}
 }
 
+func TestClientCASecretKey(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		configured  string
+		want        string
+	}{{
+		name: "not required",
+	}, {
+		name:       "config-istio",
+		configured: "istio-system/client-ca",
+		want:       "istio-system/client-ca",
+	}, {
+		name:        "annotation overrides config-istio",
+		annotations: map[string]string{ClientCASecretAnnotationKey: "ingress-ca"},
+		configured:  "istio-system/client-ca",
+		want:        system.Namespace() + "/ingress-ca",
+	}, {
+		name:        "annotation opts out",
+		annotations: map[string]string{ClientCASecretAnnotationKey: ""},
+		configured:  "istio-system/client-ca",
+	}}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := config.ToContext(TestContextWithLogger(t), &config.Config{
+				Istio: &config.Istio{
+					ClientCASecret: c.configured,
+				},
+			})
+			ing := ci.DeepCopy()
+			ing.Annotations = c.annotations
+			if got := ClientCASecretKey(ctx, ing); got != c.want {
+				t.Errorf("ClientCASecretKey() = %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestMakeClientCASecrets(t *testing.T) {
+	ctx := TestContextWithLogger(t)
+	ctx = config.ToContext(ctx, &config.Config{
+		Istio: &config.Istio{
+			IngressGateways: []config.Gateway{{
+				Name: "test-gateway",
+				// The namespace of Istio gateway service is istio-system.
+				ServiceURL: "istio-ingressgateway.istio-system.svc.cluster.local",
+			}},
+		},
+	})
+	caSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "client-ca",
+			Namespace: "knative-serving",
+		},
+		Data: map[string][]byte{
+			"ca.crt": []byte("abcd"),
+		},
+	}
+
+	cases := []struct {
+		name         string
+		originSecret *corev1.Secret
+		expected     []*corev1.Secret
+	}{{
+		name: "origin secret namespace is the same as the target secret namespace.",
+		originSecret: &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-secret",
+				Namespace: "istio-system",
+				UID:       "1234",
+			},
+		},
+		expected: []*corev1.Secret{{
+			ObjectMeta: metav1.ObjectMeta{
+				// The CA is looked up next to the origin secret.
+				Name:      "test-secret-cacert",
+				Namespace: "istio-system",
+				Labels: map[string]string{
+					networking.OriginSecretNameLabelKey:      "test-secret",
+					networking.OriginSecretNamespaceLabelKey: "istio-system",
+				},
+			},
+			Data: caSecret.Data,
+		}},
+	}, {
+		name: "origin secret namespace is different from the target secret namespace.",
+		originSecret: &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-secret",
+				Namespace: "knative-serving",
+				UID:       "1234",
+			},
+		},
+		expected: []*corev1.Secret{{
+			ObjectMeta: metav1.ObjectMeta{
+				// The CA is looked up next to the copy of the origin secret.
+				Name:      "ingress-1234-cacert",
+				Namespace: "istio-system",
+				Labels: map[string]string{
+					networking.OriginSecretNameLabelKey:      "test-secret",
+					networking.OriginSecretNamespaceLabelKey: "knative-serving",
+				},
+			},
+			Data: caSecret.Data,
+		}},
+	}}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			originSecrets := map[string]*corev1.Secret{
+				fmt.Sprintf("%s/%s", c.originSecret.Namespace, c.originSecret.Name): c.originSecret,
+			}
+			secrets, err := MakeClientCASecrets(ctx, caSecret, originSecrets, &ci)
+			if err != nil {
+				t.Fatal("MakeClientCASecrets() =", err)
+			}
+			if diff := cmp.Diff(c.expected, secrets); diff != "" {
+				t.Error("Unexpected secrets (-want, +got):", diff)
+			}
+		})
+	}
+}
+
 func TestMakeWildcardSecrets(t *testing.T) {
 	ctx := TestContextWithLogger(t)
 	ctx = config.ToContext(ctx, &config.Config{
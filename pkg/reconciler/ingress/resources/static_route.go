@@ -0,0 +1,192 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/networking/pkg/ingress"
+	"knative.dev/pkg/network"
+)
+
+// redirectCodes are the HTTP status codes of the redirects.
+var redirectCodes = sets.NewInt(301, 302, 303, 307, 308)
+
+// staticRoute answers the requests for a host and path of an Ingress itself,
+// with either a redirect or a direct response, instead of forwarding them to
+// the backends.
+type staticRoute struct {
+	// host is the host of the requests, or empty for all the hosts.
+	host string
+	// path is the path of the requests, matched as a prefix when prefix is
+	// true and exactly otherwise.
+	path   string
+	prefix bool
+
+	// redirect is the redirect answering the requests, if any.
+	redirect *istiov1beta1.HTTPRedirect
+	// status is the status code of the direct response answering the
+	// requests, when there is no redirect.
+	status int32
+}
+
+// parseRedirects parses the comma separated list of `<match> <target>
+// [<code>]` redirects, see RedirectsAnnotationKey.
+func parseRedirects(v string) ([]staticRoute, error) {
+	var routes []staticRoute
+	for _, item := range splitList(v) {
+		fields := strings.Fields(item)
+		if len(fields) != 2 && len(fields) != 3 {
+			return nil, fmt.Errorf("expected <match> <target> [<code>], got %q", item)
+		}
+		route, err := parseStaticRouteMatch(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		route.redirect = &istiov1beta1.HTTPRedirect{}
+		target := fields[1]
+		if i := strings.Index(target, "/"); i != 0 {
+			host := target
+			if i > 0 {
+				host = target[:i]
+			}
+			if errs := validation.IsDNS1123Subdomain(host); len(errs) != 0 {
+				return nil, fmt.Errorf("invalid redirect host %q: %s", host, strings.Join(errs, ", "))
+			}
+			route.redirect.Authority = host
+			target = target[len(host):]
+		}
+		route.redirect.Uri = target
+		if len(fields) == 3 {
+			code, err := strconv.Atoi(fields[2])
+			if err != nil || !redirectCodes.Has(code) {
+				return nil, fmt.Errorf("invalid redirect code %q, expected one of %v", fields[2], redirectCodes.List())
+			}
+			route.redirect.RedirectCode = uint32(code)
+		}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+// parseDirectResponses parses the comma separated list of `<match> <status>`
+// direct responses, see DirectResponsesAnnotationKey.
+func parseDirectResponses(v string) ([]staticRoute, error) {
+	var routes []staticRoute
+	for _, item := range splitList(v) {
+		fields := strings.Fields(item)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("expected <match> <status>, got %q", item)
+		}
+		route, err := parseStaticRouteMatch(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		status, err := strconv.ParseInt(fields[1], 10, 32)
+		if err != nil || status < 200 || status > 599 {
+			return nil, fmt.Errorf("invalid status %q, expected an HTTP status code in the [200, 599] range", fields[1])
+		}
+		route.status = int32(status)
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+// parseStaticRouteMatch parses the `[<host>]<path>[*]` match of a static
+// route.
+func parseStaticRouteMatch(v string) (staticRoute, error) {
+	i := strings.Index(v, "/")
+	if i < 0 {
+		return staticRoute{}, fmt.Errorf("invalid match %q, expected [<host>]<path>[*]", v)
+	}
+	route := staticRoute{
+		host: v[:i],
+		path: v[i:],
+	}
+	if route.host != "" {
+		if errs := validation.IsDNS1123Subdomain(route.host); len(errs) != 0 {
+			return staticRoute{}, fmt.Errorf("invalid host %q: %s", route.host, strings.Join(errs, ", "))
+		}
+	}
+	if strings.HasSuffix(route.path, "*") {
+		route.path, route.prefix = strings.TrimSuffix(route.path, "*"), true
+	}
+	if strings.Contains(route.path, "*") {
+		return staticRoute{}, errors.New("the path of a match may only end with *")
+	}
+	return route, nil
+}
+
+// makeStaticRoute returns the route answering the requests of the given
+// static route for the given hosts of the given Ingress rule, or nil when it
+// does not apply to them. The probes are left to the routes of the paths, and
+// so are the ACME HTTP-01 challenges, whose routes precede the static routes.
+func makeStaticRoute(hosts sets.String, rule *v1alpha1.IngressRule, gateways map[v1alpha1.IngressVisibility]sets.String, sr staticRoute, opts *routeOptions) *istiov1beta1.HTTPRoute {
+	if sr.host != "" {
+		hosts = hosts.Intersection(ingress.ExpandedHosts(sets.NewString(sr.host)))
+	}
+	if hosts.Len() == 0 || len(rule.HTTP.Paths) == 0 || len(rule.HTTP.Paths[0].Splits) == 0 {
+		return nil
+	}
+
+	uri := &istiov1beta1.StringMatch{
+		MatchType: &istiov1beta1.StringMatch_Exact{Exact: sr.path},
+	}
+	if sr.prefix {
+		uri.MatchType = &istiov1beta1.StringMatch_Prefix{Prefix: sr.path}
+	}
+	route := &istiov1beta1.HTTPRoute{}
+	for _, host := range hosts.List() {
+		match := makeMatch(host, "", nil, routeGateways(host, gateways, rule.Visibility), opts)
+		match.Uri = uri
+		match.WithoutHeaders = withoutProbes()
+		route.Match = append(route.Match, match)
+	}
+
+	if sr.redirect != nil {
+		route.Redirect = sr.redirect
+		return route
+	}
+	// The API of the vendored Istio has no direct response yet, so the
+	// requests are aborted with the status before reaching the backend of
+	// the rule, which the route needs nonetheless.
+	split := rule.HTTP.Paths[0].Splits[0]
+	route.Route = []*istiov1beta1.HTTPRouteDestination{{
+		Destination: &istiov1beta1.Destination{
+			Host: network.GetServiceHostname(split.ServiceName, split.ServiceNamespace),
+			Port: &istiov1beta1.PortSelector{
+				Number: uint32(split.ServicePort.IntValue()),
+			},
+		},
+	}}
+	route.Fault = &istiov1beta1.HTTPFaultInjection{
+		Abort: &istiov1beta1.HTTPFaultInjection_Abort{
+			ErrorType: &istiov1beta1.HTTPFaultInjection_Abort_HttpStatus{
+				HttpStatus: sr.status,
+			},
+			Percentage: &istiov1beta1.Percent{Value: 100},
+		},
+	}
+	return route
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	net "knative.dev/networking/pkg"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+)
+
+func TestParseStaticRoutes(t *testing.T) {
+	tests := []struct {
+		name            string
+		redirects       string
+		directResponses string
+		want            []staticRoute
+		wantErr         bool
+	}{{
+		name:      "redirects",
+		redirects: "old.example.com/* new.example.com 308, /docs /docs/, /v1/* new.example.com/v2",
+		want: []staticRoute{{
+			host:     "old.example.com",
+			path:     "/",
+			prefix:   true,
+			redirect: &istiov1beta1.HTTPRedirect{Authority: "new.example.com", RedirectCode: 308},
+		}, {
+			path:     "/docs",
+			redirect: &istiov1beta1.HTTPRedirect{Uri: "/docs/"},
+		}, {
+			path:     "/v1/",
+			prefix:   true,
+			redirect: &istiov1beta1.HTTPRedirect{Authority: "new.example.com", Uri: "/v2"},
+		}},
+	}, {
+		name:            "direct responses",
+		directResponses: "maintenance.example.com/* 503, /legacy 410",
+		want: []staticRoute{{
+			host:   "maintenance.example.com",
+			path:   "/",
+			prefix: true,
+			status: 503,
+		}, {
+			path:   "/legacy",
+			status: 410,
+		}},
+	}, {
+		name:      "match without path",
+		redirects: "old.example.com new.example.com",
+		wantErr:   true,
+	}, {
+		name:      "wildcard inside the path",
+		redirects: "/*/docs /docs",
+		wantErr:   true,
+	}, {
+		name:      "invalid redirect code",
+		redirects: "/docs /docs/ 200",
+		wantErr:   true,
+	}, {
+		name:      "invalid redirect host",
+		redirects: "/docs New_Example",
+		wantErr:   true,
+	}, {
+		name:      "missing redirect target",
+		redirects: "/docs",
+		wantErr:   true,
+	}, {
+		name:            "invalid status",
+		directResponses: "/legacy gone",
+		wantErr:         true,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []staticRoute
+			redirects, err := parseRedirects(tc.redirects)
+			if err == nil {
+				got = append(got, redirects...)
+				var directResponses []staticRoute
+				directResponses, err = parseDirectResponses(tc.directResponses)
+				got = append(got, directResponses...)
+			}
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parse error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(staticRoute{})); diff != "" {
+				t.Error("Unexpected static routes (-want +got):", diff)
+			}
+		})
+	}
+}
+
+func TestMakeVirtualServices_StaticRoutes(t *testing.T) {
+	ing := &v1alpha1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "test-ns",
+			Annotations: map[string]string{
+				RedirectsAnnotationKey:       "old.example.com/* new.example.com",
+				DirectResponsesAnnotationKey: "/legacy/* 410",
+			},
+		},
+		Spec: v1alpha1.IngressSpec{
+			Rules: []v1alpha1.IngressRule{{
+				Hosts:      []string{"old.example.com", "new.example.com"},
+				Visibility: v1alpha1.IngressVisibilityExternalIP,
+				HTTP: &v1alpha1.HTTPIngressRuleValue{
+					Paths: []v1alpha1.HTTPIngressPath{{
+						Path: "/.well-known/acme-challenge/token",
+						Splits: []v1alpha1.IngressBackendSplit{{
+							IngressBackend: v1alpha1.IngressBackend{
+								ServiceNamespace: "test-ns",
+								ServiceName:      "challenge-service",
+								ServicePort:      intstr.FromInt(8089),
+							},
+							Percent: 100,
+						}},
+					}, {
+						Splits: []v1alpha1.IngressBackendSplit{{
+							IngressBackend: v1alpha1.IngressBackend{
+								ServiceNamespace: "test-ns",
+								ServiceName:      "revision-service",
+								ServicePort:      intstr.FromInt(80),
+							},
+							Percent: 100,
+						}},
+					}},
+				},
+			}},
+		},
+	}
+	vses, err := MakeVirtualServices(context.Background(), ing, makeGatewayMap([]string{"gateway-1"}, nil))
+	if err != nil {
+		t.Fatal("MakeVirtualServices() =", err)
+	}
+	if len(vses) != 1 {
+		t.Fatalf("MakeVirtualServices() = %d VirtualServices, wanted 1", len(vses))
+	}
+
+	withoutProbe := map[string]*istiov1beta1.StringMatch{
+		net.HashHeaderName: exactMatch(net.HashHeaderValue),
+	}
+	match := func(host string, uri *istiov1beta1.StringMatch) *istiov1beta1.HTTPMatchRequest {
+		return &istiov1beta1.HTTPMatchRequest{
+			Gateways:       []string{"gateway-1"},
+			Authority:      prefixMatch(host),
+			Uri:            uri,
+			WithoutHeaders: withoutProbe,
+		}
+	}
+	want := []*istiov1beta1.HTTPRoute{{
+		Match: []*istiov1beta1.HTTPMatchRequest{
+			match("old.example.com", prefixMatch("/")),
+		},
+		Redirect: &istiov1beta1.HTTPRedirect{Authority: "new.example.com"},
+	}, {
+		Match: []*istiov1beta1.HTTPMatchRequest{
+			match("new.example.com", prefixMatch("/legacy/")),
+			match("old.example.com", prefixMatch("/legacy/")),
+		},
+		Route: []*istiov1beta1.HTTPRouteDestination{{
+			Destination: &istiov1beta1.Destination{
+				Host: "challenge-service.test-ns.svc.cluster.local",
+				Port: &istiov1beta1.PortSelector{Number: 8089},
+			},
+		}},
+		Fault: &istiov1beta1.HTTPFaultInjection{
+			Abort: &istiov1beta1.HTTPFaultInjection_Abort{
+				ErrorType:  &istiov1beta1.HTTPFaultInjection_Abort_HttpStatus{HttpStatus: 410},
+				Percentage: &istiov1beta1.Percent{Value: 100},
+			},
+		},
+	}}
+	routes := vses[0].Spec.Http
+	if len(routes) != 6 {
+		t.Fatalf("MakeVirtualServices() = %d routes, wanted 6", len(routes))
+	}
+	// The routes of the ACME challenge path, and of its probe, go first.
+	for _, route := range routes[:2] {
+		if got, want := route.Match[0].Uri, prefixMatch("/.well-known/acme-challenge/token"); !cmp.Equal(got, want) {
+			t.Errorf("Route URI = %v, wanted the ACME challenge path %v", got, want)
+		}
+	}
+	if diff := cmp.Diff(want, routes[2:4]); diff != "" {
+		t.Error("Unexpected static routes (-want +got):", diff)
+	}
+	// The probe route and the route of the path follow.
+	if _, probe := routes[4].Match[0].Headers[net.HashHeaderName]; !probe {
+		t.Errorf("Route %v is not a probe route", routes[4].Match)
+	}
+}
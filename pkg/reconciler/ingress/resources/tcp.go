@@ -0,0 +1,142 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+)
+
+// tcpPort is a gateway port whose TCP connections are routed to the splits of
+// an Ingress rule.
+type tcpPort struct {
+	number uint32
+	// hosts are the hosts of the rule served on the port.
+	hosts sets.String
+	// rule is the index of the rule in the Ingress.
+	rule int
+}
+
+// parseTCPPorts returns the TCP ports of the given Ingress sorted by number,
+// see TCPPortsAnnotationKey.
+func parseTCPPorts(ing *v1alpha1.Ingress) ([]tcpPort, error) {
+	v, ok := ing.GetAnnotations()[TCPPortsAnnotationKey]
+	if !ok {
+		return nil, nil
+	}
+	public := getPublicHosts(ing)
+	byNumber := map[uint32]*tcpPort{}
+	for _, item := range splitList(v) {
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 {
+			return nil, annotationError(TCPPortsAnnotationKey, v, fmt.Errorf("expected <host>=<port>, got %q", item))
+		}
+		host, port := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if !public.Has(host) {
+			return nil, annotationError(TCPPortsAnnotationKey, v, fmt.Errorf("%q is not a public host of the Ingress", host))
+		}
+		number, err := strconv.ParseUint(port, 10, 16)
+		if err != nil || number == 0 {
+			return nil, annotationError(TCPPortsAnnotationKey, v, fmt.Errorf("invalid port %q", port))
+		}
+		if number == GatewayHTTPPort || number == GatewayHTTPSPort {
+			return nil, annotationError(TCPPortsAnnotationKey, v, fmt.Errorf("port %d is reserved for HTTP", number))
+		}
+		rule := publicRuleIndex(ing, host)
+		p, ok := byNumber[uint32(number)]
+		if !ok {
+			p = &tcpPort{number: uint32(number), hosts: sets.NewString(), rule: rule}
+			byNumber[p.number] = p
+		} else if p.rule != rule {
+			return nil, annotationError(TCPPortsAnnotationKey, v,
+				fmt.Errorf("port %d is shared by hosts of different rules", number))
+		}
+		p.hosts.Insert(host)
+	}
+	if len(byNumber) == 0 {
+		return nil, annotationError(TCPPortsAnnotationKey, v, errors.New("expected at least one <host>=<port>"))
+	}
+
+	ports := make([]tcpPort, 0, len(byNumber))
+	for _, p := range byNumber {
+		ports = append(ports, *p)
+	}
+	sort.Slice(ports, func(i, j int) bool {
+		return ports[i].number < ports[j].number
+	})
+	return ports, nil
+}
+
+// publicRuleIndex returns the index of the first public rule of the given
+// Ingress with the given host.
+func publicRuleIndex(ing *v1alpha1.Ingress, host string) int {
+	for i, rule := range ing.Spec.Rules {
+		if rule.Visibility != v1alpha1.IngressVisibilityExternalIP && rule.Visibility != "" {
+			continue
+		}
+		if sets.NewString(rule.Hosts...).Has(host) {
+			return i
+		}
+	}
+	return -1
+}
+
+// MakeIngressTCPServers creates the TCP Gateway `Servers` of the given Ingress,
+// one per TCP port.
+func MakeIngressTCPServers(ing *v1alpha1.Ingress) ([]*istiov1beta1.Server, error) {
+	ports, err := parseTCPPorts(ing)
+	if err != nil {
+		return nil, err
+	}
+	servers := make([]*istiov1beta1.Server, 0, len(ports))
+	for _, p := range ports {
+		servers = append(servers, &istiov1beta1.Server{
+			Hosts: p.hosts.List(),
+			Port: &istiov1beta1.Port{
+				Name:     fmt.Sprintf("%s:tcp-%d", portNamePrefix(ing.GetNamespace(), ing.GetName()), p.number),
+				Number:   p.number,
+				Protocol: "TCP",
+			},
+		})
+	}
+	return servers, nil
+}
+
+// makeTCPRoute returns the route of the TCP connections to the given port,
+// which are routed to the splits of the given rule. It returns nil when none
+// of the given hosts is served on the port.
+func makeTCPRoute(hosts sets.String, rule *v1alpha1.IngressRule, gateways map[v1alpha1.IngressVisibility]sets.String, port tcpPort) *istiov1beta1.TCPRoute {
+	g := gateways[rule.Visibility]
+	splits := ruleSplits(rule)
+	if !hosts.HasAny(port.hosts.UnsortedList()...) || g.Len() == 0 || len(splits) == 0 {
+		return nil
+	}
+	return &istiov1beta1.TCPRoute{
+		Match: []*istiov1beta1.L4MatchAttributes{{
+			Port:     port.number,
+			Gateways: g.List(),
+		}},
+		Route: makeRouteDestinations(splits),
+	}
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+)
+
+func tcpIngress(ports string) *v1alpha1.Ingress {
+	ing := passthroughIngress("")
+	ing.Spec.Rules = append(ing.Spec.Rules, v1alpha1.IngressRule{
+		Hosts:      []string{"db.example.com", "db.example.org"},
+		Visibility: v1alpha1.IngressVisibilityExternalIP,
+		HTTP:       ing.Spec.Rules[0].HTTP,
+	})
+	if ports != "" {
+		ing.Annotations = map[string]string{TCPPortsAnnotationKey: ports}
+	}
+	return ing
+}
+
+func TestMakeIngressTCPServers(t *testing.T) {
+	tests := []struct {
+		name    string
+		ports   string
+		want    []*istiov1beta1.Server
+		wantErr bool
+	}{{
+		name: "no TCP port",
+		want: []*istiov1beta1.Server{},
+	}, {
+		name:  "TCP ports",
+		ports: "db.example.com=5432, secure.example.com=9000, db.example.com=3306",
+		want: []*istiov1beta1.Server{{
+			Hosts: []string{"db.example.com"},
+			Port: &istiov1beta1.Port{
+				Name:     "test-ns/test-ingress:tcp-3306",
+				Number:   3306,
+				Protocol: "TCP",
+			},
+		}, {
+			Hosts: []string{"db.example.com"},
+			Port: &istiov1beta1.Port{
+				Name:     "test-ns/test-ingress:tcp-5432",
+				Number:   5432,
+				Protocol: "TCP",
+			},
+		}, {
+			Hosts: []string{"secure.example.com"},
+			Port: &istiov1beta1.Port{
+				Name:     "test-ns/test-ingress:tcp-9000",
+				Number:   9000,
+				Protocol: "TCP",
+			},
+		}},
+	}, {
+		name:  "hosts of the same rule",
+		ports: "db.example.com=5432,db.example.org=5432",
+		want: []*istiov1beta1.Server{{
+			Hosts: []string{"db.example.com", "db.example.org"},
+			Port: &istiov1beta1.Port{
+				Name:     "test-ns/test-ingress:tcp-5432",
+				Number:   5432,
+				Protocol: "TCP",
+			},
+		}},
+	}, {
+		name:    "hosts of different rules",
+		ports:   "secure.example.com=9000,db.example.com=9000",
+		wantErr: true,
+	}, {
+		name:    "cluster-local host",
+		ports:   "private.test-ns.svc.cluster.local=9000",
+		wantErr: true,
+	}, {
+		name:    "HTTP port",
+		ports:   "db.example.com=443",
+		wantErr: true,
+	}, {
+		name:    "invalid port",
+		ports:   "db.example.com=70000",
+		wantErr: true,
+	}, {
+		name:    "missing port",
+		ports:   "db.example.com",
+		wantErr: true,
+	}, {
+		name:    "empty",
+		ports:   ",",
+		wantErr: true,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := MakeIngressTCPServers(tcpIngress(tc.ports))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("MakeIngressTCPServers() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Error("Unexpected servers (-want +got):", diff)
+			}
+		})
+	}
+}
+
+func TestMakeVirtualServices_TCP(t *testing.T) {
+	ing := tcpIngress("db.example.com=5432,secure.example.com=9000")
+	vses, err := MakeVirtualServices(context.Background(), ing, makeGatewayMap([]string{"gateway-1"}, []string{"private-gateway"}))
+	if err != nil {
+		t.Fatal("MakeVirtualServices() =", err)
+	}
+	if len(vses) != 2 {
+		t.Fatalf("MakeVirtualServices() = %d VirtualServices, wanted 2", len(vses))
+	}
+
+	if got := vses[0].Spec.Tcp; got != nil {
+		t.Errorf("Mesh VirtualService has TCP routes %v", got)
+	}
+	route := []*istiov1beta1.RouteDestination{{
+		Destination: &istiov1beta1.Destination{
+			Host: "rev-1.test-ns.svc.cluster.local",
+			Port: &istiov1beta1.PortSelector{Number: 8443},
+		},
+		Weight: 80,
+	}, {
+		Destination: &istiov1beta1.Destination{
+			Host: "rev-2.test-ns.svc.cluster.local",
+			Port: &istiov1beta1.PortSelector{Number: 8443},
+		},
+		Weight: 20,
+	}}
+	want := []*istiov1beta1.TCPRoute{{
+		Match: []*istiov1beta1.L4MatchAttributes{{
+			Port:     9000,
+			Gateways: []string{"gateway-1"},
+		}},
+		Route: route,
+	}, {
+		Match: []*istiov1beta1.L4MatchAttributes{{
+			Port:     5432,
+			Gateways: []string{"gateway-1"},
+		}},
+		Route: route,
+	}}
+	if diff := cmp.Diff(want, vses[1].Spec.Tcp); diff != "" {
+		t.Error("Unexpected TCP routes (-want +got):", diff)
+	}
+}
@@ -21,12 +21,14 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/gogo/protobuf/types"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 
-	istiov1alpha3 "istio.io/api/networking/v1alpha3"
-	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	"istio.io/client-go/pkg/apis/networking/v1beta1"
 	"knative.dev/net-istio/pkg/reconciler/ingress/resources/names"
+	net "knative.dev/networking/pkg"
 	"knative.dev/networking/pkg/apis/networking"
 	"knative.dev/networking/pkg/apis/networking/v1alpha1"
 	"knative.dev/networking/pkg/ingress"
@@ -35,6 +37,10 @@ import (
 	"knative.dev/pkg/system"
 )
 
+// defaultRetryOn is the set of conditions under which the routes are retried
+// when the Ingress path specifies retries. It matches the Istio default.
+const defaultRetryOn = "connect-failure,refused-stream,unavailable,cancelled,retriable-status-codes"
+
 // VirtualServiceNamespace gives the namespace of the child
 // VirtualServices for a given Ingress.
 func VirtualServiceNamespace(ing *v1alpha1.Ingress) string {
@@ -46,15 +52,19 @@ func VirtualServiceNamespace(ing *v1alpha1.Ingress) string {
 
 // MakeIngressVirtualService creates Istio VirtualService as network
 // programming for Istio Gateways other than 'mesh'.
-func MakeIngressVirtualService(ctx context.Context, ing *v1alpha1.Ingress, gateways map[v1alpha1.IngressVisibility]sets.String) *v1alpha3.VirtualService {
-	vs := &v1alpha3.VirtualService{
+func MakeIngressVirtualService(ctx context.Context, ing *v1alpha1.Ingress, gateways map[v1alpha1.IngressVisibility]sets.String) (*v1beta1.VirtualService, error) {
+	spec, err := makeVirtualServiceSpec(ctx, ing, gateways, ingress.ExpandedHosts(getHosts(ing)))
+	if err != nil {
+		return nil, err
+	}
+	vs := &v1beta1.VirtualService{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            names.IngressVirtualService(ing),
 			Namespace:       VirtualServiceNamespace(ing),
 			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(ing)},
 			Annotations:     ing.GetAnnotations(),
 		},
-		Spec: *makeVirtualServiceSpec(ing, gateways, ingress.ExpandedHosts(getHosts(ing))),
+		Spec: *spec,
 	}
 
 	// Populate the Ingress labels.
@@ -62,11 +72,12 @@ func MakeIngressVirtualService(ctx context.Context, ing *v1alpha1.Ingress, gatew
 		return k != RouteLabelKey && k != RouteNamespaceLabelKey
 	})
 	vs.Labels[networking.IngressLabelKey] = ing.Name
-	return vs
+	return vs, nil
 }
 
-// MakeMeshVirtualService creates a mesh Virtual Service
-func MakeMeshVirtualService(ctx context.Context, ing *v1alpha1.Ingress, gateways map[v1alpha1.IngressVisibility]sets.String) *v1alpha3.VirtualService {
+// MakeMeshVirtualService creates a mesh Virtual Service. It returns nil if the
+// Ingress does not have any cluster local hosts.
+func MakeMeshVirtualService(ctx context.Context, ing *v1alpha1.Ingress, gateways map[v1alpha1.IngressVisibility]sets.String) (*v1beta1.VirtualService, error) {
 	hosts := keepLocalHostnames(getHosts(ing))
 	// If cluster local gateway is configured, we need to expand hosts because of
 	// https://github.com/knative/serving/issues/6488#issuecomment-573513768.
@@ -74,37 +85,45 @@ func MakeMeshVirtualService(ctx context.Context, ing *v1alpha1.Ingress, gateways
 		hosts = ingress.ExpandedHosts(hosts)
 	}
 	if len(hosts) == 0 {
-		return nil
+		return nil, nil
+	}
+	spec, err := makeVirtualServiceSpec(ctx, ing, map[v1alpha1.IngressVisibility]sets.String{
+		v1alpha1.IngressVisibilityExternalIP:   sets.NewString("mesh"),
+		v1alpha1.IngressVisibilityClusterLocal: sets.NewString("mesh"),
+	}, hosts)
+	if err != nil {
+		return nil, err
 	}
-	vs := &v1alpha3.VirtualService{
+	vs := &v1beta1.VirtualService{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            names.MeshVirtualService(ing),
 			Namespace:       VirtualServiceNamespace(ing),
 			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(ing)},
 			Annotations:     ing.GetAnnotations(),
 		},
-		Spec: *makeVirtualServiceSpec(ing, map[v1alpha1.IngressVisibility]sets.String{
-			v1alpha1.IngressVisibilityExternalIP:   sets.NewString("mesh"),
-			v1alpha1.IngressVisibilityClusterLocal: sets.NewString("mesh"),
-		}, hosts),
+		Spec: *spec,
 	}
 	// Populate the Ingress labels.
 	vs.Labels = kmeta.FilterMap(ing.GetLabels(), func(k string) bool {
 		return k != RouteLabelKey && k != RouteNamespaceLabelKey
 	})
 	vs.Labels[networking.IngressLabelKey] = ing.Name
-	return vs
+	return vs, nil
 }
 
 // MakeVirtualServices creates a mesh VirtualService and a virtual service for each gateway
-func MakeVirtualServices(ctx context.Context, ing *v1alpha1.Ingress, gateways map[v1alpha1.IngressVisibility]sets.String) ([]*v1alpha3.VirtualService, error) {
+func MakeVirtualServices(ctx context.Context, ing *v1alpha1.Ingress, gateways map[v1alpha1.IngressVisibility]sets.String) ([]*v1beta1.VirtualService, error) {
 	// Insert probe header
 	ing = ing.DeepCopy()
 	if _, err := ingress.InsertProbe(ing); err != nil {
 		return nil, fmt.Errorf("failed to insert a probe into the Ingress: %w", err)
 	}
-	vss := []*v1alpha3.VirtualService{}
-	if meshVs := MakeMeshVirtualService(ctx, ing, gateways); meshVs != nil {
+	vss := []*v1beta1.VirtualService{}
+	meshVs, err := MakeMeshVirtualService(ctx, ing, gateways)
+	if err != nil {
+		return nil, err
+	}
+	if meshVs != nil {
 		vss = append(vss, meshVs)
 	}
 	requiredGatewayCount := 0
@@ -117,67 +136,129 @@ func MakeVirtualServices(ctx context.Context, ing *v1alpha1.Ingress, gateways ma
 	}
 
 	if requiredGatewayCount > 0 {
-		vss = append(vss, MakeIngressVirtualService(ctx, ing, gateways))
+		ingressVs, err := MakeIngressVirtualService(ctx, ing, gateways)
+		if err != nil {
+			return nil, err
+		}
+		vss = append(vss, ingressVs)
 	}
 
 	return vss, nil
 }
 
-func makeVirtualServiceSpec(ing *v1alpha1.Ingress, gateways map[v1alpha1.IngressVisibility]sets.String, hosts sets.String) *istiov1alpha3.VirtualService {
-	spec := istiov1alpha3.VirtualService{
+func makeVirtualServiceSpec(ctx context.Context, ing *v1alpha1.Ingress, gateways map[v1alpha1.IngressVisibility]sets.String, hosts sets.String) (*istiov1beta1.VirtualService, error) {
+	opts, err := makeRouteOptions(ctx, ing)
+	if err != nil {
+		return nil, err
+	}
+	spec := istiov1beta1.VirtualService{
 		Hosts: hosts.List(),
 	}
 
 	gw := sets.String{}
-	for _, rule := range ing.Spec.Rules {
-		for i := range rule.HTTP.Paths {
-			p := rule.HTTP.Paths[i]
-			hosts := hosts.Intersection(sets.NewString(rule.Hosts...))
-			if hosts.Len() != 0 {
-				http := makeVirtualServiceRoute(hosts, &p, gateways, rule.Visibility)
-				// Add all the Gateways that exist inside the http.match section of
-				// the VirtualService.
-				// This ensures that we are only using the Gateways that actually appear
-				// in VirtualService routes.
-				for _, m := range http.Match {
+	// The static routes answer the requests themselves, ahead of the routes
+	// of the paths except for those answering ACME HTTP-01 challenges, which
+	// go first so that the challenges of the hosts can still be solved.
+	var staticRoutes, challengeRoutes []*istiov1beta1.HTTPRoute
+	for i := range ing.Spec.Rules {
+		rule := &ing.Spec.Rules[i]
+		hosts := hosts.Intersection(sets.NewString(rule.Hosts...))
+		for _, sr := range opts.staticRoutes {
+			if route := makeStaticRoute(hosts, rule, gateways, sr, opts); route != nil {
+				for _, m := range route.Match {
+					gw = gw.Union(sets.NewString(m.Gateways...))
+				}
+				staticRoutes = append(staticRoutes, route)
+			}
+		}
+	}
+
+	for i := range ing.Spec.Rules {
+		rule := &ing.Spec.Rules[i]
+		hosts := hosts.Intersection(sets.NewString(rule.Hosts...))
+		if hosts.Len() == 0 {
+			continue
+		}
+		// The routes of the paths limited per path are named after the index
+		// of the path among the paths of the rule that are not probes.
+		limited := 0
+		for j := range rule.HTTP.Paths {
+			p := rule.HTTP.Paths[j]
+			http := makeVirtualServiceRoute(hosts, &p, gateways, rule.Visibility, opts)
+			if prefix, ok := opts.uriPrefixRewrites[rewriteKey{rule: i, path: p.Path}]; ok && !isProbePath(&p) {
+				if http.Rewrite == nil {
+					http.Rewrite = &istiov1beta1.HTTPRewrite{}
+				}
+				http.Rewrite.Uri = prefix
+			}
+			if opts.rateLimit != nil && opts.rateLimit.perPath && !isProbePath(&p) {
+				http.Name = rateLimitRouteName(ing, i, limited)
+				limited++
+			}
+			// Add all the Gateways that exist inside the http.match section of
+			// the VirtualService.
+			// This ensures that we are only using the Gateways that actually appear
+			// in VirtualService routes.
+			for _, m := range http.Match {
+				gw = gw.Union(sets.NewString(m.Gateways...))
+			}
+			if isACMEChallengePath(&p) {
+				challengeRoutes = append(challengeRoutes, http)
+				continue
+			}
+			// The query routes narrow down the route, so they go first.
+			if !isProbePath(&p) {
+				spec.Http = append(spec.Http, makeQueryRoutes(http, &p, opts.queryRoutes)...)
+			}
+			spec.Http = append(spec.Http, http)
+		}
+
+		if route := makeTLSRoute(hosts, rule, gateways, opts.tlsPassthroughHosts); route != nil {
+			for _, m := range route.Match {
+				gw = gw.Union(sets.NewString(m.Gateways...))
+			}
+			spec.Tls = append(spec.Tls, route)
+		}
+		for _, port := range opts.tcpPorts {
+			if port.rule != i {
+				continue
+			}
+			if route := makeTCPRoute(hosts, rule, gateways, port); route != nil {
+				for _, m := range route.Match {
 					gw = gw.Union(sets.NewString(m.Gateways...))
 				}
-				spec.Http = append(spec.Http, http)
+				spec.Tcp = append(spec.Tcp, route)
 			}
 		}
 	}
+	spec.Http = append(append(challengeRoutes, staticRoutes...), spec.Http...)
 	spec.Gateways = gw.List()
-	return &spec
+	return &spec, nil
 }
 
-func makeVirtualServiceRoute(hosts sets.String, http *v1alpha1.HTTPIngressPath, gateways map[v1alpha1.IngressVisibility]sets.String, visibility v1alpha1.IngressVisibility) *istiov1alpha3.HTTPRoute {
-	matches := []*istiov1alpha3.HTTPMatchRequest{}
-	clusterDomainName := network.GetClusterDomainName()
+func makeVirtualServiceRoute(hosts sets.String, http *v1alpha1.HTTPIngressPath, gateways map[v1alpha1.IngressVisibility]sets.String, visibility v1alpha1.IngressVisibility, opts *routeOptions) *istiov1beta1.HTTPRoute {
+	matches := []*istiov1beta1.HTTPMatchRequest{}
 	for _, host := range hosts.List() {
-		g := gateways[visibility]
-		if strings.HasSuffix(host, clusterDomainName) && len(gateways[v1alpha1.IngressVisibilityClusterLocal]) > 0 {
-			// For local hostname, always use private gateway
-			g = gateways[v1alpha1.IngressVisibilityClusterLocal]
-		}
-		matches = append(matches, makeMatch(host, http.Path, http.Headers, g))
+		g := routeGateways(host, gateways, visibility)
+		matches = append(matches, makeMatches(host, http.Path, http.Headers, g, opts)...)
 	}
 
-	weights := []*istiov1alpha3.HTTPRouteDestination{}
+	// The probes must only reach the actual backends, unaltered.
+	probe := isProbePath(http)
+
+	weights := []*istiov1beta1.HTTPRouteDestination{}
 	for _, split := range http.Splits {
-		var h *istiov1alpha3.Headers
-		if len(split.AppendHeaders) > 0 {
-			h = &istiov1alpha3.Headers{
-				Request: &istiov1alpha3.Headers_HeaderOperations{
-					Set: split.AppendHeaders,
-				},
-			}
+		var policy *istiov1beta1.Headers
+		if !probe {
+			policy = opts.splitHeaders[split.ServiceName]
 		}
+		h := makeHeaders(split.AppendHeaders, policy)
 
-		weights = append(weights, &istiov1alpha3.HTTPRouteDestination{
-			Destination: &istiov1alpha3.Destination{
+		weights = append(weights, &istiov1beta1.HTTPRouteDestination{
+			Destination: &istiov1beta1.Destination{
 				Host: network.GetServiceHostname(
 					split.ServiceName, split.ServiceNamespace),
-				Port: &istiov1alpha3.PortSelector{
+				Port: &istiov1beta1.PortSelector{
 					Number: uint32(split.ServicePort.IntValue()),
 				},
 			},
@@ -186,70 +267,154 @@ func makeVirtualServiceRoute(hosts sets.String, http *v1alpha1.HTTPIngressPath,
 		})
 	}
 
-	var h *istiov1alpha3.Headers
-	if len(http.AppendHeaders) > 0 {
-		h = &istiov1alpha3.Headers{
-			Request: &istiov1alpha3.Headers_HeaderOperations{
-				Set: http.AppendHeaders,
-			},
-		}
+	var policy *istiov1beta1.Headers
+	if !probe {
+		policy = opts.pathHeaders
 	}
+	h := makeHeaders(http.AppendHeaders, policy)
 
-	var rewrite *istiov1alpha3.HTTPRewrite
+	var rewrite *istiov1beta1.HTTPRewrite
 	if http.RewriteHost != "" {
-		rewrite = &istiov1alpha3.HTTPRewrite{
+		rewrite = &istiov1beta1.HTTPRewrite{
 			Authority: http.RewriteHost,
 		}
 	}
 
-	route := &istiov1alpha3.HTTPRoute{
-		Retries: &istiov1alpha3.HTTPRetry{}, // Override default istio behaviour of retrying twice.
+	route := &istiov1beta1.HTTPRoute{
+		Retries: &istiov1beta1.HTTPRetry{}, // Override default istio behaviour of retrying twice.
 		Match:   matches,
 		Route:   weights,
 		Rewrite: rewrite,
 		Headers: h,
 	}
+	if opts.routePolicy {
+		applyRoutePolicy(route, http)
+	}
+	if !probe {
+		if opts.mirror != nil {
+			route.Mirror = opts.mirror
+			route.MirrorPercentage = opts.mirrorPercentage
+		}
+		route.Fault = opts.fault
+		route.CorsPolicy = opts.corsPolicy
+	}
 	return route
 }
 
-func keepLocalHostnames(hosts sets.String) sets.String {
-	localSvcSuffix := ".svc." + network.GetClusterDomainName()
-	retained := sets.NewString()
-	for _, h := range hosts.List() {
-		if strings.HasSuffix(h, localSvcSuffix) {
-			retained.Insert(h)
+// routeGateways returns the gateways of the routes of the given host of a rule
+// with the given visibility.
+func routeGateways(host string, gateways map[v1alpha1.IngressVisibility]sets.String, visibility v1alpha1.IngressVisibility) sets.String {
+	if strings.HasSuffix(host, network.GetClusterDomainName()) && len(gateways[v1alpha1.IngressVisibilityClusterLocal]) > 0 {
+		// For local hostname, always use private gateway
+		return gateways[v1alpha1.IngressVisibilityClusterLocal]
+	}
+	return gateways[visibility]
+}
+
+// isProbePath returns whether the given Ingress path is one of the paths
+// inserted to probe the Ingress.
+func isProbePath(http *v1alpha1.HTTPIngressPath) bool {
+	return http.Headers[net.HashHeaderName].Exact == net.HashHeaderValue
+}
+
+// ruleSplits returns the splits of the first path of the given rule that is not
+// a probe path. The TLS passthrough and TCP routes forward the connections as
+// is, so only these splits apply to them.
+func ruleSplits(rule *v1alpha1.IngressRule) []v1alpha1.IngressBackendSplit {
+	for i := range rule.HTTP.Paths {
+		if p := &rule.HTTP.Paths[i]; !isProbePath(p) {
+			return p.Splits
 		}
 	}
-	return retained
+	return nil
 }
 
-func makeMatch(host, path string, headers map[string]v1alpha1.HeaderMatch, gateways sets.String) *istiov1alpha3.HTTPMatchRequest {
-	match := &istiov1alpha3.HTTPMatchRequest{
-		Gateways: gateways.List(),
-		Authority: &istiov1alpha3.StringMatch{
-			// Do not use Regex as Istio 1.4 or later has 100 bytes limitation.
-			MatchType: &istiov1alpha3.StringMatch_Prefix{Prefix: hostPrefix(host)},
+// makeRouteDestinations returns the weighted destinations of the given splits.
+func makeRouteDestinations(splits []v1alpha1.IngressBackendSplit) []*istiov1beta1.RouteDestination {
+	destinations := make([]*istiov1beta1.RouteDestination, 0, len(splits))
+	for _, split := range splits {
+		destinations = append(destinations, &istiov1beta1.RouteDestination{
+			Destination: &istiov1beta1.Destination{
+				Host: network.GetServiceHostname(split.ServiceName, split.ServiceNamespace),
+				Port: &istiov1beta1.PortSelector{
+					Number: uint32(split.ServicePort.IntValue()),
+				},
+			},
+			Weight: int32(split.Percent),
+		})
+	}
+	return destinations
+}
+
+// withoutProbes returns the header matches excluding the requests probing the
+// Ingress.
+func withoutProbes() map[string]*istiov1beta1.StringMatch {
+	return map[string]*istiov1beta1.StringMatch{
+		net.HashHeaderName: {
+			MatchType: &istiov1beta1.StringMatch_Exact{Exact: net.HashHeaderValue},
 		},
 	}
-	// Empty path is considered match all path. We only need to consider path
-	// when it's non-empty.
-	if path != "" {
-		match.Uri = &istiov1alpha3.StringMatch{
-			MatchType: &istiov1alpha3.StringMatch_Prefix{Prefix: path},
+}
+
+// applyRoutePolicy translates the timeout and retries of the given Ingress path
+// into the given route.
+func applyRoutePolicy(route *istiov1beta1.HTTPRoute, http *v1alpha1.HTTPIngressPath) {
+	if http.DeprecatedTimeout != nil {
+		route.Timeout = types.DurationProto(http.DeprecatedTimeout.Duration)
+	}
+	if r := http.DeprecatedRetries; r != nil {
+		route.Retries = &istiov1beta1.HTTPRetry{
+			Attempts: int32(r.Attempts),
+			RetryOn:  defaultRetryOn,
+		}
+		if r.PerTryTimeout != nil {
+			route.Retries.PerTryTimeout = types.DurationProto(r.PerTryTimeout.Duration)
 		}
 	}
+}
 
-	for k, v := range headers {
-		match.Headers = map[string]*istiov1alpha3.StringMatch{
-			k: {
-				MatchType: &istiov1alpha3.StringMatch_Exact{
-					Exact: v.Exact,
-				},
-			},
+// HasRetries returns whether any path of the given Ingress sets retries.
+func HasRetries(ing *v1alpha1.Ingress) bool {
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			if path.DeprecatedRetries != nil {
+				return true
+			}
 		}
 	}
+	return false
+}
 
-	return match
+// RestoreRetries restores the retries of the paths of the given defaulted
+// Ingress from the given original Ingress. The defaulting of the Ingresses
+// clears them as they are deprecated, but they are still translated into the
+// routes when the route policy is enabled. The original Ingress is not changed.
+func RestoreRetries(ing, original *v1alpha1.Ingress) {
+	for i, rule := range original.Spec.Rules {
+		if rule.HTTP == nil || i >= len(ing.Spec.Rules) || ing.Spec.Rules[i].HTTP == nil {
+			continue
+		}
+		paths := ing.Spec.Rules[i].HTTP.Paths
+		for j, path := range rule.HTTP.Paths {
+			if j < len(paths) && path.DeprecatedRetries != nil {
+				paths[j].DeprecatedRetries = path.DeprecatedRetries.DeepCopy()
+			}
+		}
+	}
+}
+
+func keepLocalHostnames(hosts sets.String) sets.String {
+	localSvcSuffix := ".svc." + network.GetClusterDomainName()
+	retained := sets.NewString()
+	for _, h := range hosts.List() {
+		if strings.HasSuffix(h, localSvcSuffix) {
+			retained.Insert(h)
+		}
+	}
+	return retained
 }
 
 // hostPrefix returns an host to match either host or host:<any port>.
@@ -270,6 +435,21 @@ func getHosts(ing *v1alpha1.Ingress) sets.String {
 	return hosts
 }
 
+// getPublicHosts returns the hosts of the public rules of the given Ingress
+// that are not cluster local.
+func getPublicHosts(ing *v1alpha1.Ingress) sets.String {
+	localSvcSuffix := ".svc." + network.GetClusterDomainName()
+	hosts := sets.NewString()
+	for _, rule := range getPublicIngressRules(ing) {
+		for _, h := range rule.Hosts {
+			if !strings.HasSuffix(h, localSvcSuffix) {
+				hosts.Insert(h)
+			}
+		}
+	}
+	return hosts
+}
+
 func getClusterLocalIngressRules(i *v1alpha1.Ingress) []v1alpha1.IngressRule {
 	var result []v1alpha1.IngressRule
 	for _, rule := range i.Spec.Rules {
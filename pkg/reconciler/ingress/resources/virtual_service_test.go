@@ -19,12 +19,16 @@ package resources
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/gogo/protobuf/types"
 	"github.com/google/go-cmp/cmp"
-	istiov1alpha3 "istio.io/api/networking/v1alpha3"
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	"istio.io/client-go/pkg/apis/networking/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/sets"
+	net "knative.dev/networking/pkg"
 	"knative.dev/networking/pkg/apis/networking"
 	"knative.dev/networking/pkg/apis/networking/v1alpha1"
 	"knative.dev/networking/pkg/ingress"
@@ -286,7 +290,10 @@ func TestMakeVirtualServicesSpec_CorrectGateways(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			vs := makeVirtualServiceSpec(tc.ingress, tc.gateways, ingress.ExpandedHosts(getHosts(tc.ingress)))
+			vs, err := makeVirtualServiceSpec(context.Background(), tc.ingress, tc.gateways, ingress.ExpandedHosts(getHosts(tc.ingress)))
+			if err != nil {
+				t.Fatal("makeVirtualServiceSpec() =", err)
+			}
 			actualGateways := sets.NewString(vs.Gateways...)
 			if !actualGateways.Equal(tc.expectedGateways) {
 				t.Fatalf("Got gateways %v, expected %v", actualGateways.List(), tc.expectedGateways.List())
@@ -315,7 +322,7 @@ func TestMakeMeshVirtualServiceSpec_CorrectGateways(t *testing.T) {
 			}}},
 	}
 	expected := []string{"mesh"}
-	gateways := MakeMeshVirtualService(context.Background(), ci, defaultGateways).Spec.Gateways
+	gateways := mustMakeMeshVirtualService(t, ci, defaultGateways).Spec.Gateways
 	if diff := cmp.Diff(expected, gateways); diff != "" {
 		t.Error("Unexpected gateways (-want +got):", diff)
 	}
@@ -342,7 +349,7 @@ func TestMakeMeshVirtualServiceSpecCorrectHosts(t *testing.T) {
 		expectedHosts: sets.NewString("test-route.test-ns.svc.cluster.local"),
 	}} {
 		t.Run(tc.name, func(t *testing.T) {
-			vs := MakeMeshVirtualService(context.Background(), &defaultIngress, tc.gateways)
+			vs := mustMakeMeshVirtualService(t, &defaultIngress, tc.gateways)
 			vsHosts := sets.NewString(vs.Spec.Hosts...)
 			if !vsHosts.Equal(tc.expectedHosts) {
 				t.Errorf("Unexpected hosts want %v; got %v", tc.expectedHosts, vsHosts)
@@ -405,33 +412,33 @@ func TestMakeMeshVirtualServiceSpec_CorrectRoutes(t *testing.T) {
 			}},
 		},
 	}
-	expected := []*istiov1alpha3.HTTPRoute{{
-		Retries: &istiov1alpha3.HTTPRetry{},
-		Match: []*istiov1alpha3.HTTPMatchRequest{{
-			Uri: &istiov1alpha3.StringMatch{
-				MatchType: &istiov1alpha3.StringMatch_Prefix{Prefix: "/pets/"},
+	expected := []*istiov1beta1.HTTPRoute{{
+		Retries: &istiov1beta1.HTTPRetry{},
+		Match: []*istiov1beta1.HTTPMatchRequest{{
+			Uri: &istiov1beta1.StringMatch{
+				MatchType: &istiov1beta1.StringMatch_Prefix{Prefix: "/pets/"},
 			},
-			Authority: &istiov1alpha3.StringMatch{
-				MatchType: &istiov1alpha3.StringMatch_Prefix{Prefix: `test-route.test-ns`},
+			Authority: &istiov1beta1.StringMatch{
+				MatchType: &istiov1beta1.StringMatch_Prefix{Prefix: `test-route.test-ns`},
 			},
 			Gateways: []string{"mesh"},
 		}},
-		Route: []*istiov1alpha3.HTTPRouteDestination{{
-			Destination: &istiov1alpha3.Destination{
+		Route: []*istiov1beta1.HTTPRouteDestination{{
+			Destination: &istiov1beta1.Destination{
 				Host: "v2-service.test-ns.svc.cluster.local",
-				Port: &istiov1alpha3.PortSelector{Number: 80},
+				Port: &istiov1beta1.PortSelector{Number: 80},
 			},
 			Weight: 100,
-			Headers: &istiov1alpha3.Headers{
-				Request: &istiov1alpha3.Headers_HeaderOperations{
+			Headers: &istiov1beta1.Headers{
+				Request: &istiov1beta1.Headers_HeaderOperations{
 					Set: map[string]string{
 						"ugh": "blah",
 					},
 				},
 			},
 		}},
-		Headers: &istiov1alpha3.Headers{
-			Request: &istiov1alpha3.Headers_HeaderOperations{
+		Headers: &istiov1beta1.Headers{
+			Request: &istiov1beta1.Headers_HeaderOperations{
 				Set: map[string]string{
 					"foo": "bar",
 				},
@@ -439,7 +446,7 @@ func TestMakeMeshVirtualServiceSpec_CorrectRoutes(t *testing.T) {
 		},
 	}}
 
-	routes := MakeMeshVirtualService(context.Background(), ci, defaultGateways).Spec.Http
+	routes := mustMakeMeshVirtualService(t, ci, defaultGateways).Spec.Http
 	if diff := cmp.Diff(expected, routes); diff != "" {
 		t.Error("Unexpected routes (-want +got):", diff)
 	}
@@ -452,7 +459,7 @@ func TestMakeIngressVirtualServiceSpec_CorrectGateways(t *testing.T) {
 		ci.Spec.Rules[idx].Visibility = v1alpha1.IngressVisibilityExternalIP
 	}
 	expected := []string{"knative-testing/gateway-one", "knative-testing/gateway-two"}
-	gateways := MakeIngressVirtualService(context.Background(), ci, makeGatewayMap([]string{"knative-testing/gateway-one", "knative-testing/gateway-two"}, nil)).Spec.Gateways
+	gateways := mustMakeIngressVirtualService(t, ci, makeGatewayMap([]string{"knative-testing/gateway-one", "knative-testing/gateway-two"}, nil)).Spec.Gateways
 	if diff := cmp.Diff(expected, gateways); diff != "" {
 		t.Error("Unexpected gateways (-want +got):", diff)
 	}
@@ -514,66 +521,66 @@ func TestMakeIngressVirtualServiceSpec_CorrectRoutes(t *testing.T) {
 		},
 	}
 
-	expected := []*istiov1alpha3.HTTPRoute{{
-		Retries: &istiov1alpha3.HTTPRetry{},
-		Match: []*istiov1alpha3.HTTPMatchRequest{{
-			Uri: &istiov1alpha3.StringMatch{
-				MatchType: &istiov1alpha3.StringMatch_Prefix{Prefix: "/pets/"},
+	expected := []*istiov1beta1.HTTPRoute{{
+		Retries: &istiov1beta1.HTTPRetry{},
+		Match: []*istiov1beta1.HTTPMatchRequest{{
+			Uri: &istiov1beta1.StringMatch{
+				MatchType: &istiov1beta1.StringMatch_Prefix{Prefix: "/pets/"},
 			},
-			Authority: &istiov1alpha3.StringMatch{
-				MatchType: &istiov1alpha3.StringMatch_Prefix{Prefix: `domain.com`},
+			Authority: &istiov1beta1.StringMatch{
+				MatchType: &istiov1beta1.StringMatch_Prefix{Prefix: `domain.com`},
 			},
 			Gateways: []string{"gateway.public"},
 		}, {
-			Uri: &istiov1alpha3.StringMatch{
-				MatchType: &istiov1alpha3.StringMatch_Prefix{Prefix: "/pets/"},
+			Uri: &istiov1beta1.StringMatch{
+				MatchType: &istiov1beta1.StringMatch_Prefix{Prefix: "/pets/"},
 			},
-			Authority: &istiov1alpha3.StringMatch{
-				MatchType: &istiov1alpha3.StringMatch_Prefix{Prefix: `test-route.test-ns`},
+			Authority: &istiov1beta1.StringMatch{
+				MatchType: &istiov1beta1.StringMatch_Prefix{Prefix: `test-route.test-ns`},
 			},
 			Gateways: []string{"gateway.private"},
 		}},
-		Route: []*istiov1alpha3.HTTPRouteDestination{{
-			Destination: &istiov1alpha3.Destination{
+		Route: []*istiov1beta1.HTTPRouteDestination{{
+			Destination: &istiov1beta1.Destination{
 				Host: "v2-service.test-ns.svc.cluster.local",
-				Port: &istiov1alpha3.PortSelector{Number: 80},
+				Port: &istiov1beta1.PortSelector{Number: 80},
 			},
 			Weight: 100,
-			Headers: &istiov1alpha3.Headers{
-				Request: &istiov1alpha3.Headers_HeaderOperations{
+			Headers: &istiov1beta1.Headers{
+				Request: &istiov1beta1.Headers_HeaderOperations{
 					Set: map[string]string{
 						"ugh": "blah",
 					},
 				},
 			},
 		}},
-		Headers: &istiov1alpha3.Headers{
-			Request: &istiov1alpha3.Headers_HeaderOperations{
+		Headers: &istiov1beta1.Headers{
+			Request: &istiov1beta1.Headers_HeaderOperations{
 				Set: map[string]string{
 					"foo": "bar",
 				},
 			},
 		},
 	}, {
-		Retries: &istiov1alpha3.HTTPRetry{},
-		Match: []*istiov1alpha3.HTTPMatchRequest{{
-			Uri: &istiov1alpha3.StringMatch{
-				MatchType: &istiov1alpha3.StringMatch_Prefix{Prefix: "/pets/"},
+		Retries: &istiov1beta1.HTTPRetry{},
+		Match: []*istiov1beta1.HTTPMatchRequest{{
+			Uri: &istiov1beta1.StringMatch{
+				MatchType: &istiov1beta1.StringMatch_Prefix{Prefix: "/pets/"},
 			},
-			Authority: &istiov1alpha3.StringMatch{
-				MatchType: &istiov1alpha3.StringMatch_Prefix{Prefix: `v1.domain.com`},
+			Authority: &istiov1beta1.StringMatch{
+				MatchType: &istiov1beta1.StringMatch_Prefix{Prefix: `v1.domain.com`},
 			},
 			Gateways: []string{},
 		}},
-		Route: []*istiov1alpha3.HTTPRouteDestination{{
-			Destination: &istiov1alpha3.Destination{
+		Route: []*istiov1beta1.HTTPRouteDestination{{
+			Destination: &istiov1beta1.Destination{
 				Host: "v1-service.test-ns.svc.cluster.local",
-				Port: &istiov1alpha3.PortSelector{Number: 80},
+				Port: &istiov1beta1.PortSelector{Number: 80},
 			},
 			Weight: 100,
 		}},
-		Headers: &istiov1alpha3.Headers{
-			Request: &istiov1alpha3.Headers_HeaderOperations{
+		Headers: &istiov1beta1.Headers{
+			Request: &istiov1beta1.Headers_HeaderOperations{
 				Set: map[string]string{
 					"foo": "baz",
 				},
@@ -581,7 +588,7 @@ func TestMakeIngressVirtualServiceSpec_CorrectRoutes(t *testing.T) {
 		},
 	}}
 
-	routes := MakeIngressVirtualService(context.Background(), ci, makeGatewayMap([]string{"gateway.public"}, []string{"gateway.private"})).Spec.Http
+	routes := mustMakeIngressVirtualService(t, ci, makeGatewayMap([]string{"gateway.public"}, []string{"gateway.private"})).Spec.Http
 	if diff := cmp.Diff(expected, routes); diff != "" {
 		t.Error("Unexpected routes (-want +got):", diff)
 	}
@@ -599,27 +606,27 @@ func TestMakeVirtualServiceRoute_RewriteHost(t *testing.T) {
 			},
 		}},
 	}
-	route := makeVirtualServiceRoute(sets.NewString("a.vanity.url", "another.vanity.url"), ingressPath, makeGatewayMap([]string{"gateway-1"}, nil), v1alpha1.IngressVisibilityExternalIP)
-	expected := &istiov1alpha3.HTTPRoute{
-		Retries: &istiov1alpha3.HTTPRetry{},
-		Match: []*istiov1alpha3.HTTPMatchRequest{{
+	route := makeVirtualServiceRoute(sets.NewString("a.vanity.url", "another.vanity.url"), ingressPath, makeGatewayMap([]string{"gateway-1"}, nil), v1alpha1.IngressVisibilityExternalIP, &routeOptions{})
+	expected := &istiov1beta1.HTTPRoute{
+		Retries: &istiov1beta1.HTTPRetry{},
+		Match: []*istiov1beta1.HTTPMatchRequest{{
 			Gateways: []string{"gateway-1"},
-			Authority: &istiov1alpha3.StringMatch{
-				MatchType: &istiov1alpha3.StringMatch_Prefix{Prefix: `a.vanity.url`},
+			Authority: &istiov1beta1.StringMatch{
+				MatchType: &istiov1beta1.StringMatch_Prefix{Prefix: `a.vanity.url`},
 			},
 		}, {
 			Gateways: []string{"gateway-1"},
-			Authority: &istiov1alpha3.StringMatch{
-				MatchType: &istiov1alpha3.StringMatch_Prefix{Prefix: `another.vanity.url`},
+			Authority: &istiov1beta1.StringMatch{
+				MatchType: &istiov1beta1.StringMatch_Prefix{Prefix: `another.vanity.url`},
 			},
 		}},
-		Rewrite: &istiov1alpha3.HTTPRewrite{
+		Rewrite: &istiov1beta1.HTTPRewrite{
 			Authority: "the.target.host",
 		},
-		Route: []*istiov1alpha3.HTTPRouteDestination{{
-			Destination: &istiov1alpha3.Destination{
+		Route: []*istiov1beta1.HTTPRouteDestination{{
+			Destination: &istiov1beta1.Destination{
 				Host: "the-svc.the-ns.svc.cluster.local",
-				Port: &istiov1alpha3.PortSelector{
+				Port: &istiov1beta1.PortSelector{
 					Number: 8080,
 				},
 			},
@@ -631,6 +638,92 @@ func TestMakeVirtualServiceRoute_RewriteHost(t *testing.T) {
 	}
 }
 
+func TestMakeVirtualServices_URIPrefixRewrites(t *testing.T) {
+	split := func(name string) []v1alpha1.IngressBackendSplit {
+		return []v1alpha1.IngressBackendSplit{{
+			IngressBackend: v1alpha1.IngressBackend{
+				ServiceNamespace: "test-ns",
+				ServiceName:      name,
+				ServicePort:      intstr.FromInt(80),
+			},
+			Percent: 100,
+		}}
+	}
+	ing := &v1alpha1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "test-ns",
+			Annotations: map[string]string{
+				PathMatchTypeAnnotationKey:     "segment-prefix",
+				URIPrefixRewritesAnnotationKey: "/billing=/, /orders=/v2, other.org/billing=/legacy",
+			},
+		},
+		Spec: v1alpha1.IngressSpec{
+			Rules: []v1alpha1.IngressRule{{
+				Hosts:      []string{"test.org"},
+				Visibility: v1alpha1.IngressVisibilityExternalIP,
+				HTTP: &v1alpha1.HTTPIngressRuleValue{
+					Paths: []v1alpha1.HTTPIngressPath{{
+						Path: "/billing",
+						Headers: map[string]v1alpha1.HeaderMatch{
+							net.HashHeaderName: {Exact: net.HashHeaderValue},
+						},
+						Splits: split("billing"),
+					}, {
+						Path:   "/billing",
+						Splits: split("billing"),
+					}, {
+						Path:        "/orders",
+						RewriteHost: "orders.test-ns.svc.cluster.local",
+						Splits:      split("orders"),
+					}, {
+						Path:   "/",
+						Splits: split("frontend"),
+					}},
+				},
+			}, {
+				Hosts:      []string{"other.org"},
+				Visibility: v1alpha1.IngressVisibilityExternalIP,
+				HTTP: &v1alpha1.HTTPIngressRuleValue{
+					Paths: []v1alpha1.HTTPIngressPath{{
+						Path:   "/billing",
+						Splits: split("billing"),
+					}, {
+						Path:   "/",
+						Splits: split("frontend"),
+					}},
+				},
+			}},
+		},
+	}
+	vs, err := MakeIngressVirtualService(context.Background(), ing, makeGatewayMap([]string{"gateway-1"}, nil))
+	if err != nil {
+		t.Fatal("MakeIngressVirtualService() =", err)
+	}
+
+	want := []*istiov1beta1.HTTPRewrite{nil, {
+		Uri: "/",
+	}, {
+		Authority: "orders.test-ns.svc.cluster.local",
+		Uri:       "/v2",
+	}, nil, {
+		Uri: "/legacy",
+	}, nil}
+	if len(vs.Spec.Http) != len(want) {
+		t.Fatalf("MakeIngressVirtualService() = %d routes, wanted %d", len(vs.Spec.Http), len(want))
+	}
+	for i, route := range vs.Spec.Http {
+		if diff := cmp.Diff(want[i], route.Rewrite); diff != "" {
+			t.Errorf("Unexpected rewrite of route %d (-want +got): %s", i, diff)
+		}
+	}
+
+	ing.Annotations[PathMatchTypeAnnotationKey] = "regex"
+	if _, err := MakeIngressVirtualService(context.Background(), ing, makeGatewayMap([]string{"gateway-1"}, nil)); err == nil {
+		t.Error("MakeIngressVirtualService() succeeded with the regex path match type")
+	}
+}
+
 // One active target.
 func TestMakeVirtualServiceRoute_Vanilla(t *testing.T) {
 	ingressPath := &v1alpha1.HTTPIngressPath{
@@ -648,38 +741,38 @@ func TestMakeVirtualServiceRoute_Vanilla(t *testing.T) {
 			Percent: 100,
 		}},
 	}
-	route := makeVirtualServiceRoute(sets.NewString("a.com", "b.org"), ingressPath, makeGatewayMap([]string{"gateway-1"}, nil), v1alpha1.IngressVisibilityExternalIP)
-	expected := &istiov1alpha3.HTTPRoute{
-		Retries: &istiov1alpha3.HTTPRetry{},
-		Match: []*istiov1alpha3.HTTPMatchRequest{{
+	route := makeVirtualServiceRoute(sets.NewString("a.com", "b.org"), ingressPath, makeGatewayMap([]string{"gateway-1"}, nil), v1alpha1.IngressVisibilityExternalIP, &routeOptions{})
+	expected := &istiov1beta1.HTTPRoute{
+		Retries: &istiov1beta1.HTTPRetry{},
+		Match: []*istiov1beta1.HTTPMatchRequest{{
 			Gateways: []string{"gateway-1"},
-			Authority: &istiov1alpha3.StringMatch{
-				MatchType: &istiov1alpha3.StringMatch_Prefix{Prefix: `a.com`},
+			Authority: &istiov1beta1.StringMatch{
+				MatchType: &istiov1beta1.StringMatch_Prefix{Prefix: `a.com`},
 			},
-			Headers: map[string]*istiov1alpha3.StringMatch{
+			Headers: map[string]*istiov1beta1.StringMatch{
 				"my-header": {
-					MatchType: &istiov1alpha3.StringMatch_Exact{
+					MatchType: &istiov1beta1.StringMatch_Exact{
 						Exact: "my-header-value",
 					},
 				},
 			},
 		}, {
 			Gateways: []string{"gateway-1"},
-			Authority: &istiov1alpha3.StringMatch{
-				MatchType: &istiov1alpha3.StringMatch_Prefix{Prefix: `b.org`},
+			Authority: &istiov1beta1.StringMatch{
+				MatchType: &istiov1beta1.StringMatch_Prefix{Prefix: `b.org`},
 			},
-			Headers: map[string]*istiov1alpha3.StringMatch{
+			Headers: map[string]*istiov1beta1.StringMatch{
 				"my-header": {
-					MatchType: &istiov1alpha3.StringMatch_Exact{
+					MatchType: &istiov1beta1.StringMatch_Exact{
 						Exact: "my-header-value",
 					},
 				},
 			},
 		}},
-		Route: []*istiov1alpha3.HTTPRouteDestination{{
-			Destination: &istiov1alpha3.Destination{
+		Route: []*istiov1beta1.HTTPRouteDestination{{
+			Destination: &istiov1beta1.Destination{
 				Host: "revision-service.test-ns.svc.cluster.local",
-				Port: &istiov1alpha3.PortSelector{Number: 80},
+				Port: &istiov1beta1.PortSelector{Number: 80},
 			},
 			Weight: 100,
 		}},
@@ -708,25 +801,25 @@ func TestMakeVirtualServiceRoute_TwoTargets(t *testing.T) {
 			Percent: 10,
 		}},
 	}
-	route := makeVirtualServiceRoute(sets.NewString("test.org"), ingressPath, makeGatewayMap([]string{"knative-testing/gateway-1"}, nil), v1alpha1.IngressVisibilityExternalIP)
-	expected := &istiov1alpha3.HTTPRoute{
-		Retries: &istiov1alpha3.HTTPRetry{},
-		Match: []*istiov1alpha3.HTTPMatchRequest{{
+	route := makeVirtualServiceRoute(sets.NewString("test.org"), ingressPath, makeGatewayMap([]string{"knative-testing/gateway-1"}, nil), v1alpha1.IngressVisibilityExternalIP, &routeOptions{})
+	expected := &istiov1beta1.HTTPRoute{
+		Retries: &istiov1beta1.HTTPRetry{},
+		Match: []*istiov1beta1.HTTPMatchRequest{{
 			Gateways: []string{"knative-testing/gateway-1"},
-			Authority: &istiov1alpha3.StringMatch{
-				MatchType: &istiov1alpha3.StringMatch_Prefix{Prefix: `test.org`},
+			Authority: &istiov1beta1.StringMatch{
+				MatchType: &istiov1beta1.StringMatch_Prefix{Prefix: `test.org`},
 			},
 		}},
-		Route: []*istiov1alpha3.HTTPRouteDestination{{
-			Destination: &istiov1alpha3.Destination{
+		Route: []*istiov1beta1.HTTPRouteDestination{{
+			Destination: &istiov1beta1.Destination{
 				Host: "revision-service.test-ns.svc.cluster.local",
-				Port: &istiov1alpha3.PortSelector{Number: 80},
+				Port: &istiov1beta1.PortSelector{Number: 80},
 			},
 			Weight: 90,
 		}, {
-			Destination: &istiov1alpha3.Destination{
+			Destination: &istiov1beta1.Destination{
 				Host: "new-revision-service.test-ns.svc.cluster.local",
-				Port: &istiov1alpha3.PortSelector{Number: 81},
+				Port: &istiov1beta1.PortSelector{Number: 81},
 			},
 			Weight: 10,
 		}},
@@ -736,6 +829,457 @@ func TestMakeVirtualServiceRoute_TwoTargets(t *testing.T) {
 	}
 }
 
+func TestMakeVirtualServiceRoute_RoutePolicy(t *testing.T) {
+	ingressPath := &v1alpha1.HTTPIngressPath{
+		DeprecatedTimeout: &metav1.Duration{Duration: 10 * time.Minute},
+		DeprecatedRetries: &v1alpha1.HTTPRetry{
+			Attempts:      3,
+			PerTryTimeout: &metav1.Duration{Duration: time.Minute},
+		},
+		Splits: []v1alpha1.IngressBackendSplit{{
+			IngressBackend: v1alpha1.IngressBackend{
+				ServiceNamespace: "test-ns",
+				ServiceName:      "revision-service",
+				ServicePort:      intstr.FromInt(80),
+			},
+			Percent: 100,
+		}},
+	}
+	tests := []struct {
+		name        string
+		opts        *routeOptions
+		wantTimeout *types.Duration
+		wantRetries *istiov1beta1.HTTPRetry
+	}{{
+		name:        "route policy disabled",
+		opts:        &routeOptions{},
+		wantRetries: &istiov1beta1.HTTPRetry{},
+	}, {
+		name:        "route policy enabled",
+		opts:        &routeOptions{routePolicy: true},
+		wantTimeout: types.DurationProto(10 * time.Minute),
+		wantRetries: &istiov1beta1.HTTPRetry{
+			Attempts:      3,
+			PerTryTimeout: types.DurationProto(time.Minute),
+			RetryOn:       defaultRetryOn,
+		},
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			route := makeVirtualServiceRoute(sets.NewString("test.org"), ingressPath, makeGatewayMap([]string{"gateway-1"}, nil), v1alpha1.IngressVisibilityExternalIP, tc.opts)
+			if diff := cmp.Diff(tc.wantTimeout, route.Timeout); diff != "" {
+				t.Error("Unexpected timeout (-want +got):", diff)
+			}
+			if diff := cmp.Diff(tc.wantRetries, route.Retries); diff != "" {
+				t.Error("Unexpected retries (-want +got):", diff)
+			}
+		})
+	}
+}
+
+func TestRestoreRetries(t *testing.T) {
+	original := &v1alpha1.Ingress{
+		Spec: v1alpha1.IngressSpec{
+			Rules: []v1alpha1.IngressRule{{
+				HTTP: &v1alpha1.HTTPIngressRuleValue{
+					Paths: []v1alpha1.HTTPIngressPath{{
+						Path:              "/retried",
+						DeprecatedRetries: &v1alpha1.HTTPRetry{Attempts: 3},
+					}, {
+						Path: "/not-retried",
+					}},
+				},
+			}},
+		},
+	}
+	if !HasRetries(original) {
+		t.Error("HasRetries() = false, wanted true")
+	}
+	ing := original.DeepCopy()
+	ing.SetDefaults(context.Background())
+	if HasRetries(ing) {
+		t.Error("HasRetries() = true after the defaulting, wanted false")
+	}
+
+	RestoreRetries(ing, original)
+	paths := ing.Spec.Rules[0].HTTP.Paths
+	if diff := cmp.Diff(&v1alpha1.HTTPRetry{Attempts: 3}, paths[0].DeprecatedRetries); diff != "" {
+		t.Error("Unexpected retries (-want +got):", diff)
+	}
+	if paths[1].DeprecatedRetries != nil {
+		t.Errorf("Retries = %v, wanted none", paths[1].DeprecatedRetries)
+	}
+
+	// The original Ingress, e.g. the informer copy, must not be shared.
+	paths[0].DeprecatedRetries.Attempts = 5
+	if got := original.Spec.Rules[0].HTTP.Paths[0].DeprecatedRetries.Attempts; got != 3 {
+		t.Errorf("Original attempts = %d, wanted 3", got)
+	}
+}
+
+func TestMakeVirtualServices_Mirror(t *testing.T) {
+	ing := &v1alpha1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "test-ns",
+			Annotations: map[string]string{
+				MirrorAnnotationKey:           "shadow",
+				MirrorPercentageAnnotationKey: "10",
+			},
+		},
+		Spec: v1alpha1.IngressSpec{
+			Rules: []v1alpha1.IngressRule{{
+				Hosts:      []string{"test.org"},
+				Visibility: v1alpha1.IngressVisibilityExternalIP,
+				HTTP: &v1alpha1.HTTPIngressRuleValue{
+					Paths: []v1alpha1.HTTPIngressPath{{
+						Splits: []v1alpha1.IngressBackendSplit{{
+							IngressBackend: v1alpha1.IngressBackend{
+								ServiceNamespace: "test-ns",
+								ServiceName:      "revision-service",
+								ServicePort:      intstr.FromInt(80),
+							},
+							Percent: 100,
+						}},
+					}},
+				},
+			}},
+		},
+	}
+	vses, err := MakeVirtualServices(context.Background(), ing, makeGatewayMap([]string{"gateway-1"}, nil))
+	if err != nil {
+		t.Fatal("MakeVirtualServices() =", err)
+	}
+	if len(vses) != 1 {
+		t.Fatalf("MakeVirtualServices() = %d VirtualServices, wanted 1", len(vses))
+	}
+
+	wantMirror := &istiov1beta1.Destination{
+		Host: "shadow.test-ns.svc.cluster.local",
+		Port: &istiov1beta1.PortSelector{Number: 80},
+	}
+	wantPercentage := &istiov1beta1.Percent{Value: 10}
+	for _, route := range vses[0].Spec.Http {
+		if _, probe := route.Match[0].Headers[net.HashHeaderName]; probe {
+			if route.Mirror != nil || route.MirrorPercentage != nil {
+				t.Errorf("Probe route mirrors to %v, wanted no mirror", route.Mirror)
+			}
+			continue
+		}
+		if diff := cmp.Diff(wantMirror, route.Mirror); diff != "" {
+			t.Error("Unexpected mirror (-want +got):", diff)
+		}
+		if diff := cmp.Diff(wantPercentage, route.MirrorPercentage); diff != "" {
+			t.Error("Unexpected mirror percentage (-want +got):", diff)
+		}
+	}
+}
+
+func TestMakeVirtualServices_Fault(t *testing.T) {
+	ing := &v1alpha1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "test-ns",
+			Annotations: map[string]string{
+				FaultAbortStatusAnnotationKey:     "503",
+				FaultAbortPercentageAnnotationKey: "10",
+			},
+		},
+		Spec: v1alpha1.IngressSpec{
+			Rules: []v1alpha1.IngressRule{{
+				Hosts:      []string{"test.org"},
+				Visibility: v1alpha1.IngressVisibilityExternalIP,
+				HTTP: &v1alpha1.HTTPIngressRuleValue{
+					Paths: []v1alpha1.HTTPIngressPath{{
+						Splits: []v1alpha1.IngressBackendSplit{{
+							IngressBackend: v1alpha1.IngressBackend{
+								ServiceNamespace: "test-ns",
+								ServiceName:      "revision-service",
+								ServicePort:      intstr.FromInt(80),
+							},
+							Percent: 100,
+						}},
+					}},
+				},
+			}},
+		},
+	}
+	if _, err := ingress.InsertProbe(ing); err != nil {
+		t.Fatal("InsertProbe() =", err)
+	}
+	vses, err := MakeVirtualServices(context.Background(), ing, makeGatewayMap([]string{"gateway-1"}, nil))
+	if err != nil {
+		t.Fatal("MakeVirtualServices() =", err)
+	}
+	if len(vses) != 1 {
+		t.Fatalf("MakeVirtualServices() = %d VirtualServices, wanted 1", len(vses))
+	}
+
+	wantFault := &istiov1beta1.HTTPFaultInjection{
+		Abort: &istiov1beta1.HTTPFaultInjection_Abort{
+			ErrorType: &istiov1beta1.HTTPFaultInjection_Abort_HttpStatus{
+				HttpStatus: 503,
+			},
+			Percentage: &istiov1beta1.Percent{Value: 10},
+		},
+	}
+	var probed bool
+	for _, route := range vses[0].Spec.Http {
+		if _, probe := route.Match[0].Headers[net.HashHeaderName]; probe {
+			probed = true
+			if route.Fault != nil {
+				t.Errorf("Probe route injects fault %v, wanted no fault", route.Fault)
+			}
+			continue
+		}
+		if diff := cmp.Diff(wantFault, route.Fault); diff != "" {
+			t.Error("Unexpected fault (-want +got):", diff)
+		}
+	}
+	if !probed {
+		t.Error("MakeVirtualServices() has no probe route")
+	}
+}
+
+func TestMakeVirtualServices_CORS(t *testing.T) {
+	ing := &v1alpha1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "test-ns",
+			Annotations: map[string]string{
+				CORSAllowOriginsAnnotationKey: "https://app.example.com",
+				CORSAllowMethodsAnnotationKey: "GET,POST",
+				CORSMaxAgeAnnotationKey:       "1h",
+			},
+		},
+		Spec: v1alpha1.IngressSpec{
+			Rules: []v1alpha1.IngressRule{{
+				Hosts:      []string{"test.org"},
+				Visibility: v1alpha1.IngressVisibilityExternalIP,
+				HTTP: &v1alpha1.HTTPIngressRuleValue{
+					Paths: []v1alpha1.HTTPIngressPath{{
+						Splits: []v1alpha1.IngressBackendSplit{{
+							IngressBackend: v1alpha1.IngressBackend{
+								ServiceNamespace: "test-ns",
+								ServiceName:      "revision-service",
+								ServicePort:      intstr.FromInt(80),
+							},
+							Percent: 100,
+						}},
+					}},
+				},
+			}},
+		},
+	}
+	vses, err := MakeVirtualServices(context.Background(), ing, makeGatewayMap([]string{"gateway-1"}, nil))
+	if err != nil {
+		t.Fatal("MakeVirtualServices() =", err)
+	}
+	if len(vses) != 1 {
+		t.Fatalf("MakeVirtualServices() = %d VirtualServices, wanted 1", len(vses))
+	}
+
+	wantPolicy := &istiov1beta1.CorsPolicy{
+		AllowOrigins: []*istiov1beta1.StringMatch{{
+			MatchType: &istiov1beta1.StringMatch_Exact{Exact: "https://app.example.com"},
+		}},
+		AllowMethods: []string{"GET", "POST"},
+		MaxAge:       &types.Duration{Seconds: 3600},
+	}
+	for _, route := range vses[0].Spec.Http {
+		if _, probe := route.Match[0].Headers[net.HashHeaderName]; probe {
+			continue
+		}
+		if diff := cmp.Diff(wantPolicy, route.CorsPolicy); diff != "" {
+			t.Error("Unexpected CORS policy (-want +got):", diff)
+		}
+	}
+
+	ing.Annotations[CORSAllowOriginsAnnotationKey] = "https://example.com/app"
+	if _, err := MakeVirtualServices(context.Background(), ing, makeGatewayMap([]string{"gateway-1"}, nil)); err == nil {
+		t.Error("MakeVirtualServices() succeeded with an invalid origin")
+	}
+}
+
+func TestMakeVirtualServices_HeaderPolicy(t *testing.T) {
+	ing := &v1alpha1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "test-ns",
+			Annotations: map[string]string{
+				ResponseHeadersSetAnnotationKey:              "X-Frame-Options=DENY",
+				RequestHeadersRemoveAnnotationKey + ".rev-2": "X-Debug",
+			},
+		},
+		Spec: v1alpha1.IngressSpec{
+			Rules: []v1alpha1.IngressRule{{
+				Hosts:      []string{"test.org"},
+				Visibility: v1alpha1.IngressVisibilityExternalIP,
+				HTTP: &v1alpha1.HTTPIngressRuleValue{
+					Paths: []v1alpha1.HTTPIngressPath{{
+						AppendHeaders: map[string]string{"Knative-Serving-Namespace": "test-ns"},
+						Splits: []v1alpha1.IngressBackendSplit{{
+							IngressBackend: v1alpha1.IngressBackend{
+								ServiceNamespace: "test-ns",
+								ServiceName:      "rev-1",
+								ServicePort:      intstr.FromInt(80),
+							},
+							Percent: 90,
+						}, {
+							IngressBackend: v1alpha1.IngressBackend{
+								ServiceNamespace: "test-ns",
+								ServiceName:      "rev-2",
+								ServicePort:      intstr.FromInt(80),
+							},
+							Percent:       10,
+							AppendHeaders: map[string]string{"Knative-Serving-Revision": "rev-2"},
+						}},
+					}},
+				},
+			}},
+		},
+	}
+	vses, err := MakeVirtualServices(context.Background(), ing, makeGatewayMap([]string{"gateway-1"}, nil))
+	if err != nil {
+		t.Fatal("MakeVirtualServices() =", err)
+	}
+	if len(vses) != 1 {
+		t.Fatalf("MakeVirtualServices() = %d VirtualServices, wanted 1", len(vses))
+	}
+
+	wantPathHeaders := &istiov1beta1.Headers{
+		Request: &istiov1beta1.Headers_HeaderOperations{
+			Set: map[string]string{"Knative-Serving-Namespace": "test-ns"},
+		},
+		Response: &istiov1beta1.Headers_HeaderOperations{
+			Set: map[string]string{"X-Frame-Options": "DENY"},
+		},
+	}
+	wantSplitHeaders := []*istiov1beta1.Headers{nil, {
+		Request: &istiov1beta1.Headers_HeaderOperations{
+			Set:    map[string]string{"Knative-Serving-Revision": "rev-2"},
+			Remove: []string{"X-Debug"},
+		},
+	}}
+	var probed bool
+	for _, route := range vses[0].Spec.Http {
+		if _, probe := route.Match[0].Headers[net.HashHeaderName]; probe {
+			probed = true
+			if route.Headers.Response != nil || route.Route[1].Headers.Request.Remove != nil {
+				t.Errorf("Probe route has header policy %v, wanted none", route.Headers)
+			}
+			continue
+		}
+		if diff := cmp.Diff(wantPathHeaders, route.Headers); diff != "" {
+			t.Error("Unexpected path headers (-want +got):", diff)
+		}
+		for i, split := range route.Route {
+			if diff := cmp.Diff(wantSplitHeaders[i], split.Headers); diff != "" {
+				t.Errorf("Unexpected headers of split %d (-want +got): %s", i, diff)
+			}
+		}
+	}
+	if !probed {
+		t.Error("MakeVirtualServices() has no probe route")
+	}
+}
+
+func TestMakeVirtualServices_QueryRoutes(t *testing.T) {
+	ing := &v1alpha1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "test-ns",
+			Annotations: map[string]string{
+				QueryRoutesAnnotationKey: "rev-2?canary=true, rev-3?canary=true",
+			},
+		},
+		Spec: v1alpha1.IngressSpec{
+			Rules: []v1alpha1.IngressRule{{
+				Hosts:      []string{"test.org"},
+				Visibility: v1alpha1.IngressVisibilityExternalIP,
+				HTTP: &v1alpha1.HTTPIngressRuleValue{
+					Paths: []v1alpha1.HTTPIngressPath{{
+						Path: "/api",
+						Splits: []v1alpha1.IngressBackendSplit{{
+							IngressBackend: v1alpha1.IngressBackend{
+								ServiceNamespace: "test-ns",
+								ServiceName:      "rev-1",
+								ServicePort:      intstr.FromInt(80),
+							},
+							Percent: 90,
+						}, {
+							IngressBackend: v1alpha1.IngressBackend{
+								ServiceNamespace: "test-ns",
+								ServiceName:      "rev-2",
+								ServicePort:      intstr.FromInt(80),
+							},
+							Percent:       10,
+							AppendHeaders: map[string]string{"Knative-Serving-Revision": "rev-2"},
+						}},
+					}, {
+						Splits: []v1alpha1.IngressBackendSplit{{
+							IngressBackend: v1alpha1.IngressBackend{
+								ServiceNamespace: "test-ns",
+								ServiceName:      "rev-1",
+								ServicePort:      intstr.FromInt(80),
+							},
+							Percent: 100,
+						}},
+					}},
+				},
+			}},
+		},
+	}
+	vses, err := MakeVirtualServices(context.Background(), ing, makeGatewayMap([]string{"gateway-1"}, nil))
+	if err != nil {
+		t.Fatal("MakeVirtualServices() =", err)
+	}
+	if len(vses) != 1 {
+		t.Fatalf("MakeVirtualServices() = %d VirtualServices, wanted 1", len(vses))
+	}
+
+	// The probe routes, then the query route preceding the route of /api,
+	// and the route of the other path, which has no rev-2 split.
+	routes := vses[0].Spec.Http
+	if len(routes) != 5 {
+		t.Fatalf("MakeVirtualServices() = %d routes, wanted 5", len(routes))
+	}
+	for _, route := range routes[:2] {
+		if _, probe := route.Match[0].Headers[net.HashHeaderName]; !probe {
+			t.Errorf("Route %v is not a probe route", route.Match)
+		}
+	}
+	query, base := routes[2], routes[3]
+	wantMatch := base.Match[0].DeepCopy()
+	wantMatch.QueryParams = map[string]*istiov1beta1.StringMatch{
+		"canary": {MatchType: &istiov1beta1.StringMatch_Exact{Exact: "true"}},
+	}
+	if diff := cmp.Diff([]*istiov1beta1.HTTPMatchRequest{wantMatch}, query.Match); diff != "" {
+		t.Error("Unexpected query route match (-want +got):", diff)
+	}
+	wantRoute := []*istiov1beta1.HTTPRouteDestination{{
+		Destination: &istiov1beta1.Destination{
+			Host: "rev-2.test-ns.svc.cluster.local",
+			Port: &istiov1beta1.PortSelector{Number: 80},
+		},
+		Headers: &istiov1beta1.Headers{
+			Request: &istiov1beta1.Headers_HeaderOperations{
+				Set: map[string]string{"Knative-Serving-Revision": "rev-2"},
+			},
+		},
+	}}
+	if diff := cmp.Diff(wantRoute, query.Route); diff != "" {
+		t.Error("Unexpected query route destinations (-want +got):", diff)
+	}
+	if len(base.Route) != 2 || base.Match[0].QueryParams != nil {
+		t.Errorf("Route of /api = %v, wanted the splits without query parameters", base)
+	}
+	if routes[4].Match[0].QueryParams != nil {
+		t.Errorf("Route of the other path matches query parameters %v", routes[4].Match[0].QueryParams)
+	}
+}
+
 func TestGetHosts_Duplicate(t *testing.T) {
 	ci := &v1alpha1.Ingress{
 		Spec: v1alpha1.IngressSpec{
@@ -759,3 +1303,21 @@ func makeGatewayMap(publicGateways []string, privateGateways []string) map[v1alp
 		v1alpha1.IngressVisibilityClusterLocal: sets.NewString(privateGateways...),
 	}
 }
+
+func mustMakeMeshVirtualService(t *testing.T, ing *v1alpha1.Ingress, gateways map[v1alpha1.IngressVisibility]sets.String) *v1beta1.VirtualService {
+	t.Helper()
+	vs, err := MakeMeshVirtualService(context.Background(), ing, gateways)
+	if err != nil {
+		t.Fatal("MakeMeshVirtualService() =", err)
+	}
+	return vs
+}
+
+func mustMakeIngressVirtualService(t *testing.T, ing *v1alpha1.Ingress, gateways map[v1alpha1.IngressVisibility]sets.String) *v1beta1.VirtualService {
+	t.Helper()
+	vs, err := MakeIngressVirtualService(context.Background(), ing, gateways)
+	if err != nil {
+		t.Fatal("MakeIngressVirtualService() =", err)
+	}
+	return vs
+}
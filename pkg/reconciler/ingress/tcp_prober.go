@@ -0,0 +1,267 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/networking/pkg/ingress"
+	"knative.dev/networking/pkg/status"
+	"knative.dev/pkg/kmeta"
+)
+
+const (
+	// tcpProbeInterval is the interval between the connection attempts to a
+	// TCP probe target.
+	tcpProbeInterval = time.Second
+	// tcpProbeTimeout is the maximum amount of time a connection attempt
+	// waits.
+	tcpProbeTimeout = time.Second
+)
+
+// statusManagers is a status.Manager whose Ingresses are ready when all of its
+// managers consider them ready.
+type statusManagers []status.Manager
+
+var _ status.Manager = statusManagers(nil)
+
+// IsReady implements status.Manager. All the managers are asked so that they
+// all start probing the Ingress.
+func (m statusManagers) IsReady(ctx context.Context, ing *v1alpha1.Ingress) (bool, error) {
+	ready := true
+	for _, manager := range m {
+		r, err := manager.IsReady(ctx, ing)
+		if err != nil {
+			return false, err
+		}
+		ready = ready && r
+	}
+	return ready, nil
+}
+
+// CancelIngressProbing cancels the probing of the given Ingress by the managers
+// supporting it.
+func (m statusManagers) CancelIngressProbing(obj interface{}) {
+	for _, manager := range m {
+		if c, ok := manager.(ingressProbingCanceler); ok {
+			c.CancelIngressProbing(obj)
+		}
+	}
+}
+
+// ingressProbingCanceler is implemented by the status managers that can cancel
+// the probing of an Ingress.
+type ingressProbingCanceler interface {
+	CancelIngressProbing(obj interface{})
+}
+
+// tcpProber checks the readiness of the TCP servers of the Ingresses by
+// connecting to the gateway pods serving them. Unlike the HTTP probes, the
+// connections cannot tell which version of an Ingress they reached, but the
+// gateways only listen on the TCP ports once they are configured for them.
+//
+// The HTTPS servers in MUTUAL TLS mode are probed likewise, since the prober
+// has no client certificate to reach the probe handlers through them. Their
+// probes only succeed once the gateways request a client certificate for the
+// hosts of the Ingress, which they do once they are configured for them.
+type tcpProber struct {
+	logger        *zap.SugaredLogger
+	targetLister  ProbeTargetLister
+	readyCallback func(*v1alpha1.Ingress)
+	dialContext   func(ctx context.Context, network, address string) (net.Conn, error)
+
+	// mu guards ingressStates.
+	mu            sync.Mutex
+	ingressStates map[types.NamespacedName]*tcpIngressState
+}
+
+// tcpIngressState is the probing state of a version of an Ingress.
+type tcpIngressState struct {
+	hash    string
+	ing     *v1alpha1.Ingress
+	pending int
+	cancel  context.CancelFunc
+	// podCancels cancel the probing of the targets of each pod IP.
+	podCancels map[string]context.CancelFunc
+}
+
+var _ status.Manager = (*tcpProber)(nil)
+
+func newTCPProber(logger *zap.SugaredLogger, targetLister ProbeTargetLister, readyCallback func(*v1alpha1.Ingress)) *tcpProber {
+	return &tcpProber{
+		logger:        logger,
+		targetLister:  targetLister,
+		readyCallback: readyCallback,
+		dialContext:   (&net.Dialer{Timeout: tcpProbeTimeout}).DialContext,
+		ingressStates: map[types.NamespacedName]*tcpIngressState{},
+	}
+}
+
+// IsReady implements status.Manager. The Ingresses without TCP target are
+// always ready.
+func (p *tcpProber) IsReady(ctx context.Context, ing *v1alpha1.Ingress) (bool, error) {
+	key := types.NamespacedName{Namespace: ing.Namespace, Name: ing.Name}
+	bytes, err := ingress.ComputeHash(ing)
+	if err != nil {
+		return false, fmt.Errorf("failed to compute the hash of the Ingress: %w", err)
+	}
+	hash := fmt.Sprintf("%x", bytes)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if state, ok := p.ingressStates[key]; ok {
+		if state.hash == hash {
+			return state.pending == 0, nil
+		}
+		// Cancel the probing of the outdated version.
+		state.cancel()
+		delete(p.ingressStates, key)
+	}
+
+	targets, err := p.targetLister.ListTCPProbeTargets(ctx, ing)
+	if err != nil {
+		return false, err
+	}
+	ingCtx, cancel := context.WithCancel(context.Background())
+	state := &tcpIngressState{
+		hash:       hash,
+		ing:        ing,
+		cancel:     cancel,
+		podCancels: map[string]context.CancelFunc{},
+	}
+	podContexts := map[string]context.Context{}
+	for _, target := range targets {
+		for ip := range target.PodIPs {
+			podCtx, ok := podContexts[ip]
+			if !ok {
+				podCtx, state.podCancels[ip] = context.WithCancel(ingCtx)
+				podContexts[ip] = podCtx
+			}
+			state.pending++
+			go p.probe(ingCtx, podCtx, key, state, target.URLs[0], net.JoinHostPort(ip, target.PodPort))
+		}
+	}
+	p.ingressStates[key] = state
+	return state.pending == 0, nil
+}
+
+// probe connects to the given address for the given target URL until it
+// succeeds or the probing of the pod is cancelled, which both count as done
+// unless the probing of the whole Ingress version is cancelled.
+func (p *tcpProber) probe(ingCtx, podCtx context.Context, key types.NamespacedName, state *tcpIngressState, target *url.URL, address string) {
+	wait.PollImmediateUntil(tcpProbeInterval, func() (bool, error) {
+		conn, err := p.dialContext(podCtx, "tcp", address)
+		if err == nil {
+			defer conn.Close()
+			if target.Scheme == mutualTLSScheme {
+				err = checkClientCertificateRequest(conn, target.Hostname())
+			}
+		}
+		if err != nil {
+			if podCtx.Err() != nil {
+				// The probing was cancelled.
+				return false, nil
+			}
+			p.logger.Debugf("Probing of %s for Ingress %s failed: %v", address, key, err)
+			return false, nil
+		}
+		return true, nil
+	}, podCtx.Done())
+	if ingCtx.Err() != nil {
+		return
+	}
+
+	p.mu.Lock()
+	state.pending--
+	ready := state.pending == 0 && p.ingressStates[key] == state
+	p.mu.Unlock()
+	if ready {
+		p.readyCallback(state.ing)
+	}
+}
+
+// checkClientCertificateRequest starts a TLS handshake for the given host over
+// the given connection, and returns an error unless the server requests a
+// client certificate.
+func checkClientCertificateRequest(conn net.Conn, host string) error {
+	requested := false
+	tlsConn := tls.Client(conn, &tls.Config{
+		ServerName: host,
+		//nolint:gosec
+		// Only the request of a client certificate matters, not the server one.
+		InsecureSkipVerify: true,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			requested = true
+			return &tls.Certificate{}, nil
+		},
+	})
+	if err := conn.SetDeadline(time.Now().Add(tcpProbeTimeout)); err != nil {
+		return err
+	}
+	// The handshake is expected to fail once the empty client certificate is
+	// sent.
+	err := tlsConn.Handshake()
+	if requested {
+		return nil
+	}
+	if err == nil {
+		err = errors.New("no client certificate requested")
+	}
+	return err
+}
+
+// CancelIngressProbing cancels the probing of the given Ingress.
+func (p *tcpProber) CancelIngressProbing(obj interface{}) {
+	acc, err := kmeta.DeletionHandlingAccessor(obj)
+	if err != nil {
+		return
+	}
+	key := types.NamespacedName{Namespace: acc.GetNamespace(), Name: acc.GetName()}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if state, ok := p.ingressStates[key]; ok {
+		state.cancel()
+		delete(p.ingressStates, key)
+	}
+}
+
+// CancelPodProbing cancels the probing of the given Pod.
+func (p *tcpProber) CancelPodProbing(obj interface{}) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, state := range p.ingressStates {
+		if cancel, ok := state.podCancels[pod.Status.PodIP]; ok {
+			cancel()
+		}
+	}
+}
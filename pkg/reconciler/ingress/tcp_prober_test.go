@@ -0,0 +1,178 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/networking/pkg/status"
+)
+
+type fakeTCPTargetLister []status.ProbeTarget
+
+func (l fakeTCPTargetLister) ListProbeTargets(context.Context, *v1alpha1.Ingress) ([]status.ProbeTarget, error) {
+	return nil, nil
+}
+
+func (l fakeTCPTargetLister) ListTCPProbeTargets(context.Context, *v1alpha1.Ingress) ([]status.ProbeTarget, error) {
+	return l, nil
+}
+
+func TestTCPProberNoTarget(t *testing.T) {
+	prober := newTCPProber(zaptest.NewLogger(t).Sugar(), fakeTCPTargetLister(nil), func(*v1alpha1.Ingress) {
+		t.Error("Unexpected ready callback")
+	})
+	ready, err := prober.IsReady(context.Background(), tcpIngress())
+	if err != nil {
+		t.Fatal("IsReady() =", err)
+	}
+	if !ready {
+		t.Error("IsReady() = false, wanted true")
+	}
+}
+
+func TestTCPProberReady(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Failed to listen:", err)
+	}
+	defer listener.Close()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	readyCh := make(chan *v1alpha1.Ingress, 1)
+	prober := newTCPProber(zaptest.NewLogger(t).Sugar(), fakeTCPTargetLister{{
+		PodIPs:  sets.NewString("127.0.0.1"),
+		PodPort: port,
+		Port:    "9000",
+		URLs:    []*url.URL{{Scheme: tcpScheme, Host: "foo.bar.com:9000"}},
+	}}, func(ing *v1alpha1.Ingress) {
+		readyCh <- ing
+	})
+
+	ing := tcpIngress()
+	ready, err := prober.IsReady(context.Background(), ing)
+	if err != nil {
+		t.Fatal("IsReady() =", err)
+	}
+	if ready {
+		t.Error("IsReady() = true before probing, wanted false")
+	}
+
+	select {
+	case got := <-readyCh:
+		if got != ing {
+			t.Errorf("Ready callback called with %v, wanted %v", got, ing)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the Ingress to be ready")
+	}
+
+	ready, err = prober.IsReady(context.Background(), ing)
+	if err != nil {
+		t.Fatal("IsReady() =", err)
+	}
+	if !ready {
+		t.Error("IsReady() = false after probing, wanted true")
+	}
+}
+
+func TestTCPProberCancelIngressProbing(t *testing.T) {
+	prober := newTCPProber(zaptest.NewLogger(t).Sugar(), fakeTCPTargetLister{{
+		PodIPs:  sets.NewString("1.1.1.1"),
+		PodPort: "9000",
+		Port:    "9000",
+		URLs:    []*url.URL{{Scheme: tcpScheme, Host: "foo.bar.com:9000"}},
+	}}, func(*v1alpha1.Ingress) {
+		t.Error("Unexpected ready callback")
+	})
+	prober.dialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	ing := tcpIngress()
+	if ready, err := prober.IsReady(context.Background(), ing); err != nil || ready {
+		t.Fatalf("IsReady() = %v, %v, wanted false, nil", ready, err)
+	}
+	prober.CancelIngressProbing(ing)
+
+	prober.mu.Lock()
+	defer prober.mu.Unlock()
+	if _, ok := prober.ingressStates[types.NamespacedName{Namespace: ing.Namespace, Name: ing.Name}]; ok {
+		t.Error("The probing state of the Ingress was not removed")
+	}
+}
+
+func TestCheckClientCertificateRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		clientAuth tls.ClientAuthType
+		wantErr    bool
+	}{{
+		name:       "MUTUAL TLS",
+		clientAuth: tls.RequireAndVerifyClientCert,
+	}, {
+		name:       "SIMPLE TLS",
+		clientAuth: tls.NoClientCert,
+		wantErr:    true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewUnstartedServer(http.NotFoundHandler())
+			server.TLS = &tls.Config{ClientAuth: test.clientAuth}
+			server.StartTLS()
+			defer server.Close()
+
+			conn, err := net.Dial("tcp", server.Listener.Addr().String())
+			if err != nil {
+				t.Fatal("Failed to connect:", err)
+			}
+			defer conn.Close()
+			if err := checkClientCertificateRequest(conn, "foo.bar.com"); (err != nil) != test.wantErr {
+				t.Errorf("checkClientCertificateRequest() = %v, wantErr = %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func tcpIngress() *v1alpha1.Ingress {
+	return &v1alpha1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "whatever",
+		},
+		Spec: v1alpha1.IngressSpec{
+			Rules: []v1alpha1.IngressRule{{
+				Hosts:      []string{"foo.bar.com"},
+				Visibility: v1alpha1.IngressVisibilityExternalIP,
+			}},
+		},
+	}
+}
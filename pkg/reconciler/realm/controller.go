@@ -0,0 +1,119 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package realm
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"k8s.io/client-go/tools/cache"
+	network "knative.dev/networking/pkg"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	domaininformer "knative.dev/networking/pkg/client/injection/informers/networking/v1alpha1/domain"
+	realminformer "knative.dev/networking/pkg/client/injection/informers/networking/v1alpha1/realm"
+	realmreconciler "knative.dev/networking/pkg/client/injection/reconciler/networking/v1alpha1/realm"
+	networkinglisters "knative.dev/networking/pkg/client/listers/networking/v1alpha1"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+)
+
+// domainIndex indexes the Realms by the names of their Domains.
+const domainIndex = "domain"
+
+// NewController initializes the controller and is called by the generated code.
+// Registers eventhandlers to enqueue events.
+func NewController(
+	ctx context.Context,
+	cmw configmap.Watcher,
+) *controller.Impl {
+
+	logger := logging.FromContext(ctx)
+	realmInformer := realminformer.Get(ctx)
+	domainInformer := domaininformer.Get(ctx)
+
+	c := &reconciler{
+		domainLister: domainInformer.Lister(),
+	}
+	impl := realmreconciler.NewImpl(ctx, c)
+
+	logger.Info("Setting up event handlers")
+	// Realms have no ingress class of their own, they take the one of their
+	// Domains.
+	filterFunc := isIstioRealm(domainInformer.Lister())
+	realmInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: filterFunc,
+		Handler:    controller.HandleAll(impl.Enqueue),
+	})
+
+	// Realms reflect the readiness of their Domains, so enqueue the Realms
+	// referencing a Domain when it changes.
+	realmInformer.Informer().AddIndexers(cache.Indexers{
+		domainIndex: func(obj interface{}) ([]string, error) {
+			return domainNames(obj.(*v1alpha1.Realm)), nil
+		},
+	})
+	domainInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: isIstioDomain,
+		Handler: controller.HandleAll(func(obj interface{}) {
+			realms, err := realmInformer.Informer().GetIndexer().ByIndex(domainIndex, obj.(*v1alpha1.Domain).Name)
+			if err != nil {
+				logger.Errorw("Failed to list the Realms of Domain", zap.Error(err))
+				return
+			}
+			for _, realm := range realms {
+				if filterFunc(realm) {
+					impl.Enqueue(realm)
+				}
+			}
+		}),
+	})
+
+	return impl
+}
+
+// isIstioRealm returns a filter accepting the Realms whose existing Domains
+// are all of the Istio ingress class. The class of the Realms whose Domains
+// are all missing is unknown, so they are filtered out until one of their
+// Domains is created.
+func isIstioRealm(domainLister networkinglisters.DomainLister) func(interface{}) bool {
+	return func(obj interface{}) bool {
+		realm, ok := obj.(*v1alpha1.Realm)
+		if !ok {
+			return false
+		}
+		found := false
+		for _, name := range domainNames(realm) {
+			d, err := domainLister.Get(name)
+			if err != nil {
+				continue
+			}
+			if !isIstioDomain(d) {
+				return false
+			}
+			found = true
+		}
+		return found
+	}
+}
+
+// isIstioDomain returns whether the given object is a Domain of the Istio
+// ingress class.
+func isIstioDomain(obj interface{}) bool {
+	d, ok := obj.(*v1alpha1.Domain)
+	return ok && d.Spec.IngressClass == network.IstioIngressClassName
+}
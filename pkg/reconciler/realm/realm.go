@@ -0,0 +1,99 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package realm
+
+import (
+	"context"
+	"fmt"
+
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	realmreconciler "knative.dev/networking/pkg/client/injection/reconciler/networking/v1alpha1/realm"
+	networkinglisters "knative.dev/networking/pkg/client/listers/networking/v1alpha1"
+	"knative.dev/pkg/apis"
+	pkgreconciler "knative.dev/pkg/reconciler"
+)
+
+const (
+	domainMissing  = "DomainMissing"
+	domainNotReady = "DomainNotReady"
+)
+
+// reconciler implements controller.Reconciler for Realm resources.
+type reconciler struct {
+	domainLister networkinglisters.DomainLister
+}
+
+// Check that our reconciler implements realmreconciler.Interface.
+var _ realmreconciler.Interface = (*reconciler)(nil)
+
+// ReconcileKind marks the Realm ready when all of its Domains are ready.
+// Realms whose Domains are not of the Istio ingress class are left alone.
+func (r *reconciler) ReconcileKind(ctx context.Context, realm *v1alpha1.Realm) pkgreconciler.Event {
+	domains := make([]*v1alpha1.Domain, 0, 2)
+	missing := ""
+	for _, name := range domainNames(realm) {
+		d, err := r.domainLister.Get(name)
+		if apierrs.IsNotFound(err) {
+			if missing == "" {
+				missing = name
+			}
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to get Domain %s: %w", name, err)
+		}
+		if !isIstioDomain(d) {
+			return nil
+		}
+		domains = append(domains, d)
+	}
+	if len(domains) == 0 {
+		// The ingress class of the Realm is unknown.
+		return nil
+	}
+
+	conditions := realm.GetConditionSet().Manage(realm.GetStatus())
+	if missing != "" {
+		conditions.MarkFalse(apis.ConditionReady, domainMissing, "Domain %s does not exist", missing)
+		return nil
+	}
+	for _, d := range domains {
+		if !isReady(d) {
+			conditions.MarkUnknown(apis.ConditionReady, domainNotReady, "Domain %s is not ready", d.Name)
+			return nil
+		}
+	}
+	conditions.MarkTrue(apis.ConditionReady)
+	return nil
+}
+
+// domainNames returns the names of the Domains of the given Realm.
+func domainNames(realm *v1alpha1.Realm) []string {
+	names := make([]string, 0, 2)
+	for _, name := range []string{realm.Spec.External, realm.Spec.Internal} {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// isReady returns whether the Domain is ready for its current generation.
+func isReady(d *v1alpha1.Domain) bool {
+	return d.Generation == d.Status.ObservedGeneration &&
+		d.GetConditionSet().Manage(d.GetStatus()).IsHappy()
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package realm
+
+import (
+	"context"
+	"testing"
+
+	// Inject our fakes
+	fakenetworkingclient "knative.dev/networking/pkg/client/injection/client/fake"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgotesting "k8s.io/client-go/testing"
+	network "knative.dev/networking/pkg"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	realmreconciler "knative.dev/networking/pkg/client/injection/reconciler/networking/v1alpha1/realm"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+
+	. "knative.dev/net-istio/pkg/reconciler/testing"
+	. "knative.dev/pkg/reconciler/testing"
+)
+
+func realm(name string, conds ...apis.Condition) *v1alpha1.Realm {
+	return &v1alpha1.Realm{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: v1alpha1.RealmSpec{
+			External: "external",
+			Internal: "internal",
+		},
+		Status: v1alpha1.RealmStatus{
+			Status: duckv1.Status{
+				Conditions: conds,
+			},
+		},
+	}
+}
+
+func domain(name string, conds ...apis.Condition) *v1alpha1.Domain {
+	return &v1alpha1.Domain{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: v1alpha1.DomainSpec{
+			IngressClass: network.IstioIngressClassName,
+		},
+		Status: v1alpha1.DomainStatus{
+			Status: duckv1.Status{
+				Conditions: conds,
+			},
+		},
+	}
+}
+
+func otherDomain(name string) *v1alpha1.Domain {
+	d := domain(name)
+	d.Spec.IngressClass = "other"
+	return d
+}
+
+var ready = apis.Condition{
+	Type:   apis.ConditionReady,
+	Status: corev1.ConditionTrue,
+}
+
+func TestReconcile(t *testing.T) {
+	table := TableTest{{
+		Name: "bad workqueue key",
+		Key:  "too/many/parts",
+	}, {
+		Name: "key not found",
+		Key:  "not-found",
+	}, {
+		Name: "domains ready",
+		Key:  "test",
+		Objects: []runtime.Object{
+			realm("test"),
+			domain("external", ready),
+			domain("internal", ready),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: realm("test", ready),
+		}},
+	}, {
+		Name: "stable state",
+		Key:  "test",
+		Objects: []runtime.Object{
+			realm("test", ready),
+			domain("external", ready),
+			domain("internal", ready),
+		},
+	}, {
+		Name: "domain not ready",
+		Key:  "test",
+		Objects: []runtime.Object{
+			realm("test", ready),
+			domain("external", ready),
+			domain("internal"),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: realm("test", apis.Condition{
+				Type:    apis.ConditionReady,
+				Status:  corev1.ConditionUnknown,
+				Reason:  domainNotReady,
+				Message: "Domain internal is not ready",
+			}),
+		}},
+	}, {
+		Name: "domain missing",
+		Key:  "test",
+		Objects: []runtime.Object{
+			realm("test"),
+			domain("external", ready),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: realm("test", apis.Condition{
+				Type:    apis.ConditionReady,
+				Status:  corev1.ConditionFalse,
+				Reason:  domainMissing,
+				Message: "Domain internal does not exist",
+			}),
+		}},
+	}}
+
+	table.Test(t, MakeFactory(func(ctx context.Context, listers *Listers, cmw configmap.Watcher) controller.Reconciler {
+		r := &reconciler{
+			domainLister: listers.GetDomainLister(),
+		}
+
+		return realmreconciler.NewReconciler(ctx, logging.FromContext(ctx), fakenetworkingclient.Get(ctx),
+			listers.GetRealmLister(), controller.GetEventRecorder(ctx), r)
+	}))
+}
+
+func TestIsIstioRealm(t *testing.T) {
+	tests := []struct {
+		name    string
+		domains []runtime.Object
+		want    bool
+	}{{
+		name:    "istio domains",
+		domains: []runtime.Object{domain("external"), domain("internal")},
+		want:    true,
+	}, {
+		name:    "one istio domain missing",
+		domains: []runtime.Object{domain("external")},
+		want:    true,
+	}, {
+		name: "domains missing",
+	}, {
+		name:    "domain of another class",
+		domains: []runtime.Object{domain("external"), otherDomain("internal")},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			listers := NewListers(test.domains)
+			if got := isIstioRealm(listers.GetDomainLister())(realm("test")); got != test.want {
+				t.Errorf("isIstioRealm() = %v, want: %v", got, test.want)
+			}
+		})
+	}
+}
@@ -18,13 +18,17 @@ package istio
 
 import (
 	istiov1alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istiov1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	securityv1beta1 "istio.io/client-go/pkg/apis/security/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	fakekubeclientset "k8s.io/client-go/kubernetes/fake"
 	corev1listers "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 	fakeistioclientset "knative.dev/net-istio/pkg/client/istio/clientset/versioned/fake"
-	istiolisters "knative.dev/net-istio/pkg/client/istio/listers/networking/v1alpha3"
+	istiov1alpha3listers "knative.dev/net-istio/pkg/client/istio/listers/networking/v1alpha3"
+	istiolisters "knative.dev/net-istio/pkg/client/istio/listers/networking/v1beta1"
+	securitylisters "knative.dev/net-istio/pkg/client/istio/listers/security/v1beta1"
 	networking "knative.dev/networking/pkg/apis/networking/v1alpha1"
 	fakenetworkingclientset "knative.dev/networking/pkg/client/clientset/versioned/fake"
 	networkinglisters "knative.dev/networking/pkg/client/listers/networking/v1alpha1"
@@ -93,19 +97,44 @@ func (l *Listers) GetServerlessServiceLister() networkinglisters.ServerlessServi
 	return networkinglisters.NewServerlessServiceLister(l.IndexerFor(&networking.ServerlessService{}))
 }
 
+// GetRealmLister get lister for Realm resource.
+func (l *Listers) GetRealmLister() networkinglisters.RealmLister {
+	return networkinglisters.NewRealmLister(l.IndexerFor(&networking.Realm{}))
+}
+
+// GetDomainLister get lister for Domain resource.
+func (l *Listers) GetDomainLister() networkinglisters.DomainLister {
+	return networkinglisters.NewDomainLister(l.IndexerFor(&networking.Domain{}))
+}
+
 // GetGatewayLister get lister for Gateway resource.
 func (l *Listers) GetGatewayLister() istiolisters.GatewayLister {
-	return istiolisters.NewGatewayLister(l.IndexerFor(&istiov1alpha3.Gateway{}))
+	return istiolisters.NewGatewayLister(l.IndexerFor(&istiov1beta1.Gateway{}))
 }
 
 // GetVirtualServiceLister get lister for istio VirtualService resource.
 func (l *Listers) GetVirtualServiceLister() istiolisters.VirtualServiceLister {
-	return istiolisters.NewVirtualServiceLister(l.IndexerFor(&istiov1alpha3.VirtualService{}))
+	return istiolisters.NewVirtualServiceLister(l.IndexerFor(&istiov1beta1.VirtualService{}))
 }
 
 // GetDestinationRuleLister get lister for istio DestinationRule resource.
 func (l *Listers) GetDestinationRuleLister() istiolisters.DestinationRuleLister {
-	return istiolisters.NewDestinationRuleLister(l.IndexerFor(&istiov1alpha3.DestinationRule{}))
+	return istiolisters.NewDestinationRuleLister(l.IndexerFor(&istiov1beta1.DestinationRule{}))
+}
+
+// GetAuthorizationPolicyLister get lister for istio AuthorizationPolicy resource.
+func (l *Listers) GetAuthorizationPolicyLister() securitylisters.AuthorizationPolicyLister {
+	return securitylisters.NewAuthorizationPolicyLister(l.IndexerFor(&securityv1beta1.AuthorizationPolicy{}))
+}
+
+// GetRequestAuthenticationLister get lister for istio RequestAuthentication resource.
+func (l *Listers) GetRequestAuthenticationLister() securitylisters.RequestAuthenticationLister {
+	return securitylisters.NewRequestAuthenticationLister(l.IndexerFor(&securityv1beta1.RequestAuthentication{}))
+}
+
+// GetEnvoyFilterLister get lister for istio EnvoyFilter resource.
+func (l *Listers) GetEnvoyFilterLister() istiov1alpha3listers.EnvoyFilterLister {
+	return istiov1alpha3listers.NewEnvoyFilterLister(l.IndexerFor(&istiov1alpha3.EnvoyFilter{}))
 }
 
 // GetK8sServiceLister get lister for K8s Service resource.